@@ -8,6 +8,7 @@ import (
 	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	html "github.com/gofiber/template/html/v2"
 
 	"gitlab.com/sandstone2/fiberpoc/app/handlers"
@@ -15,7 +16,9 @@ import (
 	"gitlab.com/sandstone2/fiberpoc/app/server"
 
 	"gitlab.com/sandstone2/fiberpoc/common/clients"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
 	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/secrets"
 	"gitlab.com/sandstone2/fiberpoc/common/services"
 )
 
@@ -35,29 +38,110 @@ func main() {
 
 	// Inject all dependencies.
 	fooRepo := repos.NewFooRepository(db, logger)
-	fooService := services.NewFooService(fooRepo, logger)
+	fooShareRepo := repos.NewFooShareRepository(db, logger)
+	fooRevisionRepo := repos.NewFooRevisionRepository(db, logger)
+	auditRepo := repos.NewAuditRepository(db, logger)
+	fooService := services.NewFooService(fooRepo, fooShareRepo, fooRevisionRepo, auditRepo, db, logger)
 	fooHandler := handlers.NewFooHandler(fooService, logger)
-
-	authcService, err := services.NewAuthcService(logger)
+	auditService := services.NewAuditService(auditRepo, logger)
+	auditHandler := handlers.NewAuditHandler(auditService, logger)
+
+	userRepo := repos.NewUserRepository(db, logger)
+	roleRepo := repos.NewRoleRepository(db, logger)
+	orgRepo := repos.NewOrganizationRepository(db, logger)
+	userService := services.NewUserService(userRepo, roleRepo, orgRepo, *models.GlobalConfig.GetDefaultRole(), *models.GlobalConfig.GetDefaultOrg(), logger)
+	userHandler := handlers.NewUserHandler(userService, logger)
+	roleService := services.NewRoleService(roleRepo, userRepo, db, logger)
+	roleHandler := handlers.NewRoleHandler(roleService, logger)
+	orgService := services.NewOrganizationService(orgRepo, userRepo, db, logger)
+	orgHandler := handlers.NewOrganizationHandler(orgService, logger)
+
+	oidcLoginRepo := repos.NewOidcLoginRepository(db, logger)
+	authcService, err := services.NewAuthcService(models.GlobalConfig.GetOidcProviders(), oidcLoginRepo, models.GlobalConfig.GetReturnToAllowedOrigins(), logger)
 	if err != nil {
 		logger.Sugar().Fatalf("Error: A18S5B - Creating AuthcService. Error: %v", err)
 	}
-	authcHandler := handlers.NewAuthcHandler(authcService, logger)
+	sessionRepo := repos.NewSessionRepository(db, logger)
+	// Sessions only keep the tokens of their logins when there is a key to encrypt them with.
+	var tokenCipher *secrets.Cipher
+	if key := *models.GlobalConfig.GetTokenEncryptionKey(); key != "" {
+		tokenCipher, err = secrets.NewCipher(key)
+		if err != nil {
+			logger.Sugar().Fatalf("Error: UI2CPU - Creating the token cipher. Error: %v", err)
+		}
+	}
+	sessionService := services.NewSessionService(sessionRepo, authcService, tokenCipher, db, *models.GlobalConfig.GetSessionIdleTimeout(), *models.GlobalConfig.GetSessionMaxAge(), logger)
+	sessionHandler := handlers.NewSessionHandler(sessionService, logger)
+	authcHandler := handlers.NewAuthcHandler(authcService, userService, sessionService, logger)
+	apiKeyRepo := repos.NewApiKeyRepository(db, logger)
+	apiKeyService := services.NewApiKeyService(apiKeyRepo, roleRepo, logger)
+	apiKeyHandler := handlers.NewApiKeyHandler(apiKeyService, logger)
 
 	engine := html.New("./templates", ".html")
 	engine.Reload(true)
 	// Create the Fiber app.
-	app := fiber.New(fiber.Config{Views: engine})
+	app := fiber.New(fiber.Config{Views: engine, ErrorHandler: middleware.ErrorHandler(logger)})
+
+	// Give every request an X-Request-ID, error responses include it.
+	app.Use(requestid.New())
+
+	// Bound the database work of every request.
+	app.Use(middleware.ContextMiddleware(*models.GlobalConfig.GetDbRequestTimeout()))
 
 	// Create the routes.
 
 	app.Get("/", authcHandler.HandleRoot)
-	app.Get("/login", authcHandler.HandleLogin)
-	app.Get("/callback", authcHandler.HandleOauthCallback)
-	app.Get("/foos", middleware.AuthcMiddleware(authcService.GetVerifier(), logger), fooHandler.HandleGetFoos)
-	app.Post("/foos", middleware.AuthcMiddleware(authcService.GetVerifier(), logger), fooHandler.HandleCreateFoo)
-	app.Delete("/foos", middleware.AuthcMiddleware(authcService.GetVerifier(), logger), fooHandler.HandleDeleteFoos)
-	app.Put("/foos/:id", middleware.AuthcMiddleware(authcService.GetVerifier(), logger), fooHandler.HandleUpdateFoo) // Replace all fields with new ones.
+	app.Get("/login/:provider", authcHandler.HandleLogin)
+	app.Get("/callback/:provider", authcHandler.HandleOauthCallback)
+	app.Post("/logout", sessionHandler.HandleLogout)
+
+	// Routes after authc need a logged in user, with a session cookie, a Bearer token or an API key.
+	// authorize checks they have the permissions through their roles, and tenant picks the organization whose
+	// foos the request works with.
+	authc := middleware.AuthcMiddleware(authcService, sessionService, apiKeyService, logger)
+	authorize := func(permissions ...string) fiber.Handler {
+		return middleware.Authorize(roleService, logger, permissions...)
+	}
+	tenant := middleware.TenantMiddleware(orgService, *models.GlobalConfig.GetTenantBaseDomain(), logger)
+
+	app.Get("/me", authc, userHandler.HandleGetMe)
+	app.Get("/sessions", authc, sessionHandler.HandleGetSessions)
+	app.Delete("/sessions/:id", authc, sessionHandler.HandleDeleteSession) // Log out one of your sessions.
+	app.Post("/token/refresh", authc, sessionHandler.HandleRefreshToken)   // An ID token of your session for Bearer requests.
+	app.Post("/api-keys", authc, apiKeyHandler.HandleCreateApiKey)         // The key is only in this response.
+	app.Get("/api-keys", authc, apiKeyHandler.HandleGetApiKeys)
+	app.Delete("/api-keys/:id", authc, apiKeyHandler.HandleDeleteApiKey) // Revoke one of your API keys.
+	app.Get("/orgs", authc, orgHandler.HandleGetOrganizations)           // The organizations you are a member of.
+	app.Get("/foos", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFoos)
+	app.Get("/foos/search", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleSearchFoos) // Rank foos by how well their name matches ?q=, typos included.
+	app.Get("/foos/export", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleExportFoos) // Stream as CSV or NDJSON, see ?format= and the Accept header.
+	app.Get("/foos/:id", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFoo)
+	app.Post("/foos", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleCreateFoo)
+	app.Post("/foos\\:batch", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleCreateFoos)
+	app.Post("/foos/import", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleImportFoos) // All rows or none, ?dry_run=true only checks them.
+	app.Patch("/foos\\:batch", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandlePatchFoos)
+	app.Delete("/foos", authc, tenant, authorize(models.PermissionFoosDelete), fooHandler.HandleDeleteFoos)    // Soft delete ?ids=1,2,3, or every foo with ?all=true.
+	app.Post("/foos/purge", authc, tenant, authorize(models.PermissionFoosPurge), fooHandler.HandlePurgeFoos)  // Hard delete foos soft deleted past the retention window.
+	app.Delete("/foos/:id", authc, tenant, authorize(models.PermissionFoosDelete), fooHandler.HandleDeleteFoo) // Soft delete.
+	app.Post("/foos/:id/restore", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleRestoreFoo)
+	app.Put("/foos/:id", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleUpdateFoo)  // Replace all fields with new ones. Requires If-Match.
+	app.Patch("/foos/:id", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandlePatchFoo) // Change only the given fields. Requires If-Match.
+	app.Get("/foos/:id/shares", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFooShares)
+	app.Post("/foos/:id/shares", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleShareFoo) // Share with a user or role, owner only.
+	app.Delete("/foos/:id/shares/:shareId", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleDeleteFooShare)
+	app.Get("/foos/:id/revisions", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFooRevisions)
+	app.Get("/foos/:id/revisions/diff", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleDiffFooRevisions) // ?from=1&to=2, registered before :rev.
+	app.Get("/foos/:id/revisions/:rev", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFooRevision)
+	app.Post("/foos/:id/revisions/:rev/restore", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleRestoreFooRevision) // Write revision :rev back as a new revision. If-Match is optional.
+	app.Get("/audit", authc, tenant, authorize(models.PermissionAuditRead), auditHandler.HandleGetAuditEvents)                              // Filter with ?actor=, entity=, action=, from= and to=.
+
+	// Admin routes.
+	app.Get("/roles", authc, authorize(models.PermissionRolesManage), roleHandler.HandleGetRoles)
+	app.Get("/users/:id/roles", authc, authorize(models.PermissionRolesManage), roleHandler.HandleGetUserRoles)
+	app.Put("/users/:id/roles", authc, authorize(models.PermissionRolesManage), roleHandler.HandleSetUserRoles) // Replace all roles of the user.
+	app.Post("/orgs", authc, authorize(models.PermissionOrgsManage), orgHandler.HandleCreateOrganization)
+	app.Put("/orgs/:slug/members/:id", authc, authorize(models.PermissionOrgsManage), orgHandler.HandleAddMember)
+	app.Delete("/orgs/:slug/members/:id", authc, authorize(models.PermissionOrgsManage), orgHandler.HandleRemoveMember)
 
 	// Start the Fiber server in a separate goroutine.
 	go func(app *fiber.App) {
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"gitlab.com/sandstone2/fiberpoc/common/validation"
+	"go.uber.org/zap"
+)
+
+type ApiKeyHandler struct {
+	apiKeyService *services.ApiKeyServiceInterface
+	logger        *zap.Logger
+}
+
+func NewApiKeyHandler(apiKeyService services.ApiKeyServiceInterface, logger *zap.Logger) *ApiKeyHandler {
+	return &ApiKeyHandler{apiKeyService: &apiKeyService, logger: logger}
+}
+
+// HandleCreateApiKey creates an API key for the caller and returns it with the key, which is not shown again.
+// A request made with an API key can not create keys, so a key can never get more scopes than it has. It must run
+// after AuthcMiddleware.
+func (apiKeyHandler *ApiKeyHandler) HandleCreateApiKey(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("CYGE2Z", "You need to log in.")
+	}
+	if _, ok := c.Locals("api_key").(*models.ApiKey); ok {
+		return apperrors.Forbidden("3JKY0P", "API keys can not create API keys, log in to create one.")
+	}
+
+	request := models.ApiKeyRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return apperrors.BadRequest("AV5VC6", "Bad request body.").WithCause(err)
+	}
+	if err := validation.Check("VU3FR6", &request); err != nil {
+		return err
+	}
+
+	newApiKey, err := (*apiKeyHandler.apiKeyService).CreateApiKey(c.UserContext(), claims, &request)
+	if err != nil {
+		return apperrors.Internal(err, "9ZKKFU", "Creating API key failed.")
+	}
+	return c.Status(fiber.StatusCreated).JSON(newApiKey)
+}
+
+// HandleGetApiKeys lists the API keys of the caller that are not revoked. It must run after AuthcMiddleware.
+func (apiKeyHandler *ApiKeyHandler) HandleGetApiKeys(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("IZIPVR", "You need to log in.")
+	}
+
+	apiKeys, err := (*apiKeyHandler.apiKeyService).GetApiKeys(c.UserContext(), claims)
+	if err != nil {
+		return apperrors.Internal(err, "M50UC5", "Getting API keys failed.")
+	}
+	return c.JSON(apiKeys)
+}
+
+// HandleDeleteApiKey revokes one of the caller's API keys. It must run after AuthcMiddleware.
+func (apiKeyHandler *ApiKeyHandler) HandleDeleteApiKey(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("I8Y20I", "You need to log in.")
+	}
+
+	apiKeyId, err := c.ParamsInt("id")
+	if err != nil {
+		return apperrors.BadRequest("R3N4XU", "API key id is not a number.")
+	}
+	if apiKeyId == 0 {
+		return apperrors.BadRequest("VHZRY5", "No API key id was provided.")
+	}
+
+	if err := (*apiKeyHandler.apiKeyService).RevokeApiKey(c.UserContext(), claims, int64(apiKeyId)); err != nil {
+		return apperrors.Internal(err, "0QQB7I", "Revoking API key failed.")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestApiKeyHandler_HandleCreateApiKey_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyService := mocks.NewMockApiKeyService(ctrl)
+	logger := zaptest.NewLogger(t)
+	apiKeyHandler := NewApiKeyHandler(mockApiKeyService, logger)
+
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/api-keys", withClaims(claims), apiKeyHandler.HandleCreateApiKey)
+
+	mockApiKeyService.EXPECT().
+		CreateApiKey(gomock.Any(), claims, &models.ApiKeyRequest{Name: "ci", Scopes: []string{models.PermissionFoosRead}}).
+		Return(&models.NewApiKey{ApiKey: models.ApiKey{ID: 4, Name: "ci"}, Key: "fpk_key-1"}, nil)
+
+	request := httptest.NewRequest("POST", "/api-keys", strings.NewReader(`{"name": " ci ", "scopes": ["foos:read"]}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusCreated, response.StatusCode)
+}
+
+func TestApiKeyHandler_HandleCreateApiKey_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyService := mocks.NewMockApiKeyService(ctrl)
+	logger := zaptest.NewLogger(t)
+	apiKeyHandler := NewApiKeyHandler(mockApiKeyService, logger)
+
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+	withApiKey := func(c *fiber.Ctx) error {
+		c.Locals("api_key", &models.ApiKey{ID: 4})
+		return c.Next()
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/api-keys", withClaims(claims), apiKeyHandler.HandleCreateApiKey)
+	app.Post("/api-key/api-keys", withClaims(claims), withApiKey, apiKeyHandler.HandleCreateApiKey)
+
+	// 1) Test a key without a name
+	request := httptest.NewRequest("POST", "/api-keys", strings.NewReader(`{"name": "  "}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusUnprocessableEntity, "VU3FR6")
+
+	// 2) Test a request made with an API key, the service must not be called
+	request = httptest.NewRequest("POST", "/api-key/api-keys", strings.NewReader(`{"name": "ci"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusForbidden, "3JKY0P")
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"go.uber.org/zap"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditServiceInterface
+	logger       *zap.Logger
+}
+
+func NewAuditHandler(auditService services.AuditServiceInterface, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{auditService: &auditService, logger: logger}
+}
+
+// HandleGetAuditEvents lists the audit log of the organization of the request, see models.AuditListParams for the
+// filters.
+func (auditHandler *AuditHandler) HandleGetAuditEvents(c *fiber.Ctx) error {
+	params := models.AuditListParams{}
+	if err := c.QueryParser(&params); err != nil {
+		return apperrors.BadRequest("H6PC3K", "Bad query parameters.").WithCause(err)
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	page, err := (*auditHandler.auditService).GetAuditEvents(c.UserContext(), &params)
+	if err != nil {
+		return apperrors.Internal(err, "8TC9U7", "Getting the audit log failed.")
+	}
+	return c.JSON(page)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestAuditHandler_HandleGetAuditEvents_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditService := mocks.NewMockAuditService(ctrl)
+	logger := zaptest.NewLogger(t)
+	auditHandler := NewAuditHandler(mockAuditService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/audit", auditHandler.HandleGetAuditEvents)
+
+	// The filters of the query are passed to the service
+	mockAuditService.EXPECT().
+		GetAuditEvents(gomock.Any(), &models.AuditListParams{Actor: "joe@example.com", Entity: "foo", Action: "delete", From: 1700000000000, To: 1800000000000}).
+		Return(&models.AuditPage{Items: []models.AuditEvent{{ID: 9, ActorEmail: "joe@example.com", Entity: "foo", EntityID: 3, Action: "delete", Before: []byte(`{"id":3}`)}}}, nil)
+
+	request := httptest.NewRequest("GET", "/audit?actor=joe@example.com&entity=foo&action=delete&from=1700000000000&to=1800000000000", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"items":[{"id":9,"actor_id":null,"actor_sub":"","actor_email":"joe@example.com","request_id":"",`+
+		`"entity":"foo","entity_id":3,"action":"delete","before":{"id":3},"after":null,"created_at":0}]}`, string(body))
+}
+
+func TestAuditHandler_HandleGetAuditEvents_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditService := mocks.NewMockAuditService(ctrl)
+	logger := zaptest.NewLogger(t)
+	auditHandler := NewAuditHandler(mockAuditService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/audit", auditHandler.HandleGetAuditEvents)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		code   string
+	}{
+		{"query that does not parse", "?from=yesterday", fiber.StatusBadRequest, "H6PC3K"},
+		{"to before from", "?from=1800000000000&to=1700000000000", fiber.StatusBadRequest, "S34C8D"},
+		{"limit too high", "?limit=1000", fiber.StatusBadRequest, "U8HS6P"},
+		{"cursor that is not valid", "?cursor=abc", fiber.StatusBadRequest, "043P81"},
+		{"service failure", "", fiber.StatusInternalServerError, "8TC9U7"},
+	}
+
+	mockAuditService.EXPECT().GetAuditEvents(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+
+	for _, test := range tests {
+		response, err := app.Test(httptest.NewRequest("GET", "/audit"+test.query, nil), -1)
+		require.NoError(t, err, test.name)
+		defer response.Body.Close()
+
+		requireProblem(t, response, test.status, test.code)
+	}
+}
@@ -4,107 +4,118 @@ import (
 	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
 	"gitlab.com/sandstone2/fiberpoc/common/services"
 	"go.uber.org/zap"
 )
 
 type AuthcHandler struct {
-	authcService *services.AuthcServiceInterface
-	logger       *zap.Logger
+	authcService   *services.AuthcServiceInterface
+	userService    *services.UserServiceInterface
+	sessionService *services.SessionServiceInterface
+	logger         *zap.Logger
 }
 
-func NewAuthcHandler(authcService services.AuthcServiceInterface, logger *zap.Logger) *AuthcHandler {
-	return &AuthcHandler{authcService: &authcService, logger: logger}
+// NewAuthcHandler makes the login handlers. userService provisions the user of every successful login and
+// sessionService starts their session.
+func NewAuthcHandler(authcService services.AuthcServiceInterface, userService services.UserServiceInterface, sessionService services.SessionServiceInterface, logger *zap.Logger) *AuthcHandler {
+	return &AuthcHandler{authcService: &authcService, userService: &userService, sessionService: &sessionService, logger: logger}
+}
+
+// renderHome renders the home page with the login links of every provider, or the user after a login.
+func (authcHandler *AuthcHandler) renderHome(c *fiber.Ctx, user *models.User, failed bool) error {
+	data := fiber.Map{
+		"LoggedIn":  user != nil,
+		"Error":     failed,
+		"Name":      "",
+		"Email":     "",
+		"Providers": (*authcHandler.authcService).GetProviders(),
+	}
+	if user != nil {
+		data["Name"] = user.Name
+		data["Email"] = user.Email
+	}
+	return c.Render("home", data)
 }
 
 func (authcHandler *AuthcHandler) HandleRoot(c *fiber.Ctx) error {
-	return c.Render("home", fiber.Map{
-		"LoggedIn": false,
-		"Error":    false,
-		"Name":     "",
-		"Email":    "",
-	})
+	return authcHandler.renderHome(c, nil, false)
 }
 
+// HandleLogin redirects to the provider named by the :provider param. The optional return_to query param is the
+// page the user lands on after the login.
 func (authcHandler *AuthcHandler) HandleLogin(c *fiber.Ctx) error {
-	// 1. Generate a secure, random state string
-	state, err := (*authcHandler.authcService).GenerateState()
+	// 1. Start the login with a fresh state, PKCE verifier and nonce
+	url, state, err := (*authcHandler.authcService).StartLogin(c.UserContext(), c.Params("provider"), c.Query("return_to"))
 	if err != nil {
 		(*authcHandler.logger).Sugar().Errorf("Error: NKUM7E - Logging in. Error: %v", err)
-		return c.Render("home", fiber.Map{
-			"LoggedIn": false,
-			"Error":    true,
-			"Name":     "",
-			"Email":    "",
-		})
+		return authcHandler.renderHome(c, nil, true)
 	}
 
 	// 2. Store the state in a cookie (HttpOnly for security)
 	c.Cookie(&fiber.Cookie{
 		Name:     "oidc_state",
 		Value:    state,
-		Expires:  time.Now().Add(5 * time.Minute),
+		Expires:  time.Now().Add(services.LoginTimeout),
 		HTTPOnly: true,
-		Secure:   false,
+		Secure:   *models.GlobalConfig.GetSessionCookieSecure(),
 		SameSite: "Lax",
 		Path:     "/",
 	})
 
 	// 3. Redirect to the OIDC provider with the state
-	url := (*authcHandler.authcService).GetOauthConfig().AuthCodeURL(state)
 	return c.Redirect(url, fiber.StatusFound)
 }
 
+// HandleOauthCallback finishes a login with the provider named by the :provider param.
 func (authcHandler *AuthcHandler) HandleOauthCallback(c *fiber.Ctx) error {
 	expectedState := c.Cookies("oidc_state", "")
 	receivedState := c.Query("state", "")
 
-	if receivedState != expectedState {
+	c.ClearCookie("oidc_state")
+
+	if receivedState == "" || receivedState != expectedState {
 		(*authcHandler.logger).Error("Error: 92ASWW - Logging in. CSRF attempted. States do not match.")
-		return c.Render("home", fiber.Map{
-			"LoggedIn": false,
-			"Error":    true,
-			"Name":     "",
-			"Email":    "",
-		})
+		return authcHandler.renderHome(c, nil, true)
 	}
 
 	code := c.Query("code", "")
 	if code == "" {
 		(*authcHandler.logger).Error("Error: TDUSAL - Getting oidc code from query string.")
-		return c.Render("home", fiber.Map{
-			"LoggedIn": false,
-			"Error":    true,
-			"Name":     "",
-			"Email":    "",
-		})
+		return authcHandler.renderHome(c, nil, true)
 	}
 
-	claims, jwt, err := (*authcHandler.authcService).ProcessOauth(code)
+	claims, tokens, returnTo, err := (*authcHandler.authcService).ProcessOauth(c.UserContext(), c.Params("provider"), receivedState, code)
 	if err != nil {
 		(*authcHandler.logger).Sugar().Errorf("Error: 0GLO1T - Processing OAuth. Error: %v", err)
-		return c.Render("home", fiber.Map{
-			"LoggedIn": false,
-			"Error":    true,
-			"Name":     "",
-			"Email":    "",
-		})
+		return authcHandler.renderHome(c, nil, true)
+	}
+
+	user, err := (*authcHandler.userService).ProvisionUser(c.UserContext(), claims)
+	if err != nil {
+		(*authcHandler.logger).Sugar().Errorf("Error: XN0K3A - Provisioning user. Error: %v", err)
+		return authcHandler.renderHome(c, nil, true)
 	}
 
+	token, session, err := (*authcHandler.sessionService).CreateSession(c.UserContext(), user, tokens, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		(*authcHandler.logger).Sugar().Errorf("Error: GONZUV - Creating session. Error: %v", err)
+		return authcHandler.renderHome(c, nil, true)
+	}
+
+	// The cookie only holds the session token, the session itself and its expiry live in the database.
 	c.Cookie(&fiber.Cookie{
-		Name:     "jwt_token",
-		Value:    *jwt,
-		Expires:  time.Now().Add(365 * 24 * time.Hour),
+		Name:     models.SessionCookieName,
+		Value:    token,
+		Expires:  time.UnixMilli(session.AbsoluteExpiresAt),
 		HTTPOnly: true,
-		Secure:   false,
+		Secure:   *models.GlobalConfig.GetSessionCookieSecure(),
 		SameSite: "Lax",
 		Path:     "/",
 	})
 
-	return c.Render("home", fiber.Map{
-		"LoggedIn": true,
-		"Error":    false,
-		"Name":     claims.Name,
-		"Email":    claims.Email,
-	})
+	if returnTo != "" {
+		return c.Redirect(returnTo, fiber.StatusSeeOther)
+	}
+	return authcHandler.renderHome(c, user, false)
 }
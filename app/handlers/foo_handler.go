@@ -1,14 +1,31 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
 	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"gitlab.com/sandstone2/fiberpoc/common/validation"
 	"go.uber.org/zap"
 )
 
+// The patch formats accepted by HandlePatchFoo.
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
 type FooHandler struct {
 	fooService *services.FooServiceInterface
 	logger     *zap.Logger
@@ -19,54 +36,614 @@ func NewFooHandler(fooService services.FooServiceInterface, logger *zap.Logger)
 }
 
 func (fooHandler *FooHandler) HandleGetFoos(c *fiber.Ctx) error {
-	foos, err := (*fooHandler.fooService).GetFoos()
+	params := models.FooListParams{}
+	if err := c.QueryParser(&params); err != nil {
+		return apperrors.BadRequest("SDVJJS", "Bad query parameters.").WithCause(err)
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if params.IncludeDeleted && !hasPermission(c, models.PermissionFoosReadDeleted) {
+		return apperrors.Forbidden(
+			"76GNIL", "Listing soft deleted foos needs the "+models.PermissionFoosReadDeleted+" permission.",
+		)
+	}
+
+	page, err := (*fooHandler.fooService).GetFoos(c.UserContext(), &params)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": fmt.Sprintf("Error J5TSGF - Getting foos in handler. Error: %v", err)})
+		return apperrors.Internal(err, "J5TSGF", "Getting foos failed.")
 	}
-	return c.JSON(foos)
+	return c.JSON(page)
+}
+
+// HandleSearchFoos finds foos by name with ?q=, best match first. Misspelled words still find close names.
+func (fooHandler *FooHandler) HandleSearchFoos(c *fiber.Ctx) error {
+	params := models.FooSearchParams{}
+	if err := c.QueryParser(&params); err != nil {
+		return apperrors.BadRequest("O1CCAI", "Bad query parameters.").WithCause(err)
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	results, err := (*fooHandler.fooService).SearchFoos(c.UserContext(), &params)
+	if err != nil {
+		return apperrors.Internal(err, "LFE4N3", "Searching foos failed.")
+	}
+	return c.JSON(results)
+}
+
+// HandleExportFoos streams the foos the user may see as CSV or NDJSON, picked with ?format= or else the Accept
+// header. Soft deleted foos are left out unless include_deleted=true, which needs foos:read_deleted. The body is
+// written while the foos are read from a database cursor, so an export of any size takes little memory.
+func (fooHandler *FooHandler) HandleExportFoos(c *fiber.Ctx) error {
+	format := c.Query("format")
+	if format == "" {
+		switch c.Accepts(models.MIMETextCSV, models.MIMEApplicationNDJSON) {
+		case models.MIMETextCSV:
+			format = models.FooFormatCSV
+		case models.MIMEApplicationNDJSON:
+			format = models.FooFormatNDJSON
+		default:
+			return apperrors.New(apperrors.KindNotAcceptable, "Y2PFGX", fmt.Sprintf("Foos can only be exported as %s or %s.", models.MIMETextCSV, models.MIMEApplicationNDJSON))
+		}
+	}
+	mimeType, ok := models.FooFormatMIMETypes[format]
+	if !ok {
+		return apperrors.BadRequest("LK3WT4", fmt.Sprintf("format must be %s or %s.", models.FooFormatCSV, models.FooFormatNDJSON))
+	}
+	includeDeleted := c.QueryBool("include_deleted")
+	if includeDeleted && !hasPermission(c, models.PermissionFoosReadDeleted) {
+		return apperrors.Forbidden(
+			"FNJGPG", "Exporting soft deleted foos needs the "+models.PermissionFoosReadDeleted+" permission.",
+		)
+	}
+
+	// The body is written after the handler returns, when the request timeout of ContextMiddleware has been
+	// cancelled. A client that stops reading fails the writes instead.
+	ctx := context.WithoutCancel(c.UserContext())
+
+	c.Set(fiber.HeaderContentType, mimeType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="foos.%s"`, format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The status is sent already, so a failed export can only be cut short.
+		if err := fooHandler.exportFoos(ctx, format, includeDeleted, w); err != nil {
+			fooHandler.logger.Error("Error: KA9BXD - Exporting foos failed, the export was cut short.", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+// exportFoos writes the foos to w in format, a CSV with a header row or a JSON object per line.
+func (fooHandler *FooHandler) exportFoos(ctx context.Context, format string, includeDeleted bool, w *bufio.Writer) error {
+	var err error
+	switch format {
+	case models.FooFormatCSV:
+		writer := csv.NewWriter(w)
+		if err = writer.Write(models.FooCSVHeader); err == nil {
+			err = (*fooHandler.fooService).ExportFoos(ctx, includeDeleted, func(foo *models.Foo) error {
+				return writer.Write(foo.CSVRecord())
+			})
+		}
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+	default:
+		encoder := json.NewEncoder(w)
+		err = (*fooHandler.fooService).ExportFoos(ctx, includeDeleted, func(foo *models.Foo) error {
+			return encoder.Encode(foo)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// HandleImportFoos creates foos from a CSV or NDJSON body, see models.ParseFooImport. The format is picked with
+// the Content-Type header or else ?format=. Either every foo is imported or none, a body with rows that are not
+// valid gets 422 and a report of their errors by line. With dry_run=true the foos are checked but not imported.
+func (fooHandler *FooHandler) HandleImportFoos(c *fiber.Ctx) error {
+	format := c.Query("format")
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case models.MIMETextCSV:
+		format = models.FooFormatCSV
+	case models.MIMEApplicationNDJSON:
+		format = models.FooFormatNDJSON
+	}
+	if _, ok := models.FooFormatMIMETypes[format]; !ok {
+		return apperrors.New(apperrors.KindUnsupportedMediaType, "3PCC3G", fmt.Sprintf("The body must be %s or %s.", models.MIMETextCSV, models.MIMEApplicationNDJSON))
+	}
+
+	rows, err := models.ParseFooImport(format, bytes.NewReader(c.Body()))
+	if err != nil {
+		return err
+	}
+
+	report, err := (*fooHandler.fooService).ImportFoos(c.UserContext(), rows, c.QueryBool("dry_run"))
+	if err != nil {
+		return apperrors.Internal(err, "ULMJM7", "Importing foos failed.")
+	}
+	if len(report.Errors) > 0 {
+		c.Status(fiber.StatusUnprocessableEntity)
+	}
+	return c.JSON(report)
+}
+
+func (fooHandler *FooHandler) HandleGetFoo(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	foo, err := (*fooHandler.fooService).GetFooByID(c.UserContext(), int64(fooId))
+	if err != nil {
+		return apperrors.Internal(err, "HYFSK1", "Getting foo failed.")
+	}
+	c.Set(fiber.HeaderETag, fooETag(foo))
+	return c.JSON(foo)
 }
 
 func (fooHandler *FooHandler) HandleCreateFoo(c *fiber.Ctx) error {
-	newFoo := models.Foo{}
+	newFoo := models.FooRequest{}
 	if err := c.BodyParser(&newFoo); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Error O1WQ9B - Bad request body."})
+		return apperrors.BadRequest("O1WQ9B", "Bad request body.").WithCause(err)
+	}
+	if err := validation.Check("X471N0", &newFoo); err != nil {
+		return err
 	}
 
-	resultFoo, err := (*fooHandler.fooService).CreateFoo(newFoo.Name)
+	resultFoo, err := (*fooHandler.fooService).CreateFoo(c.UserContext(), newFoo.Name)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": fmt.Sprintf("Error QONMRA - Creating foo in handler. Error: %v", err)})
+		return apperrors.Internal(err, "QONMRA", "Creating foo failed.")
 	}
+	c.Set(fiber.HeaderETag, fooETag(resultFoo))
 	return c.JSON(resultFoo)
 }
 
+// HandleCreateFoos creates a foo for each item in an array body. Failed items are reported per item.
+func (fooHandler *FooHandler) HandleCreateFoos(c *fiber.Ctx) error {
+	newFoos := []models.FooRequest{}
+	if err := c.BodyParser(&newFoos); err != nil {
+		return apperrors.BadRequest("YBUB41", "Bad request body, it must be an array of foos.").WithCause(err)
+	}
+
+	names := make([]string, len(newFoos))
+	for i, newFoo := range newFoos {
+		names[i] = newFoo.Name
+	}
+
+	items, err := (*fooHandler.fooService).CreateFoos(c.UserContext(), names)
+	if err != nil {
+		return apperrors.Internal(err, "T5GM7D", "Creating foos failed.")
+	}
+	return sendFooBatchResults(c, items)
+}
+
+// HandlePatchFoos applies an array of {"id", "version", "patch"} items, where each patch is a merge patch
+// like PATCH /foos/:id takes. Failed items are reported per item, patches that can not be read too.
+func (fooHandler *FooHandler) HandlePatchFoos(c *fiber.Ctx) error {
+	patches := []models.FooBatchPatch{}
+	if err := c.BodyParser(&patches); err != nil {
+		return apperrors.BadRequest("0TNZ5T", "Bad request body, it must be an array of foo patches.").WithCause(err)
+	}
+
+	items, err := (*fooHandler.fooService).PatchFoos(c.UserContext(), patches)
+	if err != nil {
+		return apperrors.Internal(err, "1G3YPS", "Patching foos failed.")
+	}
+	return sendFooBatchResults(c, items)
+}
+
+// HandleDeleteFoos soft deletes the foos in the ids query param, a comma separated list like ids=1,2,3.
+// Deleting every foo needs all=true instead, is for admins only and is turned off unless ALLOW_DELETE_ALL_FOOS is set.
 func (fooHandler *FooHandler) HandleDeleteFoos(c *fiber.Ctx) error {
-	rowsAffected, err := (*fooHandler.fooService).DeleteFoos()
+	if c.Query("ids") == "" {
+		if !c.QueryBool("all") {
+			return apperrors.BadRequest("L4C5JW", "Give the ids of the foos to delete.")
+		}
+		if !hasPermission(c, models.PermissionFoosDeleteAll) {
+			return apperrors.Forbidden("97PBA7", "Deleting all foos needs the "+models.PermissionFoosDeleteAll+" permission.")
+		}
+		if !*models.GlobalConfig.GetAllowDeleteAllFoos() {
+			return apperrors.Forbidden("QUZ2DS", "Deleting all foos is turned off.")
+		}
+
+		rowsAffected, err := (*fooHandler.fooService).DeleteFoos(c.UserContext())
+		if err != nil {
+			return apperrors.Internal(err, "8HCIPG", "Deleting foos failed.")
+		}
+		return c.JSON(fiber.Map{"message": fmt.Sprintf("%d foos deleted.", rowsAffected)})
+	}
+
+	fooIds, err := parseFooIds(c.Query("ids"))
+	if err != nil {
+		return err
+	}
+
+	items, err := (*fooHandler.fooService).DeleteFoosByID(c.UserContext(), fooIds)
+	if err != nil {
+		return apperrors.Internal(err, "5RKYHT", "Deleting foos failed.")
+	}
+	return sendFooBatchResults(c, items)
+}
+
+// hasPermission tells whether the request has permission, see middleware.Authorize. Routes use it for the query
+// params that need more than the permission of the route.
+func hasPermission(c *fiber.Ctx, permission string) bool {
+	permissions, _ := c.Locals("permissions").([]string)
+	return slices.Contains(permissions, permission)
+}
+
+// parseFooIds parses a comma separated list of foo ids.
+func parseFooIds(list string) ([]int64, error) {
+	fooIds := []int64{}
+	for _, field := range strings.Split(list, ",") {
+		fooId, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil || fooId <= 0 {
+			return nil, apperrors.BadRequest("DWLEDI", fmt.Sprintf("%q is not a foo id.", field))
+		}
+		fooIds = append(fooIds, fooId)
+	}
+	return fooIds, nil
+}
+
+// fooBatchResult is the outcome of one item of a batch request. Index is the position of the item in the request.
+type fooBatchResult struct {
+	Index   int                    `json:"index"`
+	ID      int64                  `json:"id,omitempty"`
+	Status  int                    `json:"status"`
+	Foo     *models.Foo            `json:"foo,omitempty"`
+	Message string                 `json:"message,omitempty"`
+	Errors  []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// sendFooBatchResults responds with the outcome of every item. The status is 200 when every item
+// succeeded and 207 Multi-Status when some failed.
+func sendFooBatchResults(c *fiber.Ctx, items []models.FooBatchItem) error {
+	results := make([]fooBatchResult, len(items))
+	failed := 0
+	for i, item := range items {
+		results[i] = fooBatchResult{Index: i, ID: item.ID, Status: fiber.StatusOK, Foo: item.Foo}
+		if item.Err != nil {
+			results[i].Status, results[i].Message, results[i].Errors = fooBatchItemError(item)
+			failed++
+		}
+	}
+
+	status := fiber.StatusOK
+	if failed > 0 {
+		status = fiber.StatusMultiStatus
+	}
+	return c.Status(status).JSON(fiber.Map{"results": results, "succeeded": len(items) - failed, "failed": failed})
+}
+
+// fooBatchItemError maps the error of a failed batch item to its status, message and field errors.
+// Like for whole requests only the messages of typed errors are shown to the client.
+func fooBatchItemError(item models.FooBatchItem) (status int, message string, fields []apperrors.FieldError) {
+	appError, ok := apperrors.From(item.Err)
+	if !ok || appError.Kind == apperrors.KindInternal {
+		return fiber.StatusInternalServerError, "Error V5V1T5 - Processing foo failed.", nil
+	}
+	return appError.Kind.Status(), fmt.Sprintf("Error %s - %s", appError.Code, appError.Message), appError.Fields
+}
+
+func (fooHandler *FooHandler) HandleDeleteFoo(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	err = (*fooHandler.fooService).DeleteFoo(c.UserContext(), int64(fooId))
+	if err != nil {
+		return apperrors.Internal(err, "UP8EVE", "Deleting foo failed.")
+	}
+	return c.JSON(fiber.Map{"message": fmt.Sprintf("Foo %d deleted.", fooId)})
+}
+
+func (fooHandler *FooHandler) HandleRestoreFoo(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	foo, err := (*fooHandler.fooService).RestoreFoo(c.UserContext(), int64(fooId))
+	if err != nil {
+		return apperrors.Internal(err, "6WPRJ3", "Restoring foo failed.")
+	}
+	c.Set(fiber.HeaderETag, fooETag(foo))
+	return c.JSON(foo)
+}
+
+// HandlePurgeFoos hard deletes foos soft deleted longer ago than the older_than query param, a Go duration like "720h".
+// Without older_than the FOO_PURGE_RETENTION config is used.
+func (fooHandler *FooHandler) HandlePurgeFoos(c *fiber.Ctx) error {
+	olderThan := *models.GlobalConfig.GetFooPurgeRetention()
+	if c.Query("older_than") != "" {
+		var err error
+		olderThan, err = time.ParseDuration(c.Query("older_than"))
+		if err != nil || olderThan <= 0 {
+			return apperrors.BadRequest("4YV4QM", "older_than must be a positive duration like 720h.")
+		}
+	}
+
+	rowsAffected, err := (*fooHandler.fooService).PurgeFoos(c.UserContext(), olderThan)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error 8HCIPG - Deleting foos in handler."})
+		return apperrors.Internal(err, "RQDXOK", "Purging foos failed.")
 	}
-	return c.JSON(fiber.Map{"message": fmt.Sprintf("%d foos deleted.", rowsAffected)})
+	return c.JSON(fiber.Map{"message": fmt.Sprintf("%d foos purged.", rowsAffected)})
 }
 
+// HandleUpdateFoo replaces the foo. The If-Match header must hold the ETag the client last saw,
+// so concurrent edits fail with 412 instead of silently overwriting each other.
 func (fooHandler *FooHandler) HandleUpdateFoo(c *fiber.Ctx) error {
 	fooId, err := c.ParamsInt("id", 0)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Error L41Q1S - Foo id is not a number."})
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
 	}
 	if fooId == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Error ZR53ES - No foo id was provided."})
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	if c.Get(fiber.HeaderIfMatch) == "" {
+		return apperrors.New(apperrors.KindPreconditionRequired, "AYK3WK", "The If-Match header is required.")
+	}
+	version, err := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
 	}
 
-	updatedFoo := models.Foo{}
+	updatedFoo := models.FooRequest{}
 	if err := c.BodyParser(&updatedFoo); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Error O1WQ9B - Bad request body."})
+		return apperrors.BadRequest("O1WQ9B", "Bad request body.").WithCause(err)
+	}
+	if err := validation.Check("PSWVLB", &updatedFoo); err != nil {
+		return err
+	}
+
+	foo, err := (*fooHandler.fooService).UpdateFoo(c.UserContext(), int64(fooId), updatedFoo.Name, version)
+	if err != nil {
+		return apperrors.Internal(err, "FSYTGZ", "Updating foo failed.")
+	}
+	c.Set(fiber.HeaderETag, fooETag(foo))
+	return c.JSON(foo)
+}
+
+// fooETag is the strong ETag of a foo, its quoted version.
+func fooETag(foo *models.Foo) string {
+	return strconv.Quote(strconv.Itoa(foo.Version))
+}
+
+// parseIfMatch returns the foo version in an If-Match header. "*" matches any version and returns 0.
+// If-Match uses strong comparison so weak ETags never match.
+func parseIfMatch(ifMatch string) (version int, err error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, apperrors.PreconditionFailed("SRTDD2", "Weak ETags can not be used with If-Match.")
+	}
+
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, apperrors.PreconditionFailed("54FUB0", "If-Match must be a single quoted ETag.").WithCause(err)
+	}
+
+	version, err = strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, apperrors.PreconditionFailed("RED78D", fmt.Sprintf("%s is not a foo ETag.", ifMatch))
+	}
+
+	return version, nil
+}
+
+// HandlePatchFoo changes only the foo fields in the body. The body is an RFC 7386 merge patch
+// (application/merge-patch+json or application/json) or an RFC 6902 JSON Patch (application/json-patch+json).
+// Like PUT the If-Match header is required.
+func (fooHandler *FooHandler) HandlePatchFoo(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	if c.Get(fiber.HeaderIfMatch) == "" {
+		return apperrors.New(apperrors.KindPreconditionRequired, "AYK3WK", "The If-Match header is required.")
+	}
+	version, err := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	var patch *models.FooPatch
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case mimeMergePatch, fiber.MIMEApplicationJSON:
+		patch, err = models.ParseFooMergePatch(c.Body())
+	case mimeJSONPatch:
+		patch, err = models.ParseFooJSONPatch(c.Body())
+	default:
+		c.Set("Accept-Patch", mimeMergePatch+", "+mimeJSONPatch)
+		return apperrors.New(apperrors.KindUnsupportedMediaType, "A2Q8ST", fmt.Sprintf("The body must be %s or %s.", mimeMergePatch, mimeJSONPatch))
+	}
+	if err != nil {
+		return err
+	}
+	if err := validation.Check("565P8G", patch); err != nil {
+		return err
+	}
+
+	foo, err := (*fooHandler.fooService).PatchFoo(c.UserContext(), int64(fooId), patch, version)
+	if err != nil {
+		return apperrors.Internal(err, "DFSZ52", "Patching foo failed.")
+	}
+	c.Set(fiber.HeaderETag, fooETag(foo))
+	return c.JSON(foo)
+}
+
+// HandleGetFooShares lists who the foo with the :id is shared with.
+func (fooHandler *FooHandler) HandleGetFooShares(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	shares, err := (*fooHandler.fooService).GetFooShares(c.UserContext(), int64(fooId))
+	if err != nil {
+		return apperrors.Internal(err, "Y6O8CH", "Getting foo shares failed.")
+	}
+	return c.JSON(shares)
+}
+
+// HandleShareFoo shares the foo with the :id with the user or role in the body.
+func (fooHandler *FooHandler) HandleShareFoo(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	request := models.FooShareRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return apperrors.BadRequest("CTH5SR", "Bad request body.").WithCause(err)
+	}
+
+	share, err := (*fooHandler.fooService).ShareFoo(c.UserContext(), int64(fooId), &request)
+	if err != nil {
+		return apperrors.Internal(err, "XVA2PY", "Sharing foo failed.")
+	}
+	return c.Status(fiber.StatusCreated).JSON(share)
+}
+
+// HandleDeleteFooShare removes the share with the :shareId of the foo with the :id.
+func (fooHandler *FooHandler) HandleDeleteFooShare(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+	shareId, err := c.ParamsInt("shareId", 0)
+	if err != nil || shareId == 0 {
+		return apperrors.BadRequest("JGRXDQ", "Share id is not a number.")
+	}
+
+	if err := (*fooHandler.fooService).UnshareFoo(c.UserContext(), int64(fooId), shareId); err != nil {
+		return apperrors.Internal(err, "QLSI02", "Removing foo share failed.")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleGetFooRevisions lists every revision of the foo with the :id, oldest first.
+func (fooHandler *FooHandler) HandleGetFooRevisions(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+
+	revisions, err := (*fooHandler.fooService).GetFooRevisions(c.UserContext(), int64(fooId))
+	if err != nil {
+		return apperrors.Internal(err, "9988U9", "Getting foo revisions failed.")
+	}
+	return c.JSON(revisions)
+}
+
+// HandleGetFooRevision returns the revision of the foo with the :id written at version :rev.
+func (fooHandler *FooHandler) HandleGetFooRevision(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+	revision, err := c.ParamsInt("rev", 0)
+	if err != nil || revision <= 0 {
+		return apperrors.BadRequest("K3GTNW", "Revision is not a positive number.")
+	}
+
+	fooRevision, err := (*fooHandler.fooService).GetFooRevision(c.UserContext(), int64(fooId), revision)
+	if err != nil {
+		return apperrors.Internal(err, "NLHGBV", "Getting foo revision failed.")
+	}
+	return c.JSON(fooRevision)
+}
+
+// HandleDiffFooRevisions shows what changed in the foo with the :id from revision ?from= to revision ?to=.
+func (fooHandler *FooHandler) HandleDiffFooRevisions(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		return apperrors.BadRequest("IW5PTG", "from must be a positive revision number.")
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to <= 0 {
+		return apperrors.BadRequest("15DWX1", "to must be a positive revision number.")
+	}
+
+	diff, err := (*fooHandler.fooService).DiffFooRevisions(c.UserContext(), int64(fooId), from, to)
+	if err != nil {
+		return apperrors.Internal(err, "SJ47D8", "Diffing foo revisions failed.")
+	}
+	return c.JSON(diff)
+}
+
+// HandleRestoreFooRevision writes revision :rev of the foo with the :id back as a new revision. The If-Match header
+// is optional, with it the foo is only changed if it is still at that version.
+func (fooHandler *FooHandler) HandleRestoreFooRevision(c *fiber.Ctx) error {
+	fooId, err := c.ParamsInt("id", 0)
+	if err != nil {
+		return apperrors.BadRequest("L41Q1S", "Foo id is not a number.")
+	}
+	if fooId == 0 {
+		return apperrors.BadRequest("ZR53ES", "No foo id was provided.")
+	}
+	revision, err := c.ParamsInt("rev", 0)
+	if err != nil || revision <= 0 {
+		return apperrors.BadRequest("K3GTNW", "Revision is not a positive number.")
+	}
+
+	version := 0
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		version, err = parseIfMatch(c.Get(fiber.HeaderIfMatch))
+		if err != nil {
+			return err
+		}
 	}
 
-	foo, err := (*fooHandler.fooService).UpdateFoo(int64(fooId), updatedFoo.Name)
+	foo, err := (*fooHandler.fooService).RestoreFooRevision(c.UserContext(), int64(fooId), revision, version)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": fmt.Sprintf("Error FSYTGZ - Updating foo. Error: %v", err)})
+		return apperrors.Internal(err, "Z84V5K", "Restoring foo revision failed.")
 	}
-	// if rowsAffected == 0 {
-	// 	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": fmt.Sprintf("Error XLM18M - Foo was not found with id %d.", fooId)})
-	// }
+	c.Set(fiber.HeaderETag, fooETag(foo))
 	return c.JSON(foo)
 }
@@ -2,22 +2,50 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	fiber "github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap/zaptest"
 
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
 	"gitlab.com/sandstone2/fiberpoc/common/mocks"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
 )
 
+// requireProblem checks the response is a problem with the given status and code, and returns the problem.
+func requireProblem(t *testing.T, response *http.Response, status int, code string) models.Problem {
+	t.Helper()
+
+	require.Equal(t, status, response.StatusCode)
+	require.Equal(t, "application/problem+json", response.Header.Get(fiber.HeaderContentType))
+
+	problem := models.Problem{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&problem))
+	require.Equal(t, status, problem.Status)
+	require.Equal(t, code, problem.Code)
+	return problem
+}
+
+// withPermissions stubs middleware.Authorize, the request has permissions.
+func withPermissions(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("permissions", permissions)
+		return c.Next()
+	}
+}
+
 func TestFooHandler_HandleGetFoos_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -27,19 +55,24 @@ func TestFooHandler_HandleGetFoos_Success(t *testing.T) {
 
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
 	app.Get("/foos", fooHandler.HandleGetFoos)
 
-	expected := &[]models.Foo{
-		{ID: 1, Name: "Foo One"},
-		{ID: 2, Name: "Foo Two"},
+	total := int64(2)
+	expected := &models.FooPage{
+		Items: []models.Foo{
+			{ID: 1, Name: "Foo One", Version: 1, CreatedAt: 1700000000000},
+			{ID: 2, Name: "Foo Two", Version: 2, CreatedAt: 1700000000001, UpdatedAt: 1700000000002},
+		},
+		NextCursor: "abc",
+		Total:      &total,
 	}
 	mockFooService.
 		EXPECT().
-		GetFoos().
+		GetFoos(gomock.Any(), &models.FooListParams{Limit: 2, SortBy: "name", Order: "desc", NamePrefix: "Foo", IncludeTotal: true}).
 		Return(expected, nil)
 
-	request := httptest.NewRequest("GET", "/foos", nil)
+	request := httptest.NewRequest("GET", "/foos?limit=2&sort=name&order=DESC&name_prefix=Foo&include_total=true", nil)
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
 	defer response.Body.Close()
@@ -47,7 +80,64 @@ func TestFooHandler_HandleGetFoos_Success(t *testing.T) {
 	require.Equal(t, fiber.StatusOK, response.StatusCode)
 
 	body, _ := io.ReadAll(response.Body)
-	require.JSONEq(t, `[{"ID":1,"Name":"Foo One"},{"ID":2,"Name":"Foo Two"}]`, string(body))
+	require.JSONEq(t, `{"items":[{"id":1,"name":"Foo One","version":1,"created_at":1700000000000,"updated_at":0,"deleted_at":0,"owner_id":null},{"id":2,"name":"Foo Two","version":2,"created_at":1700000000001,"updated_at":1700000000002,"deleted_at":0,"owner_id":null}],"next_cursor":"abc","total":2}`, string(body))
+}
+
+func TestFooHandler_HandleGetFoos_BadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos", fooHandler.HandleGetFoos)
+
+	// The service must never be called for bad parameters.
+	for _, query := range []string{"limit=abc", "limit=1000", "sort=email", "offset=5&cursor=abc", "cursor=%21%21"} {
+		request := httptest.NewRequest("GET", "/foos?"+query, nil)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		response.Body.Close()
+
+		require.Equal(t, fiber.StatusBadRequest, response.StatusCode, query)
+	}
+}
+
+func TestFooHandler_HandleGetFoos_IncludeDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos", withPermissions(models.PermissionFoosRead), fooHandler.HandleGetFoos)
+	app.Get("/admin/foos", withPermissions(models.PermissionFoosRead, models.PermissionFoosReadDeleted), fooHandler.HandleGetFoos)
+
+	// 1) Test only admins may list soft deleted foos, the service must not be called
+	request := httptest.NewRequest("GET", "/foos?include_deleted=true", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	requireProblem(t, response, fiber.StatusForbidden, "76GNIL")
+	response.Body.Close()
+
+	// 2) Test an admin lists them
+	mockFooService.
+		EXPECT().
+		GetFoos(gomock.Any(), &models.FooListParams{IncludeDeleted: true}).
+		Return(&models.FooPage{Items: []models.Foo{{ID: 1, Name: "Foo One", DeletedAt: 1700000000000}}}, nil)
+
+	request = httptest.NewRequest("GET", "/admin/foos?include_deleted=true", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
 }
 
 func TestFooHandler_HandleGetFoos_Error(t *testing.T) {
@@ -61,14 +151,14 @@ func TestFooHandler_HandleGetFoos_Error(t *testing.T) {
 
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
 	app.Get("/foos", fooHandler.HandleGetFoos)
 
 	// Stub service to return an error
 	serviceErr := errors.New("db failure")
 	mockFooService.
 		EXPECT().
-		GetFoos().
+		GetFoos(gomock.Any(), gomock.Any()).
 		Return(nil, serviceErr)
 
 	request := httptest.NewRequest("GET", "/foos", nil)
@@ -76,51 +166,107 @@ func TestFooHandler_HandleGetFoos_Error(t *testing.T) {
 	require.NoError(t, err)
 	defer response.Body.Close()
 
-	require.Equal(t, fiber.StatusInternalServerError, response.StatusCode)
-	body, _ := io.ReadAll(response.Body)
-	require.JSONEq(t, `{"message":"Error J5TSGF - Getting foos in handler. Error: db failure"}`, string(body))
+	problem := requireProblem(t, response, fiber.StatusInternalServerError, "J5TSGF")
+	require.Equal(t, "Getting foos failed.", problem.Detail, "the cause must not be sent to the client")
 }
 
-func TestFooHandler_HandleCreateFoo_Success(t *testing.T) {
+func TestFooHandler_HandleGetFoos_ContextErrors(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockFooService := mocks.NewMockFooService(ctrl)
 	logger := zaptest.NewLogger(t)
+
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
-	app.Post("/foo", fooHandler.HandleCreateFoo)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Use(middleware.ContextMiddleware(time.Minute))
+	app.Get("/foos", fooHandler.HandleGetFoos)
 
-	// Prepare the input Foo JSON
-	inputJSON := `{"Name":"New Foo"}`
-	request := httptest.NewRequest("POST", "/foo", strings.NewReader(inputJSON))
-	request.Header.Set("Content-Type", "application/json")
+	// Test the request context reaches the service with a deadline, and running out of time is a 504
+	mockFooService.
+		EXPECT().
+		GetFoos(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, params *models.FooListParams) (*models.FooPage, error) {
+			_, hasDeadline := ctx.Deadline()
+			require.True(t, hasDeadline, "the context should have the request deadline")
+			return nil, fmt.Errorf("Error: WZDCXT - Getting foos.: %w", context.DeadlineExceeded)
+		})
 
-	// Expected Foo to be returned from the service
-	createdFoo := &models.Foo{ID: 1, Name: "New Foo"}
+	request := httptest.NewRequest("GET", "/foos", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusGatewayTimeout, response.StatusCode)
+}
+
+func TestFooHandler_HandleSearchFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/search", fooHandler.HandleSearchFoos)
 
-	// Expect CreateFoo(name) to be called with "New Foo" and return createdFoo
 	mockFooService.
 		EXPECT().
-		CreateFoo("New Foo").
-		Return(createdFoo, nil)
+		SearchFoos(gomock.Any(), &models.FooSearchParams{Query: "garden party", Limit: 5}).
+		Return([]models.FooSearchResult{{Foo: models.Foo{ID: 3, Name: "Garden Party", Version: 1}, Rank: 1.5, Snippet: "<b>Garden</b> <b>Party</b>"}}, nil)
 
+	request := httptest.NewRequest("GET", "/foos/search?q=garden+party&limit=5", nil)
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
 	defer response.Body.Close()
 
 	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `[{
+		"foo":{"id":3,"name":"Garden Party","version":1,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null},
+		"rank":1.5,
+		"snippet":"<b>Garden</b> <b>Party</b>"
+	}]`, string(body))
+}
 
-	body, err := io.ReadAll(response.Body)
+func TestFooHandler_HandleSearchFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/search", fooHandler.HandleSearchFoos)
+
+	// 1) Test bad parameters, the service must not be called
+	for query, code := range map[string]string{"": "MKNBCH", "q=party&limit=abc": "O1CCAI", "q=party&limit=1000": "HDSQQ9"} {
+		request := httptest.NewRequest("GET", "/foos/search?"+query, nil)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+
+		requireProblem(t, response, fiber.StatusBadRequest, code)
+		response.Body.Close()
+	}
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		SearchFoos(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("fail"))
+
+	request := httptest.NewRequest("GET", "/foos/search?q=party", nil)
+	response, err := app.Test(request, -1)
 	require.NoError(t, err)
+	defer response.Body.Close()
 
-	// The handler returns the created Foo object as JSON
-	expectedJSON := `{"ID":1,"Name":"New Foo"}`
-	require.JSONEq(t, expectedJSON, string(body))
+	requireProblem(t, response, fiber.StatusInternalServerError, "LFE4N3")
 }
 
-func TestFooHandler_HandleCreateFoo_Error(t *testing.T) {
+func TestFooHandler_HandleExportFoos_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -128,66 +274,262 @@ func TestFooHandler_HandleCreateFoo_Error(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
-	app.Post("/foo", fooHandler.HandleCreateFoo)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/export", withPermissions(models.PermissionFoosRead, models.PermissionFoosReadDeleted), fooHandler.HandleExportFoos)
 
-	inputJSON := `{"Name":"Bad Foo"}`
-	request := httptest.NewRequest("POST", "/foo", strings.NewReader(inputJSON))
-	request.Header.Set("Content-Type", "application/json")
+	ownerId := 5
+	foos := []models.Foo{
+		{ID: 1, Name: "Foo, One", Version: 1, CreatedAt: 1700000000000, OwnerID: &ownerId},
+		{ID: 2, Name: "Foo Two", Version: 2, CreatedAt: 1700000000001, UpdatedAt: 1700000000002, DeletedAt: 1700000000003},
+	}
+	exportFoos := func(_ context.Context, _ bool, fn func(foo *models.Foo) error) error {
+		for i := range foos {
+			if err := fn(&foos[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
-	expectedErr := errors.New("fail")
+	tests := []struct {
+		target      string
+		accept      string
+		contentType string
+		body        string
+	}{
+		// 1) Test CSV is the default
+		{"/foos/export?include_deleted=true", "", models.MIMETextCSV,
+			"id,name,version,created_at,updated_at,deleted_at,owner_id\n" +
+				"1,\"Foo, One\",1,1700000000000,0,0,5\n" +
+				"2,Foo Two,2,1700000000001,1700000000002,1700000000003,\n"},
+		// 2) Test NDJSON picked with the Accept header
+		{"/foos/export?include_deleted=true", models.MIMEApplicationNDJSON, models.MIMEApplicationNDJSON,
+			`{"id":1,"name":"Foo, One","version":1,"created_at":1700000000000,"updated_at":0,"deleted_at":0,"owner_id":5}` + "\n" +
+				`{"id":2,"name":"Foo Two","version":2,"created_at":1700000000001,"updated_at":1700000000002,"deleted_at":1700000000003,"owner_id":null}` + "\n"},
+		// 3) Test the format param wins over the Accept header
+		{"/foos/export?include_deleted=true&format=csv", models.MIMEApplicationNDJSON, models.MIMETextCSV,
+			"id,name,version,created_at,updated_at,deleted_at,owner_id\n" +
+				"1,\"Foo, One\",1,1700000000000,0,0,5\n" +
+				"2,Foo Two,2,1700000000001,1700000000002,1700000000003,\n"},
+	}
+
+	for _, test := range tests {
+		mockFooService.EXPECT().ExportFoos(gomock.Any(), true, gomock.Any()).DoAndReturn(exportFoos)
+
+		request := httptest.NewRequest("GET", test.target, nil)
+		if test.accept != "" {
+			request.Header.Set(fiber.HeaderAccept, test.accept)
+		}
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+
+		require.Equal(t, fiber.StatusOK, response.StatusCode)
+		require.Equal(t, test.contentType, response.Header.Get(fiber.HeaderContentType))
+		require.Contains(t, response.Header.Get(fiber.HeaderContentDisposition), "attachment")
+		body, _ := io.ReadAll(response.Body)
+		require.Equal(t, test.body, string(body))
+		response.Body.Close()
+	}
+}
+
+func TestFooHandler_HandleExportFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/export", withPermissions(models.PermissionFoosRead), fooHandler.HandleExportFoos)
+
+	// 1) Test an unknown format, the service must not be called
+	request := httptest.NewRequest("GET", "/foos/export?format=xml", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	requireProblem(t, response, fiber.StatusBadRequest, "LK3WT4")
+	response.Body.Close()
+
+	// 2) Test an Accept header without a format of the export
+	request = httptest.NewRequest("GET", "/foos/export", nil)
+	request.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationXML)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	requireProblem(t, response, fiber.StatusNotAcceptable, "Y2PFGX")
+	response.Body.Close()
+
+	// 3) Test only admins may export soft deleted foos
+	request = httptest.NewRequest("GET", "/foos/export?include_deleted=true", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	requireProblem(t, response, fiber.StatusForbidden, "FNJGPG")
+	response.Body.Close()
+
+	// 4) Test service failure cuts the export short after the foos written so far
 	mockFooService.
 		EXPECT().
-		CreateFoo("Bad Foo").
-		Return(nil, expectedErr)
+		ExportFoos(gomock.Any(), false, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ bool, fn func(foo *models.Foo) error) error {
+			if err := fn(&models.Foo{ID: 1, Name: "Foo One", Version: 1}); err != nil {
+				return err
+			}
+			return errors.New("fail")
+		})
+
+	request = httptest.NewRequest("GET", "/foos/export?format=ndjson", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.Equal(t, `{"id":1,"name":"Foo One","version":1,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null}`+"\n", string(body))
+}
+
+func TestFooHandler_HandleImportFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/import", fooHandler.HandleImportFoos)
 
+	// 1) Test a CSV import, an export can be imported as is
+	mockFooService.
+		EXPECT().
+		ImportFoos(gomock.Any(), []models.FooImportRow{{Line: 2, Name: "Foo One"}, {Line: 3, Name: "Foo Two"}}, false).
+		Return(&models.FooImportReport{Total: 2, Imported: 2, Errors: []models.FooImportError{}}, nil)
+
+	request := httptest.NewRequest("POST", "/foos/import", strings.NewReader(
+		"id,name,version,created_at,updated_at,deleted_at,owner_id\n"+
+			"1,Foo One,1,1700000000000,0,0,5\n"+
+			"2,Foo Two,2,1700000000001,0,0,\n",
+	))
+	request.Header.Set(fiber.HeaderContentType, models.MIMETextCSV+"; charset=utf-8")
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"dry_run":false,"total":2,"imported":2,"errors":[]}`, string(body))
+	response.Body.Close()
+
+	// 2) Test a dry run of NDJSON picked with the format param
+	mockFooService.
+		EXPECT().
+		ImportFoos(gomock.Any(), []models.FooImportRow{{Line: 1, Name: "Foo One"}, {Line: 3, Name: "Foo Two"}}, true).
+		Return(&models.FooImportReport{DryRun: true, Total: 2, Imported: 2, Errors: []models.FooImportError{}}, nil)
+
+	request = httptest.NewRequest("POST", "/foos/import?format=ndjson&dry_run=true", strings.NewReader(
+		"{\"name\":\"Foo One\"}\n\n{\"name\":\"Foo Two\"}\n",
+	))
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
 	defer response.Body.Close()
 
-	require.Equal(t, fiber.StatusInternalServerError, response.StatusCode)
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	require.JSONEq(t, `{"dry_run":true,"total":2,"imported":2,"errors":[]}`, string(body))
+}
 
-	body, err := io.ReadAll(response.Body)
+func TestFooHandler_HandleImportFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/import", fooHandler.HandleImportFoos)
+
+	// 1) Test a body that is not CSV or NDJSON, the service must not be called
+	request := httptest.NewRequest("POST", "/foos/import", strings.NewReader(`[{"name":"Foo One"}]`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	requireProblem(t, response, fiber.StatusUnsupportedMediaType, "3PCC3G")
+	response.Body.Close()
+
+	// 2) Test a CSV without a name column
+	request = httptest.NewRequest("POST", "/foos/import", strings.NewReader("id,title\n1,Foo One\n"))
+	request.Header.Set(fiber.HeaderContentType, models.MIMETextCSV)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	requireProblem(t, response, fiber.StatusBadRequest, "8A1Q1B")
+	response.Body.Close()
+
+	// 3) Test rows that are not valid are reported by line
+	mockFooService.
+		EXPECT().
+		ImportFoos(gomock.Any(), gomock.Any(), false).
+		DoAndReturn(func(_ context.Context, rows []models.FooImportRow, _ bool) (*models.FooImportReport, error) {
+			require.Len(t, rows, 2)
+			require.Equal(t, 2, rows[1].Line)
+			require.Equal(t, "json", rows[1].Err.Code)
+			return &models.FooImportReport{Total: 2, Errors: []models.FooImportError{{Line: 2, Errors: []apperrors.FieldError{*rows[1].Err}}}}, nil
+		})
+
+	request = httptest.NewRequest("POST", "/foos/import", strings.NewReader("{\"name\":\"Foo One\"}\n{\"name\":7}\n"))
+	request.Header.Set(fiber.HeaderContentType, models.MIMEApplicationNDJSON)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+
+	require.Equal(t, fiber.StatusUnprocessableEntity, response.StatusCode)
+	report := models.FooImportReport{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&report))
+	require.Equal(t, 0, report.Imported)
+	require.Equal(t, 2, report.Errors[0].Line)
+	response.Body.Close()
+
+	// 4) Test service failure
+	mockFooService.
+		EXPECT().
+		ImportFoos(gomock.Any(), gomock.Any(), false).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("POST", "/foos/import", strings.NewReader("name\nFoo One\n"))
+	request.Header.Set(fiber.HeaderContentType, models.MIMETextCSV)
+	response, err = app.Test(request, -1)
 	require.NoError(t, err)
+	defer response.Body.Close()
 
-	// Your handler formats error with the err string
-	expectedMessage := fmt.Sprintf(`{"message":"Error QONMRA - Creating foo in handler. Error: %v"}`, expectedErr)
-	require.JSONEq(t, expectedMessage, string(body))
+	requireProblem(t, response, fiber.StatusInternalServerError, "ULMJM7")
 }
 
-func TestFooHandler_HandleDeleteFoos_Success(t *testing.T) {
+func TestFooHandler_HandleGetFoo_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// 1. Create mock service and logger
 	mockFooService := mocks.NewMockFooService(ctrl)
 	logger := zaptest.NewLogger(t)
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	// 2. Set up a Fiber app and route
-	app := fiber.New()
-	app.Delete("/foos", fooHandler.HandleDeleteFoos)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id", fooHandler.HandleGetFoo)
 
-	// 3. Stub service to return 5 rows deleted
 	mockFooService.
 		EXPECT().
-		DeleteFoos().
-		Return(int64(5), nil)
+		GetFooByID(gomock.Any(), int64(42)).
+		Return(&models.Foo{ID: 42, Name: "Foo Forty Two", Version: 1, CreatedAt: 1700000000000}, nil)
 
-	// 4. Perform the HTTP request
-	request := httptest.NewRequest("DELETE", "/foos", nil)
+	request := httptest.NewRequest("GET", "/foos/42", nil)
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
 	defer response.Body.Close()
 
-	// 5. Assertions
 	require.Equal(t, fiber.StatusOK, response.StatusCode)
+
+	require.Equal(t, `"1"`, response.Header.Get(fiber.HeaderETag))
+
 	body, _ := io.ReadAll(response.Body)
-	expected := `{"message":"5 foos deleted."}`
-	require.JSONEq(t, expected, string(body))
+	require.JSONEq(t, `{"id":42,"name":"Foo Forty Two","version":1,"created_at":1700000000000,"updated_at":0,"deleted_at":0,"owner_id":null}`, string(body))
 }
 
-func TestFooHandler_HandleDeleteFoos_Error(t *testing.T) {
+func TestFooHandler_HandleGetFoo_NotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -195,31 +537,24 @@ func TestFooHandler_HandleDeleteFoos_Error(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
-	app.Delete("/foo", fooHandler.HandleDeleteFoos)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id", fooHandler.HandleGetFoo)
 
-	// 3. Stub service to return an error
+	// Stub service to return a wrapped not found error
 	mockFooService.
 		EXPECT().
-		DeleteFoos().
-		Return(int64(0), errors.New("fail"))
+		GetFooByID(gomock.Any(), int64(42)).
+		Return(nil, fmt.Errorf("wrapped: %w", apperrors.NotFound("39YZ4S", "No foo found with id 42.")))
 
-	// 4. Perform the HTTP request
-	request := httptest.NewRequest("DELETE", "/foo", nil)
+	request := httptest.NewRequest("GET", "/foos/42", nil)
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
 	defer response.Body.Close()
 
-	// 5. Assertions
-	require.Equal(t, fiber.StatusInternalServerError, response.StatusCode)
-	body, _ := io.ReadAll(response.Body)
-	require.JSONEq(t,
-		`{"message":"Error 8HCIPG - Deleting foos in handler."}`,
-		string(body),
-	)
+	requireProblem(t, response, fiber.StatusNotFound, "39YZ4S")
 }
 
-func TestFooHandler_HandleUpdateFoo_Success(t *testing.T) {
+func TestFooHandler_HandleGetFoo_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -227,24 +562,56 @@ func TestFooHandler_HandleUpdateFoo_Success(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
-	// Use route with :id param to match handler expectations
-	app.Patch("/foo/:id", fooHandler.HandleUpdateFoo)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id", fooHandler.HandleGetFoo)
 
-	// Prepare request body JSON with updated Foo name
-	inputJSON := `{"Name":"Updated Foo"}`
+	// 1) Test id is not a number, the service must not be called
+	request := httptest.NewRequest("GET", "/foos/abc", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
 
-	// Expect UpdateFoo to be called with id=42 and name="Updated Foo"
-	expectedFoo := &models.Foo{ID: 42, Name: "Updated Foo"}
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
 
+	// 2) Test service failure
 	mockFooService.
 		EXPECT().
-		UpdateFoo(int64(42), "Updated Foo").
-		Return(expectedFoo, nil)
+		GetFooByID(gomock.Any(), int64(42)).
+		Return(nil, errors.New("fail"))
 
-	request := httptest.NewRequest("PATCH", "/foo/42", strings.NewReader(inputJSON))
+	request = httptest.NewRequest("GET", "/foos/42", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "HYFSK1")
+}
+
+func TestFooHandler_HandleCreateFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foo", fooHandler.HandleCreateFoo)
+
+	// Prepare the input Foo JSON
+	inputJSON := `{"name":"New Foo"}`
+	request := httptest.NewRequest("POST", "/foo", strings.NewReader(inputJSON))
 	request.Header.Set("Content-Type", "application/json")
 
+	// Expected Foo to be returned from the service
+	createdFoo := &models.Foo{ID: 1, Name: "New Foo", Version: 1, CreatedAt: 1700000000000}
+
+	// Expect CreateFoo(name) to be called with "New Foo" and return createdFoo
+	mockFooService.
+		EXPECT().
+		CreateFoo(gomock.Any(), "New Foo").
+		Return(createdFoo, nil)
+
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
 	defer response.Body.Close()
@@ -254,11 +621,12 @@ func TestFooHandler_HandleUpdateFoo_Success(t *testing.T) {
 	body, err := io.ReadAll(response.Body)
 	require.NoError(t, err)
 
-	expectedJSON := `{"ID":42,"Name":"Updated Foo"}`
+	// The handler returns the created Foo object as JSON
+	expectedJSON := `{"id":1,"name":"New Foo","version":1,"created_at":1700000000000,"updated_at":0,"deleted_at":0,"owner_id":null}`
 	require.JSONEq(t, expectedJSON, string(body))
 }
 
-func TestFooHandler_HandleUpdateFoo_Error(t *testing.T) {
+func TestFooHandler_HandleCreateFoo_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -266,29 +634,1427 @@ func TestFooHandler_HandleUpdateFoo_Error(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 	fooHandler := NewFooHandler(mockFooService, logger)
 
-	app := fiber.New()
-	app.Patch("/foo/:id", fooHandler.HandleUpdateFoo)
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foo", fooHandler.HandleCreateFoo)
 
-	inputJSON := `{"Name":"Updated Foo"}`
-	request := httptest.NewRequest("PATCH", "/foo/42", strings.NewReader(inputJSON))
+	inputJSON := `{"name":"Bad Foo"}`
+	request := httptest.NewRequest("POST", "/foo", strings.NewReader(inputJSON))
 	request.Header.Set("Content-Type", "application/json")
 
 	expectedErr := errors.New("fail")
-
 	mockFooService.
 		EXPECT().
-		UpdateFoo(int64(42), "Updated Foo").
+		CreateFoo(gomock.Any(), "Bad Foo").
 		Return(nil, expectedErr)
 
 	response, err := app.Test(request, -1)
 	require.NoError(t, err)
 	defer response.Body.Close()
 
-	require.Equal(t, fiber.StatusInternalServerError, response.StatusCode)
+	requireProblem(t, response, fiber.StatusInternalServerError, "QONMRA")
+}
 
-	body, err := io.ReadAll(response.Body)
+func TestFooHandler_HandleCreateFoo_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foo", fooHandler.HandleCreateFoo)
+
+	// 1) Test bad names, the service must not be called
+	tests := []struct {
+		inputJSON string
+		rule      string
+	}{
+		{`{"name":"   "}`, "required"},
+		{`{}`, "required"},
+		{fmt.Sprintf(`{"name":%q}`, strings.Repeat("x", 51)), "max"},
+		{`{"name":"New\nFoo"}`, "charset"},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("POST", "/foo", strings.NewReader(test.inputJSON))
+		request.Header.Set("Content-Type", "application/json")
+
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		problem := requireProblem(t, response, fiber.StatusUnprocessableEntity, "X471N0")
+		require.Len(t, problem.Errors, 1, test.inputJSON)
+		require.Equal(t, "name", problem.Errors[0].Field, test.inputJSON)
+		require.Equal(t, test.rule, problem.Errors[0].Code, test.inputJSON)
+	}
+
+	// 2) Test the name is trimmed before it reaches the service
+	mockFooService.
+		EXPECT().
+		CreateFoo(gomock.Any(), "New Foo").
+		Return(&models.Foo{ID: 1, Name: "New Foo", Version: 1}, nil)
+
+	request := httptest.NewRequest("POST", "/foo", strings.NewReader(`{"name":"  New Foo  "}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := app.Test(request, -1)
 	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+
+	// 3) Test a constraint violation from the database has the same format
+	mockFooService.
+		EXPECT().
+		CreateFoo(gomock.Any(), "Taken Foo").
+		Return(nil, fmt.Errorf("Error: DWA4G7 - Creating foos.: %w", apperrors.Validation("LKSTDT", "The request is not valid.").WithFields(apperrors.FieldError{Field: "name", Code: "unique", Message: "This value is already taken."})))
+
+	request = httptest.NewRequest("POST", "/foo", strings.NewReader(`{"name":"Taken Foo"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	problem := requireProblem(t, response, fiber.StatusUnprocessableEntity, "LKSTDT")
+	require.Equal(t, []apperrors.FieldError{{Field: "name", Code: "unique", Message: "This value is already taken."}}, problem.Errors)
+}
+
+func TestFooHandler_HandleDeleteFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	models.GlobalConfig = &models.AppConfig{AllowDeleteAllFoos: true}
+
+	// 1. Create mock service and logger
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	// 2. Set up a Fiber app and route
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos", withPermissions(models.PermissionFoosDelete, models.PermissionFoosDeleteAll), fooHandler.HandleDeleteFoos)
+
+	// 3. Stub service to return 5 rows deleted
+	mockFooService.
+		EXPECT().
+		DeleteFoos(gomock.Any()).
+		Return(int64(5), nil)
+
+	// 4. Perform the HTTP request
+	request := httptest.NewRequest("DELETE", "/foos?all=true", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	// 5. Assertions
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	expected := `{"message":"5 foos deleted."}`
+	require.JSONEq(t, expected, string(body))
+}
+
+func TestFooHandler_HandleDeleteFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	models.GlobalConfig = &models.AppConfig{AllowDeleteAllFoos: true}
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foo", withPermissions(models.PermissionFoosDelete, models.PermissionFoosDeleteAll), fooHandler.HandleDeleteFoos)
+
+	// 3. Stub service to return an error
+	mockFooService.
+		EXPECT().
+		DeleteFoos(gomock.Any()).
+		Return(int64(0), errors.New("fail"))
+
+	// 4. Perform the HTTP request
+	request := httptest.NewRequest("DELETE", "/foo?all=true", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	// 5. Assertions
+	requireProblem(t, response, fiber.StatusInternalServerError, "8HCIPG")
+}
+
+func TestFooHandler_HandleDeleteFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos/:id", fooHandler.HandleDeleteFoo)
+
+	mockFooService.
+		EXPECT().
+		DeleteFoo(gomock.Any(), int64(3)).
+		Return(nil)
+
+	request := httptest.NewRequest("DELETE", "/foos/3", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"message":"Foo 3 deleted."}`, string(body))
+}
+
+func TestFooHandler_HandleDeleteFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos/:id", fooHandler.HandleDeleteFoo)
+
+	// 1) Test not found
+	mockFooService.
+		EXPECT().
+		DeleteFoo(gomock.Any(), int64(3)).
+		Return(apperrors.NotFound("PGZFG4", "No foo found with id 3."))
+
+	request := httptest.NewRequest("DELETE", "/foos/3", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusNotFound, "PGZFG4")
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		DeleteFoo(gomock.Any(), int64(3)).
+		Return(errors.New("fail"))
+
+	request = httptest.NewRequest("DELETE", "/foos/3", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "UP8EVE")
+}
+
+func TestFooHandler_HandleRestoreFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/:id/restore", fooHandler.HandleRestoreFoo)
+
+	mockFooService.
+		EXPECT().
+		RestoreFoo(gomock.Any(), int64(3)).
+		Return(&models.Foo{ID: 3, Name: "Back Again", Version: 4, CreatedAt: 1700000000000, UpdatedAt: 1700000000002}, nil)
+
+	request := httptest.NewRequest("POST", "/foos/3/restore", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"id":3,"name":"Back Again","version":4,"created_at":1700000000000,"updated_at":1700000000002,"deleted_at":0,"owner_id":null}`, string(body))
+}
+
+func TestFooHandler_HandleRestoreFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/:id/restore", fooHandler.HandleRestoreFoo)
+
+	// 1) Test no soft deleted foo
+	mockFooService.
+		EXPECT().
+		RestoreFoo(gomock.Any(), int64(3)).
+		Return(nil, apperrors.NotFound("1QTQCC", "No deleted foo found with id 3."))
+
+	request := httptest.NewRequest("POST", "/foos/3/restore", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusNotFound, response.StatusCode)
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		RestoreFoo(gomock.Any(), int64(3)).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("POST", "/foos/3/restore", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "6WPRJ3")
+}
+
+func TestFooHandler_HandlePurgeFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	models.GlobalConfig = &models.AppConfig{FooPurgeRetention: 720 * time.Hour}
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/purge", fooHandler.HandlePurgeFoos)
+
+	// 1) Test the configured retention is used by default
+	mockFooService.
+		EXPECT().
+		PurgeFoos(gomock.Any(), 720*time.Hour).
+		Return(int64(2), nil)
+
+	request := httptest.NewRequest("POST", "/foos/purge", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"message":"2 foos purged."}`, string(body))
+
+	// 2) Test older_than overrides the configured retention
+	mockFooService.
+		EXPECT().
+		PurgeFoos(gomock.Any(), 48*time.Hour).
+		Return(int64(5), nil)
+
+	request = httptest.NewRequest("POST", "/foos/purge?older_than=48h", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+}
+
+func TestFooHandler_HandlePurgeFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	models.GlobalConfig = &models.AppConfig{FooPurgeRetention: 720 * time.Hour}
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/purge", fooHandler.HandlePurgeFoos)
+
+	// 1) Test a bad older_than, the service must not be called
+	request := httptest.NewRequest("POST", "/foos/purge?older_than=-1h", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		PurgeFoos(gomock.Any(), 720*time.Hour).
+		Return(int64(0), errors.New("fail"))
+
+	request = httptest.NewRequest("POST", "/foos/purge", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "RQDXOK")
+}
+
+func TestFooHandler_HandleUpdateFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	// Use route with :id param to match handler expectations
+	app.Patch("/foo/:id", fooHandler.HandleUpdateFoo)
+
+	// Prepare request body JSON with updated Foo name
+	inputJSON := `{"name":"Updated Foo"}`
+
+	// Expect UpdateFoo to be called with id=42, name="Updated Foo" and the If-Match version
+	expectedFoo := &models.Foo{ID: 42, Name: "Updated Foo", Version: 3, CreatedAt: 1700000000000, UpdatedAt: 1700000000001}
+
+	mockFooService.
+		EXPECT().
+		UpdateFoo(gomock.Any(), int64(42), "Updated Foo", 2).
+		Return(expectedFoo, nil)
+
+	request := httptest.NewRequest("PATCH", "/foo/42", strings.NewReader(inputJSON))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `"2"`)
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	require.Equal(t, `"3"`, response.Header.Get(fiber.HeaderETag))
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	expectedJSON := `{"id":42,"name":"Updated Foo","version":3,"created_at":1700000000000,"updated_at":1700000000001,"deleted_at":0,"owner_id":null}`
+	require.JSONEq(t, expectedJSON, string(body))
+}
+
+func TestFooHandler_HandleUpdateFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Patch("/foo/:id", fooHandler.HandleUpdateFoo)
+
+	inputJSON := `{"name":"Updated Foo"}`
+	request := httptest.NewRequest("PATCH", "/foo/42", strings.NewReader(inputJSON))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", "*")
+
+	expectedErr := errors.New("fail")
+
+	mockFooService.
+		EXPECT().
+		UpdateFoo(gomock.Any(), int64(42), "Updated Foo", 0).
+		Return(nil, expectedErr)
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "FSYTGZ")
+}
+
+func TestFooHandler_HandleUpdateFoo_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Put("/foo/:id", fooHandler.HandleUpdateFoo)
+
+	inputJSON := `{"name":"Updated Foo"}`
+	request := httptest.NewRequest("PUT", "/foo/42", strings.NewReader(inputJSON))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `"1"`)
+
+	mockFooService.
+		EXPECT().
+		UpdateFoo(gomock.Any(), int64(42), "Updated Foo", 1).
+		Return(nil, apperrors.NotFound("BATWXG", "No foo found with id 42."))
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusNotFound, "BATWXG")
+}
+
+func TestFooHandler_HandleUpdateFoo_Precondition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Put("/foo/:id", fooHandler.HandleUpdateFoo)
+
+	inputJSON := `{"name":"Updated Foo"}`
+
+	// 1) Test a missing If-Match, the service must not be called
+	request := httptest.NewRequest("PUT", "/foo/42", strings.NewReader(inputJSON))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusPreconditionRequired, "AYK3WK")
+
+	// 2) Test a weak ETag, the service must not be called
+	request = httptest.NewRequest("PUT", "/foo/42", strings.NewReader(inputJSON))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `W/"1"`)
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusPreconditionFailed, response.StatusCode)
+
+	// 3) Test the foo has moved on to another version
+	mockFooService.
+		EXPECT().
+		UpdateFoo(gomock.Any(), int64(42), "Updated Foo", 1).
+		Return(nil, apperrors.PreconditionFailed("PG3L6Q", "Foo 42 is at version 2, not 1."))
+
+	request = httptest.NewRequest("PUT", "/foo/42", strings.NewReader(inputJSON))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `"1"`)
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusPreconditionFailed, "PG3L6Q")
+}
+
+func TestFooHandler_HandlePatchFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Patch("/foos/:id", fooHandler.HandlePatchFoo)
+
+	name := "Patched Foo"
+	patchedFoo := &models.Foo{ID: 42, Name: name, Version: 3}
+
+	// 1) Test a merge patch
+	mockFooService.
+		EXPECT().
+		PatchFoo(gomock.Any(), int64(42), &models.FooPatch{Name: &name}, 2).
+		Return(patchedFoo, nil)
+
+	request := httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`{"name":"Patched Foo"}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("If-Match", `"2"`)
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	require.Equal(t, `"3"`, response.Header.Get(fiber.HeaderETag))
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"id":42,"name":"Patched Foo","version":3,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null}`, string(body))
+
+	// 2) Test a JSON patch
+	mockFooService.
+		EXPECT().
+		PatchFoo(gomock.Any(), int64(42), &models.FooPatch{Name: &name}, 2).
+		Return(patchedFoo, nil)
+
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`[{"op":"replace","path":"/name","value":"Patched Foo"}]`))
+	request.Header.Set("Content-Type", "application/json-patch+json")
+	request.Header.Set("If-Match", `"2"`)
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+}
+
+func TestFooHandler_HandlePatchFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Patch("/foos/:id", fooHandler.HandlePatchFoo)
+
+	// 1) Test an unsupported content type, the service must not be called
+	request := httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`name=Patched`))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("If-Match", "*")
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusUnsupportedMediaType, response.StatusCode)
+	require.Equal(t, "application/merge-patch+json, application/json-patch+json", response.Header.Get("Accept-Patch"))
+
+	// 2) Test removing the name, the service must not be called
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`{"name":null}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("If-Match", "*")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.Contains(t, string(body), "HE7L62")
+
+	// 3) Test a read only field in a JSON patch, the service must not be called
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`[{"op":"replace","path":"/version","value":7}]`))
+	request.Header.Set("Content-Type", "application/json-patch+json")
+	request.Header.Set("If-Match", "*")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	require.Contains(t, string(body), "7D6E0S")
+
+	// 4) Test a name that is too long, the service must not be called
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(fmt.Sprintf(`{"name":%q}`, strings.Repeat("x", 51))))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("If-Match", "*")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	problem := requireProblem(t, response, fiber.StatusUnprocessableEntity, "565P8G")
+	require.Equal(t, "max", problem.Errors[0].Code)
+
+	// 5) Test the foo has moved on to another version
+	mockFooService.
+		EXPECT().
+		PatchFoo(gomock.Any(), int64(42), gomock.Any(), 1).
+		Return(nil, apperrors.PreconditionFailed("PG3L6Q", "Foo 42 is at version 2, not 1."))
+
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`{"name":"Patched Foo"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("If-Match", `"1"`)
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusPreconditionFailed, response.StatusCode)
+
+	// 6) Test not found
+	mockFooService.
+		EXPECT().
+		PatchFoo(gomock.Any(), int64(42), gomock.Any(), 0).
+		Return(nil, apperrors.NotFound("BATWXG", "No foo found with id 42."))
+
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`{"name":"Patched Foo"}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("If-Match", "*")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusNotFound, response.StatusCode)
+
+	// 7) Test service failure
+	mockFooService.
+		EXPECT().
+		PatchFoo(gomock.Any(), int64(42), gomock.Any(), 0).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("PATCH", "/foos/42", strings.NewReader(`{"name":"Patched Foo"}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("If-Match", "*")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "DFSZ52")
+}
+
+func TestFooHandler_HandleDeleteFoos_ByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos", fooHandler.HandleDeleteFoos)
+
+	// One foo is deleted and the other one is missing
+	mockFooService.
+		EXPECT().
+		DeleteFoosByID(gomock.Any(), []int64{1, 2}).
+		Return([]models.FooBatchItem{{ID: 1}, {ID: 2, Err: apperrors.NotFound("ZCM2ZO", "No foo found with id 2.")}}, nil)
+
+	request := httptest.NewRequest("DELETE", "/foos?ids=1,2", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusMultiStatus, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"results":[{"index":0,"id":1,"status":200},{"index":1,"id":2,"status":404,"message":"Error ZCM2ZO - No foo found with id 2."}],"succeeded":1,"failed":1}`, string(body))
+}
+
+func TestFooHandler_HandleDeleteFoos_Refused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	models.GlobalConfig = &models.AppConfig{AllowDeleteAllFoos: false}
+
+	// The service must never be called
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos", withPermissions(models.PermissionFoosDelete), fooHandler.HandleDeleteFoos)
+	app.Delete("/admin/foos", withPermissions(models.PermissionFoosDelete, models.PermissionFoosDeleteAll), fooHandler.HandleDeleteFoos)
+
+	// 1) Test no ids
+	request := httptest.NewRequest("DELETE", "/foos", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	// 2) Test deleting all foos without being an admin
+	models.GlobalConfig = &models.AppConfig{AllowDeleteAllFoos: true}
+	request = httptest.NewRequest("DELETE", "/foos?all=true", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusForbidden, "97PBA7")
+
+	// 3) Test deleting all foos while it is turned off
+	models.GlobalConfig = &models.AppConfig{AllowDeleteAllFoos: false}
+	request = httptest.NewRequest("DELETE", "/admin/foos?all=true", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusForbidden, "QUZ2DS")
+
+	// 4) Test bad ids
+	request = httptest.NewRequest("DELETE", "/foos?ids=1,abc", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+}
+
+func TestFooHandler_HandleCreateFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos\\:batch", fooHandler.HandleCreateFoos)
+
+	mockFooService.
+		EXPECT().
+		CreateFoos(gomock.Any(), []string{"Foo One", "Foo Two"}).
+		Return([]models.FooBatchItem{
+			{ID: 1, Foo: &models.Foo{ID: 1, Name: "Foo One", Version: 1}},
+			{ID: 2, Foo: &models.Foo{ID: 2, Name: "Foo Two", Version: 1}},
+		}, nil)
+
+	request := httptest.NewRequest("POST", "/foos:batch", strings.NewReader(`[{"name":"Foo One"},{"name":"Foo Two"}]`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"results":[
+		{"index":0,"id":1,"status":200,"foo":{"id":1,"name":"Foo One","version":1,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null}},
+		{"index":1,"id":2,"status":200,"foo":{"id":2,"name":"Foo Two","version":1,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null}}
+	],"succeeded":2,"failed":0}`, string(body))
+}
+
+func TestFooHandler_HandleCreateFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos\\:batch", fooHandler.HandleCreateFoos)
+
+	// 1) Test a body that is not an array, the service must not be called
+	request := httptest.NewRequest("POST", "/foos:batch", strings.NewReader(`{"name":"Foo One"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	// 2) Test a batch that is too big
+	mockFooService.
+		EXPECT().
+		CreateFoos(gomock.Any(), []string{}).
+		Return(nil, apperrors.Validation("NROOZB", "A batch must have between 1 and 100 items."))
+
+	request = httptest.NewRequest("POST", "/foos:batch", strings.NewReader(`[]`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusUnprocessableEntity, response.StatusCode)
+
+	// 3) Test service failure
+	mockFooService.
+		EXPECT().
+		CreateFoos(gomock.Any(), []string{"Foo One"}).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("POST", "/foos:batch", strings.NewReader(`[{"name":"Foo One"}]`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "T5GM7D")
+}
+
+func TestFooHandler_HandlePatchFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Patch("/foos\\:batch", fooHandler.HandlePatchFoos)
+
+	name := "Patched Foo"
+	mockFooService.
+		EXPECT().
+		PatchFoos(gomock.Any(), []models.FooBatchPatch{
+			{ID: 1, Version: 2, Patch: &models.FooPatch{Name: &name}},
+			{ID: 2, Version: 1, Patch: &models.FooPatch{Name: &name}},
+		}).
+		Return([]models.FooBatchItem{
+			{ID: 1, Foo: &models.Foo{ID: 1, Name: name, Version: 3}},
+			{ID: 2, Err: apperrors.PreconditionFailed("PG3L6Q", "Foo 2 is at version 4, not 1.")},
+		}, nil)
+
+	request := httptest.NewRequest("PATCH", "/foos:batch", strings.NewReader(`[
+		{"id":1,"version":2,"patch":{"name":"Patched Foo"}},
+		{"id":2,"version":1,"patch":{"name":"Patched Foo"}}
+	]`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusMultiStatus, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"results":[
+		{"index":0,"id":1,"status":200,"foo":{"id":1,"name":"Patched Foo","version":3,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null}},
+		{"index":1,"id":2,"status":412,"message":"Error PG3L6Q - Foo 2 is at version 4, not 1."}
+	],"succeeded":1,"failed":1}`, string(body))
+}
+
+func TestFooHandler_HandlePatchFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Patch("/foos\\:batch", fooHandler.HandlePatchFoos)
+
+	// 1) Test a patch changing a read only field, only that item fails
+	name := "Patched Foo"
+	mockFooService.
+		EXPECT().
+		PatchFoos(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, patches []models.FooBatchPatch) ([]models.FooBatchItem, error) {
+			require.Len(t, patches, 2)
+			require.NoError(t, patches[0].PatchErr)
+			require.Equal(t, &models.FooPatch{Name: &name}, patches[0].Patch)
+			require.Nil(t, patches[1].Patch)
+			require.Contains(t, patches[1].PatchErr.Error(), "7D6E0S")
+			return []models.FooBatchItem{
+				{ID: 1, Foo: &models.Foo{ID: 1, Name: name, Version: 3}},
+				{ID: 2, Err: patches[1].PatchErr},
+			}, nil
+		})
+
+	request := httptest.NewRequest("PATCH", "/foos:batch", strings.NewReader(`[
+		{"id":1,"version":2,"patch":{"name":"Patched Foo"}},
+		{"id":2,"version":1,"patch":{"id":5}}
+	]`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusMultiStatus, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"results":[
+		{"index":0,"id":1,"status":200,"foo":{"id":1,"name":"Patched Foo","version":3,"created_at":0,"updated_at":0,"deleted_at":0,"owner_id":null}},
+		{"index":1,"id":2,"status":400,"message":"Error 7D6E0S - The id of a foo can not be changed."}
+	],"succeeded":1,"failed":1}`, string(body))
+
+	// 2) Test a body that is not a list of patches, the service must not be called
+	request = httptest.NewRequest("PATCH", "/foos:batch", strings.NewReader(`{"id":1}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "0TNZ5T")
+
+	// 3) Test service failure
+	mockFooService.
+		EXPECT().
+		PatchFoos(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("PATCH", "/foos:batch", strings.NewReader(`[{"id":1,"version":2,"patch":{"name":"Patched Foo"}}]`))
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusInternalServerError, response.StatusCode)
+}
+
+func TestFooHandler_HandleGetFooShares_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/shares", fooHandler.HandleGetFooShares)
+
+	userId := 9
+	mockFooService.
+		EXPECT().
+		GetFooShares(gomock.Any(), int64(3)).
+		Return([]models.FooShare{{ID: 1, FooID: 3, UserID: &userId, Permission: "read", CreatedAt: 1700000000000}}, nil)
+
+	request := httptest.NewRequest("GET", "/foos/3/shares", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `[{"id":1,"foo_id":3,"user_id":9,"permission":"read","created_at":1700000000000}]`, string(body))
+}
+
+func TestFooHandler_HandleGetFooShares_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/shares", fooHandler.HandleGetFooShares)
+
+	// 1) Test a user who does not own the foo
+	mockFooService.
+		EXPECT().
+		GetFooShares(gomock.Any(), int64(3)).
+		Return(nil, apperrors.Forbidden("A2BGNI", "You need owner access to foo 3."))
+
+	request := httptest.NewRequest("GET", "/foos/3/shares", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusForbidden, "A2BGNI")
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		GetFooShares(gomock.Any(), int64(3)).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("GET", "/foos/3/shares", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "Y6O8CH")
+}
+
+func TestFooHandler_HandleShareFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/:id/shares", fooHandler.HandleShareFoo)
+
+	role := "editor"
+	mockFooService.
+		EXPECT().
+		ShareFoo(gomock.Any(), int64(3), &models.FooShareRequest{Role: &role, Permission: "write"}).
+		Return(&models.FooShare{ID: 2, FooID: 3, Role: &role, Permission: "write", CreatedAt: 1700000000000}, nil)
+
+	request := httptest.NewRequest("POST", "/foos/3/shares", strings.NewReader(`{"role":"editor","permission":"write"}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusCreated, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"id":2,"foo_id":3,"role":"editor","permission":"write","created_at":1700000000000}`, string(body))
+}
+
+func TestFooHandler_HandleShareFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/:id/shares", fooHandler.HandleShareFoo)
+
+	// 1) Test a bad body
+	request := httptest.NewRequest("POST", "/foos/3/shares", strings.NewReader(`{"role":`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "CTH5SR")
+
+	// 2) Test an invalid share
+	mockFooService.
+		EXPECT().
+		ShareFoo(gomock.Any(), int64(3), gomock.Any()).
+		Return(nil, apperrors.Validation("5ONFLQ", "The request is not valid."))
+
+	request = httptest.NewRequest("POST", "/foos/3/shares", strings.NewReader(`{"permission":"read"}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusUnprocessableEntity, "5ONFLQ")
+
+	// 3) Test service failure
+	mockFooService.
+		EXPECT().
+		ShareFoo(gomock.Any(), int64(3), gomock.Any()).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("POST", "/foos/3/shares", strings.NewReader(`{"role":"editor","permission":"read"}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "XVA2PY")
+}
+
+func TestFooHandler_HandleDeleteFooShare_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos/:id/shares/:shareId", fooHandler.HandleDeleteFooShare)
+
+	mockFooService.
+		EXPECT().
+		UnshareFoo(gomock.Any(), int64(3), 2).
+		Return(nil)
+
+	request := httptest.NewRequest("DELETE", "/foos/3/shares/2", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusNoContent, response.StatusCode)
+}
+
+func TestFooHandler_HandleDeleteFooShare_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/foos/:id/shares/:shareId", fooHandler.HandleDeleteFooShare)
+
+	// 1) Test a share id that is not a number
+	request := httptest.NewRequest("DELETE", "/foos/3/shares/abc", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "JGRXDQ")
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		UnshareFoo(gomock.Any(), int64(3), 2).
+		Return(errors.New("fail"))
+
+	request = httptest.NewRequest("DELETE", "/foos/3/shares/2", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "QLSI02")
+}
+
+func TestFooHandler_HandleGetFooRevisions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/revisions", fooHandler.HandleGetFooRevisions)
+
+	userId := 9
+	mockFooService.
+		EXPECT().
+		GetFooRevisions(gomock.Any(), int64(3)).
+		Return([]models.FooRevision{
+			{FooID: 3, Version: 1, Name: "Foo", CreatedBy: &userId, CreatedAt: 1700000000000},
+			{FooID: 3, Version: 2, Name: "Bar", CreatedAt: 1700000000001},
+		}, nil)
+
+	request := httptest.NewRequest("GET", "/foos/3/revisions", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `[
+		{"foo_id":3,"version":1,"name":"Foo","created_by":9,"created_at":1700000000000},
+		{"foo_id":3,"version":2,"name":"Bar","created_by":null,"created_at":1700000000001}
+	]`, string(body))
+}
+
+func TestFooHandler_HandleGetFooRevisions_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/revisions", fooHandler.HandleGetFooRevisions)
+
+	// 1) Test no foo id
+	request := httptest.NewRequest("GET", "/foos/0/revisions", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "ZR53ES")
+
+	// 2) Test service failure
+	mockFooService.
+		EXPECT().
+		GetFooRevisions(gomock.Any(), int64(3)).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("GET", "/foos/3/revisions", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "9988U9")
+}
+
+func TestFooHandler_HandleGetFooRevision_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/revisions/:rev", fooHandler.HandleGetFooRevision)
+
+	mockFooService.
+		EXPECT().
+		GetFooRevision(gomock.Any(), int64(3), 2).
+		Return(&models.FooRevision{FooID: 3, Version: 2, Name: "Bar", CreatedAt: 1700000000001}, nil)
+
+	request := httptest.NewRequest("GET", "/foos/3/revisions/2", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"foo_id":3,"version":2,"name":"Bar","created_by":null,"created_at":1700000000001}`, string(body))
+}
+
+func TestFooHandler_HandleGetFooRevision_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/revisions/:rev", fooHandler.HandleGetFooRevision)
+
+	// 1) Test a revision that is not a number
+	request := httptest.NewRequest("GET", "/foos/3/revisions/abc", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "K3GTNW")
+
+	// 2) Test a revision that does not exist
+	mockFooService.
+		EXPECT().
+		GetFooRevision(gomock.Any(), int64(3), 9).
+		Return(nil, apperrors.NotFound("S2DUNB", "No revision 9 found for foo 3."))
+
+	request = httptest.NewRequest("GET", "/foos/3/revisions/9", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusNotFound, "S2DUNB")
+}
+
+func TestFooHandler_HandleDiffFooRevisions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/revisions/diff", fooHandler.HandleDiffFooRevisions)
+
+	mockFooService.
+		EXPECT().
+		DiffFooRevisions(gomock.Any(), int64(3), 1, 2).
+		Return(&models.FooRevisionDiff{
+			FooID:   3,
+			From:    1,
+			To:      2,
+			Changes: []models.FooFieldChange{{Field: "name", From: "Foo", To: "Bar"}},
+		}, nil)
+
+	request := httptest.NewRequest("GET", "/foos/3/revisions/diff?from=1&to=2", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"foo_id":3,"from":1,"to":2,"changes":[{"field":"name","from":"Foo","to":"Bar"}]}`, string(body))
+}
+
+func TestFooHandler_HandleDiffFooRevisions_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/foos/:id/revisions/diff", fooHandler.HandleDiffFooRevisions)
+
+	// 1) Test a missing from, the service must not be called
+	request := httptest.NewRequest("GET", "/foos/3/revisions/diff?to=2", nil)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "IW5PTG")
+
+	// 2) Test a to that is not a number
+	request = httptest.NewRequest("GET", "/foos/3/revisions/diff?from=1&to=abc", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "15DWX1")
+
+	// 3) Test service failure
+	mockFooService.
+		EXPECT().
+		DiffFooRevisions(gomock.Any(), int64(3), 1, 2).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("GET", "/foos/3/revisions/diff?from=1&to=2", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusInternalServerError, "SJ47D8")
+}
+
+func TestFooHandler_HandleRestoreFooRevision_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/:id/revisions/:rev/restore", fooHandler.HandleRestoreFooRevision)
+
+	// 1) Test the If-Match version is passed on
+	mockFooService.
+		EXPECT().
+		RestoreFooRevision(gomock.Any(), int64(3), 1, 4).
+		Return(&models.Foo{ID: 3, Name: "Foo", Version: 5}, nil)
+
+	request := httptest.NewRequest("POST", "/foos/3/revisions/1/restore", nil)
+	request.Header.Set("If-Match", `"4"`)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	require.Equal(t, `"5"`, response.Header.Get("ETag"))
+
+	// 2) Test without If-Match any version is restored over
+	mockFooService.
+		EXPECT().
+		RestoreFooRevision(gomock.Any(), int64(3), 1, 0).
+		Return(&models.Foo{ID: 3, Name: "Foo", Version: 6}, nil)
+
+	request = httptest.NewRequest("POST", "/foos/3/revisions/1/restore", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	require.Equal(t, `"6"`, response.Header.Get("ETag"))
+}
+
+func TestFooHandler_HandleRestoreFooRevision_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooService := mocks.NewMockFooService(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooHandler := NewFooHandler(mockFooService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/foos/:id/revisions/:rev/restore", fooHandler.HandleRestoreFooRevision)
+
+	// 1) Test a weak ETag, the service must not be called
+	request := httptest.NewRequest("POST", "/foos/3/revisions/1/restore", nil)
+	request.Header.Set("If-Match", `W/"4"`)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusPreconditionFailed, "SRTDD2")
+
+	// 2) Test the foo moved on to another version
+	mockFooService.
+		EXPECT().
+		RestoreFooRevision(gomock.Any(), int64(3), 1, 4).
+		Return(nil, apperrors.PreconditionFailed("PG3L6Q", "Foo 3 is at version 5, not 4."))
+
+	request = httptest.NewRequest("POST", "/foos/3/revisions/1/restore", nil)
+	request.Header.Set("If-Match", `"4"`)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusPreconditionFailed, "PG3L6Q")
+
+	// 3) Test service failure
+	mockFooService.
+		EXPECT().
+		RestoreFooRevision(gomock.Any(), int64(3), 1, 0).
+		Return(nil, errors.New("fail"))
+
+	request = httptest.NewRequest("POST", "/foos/3/revisions/1/restore", nil)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
 
-	expectedMessage := fmt.Sprintf(`{"message":"Error FSYTGZ - Updating foo. Error: %v"}`, expectedErr)
-	require.JSONEq(t, expectedMessage, string(body))
+	requireProblem(t, response, fiber.StatusInternalServerError, "Z84V5K")
 }
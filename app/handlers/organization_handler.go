@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"gitlab.com/sandstone2/fiberpoc/common/validation"
+	"go.uber.org/zap"
+)
+
+type OrganizationHandler struct {
+	orgService *services.OrganizationServiceInterface
+	logger     *zap.Logger
+}
+
+func NewOrganizationHandler(orgService services.OrganizationServiceInterface, logger *zap.Logger) *OrganizationHandler {
+	return &OrganizationHandler{orgService: &orgService, logger: logger}
+}
+
+// HandleGetOrganizations lists the organizations the caller is a member of. It must run after AuthcMiddleware.
+func (orgHandler *OrganizationHandler) HandleGetOrganizations(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("WJGSIE", "You need to log in.")
+	}
+
+	orgs, err := (*orgHandler.orgService).GetOrganizations(c.UserContext(), claims)
+	if err != nil {
+		return apperrors.Internal(err, "P4V5FX", "Getting organizations failed.")
+	}
+	return c.JSON(orgs)
+}
+
+// HandleCreateOrganization creates an organization with the caller as its first member. It must run after
+// AuthcMiddleware.
+func (orgHandler *OrganizationHandler) HandleCreateOrganization(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("8UYKEY", "You need to log in.")
+	}
+
+	request := models.OrganizationRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return apperrors.BadRequest("LJEJZ7", "Bad request body.").WithCause(err)
+	}
+	if err := validation.Check("AXZEA8", &request); err != nil {
+		return err
+	}
+
+	org, err := (*orgHandler.orgService).CreateOrganization(c.UserContext(), claims, &request)
+	if err != nil {
+		return apperrors.Internal(err, "RV2Z3S", "Creating organization failed.")
+	}
+	return c.Status(fiber.StatusCreated).JSON(org)
+}
+
+// HandleAddMember makes the user with the :id a member of the organization with the :slug.
+func (orgHandler *OrganizationHandler) HandleAddMember(c *fiber.Ctx) error {
+	userId, err := userIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	if err := (*orgHandler.orgService).AddMember(c.UserContext(), c.Params("slug"), userId); err != nil {
+		return apperrors.Internal(err, "PNTXSR", "Adding member failed.")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleRemoveMember ends the membership of the user with the :id in the organization with the :slug.
+func (orgHandler *OrganizationHandler) HandleRemoveMember(c *fiber.Ctx) error {
+	userId, err := userIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	if err := (*orgHandler.orgService).RemoveMember(c.UserContext(), c.Params("slug"), userId); err != nil {
+		return apperrors.Internal(err, "VLEKLG", "Removing member failed.")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
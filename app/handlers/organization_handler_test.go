@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestOrganizationHandler_HandleCreateOrganization_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgService := mocks.NewMockOrganizationService(ctrl)
+	logger := zaptest.NewLogger(t)
+	orgHandler := NewOrganizationHandler(mockOrgService, logger)
+
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/orgs", withClaims(claims), orgHandler.HandleCreateOrganization)
+
+	mockOrgService.EXPECT().
+		CreateOrganization(gomock.Any(), claims, &models.OrganizationRequest{Slug: "acme", Name: "Acme"}).
+		Return(&models.Organization{ID: 3, Slug: "acme", Name: "Acme"}, nil)
+
+	request := httptest.NewRequest("POST", "/orgs", strings.NewReader(`{"slug": " acme ", "name": "Acme"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusCreated, response.StatusCode)
+}
+
+func TestOrganizationHandler_HandleCreateOrganization_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgService := mocks.NewMockOrganizationService(ctrl)
+	logger := zaptest.NewLogger(t)
+	orgHandler := NewOrganizationHandler(mockOrgService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/orgs", withClaims(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}), orgHandler.HandleCreateOrganization)
+
+	// A slug with upper case letters, the service must not be called
+	request := httptest.NewRequest("POST", "/orgs", strings.NewReader(`{"slug": "Acme", "name": "Acme"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusUnprocessableEntity, "AXZEA8")
+}
+
+func TestOrganizationHandler_HandleRemoveMember_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgService := mocks.NewMockOrganizationService(ctrl)
+	logger := zaptest.NewLogger(t)
+	orgHandler := NewOrganizationHandler(mockOrgService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/orgs/:slug/members/:id", orgHandler.HandleRemoveMember)
+
+	// 1) Test a user id that is not a number
+	response, err := app.Test(httptest.NewRequest("DELETE", "/orgs/acme/members/abc", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "SEVM5Q")
+
+	// 2) Test a user who is not a member
+	mockOrgService.EXPECT().
+		RemoveMember(gomock.Any(), "acme", 7).
+		Return(apperrors.NotFound("CNFLM3", "User 7 is not a member of the organization."))
+
+	response, err = app.Test(httptest.NewRequest("DELETE", "/orgs/acme/members/7", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusNotFound, "CNFLM3")
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"go.uber.org/zap"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleServiceInterface
+	logger      *zap.Logger
+}
+
+func NewRoleHandler(roleService services.RoleServiceInterface, logger *zap.Logger) *RoleHandler {
+	return &RoleHandler{roleService: &roleService, logger: logger}
+}
+
+func (roleHandler *RoleHandler) HandleGetRoles(c *fiber.Ctx) error {
+	roles, err := (*roleHandler.roleService).GetRoles(c.UserContext())
+	if err != nil {
+		return apperrors.Internal(err, "L0IP1Z", "Getting roles failed.")
+	}
+	return c.JSON(roles)
+}
+
+func (roleHandler *RoleHandler) HandleGetUserRoles(c *fiber.Ctx) error {
+	userId, err := userIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	userRoles, err := (*roleHandler.roleService).GetUserRoles(c.UserContext(), userId)
+	if err != nil {
+		return apperrors.Internal(err, "F1W8LQ", "Getting user roles failed.")
+	}
+	return c.JSON(userRoles)
+}
+
+// HandleSetUserRoles replaces all the roles of a user with the roles in the body.
+func (roleHandler *RoleHandler) HandleSetUserRoles(c *fiber.Ctx) error {
+	userId, err := userIdParam(c)
+	if err != nil {
+		return err
+	}
+
+	request := models.UserRolesRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return apperrors.BadRequest("N5T293", "Bad request body.").WithCause(err)
+	}
+	if request.Roles == nil {
+		return apperrors.Validation("TLQEQQ", "The request is not valid.").
+			WithFields(apperrors.FieldError{Field: "roles", Code: "required", Message: "This field is required."})
+	}
+
+	userRoles, err := (*roleHandler.roleService).SetUserRoles(c.UserContext(), userId, request.Roles)
+	if err != nil {
+		return apperrors.Internal(err, "VITX4B", "Setting user roles failed.")
+	}
+	return c.JSON(userRoles)
+}
+
+// userIdParam reads the :id param of the user routes.
+func userIdParam(c *fiber.Ctx) (int, error) {
+	userId, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, apperrors.BadRequest("SEVM5Q", "User id is not a number.")
+	}
+	if userId == 0 {
+		return 0, apperrors.BadRequest("OT7U2Y", "No user id was provided.")
+	}
+	return userId, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestRoleHandler_HandleSetUserRoles_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleService := mocks.NewMockRoleService(ctrl)
+	logger := zaptest.NewLogger(t)
+	roleHandler := NewRoleHandler(mockRoleService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Put("/users/:id/roles", roleHandler.HandleSetUserRoles)
+
+	mockRoleService.EXPECT().
+		SetUserRoles(gomock.Any(), 7, []string{"editor"}).
+		Return(&models.UserRoles{UserID: 7, Roles: []string{"editor"}}, nil)
+
+	request := httptest.NewRequest("PUT", "/users/7/roles", strings.NewReader(`{"roles":["editor"]}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"user_id":7,"roles":["editor"]}`, string(body))
+}
+
+func TestRoleHandler_HandleSetUserRoles_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleService := mocks.NewMockRoleService(ctrl)
+	logger := zaptest.NewLogger(t)
+	roleHandler := NewRoleHandler(mockRoleService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Put("/users/:id/roles", roleHandler.HandleSetUserRoles)
+
+	tests := []struct {
+		name      string
+		path      string
+		inputJSON string
+		status    int
+		code      string
+	}{
+		{"user id is not a number", "/users/abc/roles", `{"roles":["editor"]}`, fiber.StatusBadRequest, "SEVM5Q"},
+		{"bad body", "/users/7/roles", `{"roles":`, fiber.StatusBadRequest, "N5T293"},
+		{"no roles", "/users/7/roles", `{}`, fiber.StatusUnprocessableEntity, "TLQEQQ"},
+		{"unknown role", "/users/7/roles", `{"roles":["owner"]}`, fiber.StatusUnprocessableEntity, "3CHEMI"},
+	}
+
+	mockRoleService.EXPECT().
+		SetUserRoles(gomock.Any(), 7, []string{"owner"}).
+		Return(nil, apperrors.Validation("3CHEMI", "The request is not valid.").
+			WithFields(apperrors.FieldError{Field: "roles", Code: "unknown", Message: "No role named owner."}))
+
+	for _, test := range tests {
+		request := httptest.NewRequest("PUT", test.path, strings.NewReader(test.inputJSON))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := app.Test(request, -1)
+		require.NoError(t, err, test.name)
+		defer response.Body.Close()
+
+		requireProblem(t, response, test.status, test.code)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"go.uber.org/zap"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionServiceInterface
+	logger         *zap.Logger
+}
+
+func NewSessionHandler(sessionService services.SessionServiceInterface, logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{sessionService: &sessionService, logger: logger}
+}
+
+// HandleLogout revokes the session of the cookie and clears the cookie. It needs no login, so a stale cookie is
+// cleared as well.
+func (sessionHandler *SessionHandler) HandleLogout(c *fiber.Ctx) error {
+	if err := (*sessionHandler.sessionService).Logout(c.UserContext(), c.Cookies(models.SessionCookieName)); err != nil {
+		return apperrors.Internal(err, "Y5IOE6", "Logging out failed.")
+	}
+
+	c.ClearCookie(models.SessionCookieName)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleGetSessions lists the live sessions of the caller. It must run after AuthcMiddleware.
+func (sessionHandler *SessionHandler) HandleGetSessions(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("FFYHED", "You need to log in.")
+	}
+
+	var currentSessionId int64
+	if session, ok := c.Locals("session").(*models.Session); ok {
+		currentSessionId = session.ID
+	}
+
+	sessions, err := (*sessionHandler.sessionService).GetSessions(c.UserContext(), claims, currentSessionId)
+	if err != nil {
+		return apperrors.Internal(err, "T8ETZD", "Getting sessions failed.")
+	}
+	return c.JSON(sessions)
+}
+
+// HandleDeleteSession revokes one of the caller's sessions. It must run after AuthcMiddleware.
+func (sessionHandler *SessionHandler) HandleDeleteSession(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok {
+		return apperrors.Unauthorized("UZL3NN", "You need to log in.")
+	}
+
+	sessionId, err := c.ParamsInt("id")
+	if err != nil {
+		return apperrors.BadRequest("U48RPE", "Session id is not a number.")
+	}
+	if sessionId == 0 {
+		return apperrors.BadRequest("QX6FP4", "No session id was provided.")
+	}
+
+	if err := (*sessionHandler.sessionService).RevokeSession(c.UserContext(), claims, int64(sessionId)); err != nil {
+		return apperrors.Internal(err, "X1XGF3", "Revoking session failed.")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleRefreshToken returns a fresh ID token of the caller's session, for clients that call the API with Bearer
+// tokens. It must run after AuthcMiddleware, which renews the token, and only works with the session cookie.
+func (sessionHandler *SessionHandler) HandleRefreshToken(c *fiber.Ctx) error {
+	session, ok := c.Locals("session").(*models.Session)
+	if !ok {
+		return apperrors.BadRequest("ESBHGX", "Refreshing the ID token needs the session cookie of a browser login.")
+	}
+
+	idToken, err := (*sessionHandler.sessionService).GetIDToken(c.UserContext(), session)
+	if err != nil {
+		return apperrors.Internal(err, "WASS1P", "Refreshing the ID token failed.")
+	}
+	return c.JSON(idToken)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestSessionHandler_HandleLogout_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionService := mocks.NewMockSessionService(ctrl)
+	logger := zaptest.NewLogger(t)
+	sessionHandler := NewSessionHandler(mockSessionService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/logout", sessionHandler.HandleLogout)
+
+	mockSessionService.EXPECT().Logout(gomock.Any(), "session-1").Return(nil)
+
+	request := httptest.NewRequest("POST", "/logout", nil)
+	request.AddCookie(&http.Cookie{Name: models.SessionCookieName, Value: "session-1"})
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusNoContent, response.StatusCode)
+	cookies := response.Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, models.SessionCookieName, cookies[0].Name)
+	require.Empty(t, cookies[0].Value)
+}
+
+func TestSessionHandler_HandleDeleteSession_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionService := mocks.NewMockSessionService(ctrl)
+	logger := zaptest.NewLogger(t)
+	sessionHandler := NewSessionHandler(mockSessionService, logger)
+
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Delete("/sessions/:id", withClaims(claims), sessionHandler.HandleDeleteSession)
+
+	// 1) Test a session id that is not a number
+	response, err := app.Test(httptest.NewRequest("DELETE", "/sessions/abc", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "U48RPE")
+
+	// 2) Test a session of another user
+	mockSessionService.EXPECT().
+		RevokeSession(gomock.Any(), claims, int64(9)).
+		Return(apperrors.NotFound("V2P70O", "No session found with id 9."))
+
+	response, err = app.Test(httptest.NewRequest("DELETE", "/sessions/9", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusNotFound, "V2P70O")
+}
+
+// withSession stores a session like AuthcMiddleware does for a session cookie.
+func withSession(session *models.Session) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("session", session)
+		return c.Next()
+	}
+}
+
+func TestSessionHandler_HandleRefreshToken_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionService := mocks.NewMockSessionService(ctrl)
+	logger := zaptest.NewLogger(t)
+	sessionHandler := NewSessionHandler(mockSessionService, logger)
+
+	session := &models.Session{ID: 3}
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/token/refresh", withSession(session), sessionHandler.HandleRefreshToken)
+
+	mockSessionService.EXPECT().
+		GetIDToken(gomock.Any(), session).
+		Return(&models.IDTokenResponse{IDToken: "id-1", ExpiresAt: 1700003600000}, nil)
+
+	response, err := app.Test(httptest.NewRequest("POST", "/token/refresh", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id_token":"id-1","expires_at":1700003600000}`, string(body))
+}
+
+func TestSessionHandler_HandleRefreshToken_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionService := mocks.NewMockSessionService(ctrl)
+	logger := zaptest.NewLogger(t)
+	sessionHandler := NewSessionHandler(mockSessionService, logger)
+
+	session := &models.Session{ID: 3}
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Post("/token/refresh", sessionHandler.HandleRefreshToken)
+	app.Post("/session/token/refresh", withSession(session), sessionHandler.HandleRefreshToken)
+
+	// 1) Test a login without a session, like a Bearer token or API key
+	response, err := app.Test(httptest.NewRequest("POST", "/token/refresh", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusBadRequest, "ESBHGX")
+
+	// 2) Test a session whose ID token expired
+	mockSessionService.EXPECT().
+		GetIDToken(gomock.Any(), session).
+		Return(nil, apperrors.Unauthorized("FORJFX", "The ID token of your session has expired, log in again."))
+
+	response, err = app.Test(httptest.NewRequest("POST", "/session/token/refresh", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusUnauthorized, "FORJFX")
+}
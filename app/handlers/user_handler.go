@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"go.uber.org/zap"
+)
+
+type UserHandler struct {
+	userService *services.UserServiceInterface
+	logger      *zap.Logger
+}
+
+func NewUserHandler(userService services.UserServiceInterface, logger *zap.Logger) *UserHandler {
+	return &UserHandler{userService: &userService, logger: logger}
+}
+
+// HandleGetMe returns the stored profile of the caller. It must run after AuthcMiddleware.
+func (userHandler *UserHandler) HandleGetMe(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*models.Claims)
+	if !ok || claims.Sub == "" {
+		return apperrors.Unauthorized("7LG07M", "You need to log in.")
+	}
+
+	user, err := (*userHandler.userService).GetUserBySubject(c.UserContext(), claims.Issuer, claims.Sub)
+	if err != nil {
+		return apperrors.Internal(err, "0BLIKG", "Getting your profile failed.")
+	}
+	return c.JSON(user)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+// withClaims stands in for AuthcMiddleware and logs the request in with claims.
+func withClaims(claims *models.Claims) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if claims != nil {
+			c.Locals("user", claims)
+		}
+		return c.Next()
+	}
+}
+
+func TestUserHandler_HandleGetMe_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zaptest.NewLogger(t)
+	userHandler := NewUserHandler(mockUserService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/me", withClaims(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}), userHandler.HandleGetMe)
+
+	mockUserService.EXPECT().
+		GetUserBySubject(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return(&models.User{ID: 1, Issuer: "https://idp.example.com", Subject: "sub-1", Name: "Ada", Email: "ada@example.com", LastLoginAt: 1700000000001, CreatedAt: 1700000000000}, nil)
+
+	response, err := app.Test(httptest.NewRequest("GET", "/me", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	require.JSONEq(t, `{"id":1,"issuer":"https://idp.example.com","subject":"sub-1","name":"Ada","email":"ada@example.com","last_login_at":1700000000001,"created_at":1700000000000,"updated_at":0,"deleted_at":0}`, string(body))
+}
+
+func TestUserHandler_HandleGetMe_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserService := mocks.NewMockUserService(ctrl)
+	logger := zaptest.NewLogger(t)
+	userHandler := NewUserHandler(mockUserService, logger)
+
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler(logger)})
+	app.Get("/anonymous/me", withClaims(nil), userHandler.HandleGetMe)
+	app.Get("/me", withClaims(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}), userHandler.HandleGetMe)
+
+	// 1) Test without claims, the service must not be called
+	response, err := app.Test(httptest.NewRequest("GET", "/anonymous/me", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusUnauthorized, "7LG07M")
+
+	// 2) Test a user who never went through the login callback
+	mockUserService.EXPECT().
+		GetUserBySubject(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return(nil, apperrors.NotFound("ZW6WMS", "No user found for this login."))
+
+	response, err = app.Test(httptest.NewRequest("GET", "/me", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	requireProblem(t, response, fiber.StatusNotFound, "ZW6WMS")
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	fakeoidc "gitlab.com/sandstone2/fiberpoc/app/int_testing/fake_oidc"
+)
+
+// Runs the fake OIDC provider so the app can log in offline. Configure the app with:
+//
+//	OIDC_PROVIDERS=fake
+//	OIDC_FAKE_ISSUER=http://localhost:9999
+//	OIDC_FAKE_CLIENT_ID=fiberpoc
+func main() {
+	addr := flag.String("addr", "localhost:9999", "The host and port to listen on.")
+	sub := flag.String("sub", "fake-user", "The subject of the user who logs in.")
+	email := flag.String("email", "fake-user@example.com", "The email of the user who logs in.")
+	name := flag.String("name", "Fake User", "The name of the user who logs in.")
+	flag.Parse()
+
+	issuer, err := fakeoidc.New("http://" + *addr)
+	if err != nil {
+		log.Fatalf("Error: P0JH6R - Creating fake oidc issuer. Error: %v", err)
+	}
+	issuer.SetClaims(map[string]any{"sub": *sub, "email": *email, "name": *name})
+
+	log.Printf("Fake oidc issuer listening on %s", issuer.URL())
+	if err := http.ListenAndServe(*addr, issuer); err != nil {
+		log.Fatalf("Error: 2FYRV1 - Serving fake oidc issuer. Error: %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+// Package fakeoidc is an OIDC provider for tests and offline development. It serves the discovery document, JWKS,
+// authorize and token endpoints of a real provider, so the app logs in against it like it would against Google,
+// and signs ID tokens with any claims a test needs. It has no users or consent, every authorize request logs in the
+// user of the claims set with SetClaims. Never point a deployed app at it.
+package fakeoidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// keyId is the kid of the signing key in the JWKS and in the header of every token.
+const keyId = "fake-oidc-key"
+
+// DefaultClaims are the claims of the user who logs in until SetClaims changes them.
+var DefaultClaims = map[string]any{
+	"sub":   "fake-user",
+	"email": "fake-user@example.com",
+	"name":  "Fake User",
+}
+
+// authorization is an authorize request waiting for its code to be exchanged at the token endpoint. The refresh
+// token of an authorization that asked for offline_access keeps it around to issue new ID tokens.
+type authorization struct {
+	clientId      string
+	redirectUri   string
+	nonce         string
+	codeChallenge string
+	offlineAccess bool
+	claims        map[string]any
+}
+
+type Issuer struct {
+	url    string
+	key    *rsa.PrivateKey
+	server *httptest.Server
+
+	mu             sync.Mutex
+	claims         map[string]any
+	authorizations map[string]*authorization
+	refreshTokens  map[string]*authorization
+}
+
+// New makes an issuer with a fresh signing key that serves at url, which is the iss of its tokens. Serve it with
+// http.ListenAndServe, or use Start in tests.
+func New(url string) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 4BX7QS - Generating fake oidc signing key.")
+	}
+
+	return &Issuer{
+		url:            strings.TrimSuffix(url, "/"),
+		key:            key,
+		claims:         maps.Clone(DefaultClaims),
+		authorizations: map[string]*authorization{},
+		refreshTokens:  map[string]*authorization{},
+	}, nil
+}
+
+// Start starts an issuer on a random local port. Close it when the tests are done.
+func Start() (*Issuer, error) {
+	issuer, err := New("")
+	if err != nil {
+		return nil, err
+	}
+
+	issuer.server = httptest.NewServer(issuer)
+	issuer.url = issuer.server.URL
+	return issuer, nil
+}
+
+// URL is the issuer URL, configure it as the OIDC_<NAME>_ISSUER of a provider.
+func (issuer *Issuer) URL() string {
+	return issuer.url
+}
+
+// Close stops the server of an issuer made with Start.
+func (issuer *Issuer) Close() {
+	if issuer.server != nil {
+		issuer.server.Close()
+	}
+}
+
+// SetClaims sets the claims of the user who logs in at the authorize endpoint from now on. They are added to the
+// iss, aud, iat, exp and nonce claims of the ID token and win over them, so a test can log in with a wrong nonce
+// or an expired token as well.
+func (issuer *Issuer) SetClaims(claims map[string]any) {
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+
+	issuer.claims = maps.Clone(claims)
+}
+
+// RevokeRefreshTokens revokes every refresh token issued so far, like a user who signs out of the provider.
+func (issuer *Issuer) RevokeRefreshTokens() {
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+
+	issuer.refreshTokens = map[string]*authorization{}
+}
+
+// IDToken signs an ID token of the issuer for the client with the claims, for Bearer requests. Like SetClaims the
+// claims win over the iss, aud, iat and exp it adds.
+func (issuer *Issuer) IDToken(clientId string, claims map[string]any) (string, error) {
+	now := time.Now()
+	idClaims := map[string]any{
+		"iss": issuer.url,
+		"aud": clientId,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	maps.Copy(idClaims, claims)
+
+	return issuer.SignToken(idClaims)
+}
+
+// SignToken signs the claims as they are into an RS256 JWT with the issuer's key.
+func (issuer *Issuer) SignToken(claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": keyId, "typ": "JWT"})
+	if err != nil {
+		return "", errors.Wrap(err, "Error: JB1M7Q - Marshalling fake token header.")
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "Error: 0WZP6D - Marshalling fake token claims.")
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, issuer.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrap(err, "Error: S8QK2E - Signing fake token.")
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ServeHTTP serves the endpoints of the issuer.
+func (issuer *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		issuer.handleDiscovery(w)
+	case "/jwks":
+		issuer.handleJwks(w)
+	case "/authorize":
+		issuer.handleAuthorize(w, r)
+	case "/token":
+		issuer.handleToken(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeTokenError writes an error of the token endpoint as RFC 6749 describes it.
+func writeTokenError(w http.ResponseWriter, code string, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func (issuer *Issuer) handleDiscovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                issuer.url,
+		"authorization_endpoint":                issuer.url + "/authorize",
+		"token_endpoint":                        issuer.url + "/token",
+		"jwks_uri":                              issuer.url + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile", "offline_access"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+	})
+}
+
+func (issuer *Issuer) handleJwks(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyId,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+		}},
+	})
+}
+
+// handleAuthorize logs in the user of the current claims right away and redirects back to the client with a code.
+func (issuer *Issuer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectUri, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectUri.IsAbs() {
+		http.Error(w, "Error: 9PN2VA - redirect_uri must be an absolute URL.", http.StatusBadRequest)
+		return
+	}
+	if query.Get("response_type") != "code" || query.Get("client_id") == "" {
+		http.Error(w, "Error: F2SWKD - Only the code flow with a client_id is supported.", http.StatusBadRequest)
+		return
+	}
+	if method := query.Get("code_challenge_method"); query.Get("code_challenge") != "" && method != "S256" {
+		http.Error(w, "Error: C7D3LM - Only the S256 code challenge method is supported.", http.StatusBadRequest)
+		return
+	}
+
+	code, err := randomCode()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	issuer.mu.Lock()
+	issuer.authorizations[code] = &authorization{
+		clientId:      query.Get("client_id"),
+		redirectUri:   redirectUri.String(),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		offlineAccess: slices.Contains(strings.Fields(query.Get("scope")), "offline_access"),
+		claims:        maps.Clone(issuer.claims),
+	}
+	issuer.mu.Unlock()
+
+	callback := redirectUri.Query()
+	callback.Set("code", code)
+	callback.Set("state", query.Get("state"))
+	redirectUri.RawQuery = callback.Encode()
+	http.Redirect(w, r, redirectUri.String(), http.StatusFound)
+}
+
+// handleToken exchanges a code, or a refresh token, for an ID token.
+func (issuer *Issuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, "invalid_request", "The form can not be parsed.")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		issuer.handleCodeGrant(w, r)
+	case "refresh_token":
+		issuer.handleRefreshGrant(w, r)
+	default:
+		writeTokenError(w, "unsupported_grant_type", "Only the authorization_code and refresh_token grants are supported.")
+	}
+}
+
+// clientId is the client of a token request, from its basic auth or its form.
+func clientId(r *http.Request) string {
+	clientId, _, hasBasicAuth := r.BasicAuth()
+	if !hasBasicAuth {
+		clientId = r.PostForm.Get("client_id")
+	}
+	return clientId
+}
+
+// handleCodeGrant exchanges a code for an ID token. A code can be used once, and only with the redirect_uri and the
+// PKCE code verifier of its authorize request.
+func (issuer *Issuer) handleCodeGrant(w http.ResponseWriter, r *http.Request) {
+	issuer.mu.Lock()
+	code := r.PostForm.Get("code")
+	auth, ok := issuer.authorizations[code]
+	delete(issuer.authorizations, code)
+	issuer.mu.Unlock()
+
+	if !ok {
+		writeTokenError(w, "invalid_grant", "The code is not valid or was used before.")
+		return
+	}
+
+	if clientId(r) != auth.clientId || r.PostForm.Get("redirect_uri") != auth.redirectUri {
+		writeTokenError(w, "invalid_grant", "The code was issued to another client or redirect_uri.")
+		return
+	}
+	if auth.codeChallenge != "" {
+		digest := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(digest[:]) != auth.codeChallenge {
+			writeTokenError(w, "invalid_grant", "The code verifier does not match the code challenge.")
+			return
+		}
+	}
+
+	claims := map[string]any{}
+	if auth.nonce != "" {
+		claims["nonce"] = auth.nonce
+	}
+	maps.Copy(claims, auth.claims)
+	issuer.writeTokens(w, auth, claims)
+}
+
+// handleRefreshGrant issues a new ID token for a refresh token. Refresh tokens are rotated, each can be used once.
+func (issuer *Issuer) handleRefreshGrant(w http.ResponseWriter, r *http.Request) {
+	issuer.mu.Lock()
+	refreshToken := r.PostForm.Get("refresh_token")
+	auth, ok := issuer.refreshTokens[refreshToken]
+	delete(issuer.refreshTokens, refreshToken)
+	issuer.mu.Unlock()
+
+	if !ok {
+		writeTokenError(w, "invalid_grant", "The refresh token is not valid, was revoked or was used before.")
+		return
+	}
+	if clientId(r) != auth.clientId {
+		writeTokenError(w, "invalid_grant", "The refresh token was issued to another client.")
+		return
+	}
+
+	// A refreshed ID token has no nonce.
+	issuer.writeTokens(w, auth, maps.Clone(auth.claims))
+}
+
+// writeTokens writes the token response with an ID token with the claims, and a refresh token when the
+// authorization asked for offline_access.
+func (issuer *Issuer) writeTokens(w http.ResponseWriter, auth *authorization, claims map[string]any) {
+	idToken, err := issuer.IDToken(auth.clientId, claims)
+	if err != nil {
+		writeTokenError(w, "server_error", err.Error())
+		return
+	}
+
+	response := map[string]any{
+		"access_token": "fake-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	}
+	if auth.offlineAccess {
+		refreshToken, err := randomCode()
+		if err != nil {
+			writeTokenError(w, "server_error", err.Error())
+			return
+		}
+		issuer.mu.Lock()
+		issuer.refreshTokens[refreshToken] = auth
+		issuer.mu.Unlock()
+		response["refresh_token"] = refreshToken
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func randomCode() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "Error: UQ5RBE - Generating fake oidc code.")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
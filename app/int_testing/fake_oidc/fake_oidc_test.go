@@ -0,0 +1,161 @@
+package fakeoidc_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	fakeoidc "gitlab.com/sandstone2/fiberpoc/app/int_testing/fake_oidc"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+)
+
+// newAuthcService starts an issuer and an AuthcService that logs in with it, asking for refresh tokens with
+// offlineAccess. The logins the service stores are kept in memory.
+func newAuthcService(t *testing.T, offlineAccess bool) (*fakeoidc.Issuer, *services.AuthcService) {
+	ctrl := gomock.NewController(t)
+
+	issuer, err := fakeoidc.Start()
+	require.NoError(t, err)
+	t.Cleanup(issuer.Close)
+
+	mockOidcLoginRepo := mocks.NewMockOidcLoginRepo(ctrl)
+	logins := map[string]*models.OidcLogin{}
+	mockOidcLoginRepo.EXPECT().CreateLogin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, stateHash []byte, login *models.OidcLogin, ttl int64) error {
+			logins[string(stateHash)] = login
+			return nil
+		}).AnyTimes()
+	mockOidcLoginRepo.EXPECT().TakeLogin(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, stateHash []byte) (*models.OidcLogin, error) {
+			login, ok := logins[string(stateHash)]
+			if !ok {
+				return nil, apperrors.Unauthorized("SOZRGS", "The login has expired, try again.")
+			}
+			delete(logins, string(stateHash))
+			return login, nil
+		}).AnyTimes()
+
+	authcService, err := services.NewAuthcService([]models.OidcProviderConfig{{
+		Name:          "fake",
+		Issuer:        issuer.URL(),
+		ClientId:      "client-1",
+		Scopes:        []string{"openid", "email", "profile"},
+		RedirectUri:   "http://localhost:3000/callback/fake",
+		SubjectClaim:  "sub",
+		EmailClaim:    "email",
+		NameClaim:     "name",
+		OfflineAccess: offlineAccess,
+	}}, mockOidcLoginRepo, nil, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	return issuer, authcService
+}
+
+// authorize calls the authorize endpoint of the issuer and returns the code it redirects back with.
+func authorize(t *testing.T, authURL string, state string) string {
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	response, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusFound, response.StatusCode)
+
+	callback, err := response.Location()
+	require.NoError(t, err)
+	require.Equal(t, state, callback.Query().Get("state"))
+	return callback.Query().Get("code")
+}
+
+func TestIssuer_Login_Success(t *testing.T) {
+	issuer, authcService := newAuthcService(t, false)
+	issuer.SetClaims(map[string]any{"sub": "sub-1", "email": "ada@example.com", "name": "Ada"})
+
+	authURL, state, err := authcService.StartLogin(context.Background(), "fake", "/foos")
+	require.NoError(t, err)
+	code := authorize(t, authURL, state)
+
+	claims, tokens, returnTo, err := authcService.ProcessOauth(context.Background(), "fake", state, code)
+	require.NoError(t, err)
+	require.Equal(t, &models.Claims{Issuer: issuer.URL(), Sub: "sub-1", Email: "ada@example.com", Name: "Ada"}, claims)
+	require.Equal(t, "/foos", returnTo)
+	require.NotEmpty(t, tokens.IDToken)
+	require.Empty(t, tokens.RefreshToken, "refresh tokens are only issued for offline_access")
+
+	// Tokens signed for the client verify as Bearer tokens
+	idToken, err := issuer.IDToken("client-1", map[string]any{"sub": "sub-2"})
+	require.NoError(t, err)
+	claims, err = authcService.VerifyToken(context.Background(), idToken)
+	require.NoError(t, err)
+	require.Equal(t, "sub-2", claims.Sub)
+}
+
+func TestIssuer_Refresh_Success(t *testing.T) {
+	issuer, authcService := newAuthcService(t, true)
+	issuer.SetClaims(map[string]any{"sub": "sub-1", "email": "ada@example.com"})
+
+	authURL, state, err := authcService.StartLogin(context.Background(), "fake", "")
+	require.NoError(t, err)
+	code := authorize(t, authURL, state)
+
+	_, tokens, _, err := authcService.ProcessOauth(context.Background(), "fake", state, code)
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.RefreshToken)
+
+	// 1) Test a refresh token gets a new ID token of the same user and is rotated
+	claims, refreshed, err := authcService.RefreshTokens(context.Background(), "fake", tokens.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, "sub-1", claims.Sub)
+	require.NotEmpty(t, refreshed.IDToken)
+	require.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	// 2) Test a rotated refresh token can not be used again
+	_, _, err = authcService.RefreshTokens(context.Background(), "fake", tokens.RefreshToken)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 3) Test a revoked refresh token
+	issuer.RevokeRefreshTokens()
+	_, _, err = authcService.RefreshTokens(context.Background(), "fake", refreshed.RefreshToken)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "Z0UMCB")
+}
+
+func TestIssuer_Login_Error(t *testing.T) {
+	issuer, authcService := newAuthcService(t, false)
+
+	// 1) Test a code can only be exchanged once
+	authURL, state, err := authcService.StartLogin(context.Background(), "fake", "")
+	require.NoError(t, err)
+	code := authorize(t, authURL, state)
+
+	_, _, _, err = authcService.ProcessOauth(context.Background(), "fake", state, code)
+	require.NoError(t, err)
+	authURL, state, err = authcService.StartLogin(context.Background(), "fake", "")
+	require.NoError(t, err)
+	_, _, _, err = authcService.ProcessOauth(context.Background(), "fake", state, code)
+	require.Error(t, err)
+
+	// 2) Test a token with the nonce of another login
+	issuer.SetClaims(map[string]any{"sub": "sub-1", "nonce": "other-nonce"})
+	authURL, state, err = authcService.StartLogin(context.Background(), "fake", "")
+	require.NoError(t, err)
+	code = authorize(t, authURL, state)
+
+	_, _, _, err = authcService.ProcessOauth(context.Background(), "fake", state, code)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 3) Test tokens for another client or that expired
+	for _, claims := range []map[string]any{{"sub": "sub-1", "aud": "client-2"}, {"sub": "sub-1", "exp": 1}} {
+		idToken, err := issuer.IDToken("client-1", claims)
+		require.NoError(t, err)
+		_, err = authcService.VerifyToken(context.Background(), idToken)
+		require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	}
+}
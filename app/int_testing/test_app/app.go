@@ -1,20 +1,50 @@
 package testapp
 
 import (
+	"os"
+
 	fiber "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	html "github.com/gofiber/template/html/v2"
 	"github.com/pkg/errors"
 	"gitlab.com/sandstone2/fiberpoc/app/handlers"
+	fakeoidc "gitlab.com/sandstone2/fiberpoc/app/int_testing/fake_oidc"
+	"gitlab.com/sandstone2/fiberpoc/app/middleware"
 	"gitlab.com/sandstone2/fiberpoc/app/server"
 	"gitlab.com/sandstone2/fiberpoc/common/clients"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
 	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/secrets"
 	"gitlab.com/sandstone2/fiberpoc/common/services"
 	"go.uber.org/zap"
 )
 
+// OidcProvider and OidcClientId are how the test app is configured to log in with the fake issuer.
+const (
+	OidcProvider = "fake"
+	OidcClientId = "fiberpoc-test"
+	// TokenEncryptionKey seals the tokens of the test sessions, it is not a secret.
+	TokenEncryptionKey = "ZmliZXJwb2MtdGVzdC10b2tlbi1lbmNyeXB0aW9uLWs="
+)
+
 var db *clients.PgxPoolImpl
 var logger *zap.Logger
+var issuer *fakeoidc.Issuer
 
 func GetApp() (app *fiber.App, err error) {
+	// Log in with a fake issuer instead of the providers in .env.tst, so the tests run offline. The env vars are
+	// set before the .env file is loaded, which does not override them.
+	issuer, err = fakeoidc.Start()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: D5JXW3 - Starting the fake oidc issuer.")
+	}
+	os.Setenv("OIDC_PROVIDERS", OidcProvider)
+	os.Setenv("OIDC_FAKE_ISSUER", issuer.URL())
+	os.Setenv("OIDC_FAKE_CLIENT_ID", OidcClientId)
+	os.Setenv("OIDC_FAKE_REDIRECT_URI", "http://localhost:3000/callback/"+OidcProvider)
+	os.Setenv("OIDC_FAKE_OFFLINE_ACCESS", "true")
+	os.Setenv("TOKEN_ENCRYPTION_KEY", TokenEncryptionKey)
+
 	db, logger, err = server.InitServer(".env.tst")
 	if err != nil {
 		return nil, errors.Wrap(err, "Error: LBTF9J - Initializing the server. Error: %v")
@@ -22,21 +52,99 @@ func GetApp() (app *fiber.App, err error) {
 
 	// Inject all dependencies.
 	fooRepo := repos.NewFooRepository(db, logger)
-	fooService := services.NewFooService(fooRepo, logger)
+	fooShareRepo := repos.NewFooShareRepository(db, logger)
+	fooRevisionRepo := repos.NewFooRevisionRepository(db, logger)
+	auditRepo := repos.NewAuditRepository(db, logger)
+	fooService := services.NewFooService(fooRepo, fooShareRepo, fooRevisionRepo, auditRepo, db, logger)
 	fooHandler := handlers.NewFooHandler(fooService, logger)
+	auditService := services.NewAuditService(auditRepo, logger)
+	auditHandler := handlers.NewAuditHandler(auditService, logger)
+
+	userRepo := repos.NewUserRepository(db, logger)
+	roleRepo := repos.NewRoleRepository(db, logger)
+	orgRepo := repos.NewOrganizationRepository(db, logger)
+	userService := services.NewUserService(userRepo, roleRepo, orgRepo, *models.GlobalConfig.GetDefaultRole(), *models.GlobalConfig.GetDefaultOrg(), logger)
+	userHandler := handlers.NewUserHandler(userService, logger)
+	roleService := services.NewRoleService(roleRepo, userRepo, db, logger)
+	orgService := services.NewOrganizationService(orgRepo, userRepo, db, logger)
+
+	oidcLoginRepo := repos.NewOidcLoginRepository(db, logger)
+	authcService, err := services.NewAuthcService(models.GlobalConfig.GetOidcProviders(), oidcLoginRepo, models.GlobalConfig.GetReturnToAllowedOrigins(), logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 1KQ8BT - Creating AuthcService.")
+	}
+	sessionRepo := repos.NewSessionRepository(db, logger)
+	// Sessions only keep the tokens of their logins when there is a key to encrypt them with.
+	var tokenCipher *secrets.Cipher
+	if key := *models.GlobalConfig.GetTokenEncryptionKey(); key != "" {
+		tokenCipher, err = secrets.NewCipher(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error: KNJC3G - Creating the token cipher.")
+		}
+	}
+	sessionService := services.NewSessionService(sessionRepo, authcService, tokenCipher, db, *models.GlobalConfig.GetSessionIdleTimeout(), *models.GlobalConfig.GetSessionMaxAge(), logger)
+	sessionHandler := handlers.NewSessionHandler(sessionService, logger)
+	authcHandler := handlers.NewAuthcHandler(authcService, userService, sessionService, logger)
+	apiKeyRepo := repos.NewApiKeyRepository(db, logger)
+	apiKeyService := services.NewApiKeyService(apiKeyRepo, roleRepo, logger)
 
 	// Create the Fiber app.
-	app = fiber.New(fiber.Config{})
+	engine := html.New("./templates", ".html")
+	app = fiber.New(fiber.Config{Views: engine, ErrorHandler: middleware.ErrorHandler(logger)})
 
-	app.Get("/foos", fooHandler.HandleGetFoos)
+	app.Use(requestid.New())
+	app.Use(middleware.ContextMiddleware(*models.GlobalConfig.GetDbRequestTimeout()))
+
+	app.Get("/", authcHandler.HandleRoot)
+	app.Get("/login/:provider", authcHandler.HandleLogin)
+	app.Get("/callback/:provider", authcHandler.HandleOauthCallback)
+	app.Post("/logout", sessionHandler.HandleLogout)
+
+	authc := middleware.AuthcMiddleware(authcService, sessionService, apiKeyService, logger)
+	authorize := func(permissions ...string) fiber.Handler {
+		return middleware.Authorize(roleService, logger, permissions...)
+	}
+	tenant := middleware.TenantMiddleware(orgService, *models.GlobalConfig.GetTenantBaseDomain(), logger)
+
+	app.Get("/me", authc, userHandler.HandleGetMe)
+	app.Post("/token/refresh", authc, sessionHandler.HandleRefreshToken)
+	app.Get("/foos", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFoos)
+	app.Get("/foos/search", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleSearchFoos)
+	app.Get("/foos/export", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleExportFoos)
+	app.Get("/foos/:id", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFoo)
+	app.Post("/foos", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleCreateFoo)
+	app.Post("/foos/import", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleImportFoos)
+	app.Delete("/foos/:id", authc, tenant, authorize(models.PermissionFoosDelete), fooHandler.HandleDeleteFoo)
+	app.Post("/foos/:id/shares", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleShareFoo)
+	app.Put("/foos/:id", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleUpdateFoo)
+	app.Get("/foos/:id/revisions", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFooRevisions)
+	app.Get("/foos/:id/revisions/diff", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleDiffFooRevisions)
+	app.Get("/foos/:id/revisions/:rev", authc, tenant, authorize(models.PermissionFoosRead), fooHandler.HandleGetFooRevision)
+	app.Post("/foos/:id/revisions/:rev/restore", authc, tenant, authorize(models.PermissionFoosWrite), fooHandler.HandleRestoreFooRevision)
+	app.Get("/audit", authc, tenant, authorize(models.PermissionAuditRead), auditHandler.HandleGetAuditEvents)
 
 	return app, nil
 }
 
+// GetDb returns the db pool of the test app, for tests that work with the database directly.
+func GetDb() *clients.PgxPoolImpl {
+	return db
+}
+
+// GetIssuer returns the fake oidc issuer the test app logs in with.
+func GetIssuer() *fakeoidc.Issuer {
+	return issuer
+}
+
 func CloseDbAndLogger() {
 	// Flush out the logger on server exit.
 	logger.Sync()
 
 	// Close the db pool on server exit.
 	db.Close()
+
+	// Stop the fake oidc issuer.
+	if issuer != nil {
+		issuer.Close()
+	}
 }
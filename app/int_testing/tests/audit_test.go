@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestAuditHandler_AuditLog(t *testing.T) {
+	app := getApp(t)
+
+	editorCookie := loginAs(t, "audit-editor", "editor")
+	adminCookie := loginAs(t, "audit-admin", "admin")
+
+	request := httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Audited Foo"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(editorCookie)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	created := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+
+	request = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/foos/%d", created.ID), nil)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	deleteRequestId := response.Header.Get(fiber.HeaderXRequestID)
+
+	// 1) Test only users with audit:read see the audit log
+	request = httptest.NewRequest(http.MethodGet, "/audit", nil)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	// 2) Test the create and the delete were recorded with who made them, newest first
+	request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/audit?entity=foo&entity_id=%d&actor=audit-editor@example.com", created.ID), nil)
+	request.AddCookie(adminCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	page := models.AuditPage{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&page))
+	require.Len(t, page.Items, 2)
+
+	deleted := page.Items[0]
+	require.Equal(t, models.AuditActionDelete, deleted.Action)
+	require.Equal(t, "audit-editor", deleted.ActorSub)
+	require.Equal(t, deleteRequestId, deleted.RequestID)
+	require.JSONEq(t, "null", string(deleted.After))
+	before := models.Foo{}
+	require.NoError(t, json.Unmarshal(deleted.Before, &before))
+	require.Equal(t, created, before)
+
+	require.Equal(t, models.AuditActionCreate, page.Items[1].Action)
+	require.JSONEq(t, "null", string(page.Items[1].Before))
+
+	// 3) Test the filters leave out the events that do not match
+	request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/audit?entity_id=%d&action=update", created.ID), nil)
+	request.AddCookie(adminCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	page = models.AuditPage{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&page))
+	require.Empty(t, page.Items)
+}
@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	testapp "gitlab.com/sandstone2/fiberpoc/app/int_testing/test_app"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+// getApp returns the app from the context of TestMain.
+func getApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	app, ok := (*GetContext()).Value("App").(*fiber.App)
+	require.True(t, ok, "App not found in context or wrong type")
+	require.NotNil(t, app, "App is nil")
+	return app
+}
+
+// findCookie returns the cookie with the name the response sets, or nil.
+func findCookie(response *http.Response, name string) *http.Cookie {
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == name && cookie.Value != "" {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// startLogin starts a login at /login/fake and follows the redirect to the fake issuer, which logs in the user
+// with the claims right away. It returns the callback URL the issuer sends the browser to and the state cookie.
+func startLogin(t *testing.T, app *fiber.App, claims map[string]any, returnTo string) (callback *url.URL, stateCookie *http.Cookie) {
+	t.Helper()
+
+	testapp.GetIssuer().SetClaims(claims)
+
+	target := "/login/" + testapp.OidcProvider
+	if returnTo != "" {
+		target += "?return_to=" + url.QueryEscape(returnTo)
+	}
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, response.StatusCode)
+	stateCookie = findCookie(response, "oidc_state")
+	require.NotNil(t, stateCookie)
+
+	// The issuer is a real server, call it without following its redirect back to the app.
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	authorizeResponse, err := client.Get(response.Header.Get(fiber.HeaderLocation))
+	require.NoError(t, err)
+	defer authorizeResponse.Body.Close()
+	require.Equal(t, http.StatusFound, authorizeResponse.StatusCode)
+
+	callback, err = url.Parse(authorizeResponse.Header.Get(fiber.HeaderLocation))
+	require.NoError(t, err)
+	return callback, stateCookie
+}
+
+// finishLogin calls the callback of a login with the state cookie.
+func finishLogin(t *testing.T, app *fiber.App, callback *url.URL, stateCookie *http.Cookie) *http.Response {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	request.AddCookie(stateCookie)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	return response
+}
+
+// loginAs logs in a user with the subject through the fake issuer and returns their session cookie. The user gets
+// the roles when there are any, instead of the default role.
+func loginAs(t *testing.T, subject string, roles ...string) *http.Cookie {
+	t.Helper()
+
+	app := getApp(t)
+	callback, stateCookie := startLogin(t, app, map[string]any{"sub": subject, "email": subject + "@example.com", "name": subject}, "")
+	response := finishLogin(t, app, callback, stateCookie)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	sessionCookie := findCookie(response, models.SessionCookieName)
+	require.NotNil(t, sessionCookie, "the login should have started a session")
+
+	if len(roles) > 0 {
+		ctx := context.Background()
+		_, err := testapp.GetDb().Exec(ctx,
+			"DELETE FROM user_roles USING users WHERE users.id = user_roles.user_id AND users.issuer = $1 AND users.subject = $2;",
+			testapp.GetIssuer().URL(), subject)
+		require.NoError(t, err)
+		_, err = testapp.GetDb().Exec(ctx,
+			"INSERT INTO user_roles (user_id, role_id) SELECT users.id, roles.id FROM users, roles "+
+				"WHERE users.issuer = $1 AND users.subject = $2 AND roles.name = ANY($3);",
+			testapp.GetIssuer().URL(), subject, roles)
+		require.NoError(t, err)
+	}
+
+	return sessionCookie
+}
+
+func TestAuthcHandler_Login_Success(t *testing.T) {
+	app := getApp(t)
+
+	// 1) Test the login provisions the user and starts a session
+	callback, stateCookie := startLogin(t, app, map[string]any{"sub": "login-1", "email": "login-1@example.com", "name": "Login One"}, "")
+	require.Equal(t, "/callback/"+testapp.OidcProvider, callback.Path)
+	response := finishLogin(t, app, callback, stateCookie)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	sessionCookie := findCookie(response, models.SessionCookieName)
+	require.NotNil(t, sessionCookie)
+
+	request := httptest.NewRequest(http.MethodGet, "/me", nil)
+	request.AddCookie(sessionCookie)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	user := models.User{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&user))
+	require.Equal(t, testapp.GetIssuer().URL(), user.Issuer)
+	require.Equal(t, "login-1", user.Subject)
+	require.Equal(t, "login-1@example.com", user.Email)
+
+	// 2) Test return_to sends the user on after the login
+	callback, stateCookie = startLogin(t, app, map[string]any{"sub": "login-1"}, "/foos?limit=2")
+	response = finishLogin(t, app, callback, stateCookie)
+	require.Equal(t, http.StatusSeeOther, response.StatusCode)
+	require.Equal(t, "/foos?limit=2", response.Header.Get(fiber.HeaderLocation))
+	require.NotNil(t, findCookie(response, models.SessionCookieName))
+
+	// 3) Test a Bearer ID token of the issuer logs in as well
+	idToken, err := testapp.GetIssuer().IDToken(testapp.OidcClientId, map[string]any{"sub": "login-1"})
+	require.NoError(t, err)
+	request = httptest.NewRequest(http.MethodGet, "/me", nil)
+	request.Header.Set(fiber.HeaderAuthorization, "Bearer "+idToken)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestAuthcHandler_Login_Error(t *testing.T) {
+	app := getApp(t)
+	claims := map[string]any{"sub": "login-2"}
+
+	// 1) Test a state that does not match the state cookie
+	callback, stateCookie := startLogin(t, app, claims, "")
+	query := callback.Query()
+	query.Set("state", "forged-state")
+	forged := *callback
+	forged.RawQuery = query.Encode()
+	response := finishLogin(t, app, &forged, stateCookie)
+	require.Nil(t, findCookie(response, models.SessionCookieName), "a forged state must not log in")
+
+	// 2) Test the code of a login can only be used once
+	response = finishLogin(t, app, callback, stateCookie)
+	require.NotNil(t, findCookie(response, models.SessionCookieName))
+	response = finishLogin(t, app, callback, stateCookie)
+	require.Nil(t, findCookie(response, models.SessionCookieName), "a used state must not log in again")
+
+	// 3) Test an ID token with a nonce of another login
+	callback, stateCookie = startLogin(t, app, map[string]any{"sub": "login-2", "nonce": "other-nonce"}, "")
+	response = finishLogin(t, app, callback, stateCookie)
+	require.Nil(t, findCookie(response, models.SessionCookieName), "a token of another login must not log in")
+
+	// 4) Test a return_to on another site
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/login/"+testapp.OidcProvider+"?return_to="+url.QueryEscape("https://evil.example.com/"), nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Nil(t, findCookie(response, "oidc_state"))
+
+	// 5) Test an expired Bearer ID token
+	idToken, err := testapp.GetIssuer().IDToken(testapp.OidcClientId, map[string]any{"sub": "login-2", "exp": 1})
+	require.NoError(t, err)
+	request := httptest.NewRequest(http.MethodGet, "/me", nil)
+	request.Header.Set(fiber.HeaderAuthorization, "Bearer "+idToken)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestFooHandler_ProtectedRoutes(t *testing.T) {
+	app := getApp(t)
+
+	// 1) Test the foo routes need a login
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/foos", nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	// 2) Test a viewer, the default role, can read but not write
+	viewerCookie := loginAs(t, "foo-viewer")
+	request := httptest.NewRequest(http.MethodGet, "/foos/1", nil)
+	request.AddCookie(viewerCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Viewer Foo"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(viewerCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	// 3) Test an editor can write
+	editorCookie := loginAs(t, "foo-editor", "editor")
+	request = httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Editor Foo"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	created := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+	request = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/foos/%d", created.ID), nil)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	// 4) Test the session ends with a logout
+	request = httptest.NewRequest(http.MethodPost, "/logout", nil)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/foos", nil)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
@@ -1,14 +1,19 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/require"
+	testapp "gitlab.com/sandstone2/fiberpoc/app/int_testing/test_app"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
 )
 
@@ -23,6 +28,7 @@ func TestFooRepo_GetFoos_Success(t *testing.T) {
 
 	// Build request
 	req := httptest.NewRequest(http.MethodGet, "/foos", nil)
+	req.AddCookie(loginAs(t, "foo-reader"))
 
 	// Run request
 	resp, err := app.Test(req, -1)
@@ -32,11 +38,384 @@ func TestFooRepo_GetFoos_Success(t *testing.T) {
 	body, err := io.ReadAll(resp.Body)
 	require.NoError(t, err)
 
-	var foos []models.Foo
-	err = json.Unmarshal(body, &foos)
+	var page models.FooPage
+	err = json.Unmarshal(body, &page)
 	require.NoError(t, err)
 
+	foos := page.Items
 	require.Len(t, foos, 3)
 	require.Equal(t, "Test Foo 1", foos[0].Name)
 	require.Equal(t, 2, foos[1].ID)
+	require.NotZero(t, foos[0].CreatedAt)
+	require.Zero(t, foos[0].DeletedAt)
+	require.Empty(t, page.NextCursor)
+}
+
+func TestFooRepo_GetFooByID_NotFound(t *testing.T) {
+	ctx := GetContext()
+
+	// Get app from context
+	appVal := (*ctx).Value("App")
+	app, ok := appVal.(*fiber.App)
+	require.True(t, ok, "App not found in context or wrong type")
+	require.NotNil(t, app, "App is nil")
+
+	cookie := loginAs(t, "foo-reader")
+
+	// Found
+	req := httptest.NewRequest(http.MethodGet, "/foos/2", nil)
+	req.AddCookie(cookie)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Not found
+	req = httptest.NewRequest(http.MethodGet, "/foos/999999", nil)
+	req.AddCookie(cookie)
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFooRepo_GetFoos_Paging(t *testing.T) {
+	ctx := GetContext()
+
+	// Get app from context
+	appVal := (*ctx).Value("App")
+	app, ok := appVal.(*fiber.App)
+	require.True(t, ok, "App not found in context or wrong type")
+	require.NotNil(t, app, "App is nil")
+
+	cookie := loginAs(t, "foo-reader")
+
+	// Walk all the seeded foos by name descending, two at a time.
+	names := []string{}
+	url := "/foos?limit=2&sort=name&order=desc&include_total=true"
+	for url != "" {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.AddCookie(cookie)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		page := models.FooPage{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		require.NotNil(t, page.Total)
+		require.Equal(t, int64(3), *page.Total)
+
+		for _, foo := range page.Items {
+			names = append(names, foo.Name)
+		}
+
+		url = ""
+		if page.NextCursor != "" {
+			url = "/foos?limit=2&sort=name&order=desc&cursor=" + page.NextCursor
+		}
+	}
+
+	require.Equal(t, []string{"Test Foo 3", "Test Foo 2", "Test Foo 1"}, names)
+}
+
+func TestFooHandler_TenantIsolation(t *testing.T) {
+	app := getApp(t)
+	ctx := context.Background()
+
+	// Users join the default organization, where the seeded foos are, when they first log in
+	cookie := loginAs(t, "tenant-editor", "editor")
+	_, err := testapp.GetDb().Exec(ctx, "INSERT INTO organizations (slug, name) VALUES ('other', 'Other') ON CONFLICT (slug) DO NOTHING;")
+	require.NoError(t, err)
+
+	// 1) Test an organization the user is not a member of
+	request := httptest.NewRequest(http.MethodGet, "/foos/1", nil)
+	request.Header.Set(models.OrgHeader, "other")
+	request.AddCookie(cookie)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	// 2) Test the foos of the default organization can not be seen from another organization
+	_, err = testapp.GetDb().Exec(ctx,
+		"INSERT INTO memberships (org_id, user_id) SELECT organizations.id, users.id FROM organizations, users "+
+			"WHERE organizations.slug = 'other' AND users.issuer = $1 AND users.subject = $2 ON CONFLICT DO NOTHING;",
+		testapp.GetIssuer().URL(), "tenant-editor")
+	require.NoError(t, err)
+
+	request = httptest.NewRequest(http.MethodGet, "/foos/1", nil)
+	request.Header.Set(models.OrgHeader, "other")
+	request.AddCookie(cookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	// 3) Test a foo created in the other organization is not in the default organization
+	request = httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Other Foo"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.Header.Set(models.OrgHeader, "other")
+	request.AddCookie(cookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	created := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+
+	request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/foos/%d", created.ID), nil)
+	request.AddCookie(cookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestFooHandler_Sharing(t *testing.T) {
+	app := getApp(t)
+
+	ownerCookie := loginAs(t, "share-owner", "editor")
+	otherCookie := loginAs(t, "share-other", "editor")
+	var otherId int
+	require.NoError(t, testapp.GetDb().QueryRow(context.Background(),
+		"SELECT id FROM users WHERE issuer = $1 AND subject = $2;", testapp.GetIssuer().URL(), "share-other").Scan(&otherId))
+
+	request := httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Shared Foo"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(ownerCookie)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	created := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+	require.NotNil(t, created.OwnerID, "the foo should be owned by the user who created it")
+
+	// 1) Test a foo that is not shared can not be seen by other members
+	request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/foos/%d", created.ID), nil)
+	request.AddCookie(otherCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	// 2) Test only the owner shares the foo
+	body := fmt.Sprintf(`{"user_id": %d, "permission": "read"}`, otherId)
+	request = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/foos/%d/shares", created.ID), strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(otherCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/foos/%d/shares", created.ID), strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(ownerCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+
+	// 3) Test a read share lets the user read the foo but not delete it
+	request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/foos/%d", created.ID), nil)
+	request.AddCookie(otherCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/foos/%d", created.ID), nil)
+	request.AddCookie(otherCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestFooHandler_Revisions(t *testing.T) {
+	app := getApp(t)
+	editorCookie := loginAs(t, "revisions-editor", "editor")
+
+	send := func(method string, target string, body string, ifMatch string) *http.Response {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if ifMatch != "" {
+			request.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		}
+		request.AddCookie(editorCookie)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		return response
+	}
+
+	response := send(http.MethodPost, "/foos", `{"name": "First Name"}`, "")
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	created := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+
+	response = send(http.MethodPut, fmt.Sprintf("/foos/%d", created.ID), `{"name": "Second Name"}`, `"1"`)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	// 1) Test the create and the update each wrote a revision
+	response = send(http.MethodGet, fmt.Sprintf("/foos/%d/revisions", created.ID), "", "")
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	revisions := []models.FooRevision{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&revisions))
+	require.Len(t, revisions, 2)
+	require.Equal(t, "First Name", revisions[0].Name)
+	require.Equal(t, 1, revisions[0].Version)
+	require.Equal(t, "Second Name", revisions[1].Name)
+	require.Equal(t, 2, revisions[1].Version)
+	require.NotNil(t, revisions[1].CreatedBy)
+
+	// 2) Test the diff between them
+	response = send(http.MethodGet, fmt.Sprintf("/foos/%d/revisions/diff?from=1&to=2", created.ID), "", "")
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	diff := models.FooRevisionDiff{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&diff))
+	require.Equal(t, []models.FooFieldChange{{Field: "name", From: "First Name", To: "Second Name"}}, diff.Changes)
+
+	// 3) Test restoring revision 1 over a stale version fails
+	response = send(http.MethodPost, fmt.Sprintf("/foos/%d/revisions/1/restore", created.ID), "", `"1"`)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+
+	// 4) Test restoring revision 1 writes it back as revision 3
+	response = send(http.MethodPost, fmt.Sprintf("/foos/%d/revisions/1/restore", created.ID), "", `"2"`)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, `"3"`, response.Header.Get(fiber.HeaderETag))
+	restored := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&restored))
+	require.Equal(t, "First Name", restored.Name)
+
+	response = send(http.MethodGet, fmt.Sprintf("/foos/%d/revisions/3", created.ID), "", "")
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	revision := models.FooRevision{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&revision))
+	require.Equal(t, "First Name", revision.Name)
+
+	// 5) Test a revision that does not exist
+	response = send(http.MethodGet, fmt.Sprintf("/foos/%d/revisions/9", created.ID), "", "")
+	defer response.Body.Close()
+	require.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestFooHandler_Search(t *testing.T) {
+	app := getApp(t)
+	editorCookie := loginAs(t, "search-editor", "editor")
+
+	request := httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Zanzibar Parties"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(editorCookie)
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	created := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+
+	search := func(query string) []models.FooSearchResult {
+		request := httptest.NewRequest(http.MethodGet, "/foos/search?q="+url.QueryEscape(query), nil)
+		request.AddCookie(editorCookie)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		results := []models.FooSearchResult{}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&results))
+		return results
+	}
+
+	// 1) Test a word matches its other forms and is highlighted
+	results := search("zanzibar party")
+	require.NotEmpty(t, results)
+	require.Equal(t, created.ID, results[0].Foo.ID)
+	require.Equal(t, "<b>Zanzibar</b> <b>Parties</b>", results[0].Snippet)
+
+	// 2) Test a misspelled word still finds the foo
+	results = search("zanzbar")
+	require.NotEmpty(t, results)
+	require.Equal(t, created.ID, results[0].Foo.ID)
+	require.Equal(t, "Zanzibar Parties", results[0].Snippet)
+
+	// 3) Test markup in a name is escaped in the snippet
+	request = httptest.NewRequest(http.MethodPost, "/foos", strings.NewReader(`{"name": "Quokka <img src=x onerror=alert(1)>"}`))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	marked := models.Foo{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&marked))
+
+	results = search("quokka")
+	require.NotEmpty(t, results)
+	require.Equal(t, marked.ID, results[0].Foo.ID)
+	require.Equal(t, "<b>Quokka</b> &lt;img src=x onerror=alert(1)&gt;", results[0].Snippet)
+
+	// 4) Test a blank query
+	request = httptest.NewRequest(http.MethodGet, "/foos/search?q=", nil)
+	request.AddCookie(editorCookie)
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestFooHandler_ExportImport(t *testing.T) {
+	app := getApp(t)
+	editorCookie := loginAs(t, "transfer-editor", "editor")
+
+	importFoos := func(target string, contentType string, body string) (int, models.FooImportReport) {
+		request := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		request.Header.Set(fiber.HeaderContentType, contentType)
+		request.AddCookie(editorCookie)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		report := models.FooImportReport{}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&report))
+		return response.StatusCode, report
+	}
+	export := func(format string) string {
+		request := httptest.NewRequest(http.MethodGet, "/foos/export?format="+format, nil)
+		request.AddCookie(editorCookie)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// 1) Test a dry run imports nothing
+	status, report := importFoos("/foos/import?dry_run=true", models.MIMETextCSV, "name\nTransfer Dry Run\n")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, models.FooImportReport{DryRun: true, Total: 1, Imported: 1, Errors: []models.FooImportError{}}, report)
+	require.NotContains(t, export(models.FooFormatCSV), "Transfer Dry Run")
+
+	// 2) Test a row that is not valid fails the whole import
+	status, report = importFoos("/foos/import", models.MIMEApplicationNDJSON, "{\"name\":\"Transfer Valid\"}\n{\"name\":\" \"}\n")
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Equal(t, 0, report.Imported)
+	require.Len(t, report.Errors, 1)
+	require.Equal(t, 2, report.Errors[0].Line)
+	require.NotContains(t, export(models.FooFormatNDJSON), "Transfer Valid")
+
+	// 3) Test the imported foos are exported
+	status, report = importFoos("/foos/import", models.MIMEApplicationNDJSON, "{\"name\":\"Transfer One\"}\n{\"name\":\"Transfer Two\"}\n")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 2, report.Imported)
+
+	exported := export(models.FooFormatCSV)
+	require.True(t, strings.HasPrefix(exported, "id,name,version,created_at,updated_at,deleted_at,owner_id\n"))
+	require.Contains(t, exported, ",Transfer One,1,")
+	require.Contains(t, exported, ",Transfer Two,1,")
 }
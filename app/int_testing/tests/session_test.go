@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	testapp "gitlab.com/sandstone2/fiberpoc/app/int_testing/test_app"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+)
+
+// expireIDTokens makes the ID tokens of the sessions of the user with the subject due for renewal.
+func expireIDTokens(t *testing.T, subject string) {
+	t.Helper()
+
+	_, err := testapp.GetDb().Exec(context.Background(),
+		"UPDATE sessions SET id_token_expires_at = current_epoch_milliseconds() FROM users "+
+			"WHERE users.id = sessions.user_id AND users.issuer = $1 AND users.subject = $2;",
+		testapp.GetIssuer().URL(), subject)
+	require.NoError(t, err)
+}
+
+func TestSessionHandler_RefreshToken(t *testing.T) {
+	app := getApp(t)
+	sessionCookie := loginAs(t, "refresh-1")
+
+	refresh := func() *http.Response {
+		request := httptest.NewRequest(http.MethodPost, "/token/refresh", nil)
+		request.AddCookie(sessionCookie)
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		return response
+	}
+	me := func(authorization string) int {
+		request := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if authorization != "" {
+			request.Header.Set(fiber.HeaderAuthorization, authorization)
+		} else {
+			request.AddCookie(sessionCookie)
+		}
+		response, err := app.Test(request, -1)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		return response.StatusCode
+	}
+
+	// 1) Test the session hands out its ID token for Bearer requests
+	response := refresh()
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	idToken := models.IDTokenResponse{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&idToken))
+	require.NotEmpty(t, idToken.IDToken)
+	require.Equal(t, http.StatusOK, me("Bearer "+idToken.IDToken))
+
+	// 2) Test an ID token about to expire is renewed with the refresh token
+	expireIDTokens(t, "refresh-1")
+	response = refresh()
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	renewed := models.IDTokenResponse{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&renewed))
+	require.Greater(t, renewed.ExpiresAt, time.Now().Add(services.TokenRenewBefore).UnixMilli())
+	require.Equal(t, http.StatusOK, me("Bearer "+renewed.IDToken))
+
+	// 3) Test a refresh token the provider revoked ends the session
+	testapp.GetIssuer().RevokeRefreshTokens()
+	expireIDTokens(t, "refresh-1")
+	require.Equal(t, http.StatusUnauthorized, me(""))
+	response = refresh()
+	defer response.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
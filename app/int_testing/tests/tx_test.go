@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	testapp "gitlab.com/sandstone2/fiberpoc/app/int_testing/test_app"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPgxPool_WithTx(t *testing.T) {
+	db := testapp.GetDb()
+	fooRepo := repos.NewFooRepository(db, zaptest.NewLogger(t))
+
+	// Foos belong to an organization and are owned by a user, work with the ones of the default organization
+	var orgId int
+	require.NoError(t, db.QueryRow(context.Background(), "SELECT id FROM organizations WHERE slug = 'default';").Scan(&orgId))
+	user, err := repos.NewUserRepository(db, zaptest.NewLogger(t)).UpsertUser(context.Background(), "tx-test", "tx-owner", "Tx Owner", "tx-owner@example.com")
+	require.NoError(t, err)
+	ctx := tenant.WithUserID(tenant.WithOrgID(context.Background(), orgId), user.ID)
+
+	// 1) Test an error rolls back
+	var created *models.Foo
+	err = db.WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		var err error
+		created, err = fooRepo.WithTx(tx).CreateFoo(ctx, "Rolled Back Foo")
+		require.NoError(t, err)
+		return errors.New("fail")
+	})
+	require.EqualError(t, err, "fail")
+
+	_, err = fooRepo.GetFooByID(ctx, int64(created.ID))
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err), "the foo should have been rolled back")
+
+	// 2) Test a panic rolls back and carries on
+	require.Panics(t, func() {
+		_ = db.WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+			created, err = fooRepo.WithTx(tx).CreateFoo(ctx, "Panicked Foo")
+			require.NoError(t, err)
+			panic("boom")
+		})
+	})
+
+	_, err = fooRepo.GetFooByID(ctx, int64(created.ID))
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err), "the foo should have been rolled back")
+
+	// 3) Test success commits
+	err = db.WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		created, err = fooRepo.WithTx(tx).CreateFoo(ctx, "Committed Foo")
+		return err
+	})
+	require.NoError(t, err)
+
+	found, err := fooRepo.GetFooByID(ctx, int64(created.ID))
+	require.NoError(t, err)
+	require.Equal(t, "Committed Foo", found.Name)
+
+	require.NoError(t, fooRepo.DeleteFoo(ctx, int64(created.ID)))
+}
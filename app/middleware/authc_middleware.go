@@ -3,42 +3,76 @@ package middleware
 import (
 	"strings"
 
-	oidc "github.com/coreos/go-oidc"
 	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
 	"go.uber.org/zap"
 )
 
-func AuthcMiddleware(verifier *oidc.IDTokenVerifier, logger *zap.Logger) func(c *fiber.Ctx) error {
+// AuthcMiddleware logs the request in with a Bearer ID token, an ApiKey personal API key or, without an
+// Authorization header, with the session cookie. The claims of the user are stored in c.Locals("user"), a session
+// in c.Locals("session") and an API key in c.Locals("api_key").
+func AuthcMiddleware(authcService services.AuthcServiceInterface, sessionService services.SessionServiceInterface, apiKeyService services.ApiKeyServiceInterface, logger *zap.Logger) func(c *fiber.Ctx) error {
 	return func(c *fiber.Ctx) error {
 		// 1. Extract Bearer token from Authorization header
 		authHeader := c.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			logger.Error("Error: 3R7WBW - Getting authorization header.")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Error 3R7WBW - Getting authorization header."})
+		if authHeader == "" {
+			return sessionLogin(c, sessionService)
+		}
+		if strings.HasPrefix(authHeader, "ApiKey ") {
+			return apiKeyLogin(c, apiKeyService, strings.TrimPrefix(authHeader, "ApiKey "))
+		}
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			return apperrors.Unauthorized("3R7WBW", "A session cookie, Bearer token or API key is required.")
 		}
 
 		rawToken := strings.TrimPrefix(authHeader, "Bearer ")
 
-		// 2. Verify the token with Google
-		idToken, err := verifier.Verify(c.Context(), rawToken)
+		// 2. Verify the token with the provider that issued it and extract claims
+		claims, err := authcService.VerifyToken(c.UserContext(), rawToken)
 		if err != nil {
-			logger.Sugar().Errorf("Error: S2UU5K - Verifying token. Error: %v", err)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Error S2UU5K - Verifying token."})
-		}
-
-		// 3. Extract claims
-		claims := &models.Claims{}
-
-		if err := idToken.Claims(&claims); err != nil {
-			logger.Sugar().Errorf("Error: KH1NV5 - Parsing claims. Error: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Error KH1NV5 - Parsing claims."})
+			return apperrors.Internal(err, "KH1NV5", "Verifying the token failed.")
 		}
 
-		// 4. Store user info in context
+		// 3. Store user info in context
 		c.Locals("user", claims)
 
-		// 5. Proceed to next handler
+		// 4. Proceed to next handler
 		return c.Next()
 	}
 }
+
+// sessionLogin logs the request in with the session cookie. The ID token of the session is renewed when it is
+// about to expire, a session the provider ended is logged out.
+func sessionLogin(c *fiber.Ctx, sessionService services.SessionServiceInterface) error {
+	token := c.Cookies(models.SessionCookieName)
+	if token == "" {
+		return apperrors.Unauthorized("YPAEU5", "A session cookie, Bearer token or API key is required.")
+	}
+
+	claims, session, err := sessionService.Authenticate(c.UserContext(), token)
+	if err != nil {
+		return apperrors.Internal(err, "E8FNH4", "Checking your session failed.")
+	}
+
+	if err := sessionService.RenewTokens(c.UserContext(), claims, session); err != nil {
+		return apperrors.Internal(err, "YFJE85", "Renewing your session failed.")
+	}
+
+	c.Locals("user", claims)
+	c.Locals("session", session)
+	return c.Next()
+}
+
+// apiKeyLogin logs the request in with a personal API key.
+func apiKeyLogin(c *fiber.Ctx, apiKeyService services.ApiKeyServiceInterface, key string) error {
+	claims, apiKey, err := apiKeyService.Authenticate(c.UserContext(), key)
+	if err != nil {
+		return apperrors.Internal(err, "EH4VGB", "Checking your API key failed.")
+	}
+
+	c.Locals("user", claims)
+	c.Locals("api_key", apiKey)
+	return c.Next()
+}
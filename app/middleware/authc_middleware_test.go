@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestAuthcMiddleware_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthcService := mocks.NewMockAuthcService(ctrl)
+	mockSessionService := mocks.NewMockSessionService(ctrl)
+	mockApiKeyService := mocks.NewMockApiKeyService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	app.Get("/me", AuthcMiddleware(mockAuthcService, mockSessionService, mockApiKeyService, logger), func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals("user"))
+	})
+
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1", Email: "ada@example.com", Name: "Ada"}
+
+	// 1) Test a Bearer token
+	mockAuthcService.EXPECT().VerifyToken(gomock.Any(), "token-1").Return(claims, nil)
+
+	request := httptest.NewRequest("GET", "/me", nil)
+	request.Header.Set("Authorization", "Bearer token-1")
+	response, err := app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+
+	// 2) Test a session cookie
+	mockSessionService.EXPECT().Authenticate(gomock.Any(), "session-1").Return(claims, &models.Session{ID: 3}, nil)
+	mockSessionService.EXPECT().RenewTokens(gomock.Any(), claims, &models.Session{ID: 3}).Return(nil)
+
+	request = httptest.NewRequest("GET", "/me", nil)
+	request.AddCookie(&http.Cookie{Name: models.SessionCookieName, Value: "session-1"})
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+
+	// 3) Test an API key
+	mockApiKeyService.EXPECT().Authenticate(gomock.Any(), "fpk_key-1").Return(claims, &models.ApiKey{ID: 4}, nil)
+
+	request = httptest.NewRequest("GET", "/me", nil)
+	request.Header.Set("Authorization", "ApiKey fpk_key-1")
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusOK, response.StatusCode)
+}
+
+func TestAuthcMiddleware_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthcService := mocks.NewMockAuthcService(ctrl)
+	mockSessionService := mocks.NewMockSessionService(ctrl)
+	mockApiKeyService := mocks.NewMockApiKeyService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	app.Get("/me", AuthcMiddleware(mockAuthcService, mockSessionService, mockApiKeyService, logger), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		session       string
+		verifyErr     error
+		sessionErr    error
+		renewErr      error
+		apiKeyErr     error
+		status        int
+	}{
+		{"no token or session", "", "", nil, nil, nil, nil, fiber.StatusUnauthorized},
+		{"not a Bearer token", "Basic dXNlcg==", "", nil, nil, nil, nil, fiber.StatusUnauthorized},
+		{"token the providers reject", "Bearer token-1", "", apperrors.Unauthorized("S2UU5K", "The token is not valid."), nil, nil, nil, fiber.StatusUnauthorized},
+		{"claims that can not be read", "Bearer token-1", "", errors.New("Error: WTWOO1 - Extracting the claims."), nil, nil, nil, fiber.StatusInternalServerError},
+		{"expired session", "", "session-1", nil, apperrors.Unauthorized("JRXEK9", "Your session has expired, log in again."), nil, nil, fiber.StatusUnauthorized},
+		{"session the provider ended", "", "session-2", nil, nil, apperrors.Unauthorized("Z0UMCB", "Your login with the provider has ended, log in again."), nil, fiber.StatusUnauthorized},
+		{"revoked API key", "ApiKey fpk_key-1", "", nil, nil, nil, apperrors.Unauthorized("F428P9", "The API key is not valid."), fiber.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		if test.verifyErr != nil {
+			mockAuthcService.EXPECT().VerifyToken(gomock.Any(), "token-1").Return(nil, test.verifyErr)
+		}
+		if test.sessionErr != nil {
+			mockSessionService.EXPECT().Authenticate(gomock.Any(), test.session).Return(nil, nil, test.sessionErr)
+		}
+		if test.renewErr != nil {
+			claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+			mockSessionService.EXPECT().Authenticate(gomock.Any(), test.session).Return(claims, &models.Session{ID: 3, Renewable: true}, nil)
+			mockSessionService.EXPECT().RenewTokens(gomock.Any(), claims, gomock.Any()).Return(test.renewErr)
+		}
+		if test.apiKeyErr != nil {
+			mockApiKeyService.EXPECT().Authenticate(gomock.Any(), "fpk_key-1").Return(nil, nil, test.apiKeyErr)
+		}
+
+		request := httptest.NewRequest("GET", "/me", nil)
+		if test.authorization != "" {
+			request.Header.Set("Authorization", test.authorization)
+		}
+		if test.session != "" {
+			request.AddCookie(&http.Cookie{Name: models.SessionCookieName, Value: test.session})
+		}
+		response, err := app.Test(request, -1)
+		require.NoError(t, err, test.name)
+		defer response.Body.Close()
+
+		require.Equal(t, test.status, response.StatusCode, test.name)
+	}
+}
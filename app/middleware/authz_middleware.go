@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"slices"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"go.uber.org/zap"
+)
+
+// Authorize lets a request through when the logged in user has every one of permissions through their roles.
+// A request with an API key that has scopes only has the permissions in its scopes. It must run after
+// AuthcMiddleware. The permissions of the request are stored in c.Locals("permissions").
+func Authorize(roleService services.RoleServiceInterface, logger *zap.Logger, permissions ...string) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("user").(*models.Claims)
+		if !ok || claims.Sub == "" {
+			return apperrors.Unauthorized("7OXMK6", "You need to log in.")
+		}
+
+		granted, err := roleService.GetPermissions(c.UserContext(), claims.Issuer, claims.Sub)
+		if err != nil {
+			return apperrors.Internal(err, "BB7W1E", "Checking your permissions failed.")
+		}
+		if apiKey, ok := c.Locals("api_key").(*models.ApiKey); ok && len(apiKey.Scopes) > 0 {
+			granted = slices.DeleteFunc(slices.Clone(granted), func(permission string) bool {
+				return !slices.Contains(apiKey.Scopes, permission)
+			})
+		}
+
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) {
+				logger.Sugar().Debugf("Error: GLY0EG - %s %s needs %s, %s has %v.", c.Method(), c.Path(), permission, claims.Sub, granted)
+				return apperrors.Forbidden("NXJOW6", "You need the "+permission+" permission.")
+			}
+		}
+
+		c.Locals("permissions", granted)
+		return c.Next()
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+// withUser stands in for AuthcMiddleware and logs the request in with claims.
+func withUser(claims *models.Claims) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if claims != nil {
+			c.Locals("user", claims)
+		}
+		return c.Next()
+	}
+}
+
+func TestAuthorize_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleService := mocks.NewMockRoleService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	app.Delete("/foos/:id",
+		withUser(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}),
+		Authorize(mockRoleService, logger, models.PermissionFoosRead, models.PermissionFoosDelete),
+		func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusNoContent)
+		})
+
+	mockRoleService.EXPECT().
+		GetPermissions(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return([]string{models.PermissionFoosDelete, models.PermissionFoosRead, models.PermissionFoosWrite}, nil)
+
+	response, err := app.Test(httptest.NewRequest("DELETE", "/foos/1", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, fiber.StatusNoContent, response.StatusCode)
+}
+
+func TestAuthorize_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleService := mocks.NewMockRoleService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	handler := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	app.Delete("/anonymous/foos/:id", withUser(nil), Authorize(mockRoleService, logger, models.PermissionFoosDelete), handler)
+	app.Delete("/foos/:id", withUser(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}), Authorize(mockRoleService, logger, models.PermissionFoosDelete), handler)
+	withReadOnlyApiKey := func(c *fiber.Ctx) error {
+		c.Locals("api_key", &models.ApiKey{ID: 4, Scopes: []string{models.PermissionFoosRead}})
+		return c.Next()
+	}
+	app.Delete("/api-key/foos/:id", withUser(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}), withReadOnlyApiKey, Authorize(mockRoleService, logger, models.PermissionFoosDelete), handler)
+
+	tests := []struct {
+		name        string
+		path        string
+		permissions []string
+		serviceErr  error
+		status      int
+	}{
+		{"not logged in", "/anonymous/foos/1", nil, nil, fiber.StatusUnauthorized},
+		{"missing permission", "/foos/1", []string{models.PermissionFoosRead}, nil, fiber.StatusForbidden},
+		{"no roles", "/foos/1", []string{}, nil, fiber.StatusForbidden},
+		{"API key without the scope", "/api-key/foos/1", []string{models.PermissionFoosDelete, models.PermissionFoosRead}, nil, fiber.StatusForbidden},
+		{"service failure", "/foos/1", nil, errors.New("fail"), fiber.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if test.permissions != nil || test.serviceErr != nil {
+			mockRoleService.EXPECT().
+				GetPermissions(gomock.Any(), "https://idp.example.com", "sub-1").
+				Return(test.permissions, test.serviceErr)
+		}
+
+		response, err := app.Test(httptest.NewRequest("DELETE", test.path, nil), -1)
+		require.NoError(t, err, test.name)
+		defer response.Body.Close()
+
+		require.Equal(t, test.status, response.StatusCode, test.name)
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	fiber "github.com/gofiber/fiber/v2"
+)
+
+// ContextMiddleware gives each request a user context that ends after timeout. Handlers pass c.UserContext()
+// down to the repos, so database work stops when the request runs out of time. A timeout of 0 means no limit.
+// Only the timeout ends the context. fasthttp does not tell a handler when the client goes away, so work for a
+// client that disconnected still runs until it is done or out of time.
+func ContextMiddleware(timeout time.Duration) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// ErrorHandler renders every error a handler returns as an RFC 7807 problem. Typed errors decide the status,
+// code and detail, see apperrors.From. Running out of time becomes a 504 and anything else a generic 500.
+// The full error with its causes is only logged, with the request id so the two can be matched up.
+func ErrorHandler(logger *zap.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		problem := problemFor(err)
+		problem.Instance = c.Path()
+		problem.RequestID = c.GetRespHeader(fiber.HeaderXRequestID)
+
+		fields := []zap.Field{
+			zap.Error(err),
+			zap.Int("status", problem.Status),
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+			zap.String("request_id", problem.RequestID),
+		}
+		if problem.Status >= fiber.StatusInternalServerError {
+			logger.Error("Error: "+problem.Code+" - Handling request.", fields...)
+		} else {
+			logger.Debug("Error: "+problem.Code+" - Handling request.", fields...)
+		}
+
+		return c.Status(problem.Status).JSON(problem, mimeProblemJSON)
+	}
+}
+
+// problemFor maps err to the problem sent to the client. Only client safe messages are used.
+func problemFor(err error) models.Problem {
+	var fiberError *fiber.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return newProblem(fiber.StatusGatewayTimeout, "IO6JJ2", "The request took too long.")
+	case errors.As(err, &fiberError):
+		return newProblem(fiberError.Code, "4A72VK", fiberError.Message)
+	}
+
+	if appError, ok := apperrors.From(err); ok {
+		problem := newProblem(appError.Kind.Status(), appError.Code, appError.Message)
+		problem.Errors = appError.Fields
+		return problem
+	}
+	return newProblem(fiber.StatusInternalServerError, "CUR8L7", "Something went wrong.")
+}
+
+func newProblem(status int, code string, detail string) models.Problem {
+	return models.Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail, Code: code}
+}
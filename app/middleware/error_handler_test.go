@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestErrorHandler_Success(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	app.Use(requestid.New())
+
+	var handlerErr error
+	app.Get("/foos", func(c *fiber.Ctx) error {
+		return handlerErr
+	})
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{"typed error inside an internal one", apperrors.Internal(errors.Wrap(apperrors.NotFound("39YZ4S", "No foo found with id 7."), "Error: T6D444 - Getting foo."), "HYFSK1", "Getting foo failed."), fiber.StatusNotFound, "39YZ4S", "No foo found with id 7."},
+		{"internal error keeps its cause out of the body", apperrors.Internal(errors.New("password authentication failed"), "J5TSGF", "Getting foos failed."), fiber.StatusInternalServerError, "J5TSGF", "Getting foos failed."},
+		{"untyped error", errors.New("password authentication failed"), fiber.StatusInternalServerError, "CUR8L7", "Something went wrong."},
+		{"deadline", apperrors.Internal(errors.Wrap(context.DeadlineExceeded, "Error: WZDCXT - Getting foos."), "J5TSGF", "Getting foos failed."), fiber.StatusGatewayTimeout, "IO6JJ2", "The request took too long."},
+		{"canceled", errors.Wrap(context.Canceled, "Error: WZDCXT - Getting foos."), fiber.StatusInternalServerError, "CUR8L7", "Something went wrong."},
+		{"fiber error", fiber.ErrMethodNotAllowed, fiber.StatusMethodNotAllowed, "4A72VK", "Method Not Allowed"},
+	}
+
+	for _, test := range tests {
+		handlerErr = test.err
+
+		response, err := app.Test(httptest.NewRequest("GET", "/foos", nil), -1)
+		require.NoError(t, err, test.name)
+		defer response.Body.Close()
+
+		require.Equal(t, test.status, response.StatusCode, test.name)
+		require.Equal(t, "application/problem+json", response.Header.Get(fiber.HeaderContentType), test.name)
+
+		problem := models.Problem{}
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&problem), test.name)
+		require.Equal(t, test.status, problem.Status, test.name)
+		require.Equal(t, test.code, problem.Code, test.name)
+		require.Equal(t, test.detail, problem.Detail, test.name)
+		require.Equal(t, "/foos", problem.Instance, test.name)
+		require.NotEmpty(t, problem.Title, test.name)
+		require.Equal(t, response.Header.Get(fiber.HeaderXRequestID), problem.RequestID, test.name)
+		require.NotEmpty(t, problem.RequestID, test.name)
+	}
+}
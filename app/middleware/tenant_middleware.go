@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/services"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
+	"go.uber.org/zap"
+)
+
+// TenantMiddleware picks the organization of the request, which the logged in user must be a member of. It is the
+// organization named by the X-Org header, else by the subdomain of the host under baseDomain, else the user's
+// default organization. It must run after AuthcMiddleware. The organization is stored in c.Locals("org"), and its id,
+// the id of the user and the actor the audit log records in the user context, where the repos of tenant data read them.
+func TenantMiddleware(orgService services.OrganizationServiceInterface, baseDomain string, logger *zap.Logger) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("user").(*models.Claims)
+		if !ok || claims.Sub == "" {
+			return apperrors.Unauthorized("UJQE6G", "You need to log in.")
+		}
+
+		slug := c.Get(models.OrgHeader)
+		if slug == "" {
+			slug = subdomain(c.Hostname(), baseDomain)
+		}
+
+		org, userId, err := orgService.ResolveOrganization(c.UserContext(), claims, slug)
+		if err != nil {
+			return apperrors.Internal(err, "TF8QKI", "Picking your organization failed.")
+		}
+
+		c.Locals("org", org)
+		ctx := tenant.WithUserID(tenant.WithOrgID(c.UserContext(), org.ID), userId)
+		actor := tenant.Actor{Subject: claims.Sub, Email: claims.Email, RequestID: c.GetRespHeader(fiber.HeaderXRequestID)}
+		c.SetUserContext(tenant.WithActor(ctx, actor))
+		return c.Next()
+	}
+}
+
+// subdomain returns the label of host right under baseDomain, so acme for acme.example.com under example.com.
+// It is empty when host is not a direct subdomain of baseDomain.
+func subdomain(host string, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	fiber "github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
+)
+
+func TestTenantMiddleware_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgService := mocks.NewMockOrganizationService(ctrl)
+	logger := zaptest.NewLogger(t)
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1", Email: "joe@example.com"}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	withRequestID := func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderXRequestID, "request-1")
+		return c.Next()
+	}
+	app.Get("/foos", withRequestID, withUser(claims), TenantMiddleware(mockOrgService, "example.com", logger), func(c *fiber.Ctx) error {
+		orgId, ok := tenant.OrgID(c.UserContext())
+		require.True(t, ok)
+		userId, ok := tenant.UserID(c.UserContext())
+		require.True(t, ok)
+		actor, ok := tenant.ActorOf(c.UserContext())
+		require.True(t, ok)
+		require.Equal(t, tenant.Actor{Subject: "sub-1", Email: "joe@example.com", RequestID: "request-1"}, actor)
+		return c.SendString(strconv.Itoa(orgId) + "/" + strconv.Itoa(userId))
+	})
+
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		slug   string
+	}{
+		{"header", "localhost:3000", "acme", "acme"},
+		{"header over subdomain", "other.example.com", "acme", "acme"},
+		{"subdomain", "acme.example.com:3000", "", "acme"},
+		{"default", "localhost:3000", "", ""},
+		{"not a direct subdomain", "a.acme.example.com", "", ""},
+	}
+
+	for _, test := range tests {
+		mockOrgService.EXPECT().ResolveOrganization(gomock.Any(), claims, test.slug).Return(&models.Organization{ID: 3, Slug: "acme"}, 7, nil)
+
+		request := httptest.NewRequest("GET", "/foos", nil)
+		request.Host = test.host
+		if test.header != "" {
+			request.Header.Set(models.OrgHeader, test.header)
+		}
+		response, err := app.Test(request, -1)
+		require.NoError(t, err, test.name)
+		defer response.Body.Close()
+
+		require.Equal(t, fiber.StatusOK, response.StatusCode, test.name)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err, test.name)
+		require.Equal(t, "3/7", string(body), test.name)
+	}
+}
+
+func TestTenantMiddleware_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgService := mocks.NewMockOrganizationService(ctrl)
+	logger := zaptest.NewLogger(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(logger)})
+	handler := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	app.Get("/anonymous/foos", withUser(nil), TenantMiddleware(mockOrgService, "", logger), handler)
+	app.Get("/foos", withUser(&models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}), TenantMiddleware(mockOrgService, "", logger), handler)
+
+	// 1) Test not logged in
+	response, err := app.Test(httptest.NewRequest("GET", "/anonymous/foos", nil), -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, fiber.StatusUnauthorized, response.StatusCode)
+
+	// 2) Test not a member of the organization
+	mockOrgService.EXPECT().
+		ResolveOrganization(gomock.Any(), gomock.Any(), "acme").
+		Return(nil, 0, apperrors.Forbidden("U5C8IF", "You are not a member of the organization acme."))
+
+	request := httptest.NewRequest("GET", "/foos", nil)
+	request.Header.Set(models.OrgHeader, "acme")
+	response, err = app.Test(request, -1)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, fiber.StatusForbidden, response.StatusCode)
+}
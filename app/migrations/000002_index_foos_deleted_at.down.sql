@@ -0,0 +1 @@
+DROP INDEX IF EXISTS foos_deleted_at_idx;
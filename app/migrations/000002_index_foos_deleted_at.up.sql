@@ -0,0 +1,3 @@
+-- Soft deleted foos have deleted_at set to the epoch milliseconds of the delete, live foos have 0.
+-- This index keeps purging soft deleted foos past the retention window cheap.
+CREATE INDEX IF NOT EXISTS foos_deleted_at_idx ON foos (deleted_at) WHERE deleted_at > 0;
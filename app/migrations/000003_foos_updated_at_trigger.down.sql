@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS foos_set_updated_at ON foos;
+
+-- Drop the function
+DROP FUNCTION IF EXISTS set_updated_at();
@@ -0,0 +1,15 @@
+-- Bump updated_at to the current epoch milliseconds on every update.
+CREATE OR REPLACE FUNCTION set_updated_at()
+RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at := current_epoch_milliseconds();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS foos_set_updated_at ON foos;
+
+CREATE TRIGGER foos_set_updated_at
+BEFORE UPDATE ON foos
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
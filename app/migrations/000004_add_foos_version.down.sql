@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS foos_bump_version ON foos;
+
+-- Drop the function
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE foos DROP COLUMN IF EXISTS version;
@@ -0,0 +1,18 @@
+-- Every foo carries a version for optimistic concurrency. It is sent to clients as the ETag.
+ALTER TABLE foos ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+-- Bump the version on every update.
+CREATE OR REPLACE FUNCTION bump_version()
+RETURNS trigger AS $$
+BEGIN
+  NEW.version := OLD.version + 1;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS foos_bump_version ON foos;
+
+CREATE TRIGGER foos_bump_version
+BEFORE UPDATE ON foos
+FOR EACH ROW
+EXECUTE FUNCTION bump_version();
@@ -0,0 +1 @@
+ALTER TABLE foos DROP CONSTRAINT IF EXISTS foos_name_not_blank;
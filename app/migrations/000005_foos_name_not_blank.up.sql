@@ -0,0 +1,4 @@
+-- Foo names can not be blank. NOT VALID keeps old rows as they are but checks every new insert and update.
+ALTER TABLE foos DROP CONSTRAINT IF EXISTS foos_name_not_blank;
+
+ALTER TABLE foos ADD CONSTRAINT foos_name_not_blank CHECK (btrim(name) <> '') NOT VALID;
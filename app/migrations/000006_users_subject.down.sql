@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+
+DROP INDEX IF EXISTS users_subject_idx;
+
+ALTER TABLE users ALTER COLUMN name TYPE VARCHAR (50) USING left(name, 50);
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
+ALTER TABLE users DROP COLUMN IF EXISTS subject;
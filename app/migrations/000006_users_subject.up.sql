@@ -0,0 +1,16 @@
+-- Users are provisioned on every OIDC login and keyed on the subject, the sub claim of their ID token.
+-- Rows from before have no subject, the unique index allows any number of NULLs.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS subject VARCHAR (255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at bigint NOT NULL DEFAULT 0;
+
+-- Names come from the identity provider and can be longer than 50 characters.
+ALTER TABLE users ALTER COLUMN name TYPE VARCHAR (300);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_subject_idx ON users (subject);
+
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+
+CREATE TRIGGER users_set_updated_at
+BEFORE UPDATE ON users
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS users_issuer_subject_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_subject_idx ON users (subject);
+
+ALTER TABLE users DROP COLUMN IF EXISTS issuer;
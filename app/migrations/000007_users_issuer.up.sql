@@ -0,0 +1,9 @@
+-- Subjects are only unique per identity provider, so users are keyed on the issuer and subject of their ID token.
+-- Every user provisioned before came from Google.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS issuer VARCHAR (300);
+
+UPDATE users SET issuer = 'https://accounts.google.com' WHERE subject IS NOT NULL AND issuer IS NULL;
+
+DROP INDEX IF EXISTS users_subject_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_issuer_subject_idx ON users (issuer, subject);
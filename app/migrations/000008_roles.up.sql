@@ -0,0 +1,48 @@
+-- Role based access control. Users have roles and roles grant permissions, routes require permissions.
+CREATE TABLE IF NOT EXISTS roles(
+   id serial PRIMARY KEY,
+   name VARCHAR (50) NOT NULL UNIQUE,
+   description VARCHAR (300) NOT NULL DEFAULT '',
+   created_at bigint DEFAULT current_epoch_milliseconds()
+);
+
+CREATE TABLE IF NOT EXISTS permissions(
+   id serial PRIMARY KEY,
+   name VARCHAR (100) NOT NULL UNIQUE,
+   description VARCHAR (300) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions(
+   role_id integer NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+   permission_id integer NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+   PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles(
+   user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+   role_id integer NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name, description) VALUES
+   ('viewer', 'Reads foos.'),
+   ('editor', 'Reads, creates, changes and deletes foos.'),
+   ('admin', 'Does anything, including purging foos and assigning roles.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+   ('foos:read', 'Read foos.'),
+   ('foos:write', 'Create, change and restore foos.'),
+   ('foos:delete', 'Soft delete foos.'),
+   ('foos:purge', 'Hard delete soft deleted foos.'),
+   ('roles:manage', 'List roles and assign them to users.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id
+FROM roles JOIN permissions ON
+   (roles.name = 'viewer' AND permissions.name IN ('foos:read')) OR
+   (roles.name = 'editor' AND permissions.name IN ('foos:read', 'foos:write', 'foos:delete')) OR
+   (roles.name = 'admin')
+ON CONFLICT DO NOTHING;
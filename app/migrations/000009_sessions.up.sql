@@ -0,0 +1,17 @@
+-- Logins are kept as server side sessions. The session cookie holds a random token and only its SHA-256 hash is
+-- stored, so the table can not be used to log in. expires_at slides forward on use but never past
+-- absolute_expires_at.
+CREATE TABLE IF NOT EXISTS sessions(
+   id bigserial PRIMARY KEY,
+   token_hash bytea NOT NULL UNIQUE,
+   user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+   user_agent VARCHAR (500) NOT NULL DEFAULT '',
+   ip_address VARCHAR (100) NOT NULL DEFAULT '',
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   last_seen_at bigint NOT NULL DEFAULT current_epoch_milliseconds(),
+   expires_at bigint NOT NULL,
+   absolute_expires_at bigint NOT NULL,
+   revoked_at bigint NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id) WHERE revoked_at = 0;
@@ -0,0 +1,13 @@
+-- Logins in progress, from the redirect to the provider until its callback. Each holds the PKCE code verifier and
+-- nonce of the login and is keyed on the SHA-256 hash of its state. The callback deletes it, so it is used once.
+CREATE TABLE IF NOT EXISTS oidc_logins(
+   state_hash bytea PRIMARY KEY,
+   provider VARCHAR (50) NOT NULL,
+   code_verifier VARCHAR (128) NOT NULL,
+   nonce VARCHAR (100) NOT NULL,
+   return_to VARCHAR (2000) NOT NULL DEFAULT '',
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   expires_at bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS oidc_logins_expires_at_idx ON oidc_logins (expires_at);
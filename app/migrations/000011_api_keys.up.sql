@@ -0,0 +1,18 @@
+-- Personal API keys let scripts and CI jobs call the API as the user who created them. Like sessions only the
+-- SHA-256 hash of a key is stored, prefix is its first characters so users can tell their keys apart. A key with
+-- scopes only has the permissions of its user that are in scopes, without scopes it has all of them. expires_at is
+-- 0 for keys that do not expire.
+CREATE TABLE IF NOT EXISTS api_keys(
+   id bigserial PRIMARY KEY,
+   key_hash bytea NOT NULL UNIQUE,
+   prefix VARCHAR (20) NOT NULL,
+   user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+   name VARCHAR (100) NOT NULL,
+   scopes text[] NOT NULL DEFAULT '{}',
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   last_used_at bigint NOT NULL DEFAULT 0,
+   expires_at bigint NOT NULL DEFAULT 0,
+   revoked_at bigint NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id) WHERE revoked_at = 0;
@@ -0,0 +1,12 @@
+DROP POLICY IF EXISTS foos_tenant_isolation ON foos;
+ALTER TABLE foos NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE foos DISABLE ROW LEVEL SECURITY;
+
+DELETE FROM permissions WHERE name = 'orgs:manage';
+
+DROP INDEX IF EXISTS foos_org_id_idx;
+ALTER TABLE foos DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users DROP COLUMN IF EXISTS default_org_id;
+
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS organizations;
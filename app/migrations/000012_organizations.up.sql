@@ -0,0 +1,53 @@
+-- Organizations are the tenants. Every foo belongs to one and users work with the foos of the organizations they
+-- are members of. default_org_id is the organization of a user's requests that do not pick one.
+CREATE TABLE IF NOT EXISTS organizations(
+   id serial PRIMARY KEY,
+   slug VARCHAR (50) NOT NULL UNIQUE,
+   name VARCHAR (100) NOT NULL,
+   created_at bigint DEFAULT current_epoch_milliseconds()
+);
+
+CREATE TABLE IF NOT EXISTS memberships(
+   org_id integer NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+   user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS memberships_user_id_idx ON memberships (user_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_org_id integer REFERENCES organizations (id) ON DELETE SET NULL;
+
+-- The users and foos from before organizations move into the default organization.
+INSERT INTO organizations (slug, name) VALUES ('default', 'Default') ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO memberships (org_id, user_id)
+SELECT organizations.id, users.id FROM organizations, users WHERE organizations.slug = 'default'
+ON CONFLICT DO NOTHING;
+
+UPDATE users SET default_org_id = (SELECT id FROM organizations WHERE slug = 'default') WHERE default_org_id IS NULL;
+
+ALTER TABLE foos ADD COLUMN IF NOT EXISTS org_id integer REFERENCES organizations (id) ON DELETE CASCADE;
+UPDATE foos SET org_id = (SELECT id FROM organizations WHERE slug = 'default') WHERE org_id IS NULL;
+ALTER TABLE foos ALTER COLUMN org_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS foos_org_id_idx ON foos (org_id, id) WHERE deleted_at = 0;
+
+INSERT INTO permissions (name, description) VALUES
+   ('orgs:manage', 'Create organizations and add and remove their members.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions WHERE roles.name = 'admin' AND permissions.name = 'orgs:manage'
+ON CONFLICT DO NOTHING;
+
+-- Row level security backs up the org_id conditions of the queries. The app sets app.org_id on the connection of
+-- every request when TENANT_RLS is on, then only the foos of that organization can be seen or written.
+-- Connections without app.org_id, like migrations and psql, see every foo.
+ALTER TABLE foos ENABLE ROW LEVEL SECURITY;
+ALTER TABLE foos FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS foos_tenant_isolation ON foos;
+CREATE POLICY foos_tenant_isolation ON foos
+   USING (NULLIF(current_setting('app.org_id', true), '') IS NULL OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer)
+   WITH CHECK (NULLIF(current_setting('app.org_id', true), '') IS NULL OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer);
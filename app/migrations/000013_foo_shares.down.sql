@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS foo_shares;
+
+DROP INDEX IF EXISTS foos_owner_id_idx;
+ALTER TABLE foos DROP COLUMN IF EXISTS owner_id;
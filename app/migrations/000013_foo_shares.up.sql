@@ -0,0 +1,22 @@
+-- Foos belong to the user who created them. owner_id is NULL for the foos from before owners, which every member
+-- of their organization can still read and write.
+ALTER TABLE foos ADD COLUMN IF NOT EXISTS owner_id integer REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS foos_owner_id_idx ON foos (owner_id) WHERE deleted_at = 0;
+
+-- The owner of a foo shares it with another user, or with a group, which is every user with a role. A share lets
+-- them read the foo or read and write it. A user or role has at most one share of a foo.
+CREATE TABLE IF NOT EXISTS foo_shares(
+   id serial PRIMARY KEY,
+   foo_id integer NOT NULL REFERENCES foos (id) ON DELETE CASCADE,
+   user_id integer REFERENCES users (id) ON DELETE CASCADE,
+   role_id integer REFERENCES roles (id) ON DELETE CASCADE,
+   permission VARCHAR (10) NOT NULL,
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   CONSTRAINT foo_shares_grantee CHECK ((user_id IS NULL) <> (role_id IS NULL)),
+   CONSTRAINT foo_shares_permission CHECK (permission IN ('read', 'write'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS foo_shares_grantee_idx ON foo_shares (foo_id, COALESCE(user_id, 0), COALESCE(role_id, 0));
+CREATE INDEX IF NOT EXISTS foo_shares_user_id_idx ON foo_shares (user_id);
+CREATE INDEX IF NOT EXISTS foo_shares_role_id_idx ON foo_shares (role_id);
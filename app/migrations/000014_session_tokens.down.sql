@@ -0,0 +1,4 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS refresh_token;
+ALTER TABLE sessions DROP COLUMN IF EXISTS id_token_expires_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS id_token;
+ALTER TABLE sessions DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,7 @@
+-- A session keeps the ID token of its login and, when the provider issued one, the refresh token that renews it.
+-- Both are encrypted with TOKEN_ENCRYPTION_KEY, so the table can not be used to call the API or the provider.
+-- refresh_token is NULL for sessions that can not be renewed.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS provider VARCHAR (50) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS id_token bytea;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS id_token_expires_at bigint NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS refresh_token bytea;
@@ -0,0 +1,39 @@
+-- The audit log. Every change to a foo adds a row in the same transaction as the change, with who made it, the
+-- request it was made in and the foo before and after it. before is NULL for a create and after for a delete or
+-- purge. Rows are never changed, and entity_id has no foreign key so they outlive purged foos.
+CREATE TABLE IF NOT EXISTS audit_events(
+   id bigserial PRIMARY KEY,
+   org_id integer NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+   actor_id integer REFERENCES users (id) ON DELETE SET NULL,
+   actor_sub VARCHAR (255) NOT NULL DEFAULT '',
+   actor_email VARCHAR (300) NOT NULL DEFAULT '',
+   request_id VARCHAR (100) NOT NULL DEFAULT '',
+   entity VARCHAR (50) NOT NULL,
+   entity_id bigint NOT NULL,
+   action VARCHAR (50) NOT NULL,
+   before jsonb,
+   after jsonb,
+   created_at bigint NOT NULL DEFAULT current_epoch_milliseconds()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_org_id_idx ON audit_events (org_id, id);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (org_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON audit_events (org_id, entity, entity_id);
+CREATE INDEX IF NOT EXISTS audit_events_actor_sub_idx ON audit_events (org_id, actor_sub);
+
+INSERT INTO permissions (name, description) VALUES
+   ('audit:read', 'Read the audit log of the organization.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions WHERE roles.name = 'admin' AND permissions.name = 'audit:read'
+ON CONFLICT DO NOTHING;
+
+-- The same row level security as the foos, see 000012_organizations.
+ALTER TABLE audit_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_events FORCE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS audit_events_tenant_isolation ON audit_events;
+CREATE POLICY audit_events_tenant_isolation ON audit_events
+   USING (NULLIF(current_setting('app.org_id', true), '') IS NULL OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer)
+   WITH CHECK (NULLIF(current_setting('app.org_id', true), '') IS NULL OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer);
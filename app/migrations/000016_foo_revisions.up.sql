@@ -0,0 +1,16 @@
+-- Every version of a foo written by a create, update or patch, so clients can see how a foo changed and go back to
+-- an older version. version is the foo version the revision was written at, a soft delete or restore bumps the
+-- version of a foo without writing a revision. created_by is the user who wrote it.
+CREATE TABLE IF NOT EXISTS foo_revisions(
+   foo_id integer NOT NULL REFERENCES foos (id) ON DELETE CASCADE,
+   version integer NOT NULL,
+   name VARCHAR (50) NOT NULL,
+   created_by integer REFERENCES users (id) ON DELETE SET NULL,
+   created_at bigint DEFAULT current_epoch_milliseconds(),
+   PRIMARY KEY (foo_id, version)
+);
+
+-- The foos from before revisions start with their current version.
+INSERT INTO foo_revisions (foo_id, version, name, created_by, created_at)
+SELECT id, version, name, owner_id, GREATEST(created_at, updated_at) FROM foos
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS foos_name_trgm_idx;
+DROP EXTENSION IF EXISTS pg_trgm;
+
+DROP INDEX IF EXISTS foos_search_idx;
+ALTER TABLE foos DROP COLUMN IF EXISTS search;
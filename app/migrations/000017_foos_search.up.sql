@@ -0,0 +1,10 @@
+-- Full text search over the foo names. search is kept up to date by Postgres from the name, with the english
+-- configuration so "parties" finds "party".
+ALTER TABLE foos ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (to_tsvector('english', name)) STORED;
+
+CREATE INDEX IF NOT EXISTS foos_search_idx ON foos USING GIN (search);
+
+-- Trigram similarity finds the names a search misspelled, like "prty" for "party".
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS foos_name_trgm_idx ON foos USING GIN (name gin_trgm_ops);
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_check;
+
+ALTER TABLE users ADD CONSTRAINT users_email_check CHECK (email <> '') NOT VALID;
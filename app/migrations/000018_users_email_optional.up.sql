@@ -0,0 +1,2 @@
+-- Not every identity provider sends an email claim, users without one are stored with an empty email.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_check;
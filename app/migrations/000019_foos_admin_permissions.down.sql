@@ -0,0 +1 @@
+DELETE FROM permissions WHERE name IN ('foos:read_deleted', 'foos:delete_all');
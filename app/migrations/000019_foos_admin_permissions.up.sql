@@ -0,0 +1,10 @@
+-- Listing soft deleted foos and deleting every foo at once get their own permissions, only admins have them.
+INSERT INTO permissions (name, description) VALUES
+   ('foos:read_deleted', 'List and export soft deleted foos.'),
+   ('foos:delete_all', 'Soft delete every foo at once.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name = 'admin' AND permissions.name IN ('foos:read_deleted', 'foos:delete_all')
+ON CONFLICT DO NOTHING;
@@ -1,3 +1,8 @@
-INSERT INTO foos (name) VALUES ('Test Foo 1');
-INSERT INTO foos (name) VALUES ('Test Foo 2');
-INSERT INTO foos (name) VALUES ('Test Foo 3');
\ No newline at end of file
+INSERT INTO foos (name, org_id) SELECT 'Test Foo 1', id FROM organizations WHERE slug = 'default';
+INSERT INTO foos (name, org_id) SELECT 'Test Foo 2', id FROM organizations WHERE slug = 'default';
+INSERT INTO foos (name, org_id) SELECT 'Test Foo 3', id FROM organizations WHERE slug = 'default';
+
+-- The seeded foos come after the migrations, give them the first revision a create writes.
+INSERT INTO foo_revisions (foo_id, version, name, created_by, created_at)
+SELECT id, version, name, owner_id, GREATEST(created_at, updated_at) FROM foos
+ON CONFLICT DO NOTHING;
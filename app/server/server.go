@@ -1,7 +1,11 @@
 package server
 
 import (
+	"fmt"
 	"log"
+	"regexp"
+	"slices"
+	"strings"
 
 	env "github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
@@ -27,6 +31,17 @@ func InitServer(envFileName string) (db *clients.PgxPoolImpl, logger *zap.Logger
 		return nil, nil, errors.Wrap(err, "Error: YN80XB - Parsing and validating env vars")
 	}
 
+	config.OidcProviders, err = parseOidcProviders(&config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error: OE0DFL - Parsing OIDC provider env vars.")
+	}
+
+	// Refresh tokens are only stored encrypted.
+	offlineAccess := slices.ContainsFunc(config.OidcProviders, func(provider models.OidcProviderConfig) bool { return provider.OfflineAccess })
+	if offlineAccess && config.TokenEncryptionKey == "" {
+		return nil, nil, errors.New("Error: 4XA5NU - OFFLINE_ACCESS of a provider needs TOKEN_ENCRYPTION_KEY.")
+	}
+
 	models.GlobalConfig = &config
 
 	logger = clients.GetLogger()
@@ -39,3 +54,40 @@ func InitServer(envFileName string) (db *clients.PgxPoolImpl, logger *zap.Logger
 
 	return db, logger, nil
 }
+
+// oidcProviderName is what provider names can look like, they are used in env vars and URLs.
+var oidcProviderName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// parseOidcProviders parses the OIDC_<NAME>_ env vars of each provider in OIDC_PROVIDERS.
+// The redirect URI of a provider defaults to REDIRECT_URI/<name>, so REDIRECT_URI is only needed by providers without
+// one of their own. The display name of a provider defaults to its name.
+func parseOidcProviders(config *models.AppConfig) (providers []models.OidcProviderConfig, err error) {
+	if len(config.OidcProviderNames) == 0 {
+		return nil, errors.New("Error: SD719L - OIDC_PROVIDERS needs at least one provider.")
+	}
+
+	for _, name := range config.OidcProviderNames {
+		if !oidcProviderName.MatchString(name) {
+			return nil, errors.Errorf("Error: 7WZ1VL - %q is not a provider name, use lower case letters, digits and _.", name)
+		}
+
+		provider := models.OidcProviderConfig{Name: name}
+		prefix := fmt.Sprintf("OIDC_%s_", strings.ToUpper(name))
+		if err := env.Parse(&provider, env.Options{Prefix: prefix}); err != nil {
+			return nil, errors.Wrapf(err, "Error: TNAOFR - Parsing the %s env vars.", prefix)
+		}
+
+		if provider.DisplayName == "" {
+			provider.DisplayName = name
+		}
+		if provider.RedirectUri == "" {
+			if config.RedirectUri == "" {
+				return nil, errors.Errorf("Error: FOZ0WW - The %s provider needs %sREDIRECT_URI or REDIRECT_URI.", name, prefix)
+			}
+			provider.RedirectUri = strings.TrimSuffix(config.RedirectUri, "/") + "/" + name
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
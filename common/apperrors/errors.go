@@ -0,0 +1,164 @@
+// Package apperrors has the typed errors of the app. Each error has a kind that decides the HTTP status,
+// one of the short codes used across the repo and a message that is safe to show clients. The cause of an
+// error is only for the logs and is never sent to clients.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Kind string
+
+const (
+	KindBadRequest           Kind = "bad_request"
+	KindUnauthorized         Kind = "unauthorized"
+	KindForbidden            Kind = "forbidden"
+	KindNotFound             Kind = "not_found"
+	KindConflict             Kind = "conflict"
+	KindPreconditionFailed   Kind = "precondition_failed"
+	KindNotAcceptable        Kind = "not_acceptable"
+	KindUnsupportedMediaType Kind = "unsupported_media_type"
+	KindValidation           Kind = "validation"
+	KindPreconditionRequired Kind = "precondition_required"
+	KindInternal             Kind = "internal"
+)
+
+// Status is the HTTP status of errors of the kind.
+func (kind Kind) Status() int {
+	switch kind {
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case KindNotAcceptable:
+		return http.StatusNotAcceptable
+	case KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error is a typed error. Message is meant for the client, Cause only for the logs.
+// Validation errors list what is wrong with each field in Fields.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Cause   error
+}
+
+// FieldError is what is wrong with one field of a request. Field is the JSON name of the field, with the index
+// in front for items of an array body, like "[2].name". Code is the rule that failed, like "required".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func New(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func BadRequest(code string, message string) *Error {
+	return New(KindBadRequest, code, message)
+}
+
+func Unauthorized(code string, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code string, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code string, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func PreconditionFailed(code string, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+func Validation(code string, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Internal wraps err with a code and a client safe message. When err holds a typed error that is not internal,
+// that error still decides the response, see From. So callers can wrap any error they can not handle with Internal.
+func Internal(err error, code string, message string) *Error {
+	return &Error{Kind: KindInternal, Code: code, Message: message, Cause: err}
+}
+
+// WithCause returns a copy of the error with cause attached for the logs.
+func (appError *Error) WithCause(cause error) *Error {
+	withCause := *appError
+	withCause.Cause = cause
+	return &withCause
+}
+
+// WithFields returns a copy of the error with the field errors.
+func (appError *Error) WithFields(fields ...FieldError) *Error {
+	withFields := *appError
+	withFields.Fields = fields
+	return &withFields
+}
+
+func (appError *Error) Error() string {
+	if appError.Cause != nil {
+		return fmt.Sprintf("Error: %s - %s: %v", appError.Code, appError.Message, appError.Cause)
+	}
+	return fmt.Sprintf("Error: %s - %s", appError.Code, appError.Message)
+}
+
+func (appError *Error) Unwrap() error {
+	return appError.Cause
+}
+
+// From returns the typed error in the chain of err that decides the response. That is the outermost error
+// that is not internal, as it is the most specific reason the request failed, or else the outermost internal
+// error. It returns false when err has no typed error.
+func From(err error) (*Error, bool) {
+	var internal *Error
+	for ; err != nil; err = errors.Unwrap(err) {
+		appError, ok := err.(*Error)
+		if !ok {
+			continue
+		}
+		if appError.Kind != KindInternal {
+			return appError, true
+		}
+		if internal == nil {
+			internal = appError
+		}
+	}
+	return internal, internal != nil
+}
+
+// KindOf is the kind of the typed error that decides the response for err, see From. It is KindInternal when
+// err has no typed error.
+func KindOf(err error) Kind {
+	if appError, ok := From(err); ok {
+		return appError.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,50 @@
+package apperrors
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrom_Success(t *testing.T) {
+	// 1) Test the outermost error that is not internal wins
+	notFound := NotFound("39YZ4S", "No foo found with id 7.")
+	err := Internal(errors.Wrap(notFound, "Error: T6D444 - Getting foo."), "HYFSK1", "Getting foo failed.")
+	appError, ok := From(err)
+	require.True(t, ok)
+	require.Same(t, notFound, appError)
+	require.Equal(t, http.StatusNotFound, appError.Kind.Status())
+
+	// 2) Test the outermost internal error is used when there is nothing more specific
+	internal := Internal(errors.New("connection refused"), "J5TSGF", "Getting foos failed.")
+	err = Internal(internal, "WZDCXT", "Getting foos.")
+	appError, ok = From(errors.Wrap(err, "Error: ABC123 - Wrapped."))
+	require.True(t, ok)
+	require.Equal(t, "WZDCXT", appError.Code)
+	require.Equal(t, http.StatusInternalServerError, appError.Kind.Status())
+}
+
+func TestFrom_Error(t *testing.T) {
+	appError, ok := From(errors.New("connection refused"))
+	require.False(t, ok)
+	require.Nil(t, appError)
+	require.Equal(t, KindInternal, KindOf(errors.New("connection refused")))
+}
+
+func TestError_Error_Success(t *testing.T) {
+	// 1) Test without a cause
+	require.Equal(t, "Error: 39YZ4S - No foo found.", NotFound("39YZ4S", "No foo found.").Error())
+
+	// 2) Test the cause is kept for the logs
+	cause := errors.New("connection refused")
+	err := Internal(cause, "J5TSGF", "Getting foos failed.")
+	require.Equal(t, "Error: J5TSGF - Getting foos failed.: connection refused", err.Error())
+	require.ErrorIs(t, err, cause)
+
+	// 3) Test WithCause does not change the original error
+	badRequest := BadRequest("2OSECA", "The cursor is not valid.")
+	require.ErrorIs(t, badRequest.WithCause(cause), cause)
+	require.Nil(t, badRequest.Cause)
+}
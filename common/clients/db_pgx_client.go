@@ -2,6 +2,7 @@ package clients
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -9,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
 )
 
 // pgxPoolImpl is our production wrapper around *pgxpool.Pool.
@@ -26,6 +28,10 @@ func NewPgxPoolImpl() (*PgxPoolImpl, error) {
 		return nil, errors.Wrap(err, "Error: V78BO4 - Parsing the database configs from the url.")
 	}
 
+	if *models.GlobalConfig.GetTenantRls() {
+		connConfig.BeforeAcquire = setConnOrgID
+	}
+
 	var pool *pgxpool.Pool
 
 	pool, err = pgxpool.NewWithConfig(context.Background(), connConfig)
@@ -45,6 +51,22 @@ func NewPgxPoolImpl() (*PgxPoolImpl, error) {
 	return &PgxPoolImpl{pool: pool}, nil
 }
 
+// setConnOrgID sets app.org_id on a connection as it is acquired for ctx, to the organization of ctx or to an empty
+// string when it has none. The row level security policies of the tenant tables read it.
+func setConnOrgID(ctx context.Context, conn *pgx.Conn) bool {
+	orgId := ""
+	if id, ok := tenant.OrgID(ctx); ok {
+		orgId = strconv.Itoa(id)
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT set_config('app.org_id', $1, false);", orgId); err != nil {
+		// The pool destroys the connection and acquires another one.
+		GetLogger().Sugar().Errorf("Error: B618MS - Setting the organization of the connection. Error: %v", err)
+		return false
+	}
+	return true
+}
+
 // Exec delegates to the real pool.Exec.
 func (p *PgxPoolImpl) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
 	return p.pool.Exec(ctx, sql, args...)
@@ -64,10 +86,106 @@ func (p *PgxPoolImpl) QueryRow(ctx context.Context, sql string, args ...interfac
 	return &pgxRow{row: p.pool.QueryRow(ctx, sql, args...)}
 }
 
+// SendBatch delegates to the real pool.SendBatch, returning a *pgxBatchResults wrapper.
+// The queued queries run in one implicit transaction, so if one fails none of them are committed.
+func (p *PgxPoolImpl) SendBatch(ctx context.Context, batch *pgx.Batch) interfaces.PgxBatchResultsInterface {
+	return &pgxBatchResults{results: p.pool.SendBatch(ctx, batch)}
+}
+
+// CopyFrom delegates to the real pool.CopyFrom, which loads the rows with the COPY protocol.
+func (p *PgxPoolImpl) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return p.pool.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+// Begin delegates to the real pool.Begin, returning a *pgxTx wrapper.
+func (p *PgxPoolImpl) Begin(ctx context.Context) (interfaces.PgxTxInterface, error) {
+	rawTx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pgxTx{tx: rawTx}, nil
+}
+
+// BeginTx delegates to the real pool.BeginTx, returning a *pgxTx wrapper.
+func (p *PgxPoolImpl) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (interfaces.PgxTxInterface, error) {
+	rawTx, err := p.pool.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &pgxTx{tx: rawTx}, nil
+}
+
+// WithTx runs fn in a transaction. It commits when fn returns nil and rolls back when fn returns an error.
+// If fn panics the transaction is rolled back and the panic carries on up the stack.
+func (p *PgxPoolImpl) WithTx(ctx context.Context, fn func(tx interfaces.PgxTxInterface) error) (err error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Error: 0ECMZ9 - Beginning transaction.")
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			// Use a fresh context, ctx may be the reason for the panic.
+			_ = tx.Rollback(context.Background())
+			panic(recovered)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+			GetLogger().Sugar().Errorf("Error: OA32BX - Rolling back transaction. Error: %v", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "Error: LR20TI - Committing transaction.")
+	}
+
+	return nil
+}
+
 func (p *PgxPoolImpl) Close() {
 	p.pool.Close()
 }
 
+// pgxTx wraps pgx.Tx to implement our Tx interface.
+type pgxTx struct {
+	tx pgx.Tx
+}
+
+func (t *pgxTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.tx.Exec(ctx, sql, args...)
+}
+
+func (t *pgxTx) Query(ctx context.Context, sql string, args ...interface{}) (interfaces.PgxRowsInterface, error) {
+	rawRows, err := t.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &pgxRows{rows: rawRows}, nil
+}
+
+func (t *pgxTx) QueryRow(ctx context.Context, sql string, args ...interface{}) interfaces.PgxRowInterface {
+	return &pgxRow{row: t.tx.QueryRow(ctx, sql, args...)}
+}
+
+func (t *pgxTx) SendBatch(ctx context.Context, batch *pgx.Batch) interfaces.PgxBatchResultsInterface {
+	return &pgxBatchResults{results: t.tx.SendBatch(ctx, batch)}
+}
+
+func (t *pgxTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return t.tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (t *pgxTx) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *pgxTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
+
 // pgxRows wraps pgx.Rows to implement our Rows interface.
 type pgxRows struct {
 	rows pgx.Rows
@@ -97,3 +215,20 @@ type pgxRow struct {
 func (r *pgxRow) Scan(dest ...interface{}) error {
 	return r.row.Scan(dest...)
 }
+
+// pgxBatchResults wraps pgx.BatchResults to implement our BatchResults interface.
+type pgxBatchResults struct {
+	results pgx.BatchResults
+}
+
+func (r *pgxBatchResults) Exec() (pgconn.CommandTag, error) {
+	return r.results.Exec()
+}
+
+func (r *pgxBatchResults) QueryRow() interfaces.PgxRowInterface {
+	return &pgxRow{row: r.results.QueryRow()}
+}
+
+func (r *pgxBatchResults) Close() error {
+	return r.results.Close()
+}
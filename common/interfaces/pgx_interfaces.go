@@ -3,6 +3,7 @@ package interfaces
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -19,11 +20,42 @@ type PgxRowsInterface interface {
 	Err() error
 }
 
-// PgxPoolInterface is our main interface that wraps the methods we need from pgxpool.Pool.
-type PgxPoolInterface interface {
+// PgxBatchResultsInterface is an interface for the results of a batch (e.g., SendBatch).
+// Results are read in the order the queries were queued.
+type PgxBatchResultsInterface interface {
+	Exec() (pgconn.CommandTag, error)
+	QueryRow() PgxRowInterface
+	Close() error
+}
+
+// PgxQuerierInterface is the query methods shared by the pool and transactions, so repos can run against either.
+type PgxQuerierInterface interface {
 	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...interface{}) (PgxRowsInterface, error)
 	QueryRow(ctx context.Context, sql string, args ...interface{}) PgxRowInterface
+	SendBatch(ctx context.Context, batch *pgx.Batch) PgxBatchResultsInterface
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// PgxTxInterface is an interface for a transaction (e.g., Begin).
+type PgxTxInterface interface {
+	PgxQuerierInterface
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// PgxTxManagerInterface runs a unit of work in a transaction.
+// WithTx commits when fn returns nil and rolls back when fn returns an error or panics.
+type PgxTxManagerInterface interface {
+	WithTx(ctx context.Context, fn func(tx PgxTxInterface) error) error
+}
+
+// PgxPoolInterface is our main interface that wraps the methods we need from pgxpool.Pool.
+type PgxPoolInterface interface {
+	PgxQuerierInterface
+	PgxTxManagerInterface
+	Begin(ctx context.Context) (PgxTxInterface, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (PgxTxInterface, error)
 	Close()
 }
 
@@ -49,6 +81,27 @@ mockgen \
   gitlab.com/sandstone2/fiberpoc/common/interfaces \
   PgxRowsInterface
 
+mockgen \
+  -destination=./mocks/mock_pgx_batch_results.go \
+  -package=mocks \
+  -mock_names=PgxBatchResultsInterface=MockPgxBatchResults \
+  gitlab.com/sandstone2/fiberpoc/common/interfaces \
+  PgxBatchResultsInterface
+
+mockgen \
+  -destination=./mocks/mock_pgx_tx.go \
+  -package=mocks \
+  -mock_names=PgxTxInterface=MockPgxTx \
+  gitlab.com/sandstone2/fiberpoc/common/interfaces \
+  PgxTxInterface
+
+mockgen \
+  -destination=./mocks/mock_pgx_tx_manager.go \
+  -package=mocks \
+  -mock_names=PgxTxManagerInterface=MockPgxTxManager \
+  gitlab.com/sandstone2/fiberpoc/common/interfaces \
+  PgxTxManagerInterface
+
 mockgen \
   -destination=./mocks/mock_pgx_pool.go \
   -package=mocks \
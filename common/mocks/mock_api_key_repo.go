@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: ApiKeyRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_api_key_repo.go -package=mocks -mock_names=ApiKeyRepoInterface=MockApiKeyRepo gitlab.com/sandstone2/fiberpoc/common/repos ApiKeyRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApiKeyRepo is a mock of ApiKeyRepoInterface interface.
+type MockApiKeyRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockApiKeyRepoMockRecorder is the mock recorder for MockApiKeyRepo.
+type MockApiKeyRepoMockRecorder struct {
+	mock *MockApiKeyRepo
+}
+
+// NewMockApiKeyRepo creates a new mock instance.
+func NewMockApiKeyRepo(ctrl *gomock.Controller) *MockApiKeyRepo {
+	mock := &MockApiKeyRepo{ctrl: ctrl}
+	mock.recorder = &MockApiKeyRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyRepo) EXPECT() *MockApiKeyRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKeyRepo) CreateApiKey(ctx context.Context, issuer, subject string, keyHash []byte, apiKey *models.ApiKey) (*models.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", ctx, issuer, subject, keyHash, apiKey)
+	ret0, _ := ret[0].(*models.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeyRepoMockRecorder) CreateApiKey(ctx, issuer, subject, keyHash, apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).CreateApiKey), ctx, issuer, subject, keyHash, apiKey)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKeyRepo) GetApiKeys(ctx context.Context, issuer, subject string) ([]models.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", ctx, issuer, subject)
+	ret0, _ := ret[0].([]models.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeyRepoMockRecorder) GetApiKeys(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKeyRepo)(nil).GetApiKeys), ctx, issuer, subject)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockApiKeyRepo) RevokeApiKey(ctx context.Context, issuer, subject string, apiKeyId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", ctx, issuer, subject, apiKeyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockApiKeyRepoMockRecorder) RevokeApiKey(ctx, issuer, subject, apiKeyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).RevokeApiKey), ctx, issuer, subject, apiKeyId)
+}
+
+// TouchApiKey mocks base method.
+func (m *MockApiKeyRepo) TouchApiKey(ctx context.Context, keyHash []byte) (*models.ApiKey, *models.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchApiKey", ctx, keyHash)
+	ret0, _ := ret[0].(*models.ApiKey)
+	ret1, _ := ret[1].(*models.Claims)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TouchApiKey indicates an expected call of TouchApiKey.
+func (mr *MockApiKeyRepoMockRecorder) TouchApiKey(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchApiKey", reflect.TypeOf((*MockApiKeyRepo)(nil).TouchApiKey), ctx, keyHash)
+}
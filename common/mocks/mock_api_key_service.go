@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/services (interfaces: ApiKeyServiceInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_api_key_service.go -package=mocks -mock_names=ApiKeyServiceInterface=MockApiKeyService gitlab.com/sandstone2/fiberpoc/common/services ApiKeyServiceInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockApiKeyService is a mock of ApiKeyServiceInterface interface.
+type MockApiKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockApiKeyServiceMockRecorder is the mock recorder for MockApiKeyService.
+type MockApiKeyServiceMockRecorder struct {
+	mock *MockApiKeyService
+}
+
+// NewMockApiKeyService creates a new mock instance.
+func NewMockApiKeyService(ctrl *gomock.Controller) *MockApiKeyService {
+	mock := &MockApiKeyService{ctrl: ctrl}
+	mock.recorder = &MockApiKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeyService) EXPECT() *MockApiKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockApiKeyService) Authenticate(ctx context.Context, key string) (*models.Claims, *models.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, key)
+	ret0, _ := ret[0].(*models.Claims)
+	ret1, _ := ret[1].(*models.ApiKey)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockApiKeyServiceMockRecorder) Authenticate(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockApiKeyService)(nil).Authenticate), ctx, key)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKeyService) CreateApiKey(ctx context.Context, claims *models.Claims, request *models.ApiKeyRequest) (*models.NewApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", ctx, claims, request)
+	ret0, _ := ret[0].(*models.NewApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeyServiceMockRecorder) CreateApiKey(ctx, claims, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKeyService)(nil).CreateApiKey), ctx, claims, request)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKeyService) GetApiKeys(ctx context.Context, claims *models.Claims) ([]models.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", ctx, claims)
+	ret0, _ := ret[0].([]models.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeyServiceMockRecorder) GetApiKeys(ctx, claims any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKeyService)(nil).GetApiKeys), ctx, claims)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockApiKeyService) RevokeApiKey(ctx context.Context, claims *models.Claims, apiKeyId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", ctx, claims, apiKeyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockApiKeyServiceMockRecorder) RevokeApiKey(ctx, claims, apiKeyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockApiKeyService)(nil).RevokeApiKey), ctx, claims, apiKeyId)
+}
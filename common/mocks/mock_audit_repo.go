@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: AuditRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_audit_repo.go -package=mocks -mock_names=AuditRepoInterface=MockAuditRepo gitlab.com/sandstone2/fiberpoc/common/repos AuditRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditRepo is a mock of AuditRepoInterface interface.
+type MockAuditRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditRepoMockRecorder is the mock recorder for MockAuditRepo.
+type MockAuditRepoMockRecorder struct {
+	mock *MockAuditRepo
+}
+
+// NewMockAuditRepo creates a new mock instance.
+func NewMockAuditRepo(ctrl *gomock.Controller) *MockAuditRepo {
+	mock := &MockAuditRepo{ctrl: ctrl}
+	mock.recorder = &MockAuditRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepo) EXPECT() *MockAuditRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditEvents mocks base method.
+func (m *MockAuditRepo) CreateAuditEvents(ctx context.Context, events []models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvents", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvents indicates an expected call of CreateAuditEvents.
+func (mr *MockAuditRepoMockRecorder) CreateAuditEvents(ctx, events any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvents", reflect.TypeOf((*MockAuditRepo)(nil).CreateAuditEvents), ctx, events)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockAuditRepo) GetAuditEvents(ctx context.Context, params *models.AuditListParams) ([]models.AuditEvent, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", ctx, params)
+	ret0, _ := ret[0].([]models.AuditEvent)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockAuditRepoMockRecorder) GetAuditEvents(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockAuditRepo)(nil).GetAuditEvents), ctx, params)
+}
+
+// WithTx mocks base method.
+func (m *MockAuditRepo) WithTx(tx interfaces.PgxTxInterface) repos.AuditRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.AuditRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockAuditRepoMockRecorder) WithTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockAuditRepo)(nil).WithTx), tx)
+}
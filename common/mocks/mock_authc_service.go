@@ -0,0 +1,120 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/services (interfaces: AuthcServiceInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_authc_service.go -package=mocks -mock_names=AuthcServiceInterface=MockAuthcService gitlab.com/sandstone2/fiberpoc/common/services AuthcServiceInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthcService is a mock of AuthcServiceInterface interface.
+type MockAuthcService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthcServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthcServiceMockRecorder is the mock recorder for MockAuthcService.
+type MockAuthcServiceMockRecorder struct {
+	mock *MockAuthcService
+}
+
+// NewMockAuthcService creates a new mock instance.
+func NewMockAuthcService(ctrl *gomock.Controller) *MockAuthcService {
+	mock := &MockAuthcService{ctrl: ctrl}
+	mock.recorder = &MockAuthcServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthcService) EXPECT() *MockAuthcServiceMockRecorder {
+	return m.recorder
+}
+
+// GetProviders mocks base method.
+func (m *MockAuthcService) GetProviders() []models.LoginProvider {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviders")
+	ret0, _ := ret[0].([]models.LoginProvider)
+	return ret0
+}
+
+// GetProviders indicates an expected call of GetProviders.
+func (mr *MockAuthcServiceMockRecorder) GetProviders() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviders", reflect.TypeOf((*MockAuthcService)(nil).GetProviders))
+}
+
+// ProcessOauth mocks base method.
+func (m *MockAuthcService) ProcessOauth(ctx context.Context, provider, state, code string) (*models.Claims, *models.OidcTokens, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessOauth", ctx, provider, state, code)
+	ret0, _ := ret[0].(*models.Claims)
+	ret1, _ := ret[1].(*models.OidcTokens)
+	ret2, _ := ret[2].(string)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// ProcessOauth indicates an expected call of ProcessOauth.
+func (mr *MockAuthcServiceMockRecorder) ProcessOauth(ctx, provider, state, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOauth", reflect.TypeOf((*MockAuthcService)(nil).ProcessOauth), ctx, provider, state, code)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockAuthcService) RefreshTokens(ctx context.Context, provider, refreshToken string) (*models.Claims, *models.OidcTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, provider, refreshToken)
+	ret0, _ := ret[0].(*models.Claims)
+	ret1, _ := ret[1].(*models.OidcTokens)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockAuthcServiceMockRecorder) RefreshTokens(ctx, provider, refreshToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockAuthcService)(nil).RefreshTokens), ctx, provider, refreshToken)
+}
+
+// StartLogin mocks base method.
+func (m *MockAuthcService) StartLogin(ctx context.Context, provider, returnTo string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartLogin", ctx, provider, returnTo)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartLogin indicates an expected call of StartLogin.
+func (mr *MockAuthcServiceMockRecorder) StartLogin(ctx, provider, returnTo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartLogin", reflect.TypeOf((*MockAuthcService)(nil).StartLogin), ctx, provider, returnTo)
+}
+
+// VerifyToken mocks base method.
+func (m *MockAuthcService) VerifyToken(ctx context.Context, rawToken string) (*models.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyToken", ctx, rawToken)
+	ret0, _ := ret[0].(*models.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyToken indicates an expected call of VerifyToken.
+func (mr *MockAuthcServiceMockRecorder) VerifyToken(ctx, rawToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyToken", reflect.TypeOf((*MockAuthcService)(nil).VerifyToken), ctx, rawToken)
+}
@@ -10,9 +10,12 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
 	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,62 +43,270 @@ func (m *MockFooRepo) EXPECT() *MockFooRepoMockRecorder {
 	return m.recorder
 }
 
+// CountFoos mocks base method.
+func (m *MockFooRepo) CountFoos(ctx context.Context, params *models.FooListParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFoos", ctx, params)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFoos indicates an expected call of CountFoos.
+func (mr *MockFooRepoMockRecorder) CountFoos(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFoos", reflect.TypeOf((*MockFooRepo)(nil).CountFoos), ctx, params)
+}
+
 // CreateFoo mocks base method.
-func (m *MockFooRepo) CreateFoo(name string) (*models.Foo, error) {
+func (m *MockFooRepo) CreateFoo(ctx context.Context, name string) (*models.Foo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFoo", name)
+	ret := m.ctrl.Call(m, "CreateFoo", ctx, name)
 	ret0, _ := ret[0].(*models.Foo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateFoo indicates an expected call of CreateFoo.
-func (mr *MockFooRepoMockRecorder) CreateFoo(name any) *gomock.Call {
+func (mr *MockFooRepoMockRecorder) CreateFoo(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFoo", reflect.TypeOf((*MockFooRepo)(nil).CreateFoo), ctx, name)
+}
+
+// CreateFoos mocks base method.
+func (m *MockFooRepo) CreateFoos(ctx context.Context, names []string) (*[]models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFoos", ctx, names)
+	ret0, _ := ret[0].(*[]models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFoos indicates an expected call of CreateFoos.
+func (mr *MockFooRepoMockRecorder) CreateFoos(ctx, names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFoos", reflect.TypeOf((*MockFooRepo)(nil).CreateFoos), ctx, names)
+}
+
+// DeleteFoo mocks base method.
+func (m *MockFooRepo) DeleteFoo(ctx context.Context, fooId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFoo", ctx, fooId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFoo indicates an expected call of DeleteFoo.
+func (mr *MockFooRepoMockRecorder) DeleteFoo(ctx, fooId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFoo", reflect.TypeOf((*MockFooRepo)(nil).CreateFoo), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoo", reflect.TypeOf((*MockFooRepo)(nil).DeleteFoo), ctx, fooId)
 }
 
 // DeleteFoos mocks base method.
-func (m *MockFooRepo) DeleteFoos() (int64, error) {
+func (m *MockFooRepo) DeleteFoos(ctx context.Context) ([]models.Foo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteFoos")
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "DeleteFoos", ctx)
+	ret0, _ := ret[0].([]models.Foo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteFoos indicates an expected call of DeleteFoos.
-func (mr *MockFooRepoMockRecorder) DeleteFoos() *gomock.Call {
+func (mr *MockFooRepoMockRecorder) DeleteFoos(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoos", reflect.TypeOf((*MockFooRepo)(nil).DeleteFoos), ctx)
+}
+
+// DeleteFoosByID mocks base method.
+func (m *MockFooRepo) DeleteFoosByID(ctx context.Context, fooIds []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFoosByID", ctx, fooIds)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFoosByID indicates an expected call of DeleteFoosByID.
+func (mr *MockFooRepoMockRecorder) DeleteFoosByID(ctx, fooIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoosByID", reflect.TypeOf((*MockFooRepo)(nil).DeleteFoosByID), ctx, fooIds)
+}
+
+// ExportFoos mocks base method.
+func (m *MockFooRepo) ExportFoos(ctx context.Context, includeDeleted bool, fn func(*models.Foo) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportFoos", ctx, includeDeleted, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportFoos indicates an expected call of ExportFoos.
+func (mr *MockFooRepoMockRecorder) ExportFoos(ctx, includeDeleted, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportFoos", reflect.TypeOf((*MockFooRepo)(nil).ExportFoos), ctx, includeDeleted, fn)
+}
+
+// GetFooByID mocks base method.
+func (m *MockFooRepo) GetFooByID(ctx context.Context, fooId int64) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooByID", ctx, fooId)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooByID indicates an expected call of GetFooByID.
+func (mr *MockFooRepoMockRecorder) GetFooByID(ctx, fooId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoos", reflect.TypeOf((*MockFooRepo)(nil).DeleteFoos))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooByID", reflect.TypeOf((*MockFooRepo)(nil).GetFooByID), ctx, fooId)
 }
 
 // GetFoos mocks base method.
-func (m *MockFooRepo) GetFoos() (*[]models.Foo, error) {
+func (m *MockFooRepo) GetFoos(ctx context.Context, params *models.FooListParams) (*[]models.Foo, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFoos")
+	ret := m.ctrl.Call(m, "GetFoos", ctx, params)
 	ret0, _ := ret[0].(*[]models.Foo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetFoos indicates an expected call of GetFoos.
+func (mr *MockFooRepoMockRecorder) GetFoos(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFoos", reflect.TypeOf((*MockFooRepo)(nil).GetFoos), ctx, params)
+}
+
+// GetFoosForUpdate mocks base method.
+func (m *MockFooRepo) GetFoosForUpdate(ctx context.Context, fooIds []int64) (map[int64]models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFoosForUpdate", ctx, fooIds)
+	ret0, _ := ret[0].(map[int64]models.Foo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetFoos indicates an expected call of GetFoos.
-func (mr *MockFooRepoMockRecorder) GetFoos() *gomock.Call {
+// GetFoosForUpdate indicates an expected call of GetFoosForUpdate.
+func (mr *MockFooRepoMockRecorder) GetFoosForUpdate(ctx, fooIds any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFoos", reflect.TypeOf((*MockFooRepo)(nil).GetFoos))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFoosForUpdate", reflect.TypeOf((*MockFooRepo)(nil).GetFoosForUpdate), ctx, fooIds)
+}
+
+// ImportFoos mocks base method.
+func (m *MockFooRepo) ImportFoos(ctx context.Context, names []string) ([]models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportFoos", ctx, names)
+	ret0, _ := ret[0].([]models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportFoos indicates an expected call of ImportFoos.
+func (mr *MockFooRepoMockRecorder) ImportFoos(ctx, names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportFoos", reflect.TypeOf((*MockFooRepo)(nil).ImportFoos), ctx, names)
+}
+
+// PatchFoo mocks base method.
+func (m *MockFooRepo) PatchFoo(ctx context.Context, fooId int64, patch *models.FooPatch, version int) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchFoo", ctx, fooId, patch, version)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchFoo indicates an expected call of PatchFoo.
+func (mr *MockFooRepoMockRecorder) PatchFoo(ctx, fooId, patch, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchFoo", reflect.TypeOf((*MockFooRepo)(nil).PatchFoo), ctx, fooId, patch, version)
+}
+
+// PatchFoos mocks base method.
+func (m *MockFooRepo) PatchFoos(ctx context.Context, patches []models.FooBatchPatch) ([]models.FooBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchFoos", ctx, patches)
+	ret0, _ := ret[0].([]models.FooBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchFoos indicates an expected call of PatchFoos.
+func (mr *MockFooRepoMockRecorder) PatchFoos(ctx, patches any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchFoos", reflect.TypeOf((*MockFooRepo)(nil).PatchFoos), ctx, patches)
+}
+
+// PurgeFoos mocks base method.
+func (m *MockFooRepo) PurgeFoos(ctx context.Context, deletedBefore int64) ([]models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeFoos", ctx, deletedBefore)
+	ret0, _ := ret[0].([]models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeFoos indicates an expected call of PurgeFoos.
+func (mr *MockFooRepoMockRecorder) PurgeFoos(ctx, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeFoos", reflect.TypeOf((*MockFooRepo)(nil).PurgeFoos), ctx, deletedBefore)
+}
+
+// RestoreFoo mocks base method.
+func (m *MockFooRepo) RestoreFoo(ctx context.Context, fooId int64) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreFoo", ctx, fooId)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreFoo indicates an expected call of RestoreFoo.
+func (mr *MockFooRepoMockRecorder) RestoreFoo(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreFoo", reflect.TypeOf((*MockFooRepo)(nil).RestoreFoo), ctx, fooId)
+}
+
+// SearchFoos mocks base method.
+func (m *MockFooRepo) SearchFoos(ctx context.Context, params *models.FooSearchParams) ([]models.FooSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchFoos", ctx, params)
+	ret0, _ := ret[0].([]models.FooSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchFoos indicates an expected call of SearchFoos.
+func (mr *MockFooRepoMockRecorder) SearchFoos(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchFoos", reflect.TypeOf((*MockFooRepo)(nil).SearchFoos), ctx, params)
 }
 
 // UpdateFoo mocks base method.
-func (m *MockFooRepo) UpdateFoo(fooId int64, name string) (*models.Foo, error) {
+func (m *MockFooRepo) UpdateFoo(ctx context.Context, fooId int64, name string, version int) (*models.Foo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateFoo", fooId, name)
+	ret := m.ctrl.Call(m, "UpdateFoo", ctx, fooId, name, version)
 	ret0, _ := ret[0].(*models.Foo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateFoo indicates an expected call of UpdateFoo.
-func (mr *MockFooRepoMockRecorder) UpdateFoo(fooId, name any) *gomock.Call {
+func (mr *MockFooRepoMockRecorder) UpdateFoo(ctx, fooId, name, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFoo", reflect.TypeOf((*MockFooRepo)(nil).UpdateFoo), ctx, fooId, name, version)
+}
+
+// WithTx mocks base method.
+func (m *MockFooRepo) WithTx(tx interfaces.PgxTxInterface) repos.FooRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.FooRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockFooRepoMockRecorder) WithTx(tx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFoo", reflect.TypeOf((*MockFooRepo)(nil).UpdateFoo), fooId, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockFooRepo)(nil).WithTx), tx)
 }
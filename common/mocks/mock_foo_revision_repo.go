@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: FooRevisionRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_foo_revision_repo.go -package=mocks -mock_names=FooRevisionRepoInterface=MockFooRevisionRepo gitlab.com/sandstone2/fiberpoc/common/repos FooRevisionRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFooRevisionRepo is a mock of FooRevisionRepoInterface interface.
+type MockFooRevisionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockFooRevisionRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockFooRevisionRepoMockRecorder is the mock recorder for MockFooRevisionRepo.
+type MockFooRevisionRepoMockRecorder struct {
+	mock *MockFooRevisionRepo
+}
+
+// NewMockFooRevisionRepo creates a new mock instance.
+func NewMockFooRevisionRepo(ctrl *gomock.Controller) *MockFooRevisionRepo {
+	mock := &MockFooRevisionRepo{ctrl: ctrl}
+	mock.recorder = &MockFooRevisionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFooRevisionRepo) EXPECT() *MockFooRevisionRepoMockRecorder {
+	return m.recorder
+}
+
+// GetFooRevision mocks base method.
+func (m *MockFooRevisionRepo) GetFooRevision(ctx context.Context, fooId int64, version int) (*models.FooRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooRevision", ctx, fooId, version)
+	ret0, _ := ret[0].(*models.FooRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooRevision indicates an expected call of GetFooRevision.
+func (mr *MockFooRevisionRepoMockRecorder) GetFooRevision(ctx, fooId, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooRevision", reflect.TypeOf((*MockFooRevisionRepo)(nil).GetFooRevision), ctx, fooId, version)
+}
+
+// GetFooRevisions mocks base method.
+func (m *MockFooRevisionRepo) GetFooRevisions(ctx context.Context, fooId int64) ([]models.FooRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooRevisions", ctx, fooId)
+	ret0, _ := ret[0].([]models.FooRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooRevisions indicates an expected call of GetFooRevisions.
+func (mr *MockFooRevisionRepoMockRecorder) GetFooRevisions(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooRevisions", reflect.TypeOf((*MockFooRevisionRepo)(nil).GetFooRevisions), ctx, fooId)
+}
+
+// WithTx mocks base method.
+func (m *MockFooRevisionRepo) WithTx(tx interfaces.PgxTxInterface) repos.FooRevisionRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.FooRevisionRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockFooRevisionRepoMockRecorder) WithTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockFooRevisionRepo)(nil).WithTx), tx)
+}
@@ -10,7 +10,9 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "gitlab.com/sandstone2/fiberpoc/common/models"
 	gomock "go.uber.org/mock/gomock"
@@ -41,61 +43,328 @@ func (m *MockFooService) EXPECT() *MockFooServiceMockRecorder {
 }
 
 // CreateFoo mocks base method.
-func (m *MockFooService) CreateFoo(name string) (*models.Foo, error) {
+func (m *MockFooService) CreateFoo(ctx context.Context, name string) (*models.Foo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFoo", name)
+	ret := m.ctrl.Call(m, "CreateFoo", ctx, name)
 	ret0, _ := ret[0].(*models.Foo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateFoo indicates an expected call of CreateFoo.
-func (mr *MockFooServiceMockRecorder) CreateFoo(name any) *gomock.Call {
+func (mr *MockFooServiceMockRecorder) CreateFoo(ctx, name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFoo", reflect.TypeOf((*MockFooService)(nil).CreateFoo), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFoo", reflect.TypeOf((*MockFooService)(nil).CreateFoo), ctx, name)
+}
+
+// CreateFoos mocks base method.
+func (m *MockFooService) CreateFoos(ctx context.Context, names []string) ([]models.FooBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFoos", ctx, names)
+	ret0, _ := ret[0].([]models.FooBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFoos indicates an expected call of CreateFoos.
+func (mr *MockFooServiceMockRecorder) CreateFoos(ctx, names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFoos", reflect.TypeOf((*MockFooService)(nil).CreateFoos), ctx, names)
+}
+
+// DeleteFoo mocks base method.
+func (m *MockFooService) DeleteFoo(ctx context.Context, fooId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFoo", ctx, fooId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFoo indicates an expected call of DeleteFoo.
+func (mr *MockFooServiceMockRecorder) DeleteFoo(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoo", reflect.TypeOf((*MockFooService)(nil).DeleteFoo), ctx, fooId)
 }
 
 // DeleteFoos mocks base method.
-func (m *MockFooService) DeleteFoos() (int64, error) {
+func (m *MockFooService) DeleteFoos(ctx context.Context) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteFoos")
+	ret := m.ctrl.Call(m, "DeleteFoos", ctx)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteFoos indicates an expected call of DeleteFoos.
-func (mr *MockFooServiceMockRecorder) DeleteFoos() *gomock.Call {
+func (mr *MockFooServiceMockRecorder) DeleteFoos(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoos", reflect.TypeOf((*MockFooService)(nil).DeleteFoos), ctx)
+}
+
+// DeleteFoosByID mocks base method.
+func (m *MockFooService) DeleteFoosByID(ctx context.Context, fooIds []int64) ([]models.FooBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFoosByID", ctx, fooIds)
+	ret0, _ := ret[0].([]models.FooBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFoosByID indicates an expected call of DeleteFoosByID.
+func (mr *MockFooServiceMockRecorder) DeleteFoosByID(ctx, fooIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoosByID", reflect.TypeOf((*MockFooService)(nil).DeleteFoosByID), ctx, fooIds)
+}
+
+// DiffFooRevisions mocks base method.
+func (m *MockFooService) DiffFooRevisions(ctx context.Context, fooId int64, from, to int) (*models.FooRevisionDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffFooRevisions", ctx, fooId, from, to)
+	ret0, _ := ret[0].(*models.FooRevisionDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffFooRevisions indicates an expected call of DiffFooRevisions.
+func (mr *MockFooServiceMockRecorder) DiffFooRevisions(ctx, fooId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffFooRevisions", reflect.TypeOf((*MockFooService)(nil).DiffFooRevisions), ctx, fooId, from, to)
+}
+
+// ExportFoos mocks base method.
+func (m *MockFooService) ExportFoos(ctx context.Context, includeDeleted bool, fn func(*models.Foo) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportFoos", ctx, includeDeleted, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportFoos indicates an expected call of ExportFoos.
+func (mr *MockFooServiceMockRecorder) ExportFoos(ctx, includeDeleted, fn any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFoos", reflect.TypeOf((*MockFooService)(nil).DeleteFoos))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportFoos", reflect.TypeOf((*MockFooService)(nil).ExportFoos), ctx, includeDeleted, fn)
+}
+
+// GetFooByID mocks base method.
+func (m *MockFooService) GetFooByID(ctx context.Context, fooId int64) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooByID", ctx, fooId)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooByID indicates an expected call of GetFooByID.
+func (mr *MockFooServiceMockRecorder) GetFooByID(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooByID", reflect.TypeOf((*MockFooService)(nil).GetFooByID), ctx, fooId)
+}
+
+// GetFooRevision mocks base method.
+func (m *MockFooService) GetFooRevision(ctx context.Context, fooId int64, revision int) (*models.FooRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooRevision", ctx, fooId, revision)
+	ret0, _ := ret[0].(*models.FooRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooRevision indicates an expected call of GetFooRevision.
+func (mr *MockFooServiceMockRecorder) GetFooRevision(ctx, fooId, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooRevision", reflect.TypeOf((*MockFooService)(nil).GetFooRevision), ctx, fooId, revision)
+}
+
+// GetFooRevisions mocks base method.
+func (m *MockFooService) GetFooRevisions(ctx context.Context, fooId int64) ([]models.FooRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooRevisions", ctx, fooId)
+	ret0, _ := ret[0].([]models.FooRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooRevisions indicates an expected call of GetFooRevisions.
+func (mr *MockFooServiceMockRecorder) GetFooRevisions(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooRevisions", reflect.TypeOf((*MockFooService)(nil).GetFooRevisions), ctx, fooId)
+}
+
+// GetFooShares mocks base method.
+func (m *MockFooService) GetFooShares(ctx context.Context, fooId int64) ([]models.FooShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooShares", ctx, fooId)
+	ret0, _ := ret[0].([]models.FooShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooShares indicates an expected call of GetFooShares.
+func (mr *MockFooServiceMockRecorder) GetFooShares(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooShares", reflect.TypeOf((*MockFooService)(nil).GetFooShares), ctx, fooId)
 }
 
 // GetFoos mocks base method.
-func (m *MockFooService) GetFoos() (*[]models.Foo, error) {
+func (m *MockFooService) GetFoos(ctx context.Context, params *models.FooListParams) (*models.FooPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFoos")
-	ret0, _ := ret[0].(*[]models.Foo)
+	ret := m.ctrl.Call(m, "GetFoos", ctx, params)
+	ret0, _ := ret[0].(*models.FooPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFoos indicates an expected call of GetFoos.
-func (mr *MockFooServiceMockRecorder) GetFoos() *gomock.Call {
+func (mr *MockFooServiceMockRecorder) GetFoos(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFoos", reflect.TypeOf((*MockFooService)(nil).GetFoos), ctx, params)
+}
+
+// ImportFoos mocks base method.
+func (m *MockFooService) ImportFoos(ctx context.Context, rows []models.FooImportRow, dryRun bool) (*models.FooImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportFoos", ctx, rows, dryRun)
+	ret0, _ := ret[0].(*models.FooImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportFoos indicates an expected call of ImportFoos.
+func (mr *MockFooServiceMockRecorder) ImportFoos(ctx, rows, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportFoos", reflect.TypeOf((*MockFooService)(nil).ImportFoos), ctx, rows, dryRun)
+}
+
+// PatchFoo mocks base method.
+func (m *MockFooService) PatchFoo(ctx context.Context, fooId int64, patch *models.FooPatch, version int) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchFoo", ctx, fooId, patch, version)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchFoo indicates an expected call of PatchFoo.
+func (mr *MockFooServiceMockRecorder) PatchFoo(ctx, fooId, patch, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchFoo", reflect.TypeOf((*MockFooService)(nil).PatchFoo), ctx, fooId, patch, version)
+}
+
+// PatchFoos mocks base method.
+func (m *MockFooService) PatchFoos(ctx context.Context, patches []models.FooBatchPatch) ([]models.FooBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchFoos", ctx, patches)
+	ret0, _ := ret[0].([]models.FooBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchFoos indicates an expected call of PatchFoos.
+func (mr *MockFooServiceMockRecorder) PatchFoos(ctx, patches any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchFoos", reflect.TypeOf((*MockFooService)(nil).PatchFoos), ctx, patches)
+}
+
+// PurgeFoos mocks base method.
+func (m *MockFooService) PurgeFoos(ctx context.Context, olderThan time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeFoos", ctx, olderThan)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeFoos indicates an expected call of PurgeFoos.
+func (mr *MockFooServiceMockRecorder) PurgeFoos(ctx, olderThan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeFoos", reflect.TypeOf((*MockFooService)(nil).PurgeFoos), ctx, olderThan)
+}
+
+// RestoreFoo mocks base method.
+func (m *MockFooService) RestoreFoo(ctx context.Context, fooId int64) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreFoo", ctx, fooId)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreFoo indicates an expected call of RestoreFoo.
+func (mr *MockFooServiceMockRecorder) RestoreFoo(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreFoo", reflect.TypeOf((*MockFooService)(nil).RestoreFoo), ctx, fooId)
+}
+
+// RestoreFooRevision mocks base method.
+func (m *MockFooService) RestoreFooRevision(ctx context.Context, fooId int64, revision, version int) (*models.Foo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreFooRevision", ctx, fooId, revision, version)
+	ret0, _ := ret[0].(*models.Foo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreFooRevision indicates an expected call of RestoreFooRevision.
+func (mr *MockFooServiceMockRecorder) RestoreFooRevision(ctx, fooId, revision, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreFooRevision", reflect.TypeOf((*MockFooService)(nil).RestoreFooRevision), ctx, fooId, revision, version)
+}
+
+// SearchFoos mocks base method.
+func (m *MockFooService) SearchFoos(ctx context.Context, params *models.FooSearchParams) ([]models.FooSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchFoos", ctx, params)
+	ret0, _ := ret[0].([]models.FooSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchFoos indicates an expected call of SearchFoos.
+func (mr *MockFooServiceMockRecorder) SearchFoos(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchFoos", reflect.TypeOf((*MockFooService)(nil).SearchFoos), ctx, params)
+}
+
+// ShareFoo mocks base method.
+func (m *MockFooService) ShareFoo(ctx context.Context, fooId int64, request *models.FooShareRequest) (*models.FooShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareFoo", ctx, fooId, request)
+	ret0, _ := ret[0].(*models.FooShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShareFoo indicates an expected call of ShareFoo.
+func (mr *MockFooServiceMockRecorder) ShareFoo(ctx, fooId, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareFoo", reflect.TypeOf((*MockFooService)(nil).ShareFoo), ctx, fooId, request)
+}
+
+// UnshareFoo mocks base method.
+func (m *MockFooService) UnshareFoo(ctx context.Context, fooId int64, shareId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnshareFoo", ctx, fooId, shareId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnshareFoo indicates an expected call of UnshareFoo.
+func (mr *MockFooServiceMockRecorder) UnshareFoo(ctx, fooId, shareId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFoos", reflect.TypeOf((*MockFooService)(nil).GetFoos))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnshareFoo", reflect.TypeOf((*MockFooService)(nil).UnshareFoo), ctx, fooId, shareId)
 }
 
 // UpdateFoo mocks base method.
-func (m *MockFooService) UpdateFoo(fooId int64, name string) (*models.Foo, error) {
+func (m *MockFooService) UpdateFoo(ctx context.Context, fooId int64, name string, version int) (*models.Foo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateFoo", fooId, name)
+	ret := m.ctrl.Call(m, "UpdateFoo", ctx, fooId, name, version)
 	ret0, _ := ret[0].(*models.Foo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateFoo indicates an expected call of UpdateFoo.
-func (mr *MockFooServiceMockRecorder) UpdateFoo(fooId, name any) *gomock.Call {
+func (mr *MockFooServiceMockRecorder) UpdateFoo(ctx, fooId, name, version any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFoo", reflect.TypeOf((*MockFooService)(nil).UpdateFoo), fooId, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFoo", reflect.TypeOf((*MockFooService)(nil).UpdateFoo), ctx, fooId, name, version)
 }
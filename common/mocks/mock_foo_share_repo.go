@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: FooShareRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_foo_share_repo.go -package=mocks -mock_names=FooShareRepoInterface=MockFooShareRepo gitlab.com/sandstone2/fiberpoc/common/repos FooShareRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFooShareRepo is a mock of FooShareRepoInterface interface.
+type MockFooShareRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockFooShareRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockFooShareRepoMockRecorder is the mock recorder for MockFooShareRepo.
+type MockFooShareRepoMockRecorder struct {
+	mock *MockFooShareRepo
+}
+
+// NewMockFooShareRepo creates a new mock instance.
+func NewMockFooShareRepo(ctrl *gomock.Controller) *MockFooShareRepo {
+	mock := &MockFooShareRepo{ctrl: ctrl}
+	mock.recorder = &MockFooShareRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFooShareRepo) EXPECT() *MockFooShareRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateFooShare mocks base method.
+func (m *MockFooShareRepo) CreateFooShare(ctx context.Context, fooId int64, request *models.FooShareRequest) (*models.FooShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFooShare", ctx, fooId, request)
+	ret0, _ := ret[0].(*models.FooShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFooShare indicates an expected call of CreateFooShare.
+func (mr *MockFooShareRepoMockRecorder) CreateFooShare(ctx, fooId, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFooShare", reflect.TypeOf((*MockFooShareRepo)(nil).CreateFooShare), ctx, fooId, request)
+}
+
+// DeleteFooShare mocks base method.
+func (m *MockFooShareRepo) DeleteFooShare(ctx context.Context, fooId int64, shareId int) (*models.FooShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFooShare", ctx, fooId, shareId)
+	ret0, _ := ret[0].(*models.FooShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFooShare indicates an expected call of DeleteFooShare.
+func (mr *MockFooShareRepoMockRecorder) DeleteFooShare(ctx, fooId, shareId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFooShare", reflect.TypeOf((*MockFooShareRepo)(nil).DeleteFooShare), ctx, fooId, shareId)
+}
+
+// GetFooAccess mocks base method.
+func (m *MockFooShareRepo) GetFooAccess(ctx context.Context, fooIds []int64) (map[int64]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooAccess", ctx, fooIds)
+	ret0, _ := ret[0].(map[int64]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooAccess indicates an expected call of GetFooAccess.
+func (mr *MockFooShareRepoMockRecorder) GetFooAccess(ctx, fooIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooAccess", reflect.TypeOf((*MockFooShareRepo)(nil).GetFooAccess), ctx, fooIds)
+}
+
+// GetFooShares mocks base method.
+func (m *MockFooShareRepo) GetFooShares(ctx context.Context, fooId int64) ([]models.FooShare, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFooShares", ctx, fooId)
+	ret0, _ := ret[0].([]models.FooShare)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFooShares indicates an expected call of GetFooShares.
+func (mr *MockFooShareRepoMockRecorder) GetFooShares(ctx, fooId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFooShares", reflect.TypeOf((*MockFooShareRepo)(nil).GetFooShares), ctx, fooId)
+}
+
+// WithTx mocks base method.
+func (m *MockFooShareRepo) WithTx(tx interfaces.PgxTxInterface) repos.FooShareRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.FooShareRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockFooShareRepoMockRecorder) WithTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockFooShareRepo)(nil).WithTx), tx)
+}
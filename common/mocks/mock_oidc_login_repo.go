@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: OidcLoginRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_oidc_login_repo.go -package=mocks -mock_names=OidcLoginRepoInterface=MockOidcLoginRepo gitlab.com/sandstone2/fiberpoc/common/repos OidcLoginRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOidcLoginRepo is a mock of OidcLoginRepoInterface interface.
+type MockOidcLoginRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockOidcLoginRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockOidcLoginRepoMockRecorder is the mock recorder for MockOidcLoginRepo.
+type MockOidcLoginRepoMockRecorder struct {
+	mock *MockOidcLoginRepo
+}
+
+// NewMockOidcLoginRepo creates a new mock instance.
+func NewMockOidcLoginRepo(ctrl *gomock.Controller) *MockOidcLoginRepo {
+	mock := &MockOidcLoginRepo{ctrl: ctrl}
+	mock.recorder = &MockOidcLoginRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOidcLoginRepo) EXPECT() *MockOidcLoginRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateLogin mocks base method.
+func (m *MockOidcLoginRepo) CreateLogin(ctx context.Context, stateHash []byte, login *models.OidcLogin, ttl int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLogin", ctx, stateHash, login, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLogin indicates an expected call of CreateLogin.
+func (mr *MockOidcLoginRepoMockRecorder) CreateLogin(ctx, stateHash, login, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLogin", reflect.TypeOf((*MockOidcLoginRepo)(nil).CreateLogin), ctx, stateHash, login, ttl)
+}
+
+// TakeLogin mocks base method.
+func (m *MockOidcLoginRepo) TakeLogin(ctx context.Context, stateHash []byte) (*models.OidcLogin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeLogin", ctx, stateHash)
+	ret0, _ := ret[0].(*models.OidcLogin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeLogin indicates an expected call of TakeLogin.
+func (mr *MockOidcLoginRepoMockRecorder) TakeLogin(ctx, stateHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeLogin", reflect.TypeOf((*MockOidcLoginRepo)(nil).TakeLogin), ctx, stateHash)
+}
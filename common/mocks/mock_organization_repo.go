@@ -0,0 +1,177 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: OrganizationRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_organization_repo.go -package=mocks -mock_names=OrganizationRepoInterface=MockOrganizationRepo gitlab.com/sandstone2/fiberpoc/common/repos OrganizationRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrganizationRepo is a mock of OrganizationRepoInterface interface.
+type MockOrganizationRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockOrganizationRepoMockRecorder is the mock recorder for MockOrganizationRepo.
+type MockOrganizationRepoMockRecorder struct {
+	mock *MockOrganizationRepo
+}
+
+// NewMockOrganizationRepo creates a new mock instance.
+func NewMockOrganizationRepo(ctrl *gomock.Controller) *MockOrganizationRepo {
+	mock := &MockOrganizationRepo{ctrl: ctrl}
+	mock.recorder = &MockOrganizationRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationRepo) EXPECT() *MockOrganizationRepoMockRecorder {
+	return m.recorder
+}
+
+// AddMember mocks base method.
+func (m *MockOrganizationRepo) AddMember(ctx context.Context, orgId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, orgId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockOrganizationRepoMockRecorder) AddMember(ctx, orgId, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockOrganizationRepo)(nil).AddMember), ctx, orgId, userId)
+}
+
+// AssignDefaultOrganization mocks base method.
+func (m *MockOrganizationRepo) AssignDefaultOrganization(ctx context.Context, userId int, slug string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDefaultOrganization", ctx, userId, slug)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignDefaultOrganization indicates an expected call of AssignDefaultOrganization.
+func (mr *MockOrganizationRepoMockRecorder) AssignDefaultOrganization(ctx, userId, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDefaultOrganization", reflect.TypeOf((*MockOrganizationRepo)(nil).AssignDefaultOrganization), ctx, userId, slug)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockOrganizationRepo) CreateOrganization(ctx context.Context, slug, name string) (*models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, slug, name)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockOrganizationRepoMockRecorder) CreateOrganization(ctx, slug, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockOrganizationRepo)(nil).CreateOrganization), ctx, slug, name)
+}
+
+// GetDefaultOrganization mocks base method.
+func (m *MockOrganizationRepo) GetDefaultOrganization(ctx context.Context, issuer, subject string) (*models.Organization, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultOrganization", ctx, issuer, subject)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDefaultOrganization indicates an expected call of GetDefaultOrganization.
+func (mr *MockOrganizationRepoMockRecorder) GetDefaultOrganization(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultOrganization", reflect.TypeOf((*MockOrganizationRepo)(nil).GetDefaultOrganization), ctx, issuer, subject)
+}
+
+// GetMemberOrganization mocks base method.
+func (m *MockOrganizationRepo) GetMemberOrganization(ctx context.Context, issuer, subject, slug string) (*models.Organization, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberOrganization", ctx, issuer, subject, slug)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMemberOrganization indicates an expected call of GetMemberOrganization.
+func (mr *MockOrganizationRepoMockRecorder) GetMemberOrganization(ctx, issuer, subject, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberOrganization", reflect.TypeOf((*MockOrganizationRepo)(nil).GetMemberOrganization), ctx, issuer, subject, slug)
+}
+
+// GetOrganizationBySlug mocks base method.
+func (m *MockOrganizationRepo) GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationBySlug", ctx, slug)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationBySlug indicates an expected call of GetOrganizationBySlug.
+func (mr *MockOrganizationRepoMockRecorder) GetOrganizationBySlug(ctx, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationBySlug", reflect.TypeOf((*MockOrganizationRepo)(nil).GetOrganizationBySlug), ctx, slug)
+}
+
+// GetOrganizations mocks base method.
+func (m *MockOrganizationRepo) GetOrganizations(ctx context.Context, issuer, subject string) ([]models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizations", ctx, issuer, subject)
+	ret0, _ := ret[0].([]models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizations indicates an expected call of GetOrganizations.
+func (mr *MockOrganizationRepoMockRecorder) GetOrganizations(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizations", reflect.TypeOf((*MockOrganizationRepo)(nil).GetOrganizations), ctx, issuer, subject)
+}
+
+// RemoveMember mocks base method.
+func (m *MockOrganizationRepo) RemoveMember(ctx context.Context, orgId, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, orgId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockOrganizationRepoMockRecorder) RemoveMember(ctx, orgId, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockOrganizationRepo)(nil).RemoveMember), ctx, orgId, userId)
+}
+
+// WithTx mocks base method.
+func (m *MockOrganizationRepo) WithTx(tx interfaces.PgxTxInterface) repos.OrganizationRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.OrganizationRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockOrganizationRepoMockRecorder) WithTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockOrganizationRepo)(nil).WithTx), tx)
+}
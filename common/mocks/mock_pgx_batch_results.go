@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/interfaces (interfaces: PgxBatchResultsInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_pgx_batch_results.go -package=mocks -mock_names=PgxBatchResultsInterface=MockPgxBatchResults gitlab.com/sandstone2/fiberpoc/common/interfaces PgxBatchResultsInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	pgconn "github.com/jackc/pgx/v5/pgconn"
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPgxBatchResults is a mock of PgxBatchResultsInterface interface.
+type MockPgxBatchResults struct {
+	ctrl     *gomock.Controller
+	recorder *MockPgxBatchResultsMockRecorder
+	isgomock struct{}
+}
+
+// MockPgxBatchResultsMockRecorder is the mock recorder for MockPgxBatchResults.
+type MockPgxBatchResultsMockRecorder struct {
+	mock *MockPgxBatchResults
+}
+
+// NewMockPgxBatchResults creates a new mock instance.
+func NewMockPgxBatchResults(ctrl *gomock.Controller) *MockPgxBatchResults {
+	mock := &MockPgxBatchResults{ctrl: ctrl}
+	mock.recorder = &MockPgxBatchResultsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPgxBatchResults) EXPECT() *MockPgxBatchResultsMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockPgxBatchResults) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockPgxBatchResultsMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPgxBatchResults)(nil).Close))
+}
+
+// Exec mocks base method.
+func (m *MockPgxBatchResults) Exec() (pgconn.CommandTag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exec")
+	ret0, _ := ret[0].(pgconn.CommandTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockPgxBatchResultsMockRecorder) Exec() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockPgxBatchResults)(nil).Exec))
+}
+
+// QueryRow mocks base method.
+func (m *MockPgxBatchResults) QueryRow() interfaces.PgxRowInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryRow")
+	ret0, _ := ret[0].(interfaces.PgxRowInterface)
+	return ret0
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockPgxBatchResultsMockRecorder) QueryRow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockPgxBatchResults)(nil).QueryRow))
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	pgx "github.com/jackc/pgx/v5"
 	pgconn "github.com/jackc/pgx/v5/pgconn"
 	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
 	gomock "go.uber.org/mock/gomock"
@@ -42,6 +43,36 @@ func (m *MockPgxPool) EXPECT() *MockPgxPoolMockRecorder {
 	return m.recorder
 }
 
+// Begin mocks base method.
+func (m *MockPgxPool) Begin(ctx context.Context) (interfaces.PgxTxInterface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx)
+	ret0, _ := ret[0].(interfaces.PgxTxInterface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockPgxPoolMockRecorder) Begin(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockPgxPool)(nil).Begin), ctx)
+}
+
+// BeginTx mocks base method.
+func (m *MockPgxPool) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (interfaces.PgxTxInterface, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTx", ctx, txOptions)
+	ret0, _ := ret[0].(interfaces.PgxTxInterface)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockPgxPoolMockRecorder) BeginTx(ctx, txOptions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockPgxPool)(nil).BeginTx), ctx, txOptions)
+}
+
 // Close mocks base method.
 func (m *MockPgxPool) Close() {
 	m.ctrl.T.Helper()
@@ -54,6 +85,21 @@ func (mr *MockPgxPoolMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPgxPool)(nil).Close))
 }
 
+// CopyFrom mocks base method.
+func (m *MockPgxPool) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFrom", ctx, tableName, columnNames, rowSrc)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CopyFrom indicates an expected call of CopyFrom.
+func (mr *MockPgxPoolMockRecorder) CopyFrom(ctx, tableName, columnNames, rowSrc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFrom", reflect.TypeOf((*MockPgxPool)(nil).CopyFrom), ctx, tableName, columnNames, rowSrc)
+}
+
 // Exec mocks base method.
 func (m *MockPgxPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
 	m.ctrl.T.Helper()
@@ -112,3 +158,31 @@ func (mr *MockPgxPoolMockRecorder) QueryRow(ctx, sql any, args ...any) *gomock.C
 	varargs := append([]any{ctx, sql}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockPgxPool)(nil).QueryRow), varargs...)
 }
+
+// SendBatch mocks base method.
+func (m *MockPgxPool) SendBatch(ctx context.Context, batch *pgx.Batch) interfaces.PgxBatchResultsInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendBatch", ctx, batch)
+	ret0, _ := ret[0].(interfaces.PgxBatchResultsInterface)
+	return ret0
+}
+
+// SendBatch indicates an expected call of SendBatch.
+func (mr *MockPgxPoolMockRecorder) SendBatch(ctx, batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendBatch", reflect.TypeOf((*MockPgxPool)(nil).SendBatch), ctx, batch)
+}
+
+// WithTx mocks base method.
+func (m *MockPgxPool) WithTx(ctx context.Context, fn func(interfaces.PgxTxInterface) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockPgxPoolMockRecorder) WithTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockPgxPool)(nil).WithTx), ctx, fn)
+}
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/interfaces (interfaces: PgxTxManagerInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_pgx_tx_manager.go -package=mocks -mock_names=PgxTxManagerInterface=MockPgxTxManager gitlab.com/sandstone2/fiberpoc/common/interfaces PgxTxManagerInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPgxTxManager is a mock of PgxTxManagerInterface interface.
+type MockPgxTxManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockPgxTxManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockPgxTxManagerMockRecorder is the mock recorder for MockPgxTxManager.
+type MockPgxTxManagerMockRecorder struct {
+	mock *MockPgxTxManager
+}
+
+// NewMockPgxTxManager creates a new mock instance.
+func NewMockPgxTxManager(ctrl *gomock.Controller) *MockPgxTxManager {
+	mock := &MockPgxTxManager{ctrl: ctrl}
+	mock.recorder = &MockPgxTxManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPgxTxManager) EXPECT() *MockPgxTxManagerMockRecorder {
+	return m.recorder
+}
+
+// WithTx mocks base method.
+func (m *MockPgxTxManager) WithTx(ctx context.Context, fn func(interfaces.PgxTxInterface) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockPgxTxManagerMockRecorder) WithTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockPgxTxManager)(nil).WithTx), ctx, fn)
+}
@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: RoleRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_role_repo.go -package=mocks -mock_names=RoleRepoInterface=MockRoleRepo gitlab.com/sandstone2/fiberpoc/common/repos RoleRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoleRepo is a mock of RoleRepoInterface interface.
+type MockRoleRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockRoleRepoMockRecorder is the mock recorder for MockRoleRepo.
+type MockRoleRepoMockRecorder struct {
+	mock *MockRoleRepo
+}
+
+// NewMockRoleRepo creates a new mock instance.
+func NewMockRoleRepo(ctrl *gomock.Controller) *MockRoleRepo {
+	mock := &MockRoleRepo{ctrl: ctrl}
+	mock.recorder = &MockRoleRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepo) EXPECT() *MockRoleRepoMockRecorder {
+	return m.recorder
+}
+
+// AddUserRoles mocks base method.
+func (m *MockRoleRepo) AddUserRoles(ctx context.Context, userId int, roles []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUserRoles", ctx, userId, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUserRoles indicates an expected call of AddUserRoles.
+func (mr *MockRoleRepoMockRecorder) AddUserRoles(ctx, userId, roles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserRoles", reflect.TypeOf((*MockRoleRepo)(nil).AddUserRoles), ctx, userId, roles)
+}
+
+// AssignDefaultRole mocks base method.
+func (m *MockRoleRepo) AssignDefaultRole(ctx context.Context, userId int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDefaultRole", ctx, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignDefaultRole indicates an expected call of AssignDefaultRole.
+func (mr *MockRoleRepoMockRecorder) AssignDefaultRole(ctx, userId, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDefaultRole", reflect.TypeOf((*MockRoleRepo)(nil).AssignDefaultRole), ctx, userId, role)
+}
+
+// DeleteUserRoles mocks base method.
+func (m *MockRoleRepo) DeleteUserRoles(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRoles", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserRoles indicates an expected call of DeleteUserRoles.
+func (mr *MockRoleRepoMockRecorder) DeleteUserRoles(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRoles", reflect.TypeOf((*MockRoleRepo)(nil).DeleteUserRoles), ctx, userId)
+}
+
+// GetPermissionsBySubject mocks base method.
+func (m *MockRoleRepo) GetPermissionsBySubject(ctx context.Context, issuer, subject string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPermissionsBySubject", ctx, issuer, subject)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPermissionsBySubject indicates an expected call of GetPermissionsBySubject.
+func (mr *MockRoleRepoMockRecorder) GetPermissionsBySubject(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermissionsBySubject", reflect.TypeOf((*MockRoleRepo)(nil).GetPermissionsBySubject), ctx, issuer, subject)
+}
+
+// GetRoles mocks base method.
+func (m *MockRoleRepo) GetRoles(ctx context.Context) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockRoleRepoMockRecorder) GetRoles(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockRoleRepo)(nil).GetRoles), ctx)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockRoleRepo) GetUserRoles(ctx context.Context, userId int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockRoleRepoMockRecorder) GetUserRoles(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockRoleRepo)(nil).GetUserRoles), ctx, userId)
+}
+
+// WithTx mocks base method.
+func (m *MockRoleRepo) WithTx(tx interfaces.PgxTxInterface) repos.RoleRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.RoleRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockRoleRepoMockRecorder) WithTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockRoleRepo)(nil).WithTx), tx)
+}
@@ -0,0 +1,176 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: gitlab.com/sandstone2/fiberpoc/common/repos (interfaces: SessionRepoInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_session_repo.go -package=mocks -mock_names=SessionRepoInterface=MockSessionRepo gitlab.com/sandstone2/fiberpoc/common/repos SessionRepoInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interfaces "gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	models "gitlab.com/sandstone2/fiberpoc/common/models"
+	repos "gitlab.com/sandstone2/fiberpoc/common/repos"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSessionRepo is a mock of SessionRepoInterface interface.
+type MockSessionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepoMockRecorder
+	isgomock struct{}
+}
+
+// MockSessionRepoMockRecorder is the mock recorder for MockSessionRepo.
+type MockSessionRepoMockRecorder struct {
+	mock *MockSessionRepo
+}
+
+// NewMockSessionRepo creates a new mock instance.
+func NewMockSessionRepo(ctrl *gomock.Controller) *MockSessionRepo {
+	mock := &MockSessionRepo{ctrl: ctrl}
+	mock.recorder = &MockSessionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRepo) EXPECT() *MockSessionRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateSession mocks base method.
+func (m *MockSessionRepo) CreateSession(ctx context.Context, userId int, tokenHash []byte, tokens *models.SessionTokens, userAgent, ipAddress string, idleTimeout, maxAge int64) (*models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, userId, tokenHash, tokens, userAgent, ipAddress, idleTimeout, maxAge)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionRepoMockRecorder) CreateSession(ctx, userId, tokenHash, tokens, userAgent, ipAddress, idleTimeout, maxAge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionRepo)(nil).CreateSession), ctx, userId, tokenHash, tokens, userAgent, ipAddress, idleTimeout, maxAge)
+}
+
+// GetSessionTokens mocks base method.
+func (m *MockSessionRepo) GetSessionTokens(ctx context.Context, sessionId int64) (*models.SessionTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionTokens", ctx, sessionId)
+	ret0, _ := ret[0].(*models.SessionTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionTokens indicates an expected call of GetSessionTokens.
+func (mr *MockSessionRepoMockRecorder) GetSessionTokens(ctx, sessionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionTokens", reflect.TypeOf((*MockSessionRepo)(nil).GetSessionTokens), ctx, sessionId)
+}
+
+// GetSessionTokensForUpdate mocks base method.
+func (m *MockSessionRepo) GetSessionTokensForUpdate(ctx context.Context, sessionId int64) (*models.SessionTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionTokensForUpdate", ctx, sessionId)
+	ret0, _ := ret[0].(*models.SessionTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionTokensForUpdate indicates an expected call of GetSessionTokensForUpdate.
+func (mr *MockSessionRepoMockRecorder) GetSessionTokensForUpdate(ctx, sessionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionTokensForUpdate", reflect.TypeOf((*MockSessionRepo)(nil).GetSessionTokensForUpdate), ctx, sessionId)
+}
+
+// GetSessions mocks base method.
+func (m *MockSessionRepo) GetSessions(ctx context.Context, issuer, subject string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, issuer, subject)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockSessionRepoMockRecorder) GetSessions(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockSessionRepo)(nil).GetSessions), ctx, issuer, subject)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionRepo) RevokeSession(ctx context.Context, issuer, subject string, sessionId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, issuer, subject, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionRepoMockRecorder) RevokeSession(ctx, issuer, subject, sessionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionRepo)(nil).RevokeSession), ctx, issuer, subject, sessionId)
+}
+
+// RevokeSessionByToken mocks base method.
+func (m *MockSessionRepo) RevokeSessionByToken(ctx context.Context, tokenHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSessionByToken", ctx, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSessionByToken indicates an expected call of RevokeSessionByToken.
+func (mr *MockSessionRepoMockRecorder) RevokeSessionByToken(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSessionByToken", reflect.TypeOf((*MockSessionRepo)(nil).RevokeSessionByToken), ctx, tokenHash)
+}
+
+// TouchSession mocks base method.
+func (m *MockSessionRepo) TouchSession(ctx context.Context, tokenHash []byte, idleTimeout int64) (*models.Session, *models.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, tokenHash, idleTimeout)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(*models.Claims)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockSessionRepoMockRecorder) TouchSession(ctx, tokenHash, idleTimeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockSessionRepo)(nil).TouchSession), ctx, tokenHash, idleTimeout)
+}
+
+// UpdateSessionTokens mocks base method.
+func (m *MockSessionRepo) UpdateSessionTokens(ctx context.Context, sessionId int64, tokens *models.SessionTokens) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSessionTokens", ctx, sessionId, tokens)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSessionTokens indicates an expected call of UpdateSessionTokens.
+func (mr *MockSessionRepoMockRecorder) UpdateSessionTokens(ctx, sessionId, tokens any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSessionTokens", reflect.TypeOf((*MockSessionRepo)(nil).UpdateSessionTokens), ctx, sessionId, tokens)
+}
+
+// WithTx mocks base method.
+func (m *MockSessionRepo) WithTx(tx interfaces.PgxTxInterface) repos.SessionRepoInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", tx)
+	ret0, _ := ret[0].(repos.SessionRepoInterface)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockSessionRepoMockRecorder) WithTx(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockSessionRepo)(nil).WithTx), tx)
+}
@@ -0,0 +1,33 @@
+package models
+
+// ApiKeyPrefix starts every API key, so keys are easy to spot in code and logs.
+const ApiKeyPrefix = "fpk_"
+
+// ApiKey is a personal API key that machine clients send as "Authorization: ApiKey <key>" to act as the user who
+// created it. The key itself is only shown once, when it is created, Prefix is its start to tell keys apart.
+// Scopes limit the key to those of its user's permissions, a key without scopes has all of them. Timestamps are
+// epoch milliseconds, ExpiresAt is 0 for keys that do not expire.
+type ApiKey struct {
+	ID         int64    `json:"id"`
+	UserID     int      `json:"user_id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	LastUsedAt int64    `json:"last_used_at"`
+	ExpiresAt  int64    `json:"expires_at"`
+	RevokedAt  int64    `json:"revoked_at"`
+}
+
+// ApiKeyRequest is the body of POST /api-keys. The name fits the VARCHAR(100) column.
+type ApiKeyRequest struct {
+	Name      string   `json:"name" validate:"trim,required,max=100,charset=printable"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt int64    `json:"expires_at"`
+}
+
+// NewApiKey is a created API key with the key, the only time the key is returned.
+type NewApiKey struct {
+	ApiKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+// The entities and actions of the audit log.
+const (
+	AuditEntityFoo = "foo"
+
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionShare   = "share"
+	AuditActionUnshare = "unshare"
+
+	DefaultAuditPageLimit = 50
+	MaxAuditPageLimit     = 200
+)
+
+// AuditEvent is one change in the audit log. Before and After are the entity as JSON before and after the change,
+// null when it did not exist yet or any more. ActorID is nil once the user is deleted, ActorSub and ActorEmail stay.
+// CreatedAt is in epoch milliseconds.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	ActorID    *int            `json:"actor_id"`
+	ActorSub   string          `json:"actor_sub"`
+	ActorEmail string          `json:"actor_email"`
+	RequestID  string          `json:"request_id"`
+	Entity     string          `json:"entity"`
+	EntityID   int64           `json:"entity_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  int64           `json:"created_at"`
+}
+
+// AuditListParams are the filters and paging of GET /audit. Actor matches the subject or the email of the actor.
+// From and To are epoch milliseconds, From is inclusive and To exclusive. Events are listed newest first and Cursor
+// continues after the last event of the previous page.
+type AuditListParams struct {
+	Actor    string `query:"actor"`
+	Entity   string `query:"entity"`
+	EntityID int64  `query:"entity_id"`
+	Action   string `query:"action"`
+	From     int64  `query:"from"`
+	To       int64  `query:"to"`
+	Limit    int    `query:"limit"`
+	Cursor   string `query:"cursor"`
+}
+
+// AuditPage is one page of audit events.
+type AuditPage struct {
+	Items      []AuditEvent `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func (params *AuditListParams) ApplyDefaults() {
+	if params.Limit == 0 {
+		params.Limit = DefaultAuditPageLimit
+	}
+}
+
+func (params *AuditListParams) Validate() error {
+	if params.Limit < 0 || params.Limit > MaxAuditPageLimit {
+		return apperrors.BadRequest("U8HS6P", fmt.Sprintf("Limit must be between 1 and %d.", MaxAuditPageLimit))
+	}
+	if params.From < 0 || params.To < 0 {
+		return apperrors.BadRequest("0WTJJX", "From and to can not be negative.")
+	}
+	if params.From > 0 && params.To > 0 && params.To <= params.From {
+		return apperrors.BadRequest("S34C8D", "To must be after from.")
+	}
+	if params.Cursor != "" {
+		if _, err := DecodeAuditCursor(params.Cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeAuditCursor makes the cursor of the page after the event with the id.
+func EncodeAuditCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// DecodeAuditCursor returns the id of the event a page continues after.
+func DecodeAuditCursor(encoded string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, apperrors.BadRequest("FPXPI4", "The cursor is not valid.").WithCause(err)
+	}
+
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, apperrors.BadRequest("043P81", "The cursor is not valid.").WithCause(err)
+	}
+
+	return id, nil
+}
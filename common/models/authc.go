@@ -1,7 +1,40 @@
 package models
 
+// Claims are the claims of an ID token the app uses. They are mapped with the claim names configured for the
+// provider that issued the token, Issuer and Sub together identify the user.
 type Claims struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
-	Sub   string `json:"sub"`
+	Issuer string `json:"iss"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+	Sub    string `json:"sub"`
+}
+
+// LoginProvider is an OIDC provider as the login page lists it.
+type LoginProvider struct {
+	Name        string
+	DisplayName string
+}
+
+// OidcLogin is a login in progress, stored from the redirect to the provider until its callback.
+type OidcLogin struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	// ReturnTo is where the user goes after the login, empty for the home page.
+	ReturnTo string
+}
+
+// OidcTokens are the tokens a provider issued for a login or a renewal. IDTokenExpiresAt is in epoch milliseconds
+// and RefreshToken is empty when the provider issued none.
+type OidcTokens struct {
+	Provider         string
+	IDToken          string
+	IDTokenExpiresAt int64
+	RefreshToken     string
+}
+
+// IDTokenResponse is the body of POST /token/refresh, an ID token to send as a Bearer token until ExpiresAt.
+type IDTokenResponse struct {
+	IDToken   string `json:"id_token"`
+	ExpiresAt int64  `json:"expires_at"`
 }
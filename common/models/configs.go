@@ -1,6 +1,10 @@
 package models
 
-import "go.uber.org/zap/zapcore"
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
 
 var GlobalConfig Config
 
@@ -8,20 +12,61 @@ type Config interface {
 	GetLogLevel() *zapcore.Level
 	GetLogToFile() *bool
 	GetPostgresUrl() *string
-	GetGoogleOidcClientId() *string
-	GetGoogleOidcClientSecret() *string
-	GetGoogleOidcProviderUrl() *string
-	GetRedirectUri() *string
+	GetOidcProviders() []OidcProviderConfig
+	GetFooPurgeRetention() *time.Duration
+	GetAllowDeleteAllFoos() *bool
+	GetDbRequestTimeout() *time.Duration
+	GetDefaultRole() *string
+	GetSessionIdleTimeout() *time.Duration
+	GetSessionMaxAge() *time.Duration
+	GetSessionCookieSecure() *bool
+	GetReturnToAllowedOrigins() []string
+	GetDefaultOrg() *string
+	GetTenantBaseDomain() *string
+	GetTenantRls() *bool
+	GetTokenEncryptionKey() *string
 }
 
 type AppConfig struct {
 	PostgresUrl            string        `env:"POSTGRESQL_URL,required"`
 	LogLevel               zapcore.Level `env:"LOG_LEVEL" envDefault:"debug"`
 	LogToFile              bool          `env:"LOG_TO_FILE" envDefault:"false"`
-	GoogleOidcClientId     string        `env:"GOOGLE_OIDC_CLIENT_ID,required"`
-	GoogleOidcClientSecret string        `env:"GOOGLE_OIDC_CLIENT_SECRET,required"`
-	GoogleOidcProviderUrl  string        `env:"GOOGLE_OIDC_PROVIDER_URL,required"`
-	RedirectUri            string        `env:"REDIRECT_URI,required"`
+	OidcProviderNames      []string      `env:"OIDC_PROVIDERS" envDefault:"google"`
+	RedirectUri            string        `env:"REDIRECT_URI"`
+	FooPurgeRetention      time.Duration `env:"FOO_PURGE_RETENTION" envDefault:"720h"`
+	AllowDeleteAllFoos     bool          `env:"ALLOW_DELETE_ALL_FOOS" envDefault:"false"`
+	DbRequestTimeout       time.Duration `env:"DB_REQUEST_TIMEOUT" envDefault:"10s"`
+	DefaultRole            string        `env:"DEFAULT_ROLE" envDefault:"viewer"`
+	SessionIdleTimeout     time.Duration `env:"SESSION_IDLE_TIMEOUT" envDefault:"24h"`
+	SessionMaxAge          time.Duration `env:"SESSION_MAX_AGE" envDefault:"720h"`
+	SessionCookieSecure    bool          `env:"SESSION_COOKIE_SECURE" envDefault:"false"`
+	ReturnToAllowedOrigins []string      `env:"RETURN_TO_ALLOWED_ORIGINS"`
+	DefaultOrg             string        `env:"DEFAULT_ORG" envDefault:"default"`
+	TenantBaseDomain       string        `env:"TENANT_BASE_DOMAIN"`
+	TenantRls              bool          `env:"TENANT_RLS" envDefault:"false"`
+	TokenEncryptionKey     string        `env:"TOKEN_ENCRYPTION_KEY"`
+
+	// OidcProviders are parsed from the OIDC_<NAME>_ env vars of each name in OidcProviderNames.
+	OidcProviders []OidcProviderConfig
+}
+
+// OidcProviderConfig is an OpenID Connect provider users can log in with, like Google, Keycloak, Okta, Azure AD
+// or Dex. Its env vars are prefixed with OIDC_<NAME>_, so OIDC_GOOGLE_ISSUER is the issuer of the provider named
+// google. The claim settings name the ID token claims that hold the subject, email and name of the user. A dot
+// reaches into nested claims, like "profile.email". OfflineAccess asks the provider for a refresh token, which
+// renews the ID token of a session after it expires.
+type OidcProviderConfig struct {
+	Name          string
+	DisplayName   string   `env:"DISPLAY_NAME"`
+	Issuer        string   `env:"ISSUER,required"`
+	ClientId      string   `env:"CLIENT_ID,required"`
+	ClientSecret  string   `env:"CLIENT_SECRET"`
+	Scopes        []string `env:"SCOPES" envDefault:"openid,email,profile"`
+	RedirectUri   string   `env:"REDIRECT_URI"`
+	SubjectClaim  string   `env:"SUBJECT_CLAIM" envDefault:"sub"`
+	EmailClaim    string   `env:"EMAIL_CLAIM" envDefault:"email"`
+	NameClaim     string   `env:"NAME_CLAIM" envDefault:"name"`
+	OfflineAccess bool     `env:"OFFLINE_ACCESS" envDefault:"false"`
 }
 
 func (appConfig *AppConfig) GetPostgresUrl() *string {
@@ -36,18 +81,54 @@ func (appConfig *AppConfig) GetLogToFile() *bool {
 	return &appConfig.LogToFile
 }
 
-func (appConfig *AppConfig) GetGoogleOidcClientId() *string {
-	return &appConfig.GoogleOidcClientId
+func (appConfig *AppConfig) GetOidcProviders() []OidcProviderConfig {
+	return appConfig.OidcProviders
+}
+
+func (appConfig *AppConfig) GetFooPurgeRetention() *time.Duration {
+	return &appConfig.FooPurgeRetention
+}
+
+func (appConfig *AppConfig) GetAllowDeleteAllFoos() *bool {
+	return &appConfig.AllowDeleteAllFoos
+}
+
+func (appConfig *AppConfig) GetDbRequestTimeout() *time.Duration {
+	return &appConfig.DbRequestTimeout
+}
+
+func (appConfig *AppConfig) GetDefaultRole() *string {
+	return &appConfig.DefaultRole
+}
+
+func (appConfig *AppConfig) GetSessionIdleTimeout() *time.Duration {
+	return &appConfig.SessionIdleTimeout
+}
+
+func (appConfig *AppConfig) GetSessionMaxAge() *time.Duration {
+	return &appConfig.SessionMaxAge
+}
+
+func (appConfig *AppConfig) GetSessionCookieSecure() *bool {
+	return &appConfig.SessionCookieSecure
+}
+
+func (appConfig *AppConfig) GetReturnToAllowedOrigins() []string {
+	return appConfig.ReturnToAllowedOrigins
+}
+
+func (appConfig *AppConfig) GetDefaultOrg() *string {
+	return &appConfig.DefaultOrg
 }
 
-func (appConfig *AppConfig) GetGoogleOidcClientSecret() *string {
-	return &appConfig.GoogleOidcClientSecret
+func (appConfig *AppConfig) GetTenantBaseDomain() *string {
+	return &appConfig.TenantBaseDomain
 }
 
-func (appConfig *AppConfig) GetGoogleOidcProviderUrl() *string {
-	return &appConfig.GoogleOidcProviderUrl
+func (appConfig *AppConfig) GetTenantRls() *bool {
+	return &appConfig.TenantRls
 }
 
-func (appConfig *AppConfig) GetRedirectUri() *string {
-	return &appConfig.RedirectUri
+func (appConfig *AppConfig) GetTokenEncryptionKey() *string {
+	return &appConfig.TokenEncryptionKey
 }
@@ -1,6 +1,274 @@
 package models
 
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+// Foo timestamps are epoch milliseconds. DeletedAt is 0 unless the foo is soft deleted.
+// Version starts at 1 and goes up by one on every update. It is used as the foo's ETag.
+// OwnerID is the user who created the foo, nil for foos from before owners.
 type Foo struct {
-	ID   int
-	Name string
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Version   int    `json:"version"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+	DeletedAt int64  `json:"deleted_at"`
+	OwnerID   *int   `json:"owner_id"`
+}
+
+const (
+	DefaultFooPageLimit = 50
+	MaxFooPageLimit     = 200
+
+	FooSortByID        = "id"
+	FooSortByName      = "name"
+	FooSortByCreatedAt = "created_at"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+
+	MaxFooBatchSize = 100
+)
+
+// FooListParams are the paging, sorting and filtering options for listing foos.
+// Use either Offset or Cursor (keyset) paging, not both.
+type FooListParams struct {
+	Limit        int    `query:"limit"`
+	Offset       int    `query:"offset"`
+	Cursor       string `query:"cursor"`
+	SortBy       string `query:"sort"`
+	Order        string `query:"order"`
+	NamePrefix   string `query:"name_prefix"`
+	NameContains string `query:"name_contains"`
+	IncludeTotal bool   `query:"include_total"`
+	// IncludeDeleted also lists soft deleted foos.
+	IncludeDeleted bool `query:"include_deleted"`
+}
+
+// FooPage is one page of foos.
+type FooPage struct {
+	Items      []Foo  `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// FooCursor is the position of the last foo on a page. It is handed to clients as an opaque string.
+type FooCursor struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v"`
+	ID     int    `json:"id"`
+}
+
+func (params *FooListParams) ApplyDefaults() {
+	if params.Limit == 0 {
+		params.Limit = DefaultFooPageLimit
+	}
+	if params.SortBy == "" {
+		params.SortBy = FooSortByID
+	}
+	if params.Order == "" {
+		params.Order = SortOrderAsc
+	}
+}
+
+func (params *FooListParams) Validate() error {
+	if params.Limit < 0 || params.Limit > MaxFooPageLimit {
+		return apperrors.BadRequest("DTSIYX", fmt.Sprintf("Limit must be between 1 and %d.", MaxFooPageLimit))
+	}
+	if params.Offset < 0 {
+		return apperrors.BadRequest("PCXD3A", "Offset can not be negative.")
+	}
+	if params.Offset > 0 && params.Cursor != "" {
+		return apperrors.BadRequest("2ZS0JV", "Use either offset or cursor, not both.")
+	}
+
+	switch params.SortBy {
+	case "", FooSortByID, FooSortByName, FooSortByCreatedAt:
+	default:
+		return apperrors.BadRequest("T7STSM", fmt.Sprintf("Can not sort by %q.", params.SortBy))
+	}
+
+	switch strings.ToLower(params.Order) {
+	case "", SortOrderAsc, SortOrderDesc:
+	default:
+		return apperrors.BadRequest("3V6BXC", fmt.Sprintf("Order must be %q or %q.", SortOrderAsc, SortOrderDesc))
+	}
+	params.Order = strings.ToLower(params.Order)
+
+	if params.Cursor != "" {
+		cursor, err := DecodeFooCursor(params.Cursor)
+		if err != nil {
+			return err
+		}
+		sortBy, order := params.SortBy, params.Order
+		if sortBy == "" {
+			sortBy = FooSortByID
+		}
+		if order == "" {
+			order = SortOrderAsc
+		}
+		if cursor.SortBy != sortBy || cursor.Order != order {
+			return apperrors.BadRequest("IVP76R", "Cursor does not match the requested sort.")
+		}
+	}
+
+	return nil
+}
+
+func EncodeFooCursor(cursor *FooCursor) string {
+	// Marshalling a struct of strings and ints can not fail.
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeFooCursor(encoded string) (*FooCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, apperrors.BadRequest("2OSECA", "The cursor is not valid.").WithCause(err)
+	}
+
+	cursor := &FooCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, apperrors.BadRequest("Y7DST9", "The cursor is not valid.").WithCause(err)
+	}
+
+	return cursor, nil
+}
+
+// FooRequest is the body of POST /foos and PUT /foos/:id. The name fits the VARCHAR(50) column.
+type FooRequest struct {
+	Name string `json:"name" validate:"trim,required,max=50,charset=printable"`
+}
+
+// FooPatch is a partial update of a foo. Fields left nil are not changed. The rules are the ones of FooRequest.
+type FooPatch struct {
+	Name *string `json:"name" validate:"trim,required,max=50,charset=printable"`
+}
+
+func (patch *FooPatch) IsEmpty() bool {
+	return patch.Name == nil
+}
+
+// FooBatchPatch is one item of a batch update. Version is the version of the foo the client last saw. Patch is read
+// as a merge patch, see ParseFooMergePatch. PatchErr is why it could not be, which fails only this item.
+type FooBatchPatch struct {
+	ID       int64     `json:"id"`
+	Version  int       `json:"version"`
+	Patch    *FooPatch `json:"patch"`
+	PatchErr error     `json:"-"`
+}
+
+func (batchPatch *FooBatchPatch) UnmarshalJSON(data []byte) error {
+	item := struct {
+		ID      int64           `json:"id"`
+		Version int             `json:"version"`
+		Patch   json.RawMessage `json:"patch"`
+	}{}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+
+	*batchPatch = FooBatchPatch{ID: item.ID, Version: item.Version}
+	if len(item.Patch) > 0 && string(item.Patch) != "null" {
+		batchPatch.Patch, batchPatch.PatchErr = ParseFooMergePatch(item.Patch)
+	}
+	return nil
+}
+
+// FooBatchItem is the outcome of one item of a batch operation, in the order the items were given.
+// Err is nil when the item succeeded.
+type FooBatchItem struct {
+	ID  int64
+	Foo *Foo
+	Err error
+}
+
+// FooPatchOperation is one RFC 6902 JSON Patch operation.
+type FooPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParseFooMergePatch parses an RFC 7386 JSON Merge Patch of a foo. A null member removes the field.
+func ParseFooMergePatch(data []byte) (*FooPatch, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, apperrors.BadRequest("SEZ5G6", "A merge patch must be a JSON object.")
+	}
+
+	patch := &FooPatch{}
+	for field, value := range fields {
+		if err := patch.setField(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return patch, nil
+}
+
+// ParseFooJSONPatch parses an RFC 6902 JSON Patch of a foo. Foos are flat so only add, replace and remove
+// of top level fields are supported.
+func ParseFooJSONPatch(data []byte) (*FooPatch, error) {
+	operations := []FooPatchOperation{}
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, apperrors.BadRequest("NBERWY", "A JSON patch must be an array of operations.").WithCause(err)
+	}
+
+	patch := &FooPatch{}
+	for i, operation := range operations {
+		field, found := strings.CutPrefix(operation.Path, "/")
+		if !found || strings.Contains(field, "/") {
+			return nil, apperrors.BadRequest("WBSVTD", fmt.Sprintf("Operation %d: %q is not the path of a foo field.", i, operation.Path))
+		}
+		field = strings.NewReplacer("~1", "/", "~0", "~").Replace(field)
+
+		var err error
+		switch operation.Op {
+		case "add", "replace":
+			if operation.Value == nil {
+				return nil, apperrors.BadRequest("PP02Z3", fmt.Sprintf("Operation %d: %s needs a value.", i, operation.Op))
+			}
+			err = patch.setField(field, operation.Value)
+		case "remove":
+			err = patch.setField(field, json.RawMessage("null"))
+		default:
+			return nil, apperrors.BadRequest("H2OW34", fmt.Sprintf("Operation %d: op %q is not supported.", i, operation.Op))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error: 1NYRUU - Applying operation %d.", i)
+		}
+	}
+
+	return patch, nil
+}
+
+// setField sets one field of the patch from its JSON value. A null value removes the field.
+func (patch *FooPatch) setField(field string, value json.RawMessage) error {
+	switch field {
+	case "name":
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			return apperrors.BadRequest("HE7L62", "The name of a foo can not be removed.")
+		}
+		name := ""
+		if err := json.Unmarshal(value, &name); err != nil {
+			return apperrors.BadRequest("UVO64U", "The name of a foo must be a string.").WithCause(err)
+		}
+		patch.Name = &name
+	case "id", "version", "created_at", "updated_at", "deleted_at", "owner_id":
+		return apperrors.BadRequest("7D6E0S", fmt.Sprintf("The %s of a foo can not be changed.", field))
+	default:
+		return apperrors.BadRequest("XF67T7", fmt.Sprintf("Foos have no %s field.", field))
+	}
+
+	return nil
 }
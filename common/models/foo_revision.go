@@ -0,0 +1,37 @@
+package models
+
+// FooRevision is a foo as it was written at one version. Version is the foo version the revision was written at,
+// which is also the :rev of the revision endpoints. CreatedBy is the user who wrote it, nil when they were deleted
+// since. CreatedAt is epoch milliseconds.
+type FooRevision struct {
+	FooID     int64  `json:"foo_id"`
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	CreatedBy *int   `json:"created_by"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// FooRevisionDiff is what changed in a foo from one revision to another. Changes has a change for every field
+// that differs, it is empty when the revisions are the same.
+type FooRevisionDiff struct {
+	FooID   int64            `json:"foo_id"`
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Changes []FooFieldChange `json:"changes"`
+}
+
+// FooFieldChange is the value of a foo field in the From and To revisions of a FooRevisionDiff.
+type FooFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// DiffFooRevisions is the diff from revision from to revision to of the same foo.
+func DiffFooRevisions(from *FooRevision, to *FooRevision) *FooRevisionDiff {
+	diff := &FooRevisionDiff{FooID: to.FooID, From: from.Version, To: to.Version, Changes: []FooFieldChange{}}
+	if from.Name != to.Name {
+		diff.Changes = append(diff.Changes, FooFieldChange{Field: "name", From: from.Name, To: to.Name})
+	}
+	return diff
+}
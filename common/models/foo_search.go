@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+const (
+	DefaultFooSearchLimit = 20
+	MaxFooSearchLimit     = 100
+	MaxFooSearchLength    = 200
+)
+
+// FooSearchParams are the query params of GET /foos/search. Query is free text, quoted phrases, "or" and -word
+// work like in web search engines.
+type FooSearchParams struct {
+	Query string `query:"q"`
+	Limit int    `query:"limit"`
+}
+
+// FooSearchResult is a foo found by a search. Rank is how well it matches, higher is better. Snippet is the HTML
+// escaped name with the matched words between <b> and </b>, it has no <b> when only a misspelling matched.
+type FooSearchResult struct {
+	Foo     Foo     `json:"foo"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (params *FooSearchParams) ApplyDefaults() {
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Limit == 0 {
+		params.Limit = DefaultFooSearchLimit
+	}
+}
+
+func (params *FooSearchParams) Validate() error {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return apperrors.BadRequest("MKNBCH", "The search query q is required.")
+	}
+	if len(query) > MaxFooSearchLength {
+		return apperrors.BadRequest("HS96T7", fmt.Sprintf("The search query can have at most %d characters.", MaxFooSearchLength))
+	}
+	if params.Limit < 0 || params.Limit > MaxFooSearchLimit {
+		return apperrors.BadRequest("HDSQQ9", fmt.Sprintf("Limit must be between 1 and %d.", MaxFooSearchLimit))
+	}
+	return nil
+}
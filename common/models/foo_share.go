@@ -0,0 +1,28 @@
+package models
+
+// The access a user has to a foo, from least to most. The owner has every access, the others get read or write
+// through a share. Foos without an owner give write access to every member of their organization.
+const (
+	FooAccessRead  = "read"
+	FooAccessWrite = "write"
+	FooAccessOwner = "owner"
+)
+
+// FooShare gives a user, or a group, which is every user with a role, read or write access to a foo. Exactly one
+// of UserID and Role is set. CreatedAt is epoch milliseconds.
+type FooShare struct {
+	ID         int     `json:"id"`
+	FooID      int64   `json:"foo_id"`
+	UserID     *int    `json:"user_id,omitempty"`
+	Role       *string `json:"role,omitempty"`
+	Permission string  `json:"permission"`
+	CreatedAt  int64   `json:"created_at"`
+}
+
+// FooShareRequest is the body of POST /foos/:id/shares. It has either the id of a member of the organization or the
+// name of a role, and the permission, read or write.
+type FooShareRequest struct {
+	UserID     *int    `json:"user_id"`
+	Role       *string `json:"role" validate:"trim,required,max=50"`
+	Permission string  `json:"permission" validate:"trim,required"`
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+// The formats foos are exported and imported in, and their media types.
+const (
+	FooFormatCSV    = "csv"
+	FooFormatNDJSON = "ndjson"
+
+	MIMETextCSV           = "text/csv"
+	MIMEApplicationNDJSON = "application/x-ndjson"
+
+	// FooExportBatchSize is how many foos an export fetches from the database at a time.
+	FooExportBatchSize = 500
+
+	MaxFooImportRows = 10000
+)
+
+// FooFormatMIMETypes maps the foo formats to their media types.
+var FooFormatMIMETypes = map[string]string{
+	FooFormatCSV:    MIMETextCSV,
+	FooFormatNDJSON: MIMEApplicationNDJSON,
+}
+
+// FooCSVHeader is the header row of a CSV export, the columns of Foo.CSVRecord.
+var FooCSVHeader = []string{"id", "name", "version", "created_at", "updated_at", "deleted_at", "owner_id"}
+
+// CSVRecord is the foo as a CSV row under FooCSVHeader. The owner_id of a foo without an owner is empty.
+func (foo *Foo) CSVRecord() []string {
+	ownerId := ""
+	if foo.OwnerID != nil {
+		ownerId = strconv.Itoa(*foo.OwnerID)
+	}
+	return []string{
+		strconv.Itoa(foo.ID),
+		foo.Name,
+		strconv.Itoa(foo.Version),
+		strconv.FormatInt(foo.CreatedAt, 10),
+		strconv.FormatInt(foo.UpdatedAt, 10),
+		strconv.FormatInt(foo.DeletedAt, 10),
+		ownerId,
+	}
+}
+
+// FooImportRow is a foo to import from line Line of the body. Err is set when the line could not be read at all.
+type FooImportRow struct {
+	Line int
+	Name string
+	Err  *apperrors.FieldError
+}
+
+// FooImportError is what is wrong with the foo on line Line of an import.
+type FooImportError struct {
+	Line   int                    `json:"line"`
+	Errors []apperrors.FieldError `json:"errors"`
+}
+
+// FooImportReport is the outcome of an import. Either every row is imported or, when one of them has Errors, none
+// are. A dry run checks the rows and the inserts the same way but imports nothing, Imported is what it would have
+// imported.
+type FooImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Errors   []FooImportError `json:"errors"`
+}
+
+// ParseFooImport reads the foos to import from body in format, one of the foo formats. Only the name of each foo is
+// imported, the other columns or fields, like the ones of an export, are ignored. A CSV body starts with a header row
+// that has a name column. An NDJSON body has a JSON object per line, blank lines are skipped.
+func ParseFooImport(format string, body io.Reader) (rows []FooImportRow, err error) {
+	switch format {
+	case FooFormatCSV:
+		return parseFooImportCSV(body)
+	case FooFormatNDJSON:
+		return parseFooImportNDJSON(body)
+	default:
+		return nil, apperrors.BadRequest("I368DK", fmt.Sprintf("Foos can only be imported as %s or %s.", FooFormatCSV, FooFormatNDJSON))
+	}
+}
+
+func parseFooImportCSV(body io.Reader) (rows []FooImportRow, err error) {
+	reader := csv.NewReader(body)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, apperrors.BadRequest("NJTMNF", "The CSV has no header row.")
+	}
+	if err != nil {
+		return nil, apperrors.BadRequest("4MOTUJ", "The CSV header row can not be read.").WithCause(err)
+	}
+
+	nameColumn := -1
+	for i, column := range header {
+		// Spreadsheets like to start UTF-8 files with a byte order mark.
+		column = strings.TrimPrefix(column, "\ufeff")
+		if strings.EqualFold(strings.TrimSpace(column), "name") {
+			nameColumn = i
+			break
+		}
+	}
+	if nameColumn == -1 {
+		return nil, apperrors.BadRequest("8A1Q1B", "The CSV header row has no name column.")
+	}
+
+	rows = []FooImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) && errors.Is(err, csv.ErrFieldCount) {
+			rows = append(rows, FooImportRow{
+				Line: parseError.StartLine,
+				Err:  &apperrors.FieldError{Code: "columns", Message: fmt.Sprintf("The row needs %d columns like the header row.", len(header))},
+			})
+			continue
+		}
+		if err != nil {
+			// Broken quoting throws the reader off for the rest of the body.
+			return nil, apperrors.BadRequest("VJD5AL", "The CSV can not be read.").WithCause(err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, FooImportRow{Line: line, Name: record[nameColumn]})
+	}
+
+	return rows, nil
+}
+
+func parseFooImportNDJSON(body io.Reader) (rows []FooImportRow, err error) {
+	scanner := bufio.NewScanner(body)
+
+	rows = []FooImportRow{}
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		object := struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			rows = append(rows, FooImportRow{Line: line, Err: &apperrors.FieldError{Code: "json", Message: "The line is not a JSON object with a string name."}})
+			continue
+		}
+		rows = append(rows, FooImportRow{Line: line, Name: object.Name})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, apperrors.BadRequest("VZXAUH", "The NDJSON can not be read.").WithCause(err)
+	}
+
+	return rows, nil
+}
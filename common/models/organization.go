@@ -0,0 +1,19 @@
+package models
+
+// OrgHeader is the request header that picks the organization of a request by its slug.
+const OrgHeader = "X-Org"
+
+// Organization is a tenant. Every foo belongs to one, and users work with the foos of the organizations they are
+// members of. The slug names it in the X-Org header and in subdomains. CreatedAt is epoch milliseconds.
+type Organization struct {
+	ID        int    `json:"id"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// OrganizationRequest is the body of POST /orgs. They fit the VARCHAR(50) and VARCHAR(100) columns.
+type OrganizationRequest struct {
+	Slug string `json:"slug" validate:"trim,required,max=50,charset=slug"`
+	Name string `json:"name" validate:"trim,required,max=100,charset=printable"`
+}
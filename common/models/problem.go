@@ -0,0 +1,17 @@
+package models
+
+import "gitlab.com/sandstone2/fiberpoc/common/apperrors"
+
+// Problem is an RFC 7807 problem details body, the body of every error response.
+// Code is the short error code and RequestID the X-Request-ID of the request, to find it in the logs.
+// Validation problems list what is wrong with each field in Errors.
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Errors    []apperrors.FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// The permissions routes can require. They match the rows of the permissions table.
+const (
+	PermissionFoosRead        = "foos:read"
+	PermissionFoosReadDeleted = "foos:read_deleted"
+	PermissionFoosWrite       = "foos:write"
+	PermissionFoosDelete      = "foos:delete"
+	PermissionFoosDeleteAll   = "foos:delete_all"
+	PermissionFoosPurge       = "foos:purge"
+	PermissionRolesManage     = "roles:manage"
+	PermissionOrgsManage      = "orgs:manage"
+	PermissionAuditRead       = "audit:read"
+)
+
+// Role is a named set of permissions that users are given.
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   int64    `json:"created_at"`
+}
+
+// UserRolesRequest replaces all the roles of a user.
+type UserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+// UserRoles are the roles of a user.
+type UserRoles struct {
+	UserID int      `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
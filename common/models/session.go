@@ -0,0 +1,34 @@
+package models
+
+// SessionCookieName is the cookie that holds the session token of a browser login.
+const SessionCookieName = "session"
+
+// Session is a login of a user, kept on the server and referenced by the token in the session cookie.
+// A session expires ExpiresAt unless it is used, which moves ExpiresAt forward, but never lives past
+// AbsoluteExpiresAt. Timestamps are epoch milliseconds.
+type Session struct {
+	ID                int64  `json:"id"`
+	UserID            int    `json:"user_id"`
+	UserAgent         string `json:"user_agent"`
+	IPAddress         string `json:"ip_address"`
+	CreatedAt         int64  `json:"created_at"`
+	LastSeenAt        int64  `json:"last_seen_at"`
+	ExpiresAt         int64  `json:"expires_at"`
+	AbsoluteExpiresAt int64  `json:"absolute_expires_at"`
+	RevokedAt         int64  `json:"revoked_at"`
+	// Current is true for the session of the request.
+	Current bool `json:"current"`
+	// IDTokenExpiresAt is when the ID token of the session expires, 0 when it has none.
+	IDTokenExpiresAt int64 `json:"-"`
+	// Renewable is true when the session has a refresh token to renew its ID token with.
+	Renewable bool `json:"-"`
+}
+
+// SessionTokens are the tokens of the login of a session as the sessions table stores them. IDToken and
+// RefreshToken are sealed with the token cipher, RefreshToken is nil when the provider issued none.
+type SessionTokens struct {
+	Provider         string
+	IDToken          []byte
+	IDTokenExpiresAt int64
+	RefreshToken     []byte
+}
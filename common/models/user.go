@@ -0,0 +1,15 @@
+package models
+
+// User is a person who has logged in with OIDC. Issuer and Subject are the iss and sub claims of their ID token,
+// which never change, while the name and email are refreshed from the claims on every login. Timestamps are epoch milliseconds.
+type User struct {
+	ID          int    `json:"id"`
+	Issuer      string `json:"issuer"`
+	Subject     string `json:"subject"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	LastLoginAt int64  `json:"last_login_at"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+	DeletedAt   int64  `json:"deleted_at"`
+}
@@ -0,0 +1,158 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_api_key_repo.go \
+  -package=mocks \
+  -mock_names=ApiKeyRepoInterface=MockApiKeyRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  ApiKeyRepoInterface
+*/
+
+type ApiKeyRepoInterface interface {
+	CreateApiKey(ctx context.Context, issuer string, subject string, keyHash []byte, apiKey *models.ApiKey) (createdApiKey *models.ApiKey, err error)
+	TouchApiKey(ctx context.Context, keyHash []byte) (apiKey *models.ApiKey, claims *models.Claims, err error)
+	GetApiKeys(ctx context.Context, issuer string, subject string) (apiKeys []models.ApiKey, err error)
+	RevokeApiKey(ctx context.Context, issuer string, subject string, apiKeyId int64) (err error)
+}
+
+type ApiKeyRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+func NewApiKeyRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *ApiKeyRepo {
+	return &ApiKeyRepo{db: &db, logger: logger}
+}
+
+// apiKeyColumns are the API key columns in the order apiKeyDest reads them.
+const apiKeyColumns = "api_keys.id, api_keys.user_id, api_keys.name, api_keys.prefix, api_keys.scopes, api_keys.created_at, " +
+	"api_keys.last_used_at, api_keys.expires_at, api_keys.revoked_at"
+
+// apiKeyDest returns the scan destinations of apiKeyColumns in apiKey.
+func apiKeyDest(apiKey *models.ApiKey) []any {
+	return []any{&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.Scopes, &apiKey.CreatedAt,
+		&apiKey.LastUsedAt, &apiKey.ExpiresAt, &apiKey.RevokedAt}
+}
+
+// CreateApiKey stores a new API key with the name, prefix, scopes and expiry of apiKey for the user with the issuer
+// and subject. A user who does not exist or is soft deleted is not found.
+func (apiKeyRepo *ApiKeyRepo) CreateApiKey(ctx context.Context, issuer string, subject string, keyHash []byte, apiKey *models.ApiKey) (createdApiKey *models.ApiKey, err error) {
+	createdApiKey = &models.ApiKey{}
+	row := (*apiKeyRepo.db).QueryRow(
+		ctx,
+		"INSERT INTO api_keys (key_hash, prefix, user_id, name, scopes, expires_at) "+
+			"SELECT $3, $4, users.id, $5, $6, $7 FROM users WHERE users.issuer = $1 AND users.subject = $2 AND users.deleted_at = 0 "+
+			"RETURNING "+apiKeyColumns+";",
+		issuer,
+		subject,
+		keyHash,
+		apiKey.Prefix,
+		apiKey.Name,
+		apiKey.Scopes,
+		apiKey.ExpiresAt,
+	)
+	if err := row.Scan(apiKeyDest(createdApiKey)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("H38TR1", "No user found to create the API key for.")
+		}
+		return nil, errors.Wrap(err, "Error: 0TUGTE - Inserting API key in database.")
+	}
+
+	return createdApiKey, nil
+}
+
+// TouchApiKey finds the live API key with the key hash and records that it was used. It returns the claims of the
+// key's user. Keys that are expired, revoked or belong to a soft deleted user get an unauthorized error.
+func (apiKeyRepo *ApiKeyRepo) TouchApiKey(ctx context.Context, keyHash []byte) (apiKey *models.ApiKey, claims *models.Claims, err error) {
+	apiKey = &models.ApiKey{}
+	claims = &models.Claims{}
+	row := (*apiKeyRepo.db).QueryRow(
+		ctx,
+		"UPDATE api_keys SET last_used_at = current_epoch_milliseconds() FROM users "+
+			"WHERE api_keys.key_hash = $1 AND api_keys.revoked_at = 0 "+
+			"AND (api_keys.expires_at = 0 OR api_keys.expires_at > current_epoch_milliseconds()) "+
+			"AND users.id = api_keys.user_id AND users.deleted_at = 0 "+
+			"RETURNING "+apiKeyColumns+", users.issuer, users.subject, users.email, users.name;",
+		keyHash,
+	)
+	dest := append(apiKeyDest(apiKey), &claims.Issuer, &claims.Sub, &claims.Email, &claims.Name)
+	if err := row.Scan(dest...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, apperrors.Unauthorized("F428P9", "The API key is not valid, it may have expired or been revoked.")
+		}
+		return nil, nil, errors.Wrap(err, "Error: GI12UX - Touching API key in database.")
+	}
+
+	return apiKey, claims, nil
+}
+
+// GetApiKeys returns the API keys of the user with the issuer and subject that are not revoked, expired ones
+// included, the newest first.
+func (apiKeyRepo *ApiKeyRepo) GetApiKeys(ctx context.Context, issuer string, subject string) (apiKeys []models.ApiKey, err error) {
+	rows, err := (*apiKeyRepo.db).Query(
+		ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys JOIN users ON users.id = api_keys.user_id "+
+			"WHERE users.issuer = $1 AND users.subject = $2 AND api_keys.revoked_at = 0 ORDER BY api_keys.id DESC;",
+		issuer,
+		subject,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 1Y9RN9 - Querying API keys from database.")
+	}
+	defer rows.Close()
+
+	apiKeys = []models.ApiKey{}
+	for rows.Next() {
+		apiKey := models.ApiKey{}
+		if err := rows.Scan(apiKeyDest(&apiKey)...); err != nil {
+			return nil, errors.Wrap(err, "Error: J2VUAH - Scanning row of API keys from database.")
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: 18D3ZG - Processing rows of API keys from database.")
+	}
+
+	return apiKeys, nil
+}
+
+// RevokeApiKey revokes an API key of the user with the issuer and subject. Keys of other users are not found.
+func (apiKeyRepo *ApiKeyRepo) RevokeApiKey(ctx context.Context, issuer string, subject string, apiKeyId int64) (err error) {
+	result, err := (*apiKeyRepo.db).Exec(
+		ctx,
+		"UPDATE api_keys SET revoked_at = current_epoch_milliseconds() FROM users "+
+			"WHERE api_keys.id = $3 AND api_keys.revoked_at = 0 AND users.id = api_keys.user_id "+
+			"AND users.issuer = $1 AND users.subject = $2;",
+		issuer,
+		subject,
+		apiKeyId,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: 4ZIO0C - Revoking API key in database.")
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("Y022K9", fmt.Sprintf("No API key found with id %d.", apiKeyId))
+	}
+
+	return nil
+}
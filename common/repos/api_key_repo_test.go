@@ -0,0 +1,50 @@
+package repos_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestApiKeyRepo_TouchApiKey_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	apiKeyRepo := repos.NewApiKeyRepository(mockPool, zaptest.NewLogger(t))
+
+	// An expired, revoked or unknown key
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), []byte("hash")).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(pgx.ErrNoRows)
+
+	apiKey, claims, err := apiKeyRepo.TouchApiKey(context.Background(), []byte("hash"))
+	require.Nil(t, apiKey)
+	require.Nil(t, claims)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+}
+
+func TestApiKeyRepo_RevokeApiKey_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	apiKeyRepo := repos.NewApiKeyRepository(mockPool, zaptest.NewLogger(t))
+
+	// A key of another user or one that is already revoked
+	mockPool.EXPECT().
+		Exec(gomock.Any(), gomock.Any(), issuer, "sub-1", int64(4)).
+		Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+	err := apiKeyRepo.RevokeApiKey(context.Background(), issuer, "sub-1", 4)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+}
@@ -0,0 +1,179 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_audit_repo.go \
+  -package=mocks \
+  -mock_names=AuditRepoInterface=MockAuditRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  AuditRepoInterface
+*/
+
+type AuditRepoInterface interface {
+	CreateAuditEvents(ctx context.Context, events []models.AuditEvent) (err error)
+	GetAuditEvents(ctx context.Context, params *models.AuditListParams) (events []models.AuditEvent, nextCursor string, err error)
+	WithTx(tx interfaces.PgxTxInterface) AuditRepoInterface
+}
+
+type AuditRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+// NewAuditRepository makes an audit repo. Run it in the transaction of the changes it records, so they are only in
+// the log when they happened.
+func NewAuditRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *AuditRepo {
+	return &AuditRepo{db: &db, logger: logger}
+}
+
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (auditRepo *AuditRepo) WithTx(tx interfaces.PgxTxInterface) AuditRepoInterface {
+	return NewAuditRepository(tx, auditRepo.logger)
+}
+
+// auditEventColumns are the audit event columns in the order scanAuditEvent reads them.
+const auditEventColumns = "id, actor_id, actor_sub, actor_email, request_id, entity, entity_id, action, before, after, created_at"
+
+// scanAuditEvent scans a row selected with auditEventColumns into event.
+func scanAuditEvent(row interfaces.PgxRowInterface, event *models.AuditEvent) error {
+	return row.Scan(
+		&event.ID, &event.ActorID, &event.ActorSub, &event.ActorEmail, &event.RequestID,
+		&event.Entity, &event.EntityID, &event.Action, &event.Before, &event.After, &event.CreatedAt,
+	)
+}
+
+// CreateAuditEvents adds the events to the log of the organization in one batch. Only their entity, entity id,
+// action, before and after are used. The actor and request come from the context, the user and actor of a context
+// without them are left empty.
+func (auditRepo *AuditRepo) CreateAuditEvents(ctx context.Context, events []models.AuditEvent) (err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var actorId *int
+	if userId, ok := tenant.UserID(ctx); ok {
+		actorId = &userId
+	}
+	actor, _ := tenant.ActorOf(ctx)
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		batch.Queue(
+			"INSERT INTO audit_events (org_id, actor_id, actor_sub, actor_email, request_id, entity, entity_id, action, before, after) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);",
+			orgId, actorId, actor.Subject, actor.Email, actor.RequestID, event.Entity, event.EntityID, event.Action, event.Before, event.After,
+		)
+	}
+
+	results := (*auditRepo.db).SendBatch(ctx, batch)
+
+	for range events {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return errors.Wrap(err, "Error: R5A9AG - Inserting audit events into database.")
+		}
+	}
+
+	if err := results.Close(); err != nil {
+		return errors.Wrap(err, "Error: 6UDAK6 - Finishing batch of audit event inserts.")
+	}
+
+	return nil
+}
+
+// GetAuditEvents returns one page of the log of the organization matching the filters of params, newest first.
+// params must have defaults applied. nextCursor is empty when there are no more events.
+func (auditRepo *AuditRepo) GetAuditEvents(ctx context.Context, params *models.AuditListParams) (events []models.AuditEvent, nextCursor string, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	args := []interface{}{orgId}
+	conditions := []string{"org_id = $1"}
+	if params.Actor != "" {
+		args = append(args, params.Actor)
+		conditions = append(conditions, fmt.Sprintf("(actor_sub = $%[1]d OR actor_email = $%[1]d)", len(args)))
+	}
+	if params.Entity != "" {
+		args = append(args, params.Entity)
+		conditions = append(conditions, fmt.Sprintf("entity = $%d", len(args)))
+	}
+	if params.EntityID > 0 {
+		args = append(args, params.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+	if params.Action != "" {
+		args = append(args, params.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if params.From > 0 {
+		args = append(args, params.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if params.To > 0 {
+		args = append(args, params.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+	if params.Cursor != "" {
+		afterId, err := models.DecodeAuditCursor(params.Cursor)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Error: 4MWP05 - Decoding audit cursor.")
+		}
+		args = append(args, afterId)
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)))
+	}
+
+	// Fetch one extra row to find out if there is a next page.
+	args = append(args, params.Limit+1)
+	sql := fmt.Sprintf(
+		"SELECT "+auditEventColumns+" FROM audit_events WHERE %s ORDER BY id DESC LIMIT $%d;",
+		strings.Join(conditions, " AND "),
+		len(args),
+	)
+
+	rows, err := (*auditRepo.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Error: G6KX9W - Querying audit events from database.")
+	}
+	defer rows.Close()
+
+	events = []models.AuditEvent{}
+	for rows.Next() {
+		event := models.AuditEvent{}
+		if err := scanAuditEvent(rows, &event); err != nil {
+			return nil, "", errors.Wrap(err, "Error: P7JJ8J - Scanning row of audit events from database.")
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", errors.Wrap(err, "Error: YFEHGP - Processing rows of audit events from database.")
+	}
+
+	if len(events) > params.Limit {
+		events = events[:params.Limit]
+		nextCursor = models.EncodeAuditCursor(events[params.Limit-1].ID)
+	}
+
+	return events, nextCursor, nil
+}
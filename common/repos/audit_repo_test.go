@@ -0,0 +1,203 @@
+package repos_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
+)
+
+// auditScan returns a Scan stub that fills the auditEventColumns destinations from event.
+func auditScan(event models.AuditEvent) func(dest ...any) error {
+	return func(dest ...any) error {
+		*(dest[0].(*int64)) = event.ID
+		*(dest[1].(**int)) = event.ActorID
+		*(dest[2].(*string)) = event.ActorSub
+		*(dest[3].(*string)) = event.ActorEmail
+		*(dest[4].(*string)) = event.RequestID
+		*(dest[5].(*string)) = event.Entity
+		*(dest[6].(*int64)) = event.EntityID
+		*(dest[7].(*string)) = event.Action
+		*(dest[8].(*json.RawMessage)) = event.Before
+		*(dest[9].(*json.RawMessage)) = event.After
+		*(dest[10].(*int64)) = event.CreatedAt
+		return nil
+	}
+}
+
+func TestAuditRepo_CreateAuditEvents_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockResults := mocks.NewMockPgxBatchResults(ctrl)
+
+	ctx := tenant.WithActor(orgCtx, tenant.Actor{Subject: "sub-1", Email: "joe@example.com", RequestID: "request-1"})
+	actorId := userId
+	before := json.RawMessage(`{"id":3,"name":"Old"}`)
+	after := json.RawMessage(`{"id":3,"name":"New"}`)
+
+	// One INSERT is queued per event, with the actor of the context
+	mockPool.
+		EXPECT().
+		SendBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, batch *pgx.Batch) *mocks.MockPgxBatchResults {
+			require.Equal(t, 2, batch.Len())
+			require.Equal(t, "INSERT INTO audit_events (org_id, actor_id, actor_sub, actor_email, request_id, entity, entity_id, action, before, after) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);", batch.QueuedQueries[0].SQL)
+			require.Equal(t, []any{orgId, &actorId, "sub-1", "joe@example.com", "request-1", "foo", int64(3), "update", before, after}, batch.QueuedQueries[0].Arguments)
+			require.Equal(t, []any{orgId, &actorId, "sub-1", "joe@example.com", "request-1", "foo", int64(4), "create", json.RawMessage(nil), after}, batch.QueuedQueries[1].Arguments)
+			return mockResults
+		})
+	mockResults.EXPECT().Exec().Return(pgconn.NewCommandTag("INSERT 0 1"), nil).Times(2)
+	mockResults.EXPECT().Close().Return(nil)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewAuditRepository(mockPool, logger)
+
+	err := repo.CreateAuditEvents(ctx, []models.AuditEvent{
+		{Entity: models.AuditEntityFoo, EntityID: 3, Action: models.AuditActionUpdate, Before: before, After: after},
+		{Entity: models.AuditEntityFoo, EntityID: 4, Action: models.AuditActionCreate, After: after},
+	})
+	require.NoError(t, err)
+}
+
+func TestAuditRepo_CreateAuditEvents_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockResults := mocks.NewMockPgxBatchResults(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewAuditRepository(mockPool, logger)
+	events := []models.AuditEvent{{Entity: models.AuditEntityFoo, EntityID: 3, Action: models.AuditActionDelete}}
+
+	// 1) Test an insert failing
+	mockPool.EXPECT().SendBatch(gomock.Any(), gomock.Any()).Return(mockResults)
+	mockResults.EXPECT().Exec().Return(pgconn.CommandTag{}, errors.New("insert failed"))
+	mockResults.EXPECT().Close().Return(nil)
+
+	err := repo.CreateAuditEvents(orgCtx, events)
+	require.Contains(t, err.Error(), "R5A9AG")
+
+	// 2) Test finishing the batch failing
+	mockPool.EXPECT().SendBatch(gomock.Any(), gomock.Any()).Return(mockResults)
+	mockResults.EXPECT().Exec().Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+	mockResults.EXPECT().Close().Return(errors.New("close failed"))
+
+	err = repo.CreateAuditEvents(orgCtx, events)
+	require.Contains(t, err.Error(), "6UDAK6")
+
+	// 3) Test a context without an organization
+	err = repo.CreateAuditEvents(context.Background(), events)
+	require.Contains(t, err.Error(), "6V70UA")
+}
+
+func TestAuditRepo_GetAuditEvents_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewAuditRepository(mockPool, logger)
+
+	// 1) Test every filter, with one more event than the limit for a next page
+	params := &models.AuditListParams{
+		Actor: "joe@example.com", Entity: "foo", EntityID: 3, Action: "update",
+		From: 1700000000000, To: 1800000000000, Limit: 1, Cursor: models.EncodeAuditCursor(20),
+	}
+	mockPool.
+		EXPECT().
+		Query(
+			gomock.Any(),
+			"SELECT id, actor_id, actor_sub, actor_email, request_id, entity, entity_id, action, before, after, created_at "+
+				"FROM audit_events WHERE org_id = $1 AND (actor_sub = $2 OR actor_email = $2) AND entity = $3 AND entity_id = $4 "+
+				"AND action = $5 AND created_at >= $6 AND created_at < $7 AND id < $8 ORDER BY id DESC LIMIT $9;",
+			orgId, "joe@example.com", "foo", int64(3), "update", int64(1700000000000), int64(1800000000000), int64(20), 2,
+		).
+		Return(mockRows, nil)
+
+	first := models.AuditEvent{ID: 19, ActorSub: "sub-1", ActorEmail: "joe@example.com", Entity: "foo", EntityID: 3, Action: "update", After: json.RawMessage(`{"id":3}`)}
+	for _, event := range []models.AuditEvent{first, {ID: 12}} {
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(auditScan(event))
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	events, nextCursor, err := repo.GetAuditEvents(orgCtx, params)
+	require.NoError(t, err)
+	require.Equal(t, []models.AuditEvent{first}, events)
+	require.Equal(t, models.EncodeAuditCursor(19), nextCursor)
+
+	// 2) Test no filters and the last page
+	mockPool.
+		EXPECT().
+		Query(
+			gomock.Any(),
+			"SELECT id, actor_id, actor_sub, actor_email, request_id, entity, entity_id, action, before, after, created_at "+
+				"FROM audit_events WHERE org_id = $1 ORDER BY id DESC LIMIT $2;",
+			orgId, 51,
+		).
+		Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	events, nextCursor, err = repo.GetAuditEvents(orgCtx, &models.AuditListParams{Limit: 50})
+	require.NoError(t, err)
+	require.Equal(t, []models.AuditEvent{}, events)
+	require.Empty(t, nextCursor)
+}
+
+func TestAuditRepo_GetAuditEvents_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewAuditRepository(mockPool, logger)
+
+	// 1) Test the query failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), orgId, 51).Return(nil, errors.New("query failed"))
+
+	events, _, err := repo.GetAuditEvents(orgCtx, &models.AuditListParams{Limit: 50})
+	require.Nil(t, events)
+	require.Contains(t, err.Error(), "G6KX9W")
+
+	// 2) Test the scan failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), orgId, 51).Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	events, _, err = repo.GetAuditEvents(orgCtx, &models.AuditListParams{Limit: 50})
+	require.Nil(t, events)
+	require.Contains(t, err.Error(), "P7JJ8J")
+
+	// 3) Test a cursor that is not valid
+	events, _, err = repo.GetAuditEvents(orgCtx, &models.AuditListParams{Limit: 50, Cursor: "not a cursor"})
+	require.Nil(t, events)
+	require.Contains(t, err.Error(), "4MWP05")
+}
@@ -0,0 +1,65 @@
+package repos
+
+import (
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+// The Postgres error codes of the constraint violations that are the client's fault.
+const (
+	pgStringTooLong      = "22001"
+	pgNotNullViolation   = "23502"
+	pgUniqueViolation    = "23505"
+	pgCheckViolation     = "23514"
+	pgExclusionViolation = "23P01"
+)
+
+// fooConstraintFields maps the constraints of the foos table to the request fields they check.
+var fooConstraintFields = map[string]string{
+	"foos_name_not_blank": "name",
+}
+
+// organizationConstraintFields maps the constraints of the organizations table to the request fields they check.
+var organizationConstraintFields = map[string]string{
+	"organizations_slug_key": "slug",
+}
+
+// userConstraintFields maps the constraints of the users table to the fields they check.
+var userConstraintFields = map[string]string{
+	"users_email_check":        "email",
+	"users_issuer_subject_idx": "subject",
+}
+
+// constraintError translates a constraint violation reported by Postgres into a validation error in the same
+// format as request validation. fields maps constraint names to the fields they check, the column of the
+// violation is used when there is no mapping. Any other error is returned as it is.
+func constraintError(err error, fields map[string]string) error {
+	var pgError *pgconn.PgError
+	if !errors.As(err, &pgError) {
+		return err
+	}
+
+	field := fields[pgError.ConstraintName]
+	if field == "" {
+		field = pgError.ColumnName
+	}
+
+	var fieldError apperrors.FieldError
+	var code string
+	switch pgError.Code {
+	case pgStringTooLong:
+		code, fieldError = "1G36FP", apperrors.FieldError{Code: "max", Message: "This field is too long."}
+	case pgNotNullViolation:
+		code, fieldError = "VJGKBD", apperrors.FieldError{Code: "required", Message: "This field is required."}
+	case pgUniqueViolation, pgExclusionViolation:
+		code, fieldError = "LKSTDT", apperrors.FieldError{Code: "unique", Message: "This value is already taken."}
+	case pgCheckViolation:
+		code, fieldError = "JRTRMB", apperrors.FieldError{Code: "check", Message: "This value is not allowed."}
+	default:
+		return err
+	}
+	fieldError.Field = field
+
+	return apperrors.Validation(code, "The request is not valid.").WithFields(fieldError).WithCause(err)
+}
@@ -3,12 +3,16 @@ package repos
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
 	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
 	"go.uber.org/zap"
 )
 
@@ -27,87 +31,907 @@ mockgen \
 */
 
 type FooRepoInterface interface {
-	GetFoos() (foos *[]models.Foo, err error)
-	CreateFoo(name string) (foo *models.Foo, err error)
-	DeleteFoos() (rowsAffected int64, err error)
-	UpdateFoo(fooId int64, name string) (foo *models.Foo, err error)
+	GetFoos(ctx context.Context, params *models.FooListParams) (foos *[]models.Foo, nextCursor string, err error)
+	CountFoos(ctx context.Context, params *models.FooListParams) (total int64, err error)
+	SearchFoos(ctx context.Context, params *models.FooSearchParams) (results []models.FooSearchResult, err error)
+	GetFooByID(ctx context.Context, fooId int64) (foo *models.Foo, err error)
+	GetFoosForUpdate(ctx context.Context, fooIds []int64) (foos map[int64]models.Foo, err error)
+	CreateFoo(ctx context.Context, name string) (foo *models.Foo, err error)
+	CreateFoos(ctx context.Context, names []string) (foos *[]models.Foo, err error)
+	DeleteFoos(ctx context.Context) (deleted []models.Foo, err error)
+	DeleteFoosByID(ctx context.Context, fooIds []int64) (deletedIds []int64, err error)
+	DeleteFoo(ctx context.Context, fooId int64) (err error)
+	RestoreFoo(ctx context.Context, fooId int64) (foo *models.Foo, err error)
+	PurgeFoos(ctx context.Context, deletedBefore int64) (purged []models.Foo, err error)
+	UpdateFoo(ctx context.Context, fooId int64, name string, version int) (foo *models.Foo, err error)
+	PatchFoo(ctx context.Context, fooId int64, patch *models.FooPatch, version int) (foo *models.Foo, err error)
+	PatchFoos(ctx context.Context, patches []models.FooBatchPatch) (items []models.FooBatchItem, err error)
+	ExportFoos(ctx context.Context, includeDeleted bool, fn func(foo *models.Foo) error) (err error)
+	ImportFoos(ctx context.Context, names []string) (foos []models.Foo, err error)
+	WithTx(tx interfaces.PgxTxInterface) FooRepoInterface
 }
 
 type FooRepo struct {
-	db     *interfaces.PgxPoolInterface
+	db     *interfaces.PgxQuerierInterface
 	logger *zap.Logger
 }
 
-func NewFooRepository(db interfaces.PgxPoolInterface, logger *zap.Logger) *FooRepo {
+// NewFooRepository makes a foo repo. Every query is scoped to the organization of its context, see the tenant
+// package, and queries with a context without one fail.
+func NewFooRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *FooRepo {
 	return &FooRepo{db: &db, logger: logger}
 }
 
-func (fooRepo *FooRepo) GetFoos() (foos *[]models.Foo, err error) {
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (fooRepo *FooRepo) WithTx(tx interfaces.PgxTxInterface) FooRepoInterface {
+	return NewFooRepository(tx, fooRepo.logger)
+}
+
+// fooOrgID returns the organization the foo queries of ctx are scoped to.
+func fooOrgID(ctx context.Context) (orgId int, err error) {
+	orgId, ok := tenant.OrgID(ctx)
+	if !ok {
+		return 0, errors.New("Error: 6V70UA - No organization in the context of the foo query.")
+	}
+	return orgId, nil
+}
+
+// fooUserID returns the user the foo queries of ctx run for.
+func fooUserID(ctx context.Context) (userId int, err error) {
+	userId, ok := tenant.UserID(ctx)
+	if !ok {
+		return 0, errors.New("Error: 5ANHWU - No user in the context of the foo query.")
+	}
+	return userId, nil
+}
+
+// fooVisibleCondition is the condition for the foos the user in the userParam placeholder may see. Those are the
+// foos without an owner, the foos they own and the foos shared with them or one of their roles.
+func fooVisibleCondition(userParam int) string {
+	return fmt.Sprintf(
+		"(owner_id IS NULL OR owner_id = $%[1]d OR EXISTS (SELECT 1 FROM foo_shares WHERE foo_shares.foo_id = foos.id "+
+			"AND (foo_shares.user_id = $%[1]d OR foo_shares.role_id IN (SELECT role_id FROM user_roles WHERE user_id = $%[1]d))))",
+		userParam,
+	)
+}
+
+// fooWritableCondition is the condition for the foos the user in the userParam placeholder may change, like
+// fooVisibleCondition but only counting shares with write permission.
+func fooWritableCondition(userParam int) string {
+	return fmt.Sprintf(
+		"(owner_id IS NULL OR owner_id = $%[1]d OR EXISTS (SELECT 1 FROM foo_shares WHERE foo_shares.foo_id = foos.id "+
+			"AND foo_shares.permission = '"+models.FooAccessWrite+"' "+
+			"AND (foo_shares.user_id = $%[1]d OR foo_shares.role_id IN (SELECT role_id FROM user_roles WHERE user_id = $%[1]d))))",
+		userParam,
+	)
+}
+
+// fooColumns are the foo columns in the order scanFoo reads them.
+const fooColumns = "id, name, version, created_at, updated_at, deleted_at, owner_id"
+
+// qualifiedFooColumns are the fooColumns of the table or alias table.
+func qualifiedFooColumns(table string) string {
+	columns := strings.Split(fooColumns, ", ")
+	for i, column := range columns {
+		columns[i] = table + "." + column
+	}
+	return strings.Join(columns, ", ")
+}
+
+// fooRevisionQuery wraps write, a statement that writes foos and returns them with fooColumns, so every foo it writes
+// is also saved as a revision written by the user in the createdByParam placeholder. It returns the written foos
+// with fooColumns too.
+func fooRevisionQuery(write string, createdByParam int) string {
+	return fmt.Sprintf(
+		"WITH foo AS (%s), revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) "+
+			"SELECT id, version, name, $%d FROM foo) SELECT "+fooColumns+" FROM foo;",
+		write,
+		createdByParam,
+	)
+}
+
+// scanFoo scans a row selected or returned with fooColumns into foo.
+func scanFoo(row interfaces.PgxRowInterface, foo *models.Foo) error {
+	return row.Scan(&foo.ID, &foo.Name, &foo.Version, &foo.CreatedAt, &foo.UpdatedAt, &foo.DeletedAt, &foo.OwnerID)
+}
+
+// fooSortColumns maps the allowed sort fields to columns so request input never reaches the SQL.
+var fooSortColumns = map[string]string{
+	models.FooSortByID:        "id",
+	models.FooSortByName:      "name",
+	models.FooSortByCreatedAt: "created_at",
+}
+
+// GetFoos returns one page of the foos the user may see. params must have defaults applied.
+// nextCursor is empty when there are no more foos.
+func (fooRepo *FooRepo) GetFoos(ctx context.Context, params *models.FooListParams) (foos *[]models.Foo, nextCursor string, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
 	foos = &[]models.Foo{}
+	args := []interface{}{}
+	conditions := fooFilterConditions(orgId, userId, params, &args)
 
-	rows, err := (*fooRepo.db).Query(context.Background(), "SELECT id, name FROM foos ORDER BY id;")
+	column := fooSortColumns[params.SortBy]
+	comparison, direction := ">", "ASC"
+	if params.Order == models.SortOrderDesc {
+		comparison, direction = "<", "DESC"
+	}
+
+	// Keyset paging. Continue after the (sort value, id) of the last foo on the previous page.
+	if params.Cursor != "" {
+		cursor, err := models.DecodeFooCursor(params.Cursor)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Error: 6DWXA6 - Decoding foo cursor.")
+		}
+
+		if column == "id" {
+			args = append(args, cursor.ID)
+			conditions = append(conditions, fmt.Sprintf("id %s $%d", comparison, len(args)))
+		} else {
+			var value interface{} = cursor.Value
+			if column == "created_at" {
+				value, err = strconv.ParseInt(cursor.Value, 10, 64)
+				if err != nil {
+					return nil, "", errors.Wrap(err, "Error: QC0V1T - Parsing foo cursor value.")
+				}
+			}
+			args = append(args, value, cursor.ID)
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, comparison, len(args)-1, len(args)))
+		}
+	}
+
+	sql := "SELECT " + fooColumns + " FROM foos WHERE " + strings.Join(conditions, " AND ")
+	if column == "id" {
+		sql += " ORDER BY id " + direction
+	} else {
+		sql += fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+	}
+
+	// Fetch one extra row to find out if there is a next page.
+	args = append(args, params.Limit+1)
+	sql += fmt.Sprintf(" LIMIT $%d", len(args))
+	if params.Offset > 0 {
+		args = append(args, params.Offset)
+		sql += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	sql += ";"
+
+	rows, err := (*fooRepo.db).Query(ctx, sql, args...)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error: 30UUBR - Quering foos from db. Error")
+		return nil, "", errors.Wrap(err, "Error: 30UUBR - Quering foos from db. Error")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		foo := models.Foo{}
 
-		if err := rows.Scan(&foo.ID, &foo.Name); err != nil {
-			return nil, errors.Wrap(err, "Error: YN80XB - Scanning row of foos from db.")
+		if err := scanFoo(rows, &foo); err != nil {
+			return nil, "", errors.Wrap(err, "Error: YN80XB - Scanning row of foos from db.")
 		}
 
 		*foos = append(*foos, foo)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, errors.Wrap(err, "Error: XV4HHL - Processing rows of foos from db.")
+		return nil, "", errors.Wrap(err, "Error: XV4HHL - Processing rows of foos from db.")
+	}
+
+	if len(*foos) > params.Limit {
+		*foos = (*foos)[:params.Limit]
+
+		last := (*foos)[params.Limit-1]
+		cursor := &models.FooCursor{SortBy: params.SortBy, Order: params.Order, ID: last.ID}
+		switch column {
+		case "name":
+			cursor.Value = last.Name
+		case "created_at":
+			cursor.Value = strconv.FormatInt(last.CreatedAt, 10)
+		}
+		nextCursor = models.EncodeFooCursor(cursor)
+	}
+
+	return foos, nextCursor, nil
+}
+
+// CountFoos returns the number of foos the user may see matching the filters in params, ignoring paging.
+func (fooRepo *FooRepo) CountFoos(ctx context.Context, params *models.FooListParams) (total int64, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	args := []interface{}{}
+	conditions := fooFilterConditions(orgId, userId, params, &args)
+
+	sql := "SELECT count(*) FROM foos WHERE " + strings.Join(conditions, " AND ") + ";"
+
+	err = (*fooRepo.db).QueryRow(ctx, sql, args...).Scan(&total)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error: KNMN02 - Counting foos in database.")
+	}
+
+	return total, nil
+}
+
+// fooFilterConditions builds the WHERE conditions for the organization, the foos the user may see and the filters in
+// params and appends their values to args.
+func fooFilterConditions(orgId int, userId int, params *models.FooListParams, args *[]interface{}) (conditions []string) {
+	*args = append(*args, orgId)
+	conditions = append(conditions, fmt.Sprintf("org_id = $%d", len(*args)))
+	*args = append(*args, userId)
+	conditions = append(conditions, fooVisibleCondition(len(*args)))
+	if !params.IncludeDeleted {
+		conditions = append(conditions, "deleted_at = 0")
+	}
+	if params.NamePrefix != "" {
+		*args = append(*args, escapeLike(params.NamePrefix)+"%")
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(*args)))
+	}
+	if params.NameContains != "" {
+		*args = append(*args, "%"+escapeLike(params.NameContains)+"%")
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(*args)))
+	}
+	return conditions
+}
+
+// escapeLike escapes the LIKE wildcards so user input is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// SearchFoos returns the live foos the user may see whose name matches the words of params.Query, or comes close
+// to them, best match first. params must have defaults applied. Words match with the english full text search of
+// the search column, misspelled ones with the trigram word similarity of pg_trgm. The name is HTML escaped before
+// the matches are highlighted, so the snippet is safe to render as markup.
+func (fooRepo *FooRepo) SearchFoos(ctx context.Context, params *models.FooSearchParams) (results []models.FooSearchResult, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := (*fooRepo.db).Query(
+		ctx,
+		"SELECT "+fooColumns+", ts_rank(search, query) + word_similarity($1, name) AS rank, "+
+			"ts_headline('english', replace(replace(replace(name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query) "+
+			"FROM foos, websearch_to_tsquery('english', $1) query "+
+			"WHERE org_id = $2 AND deleted_at = 0 AND (search @@ query OR $1 <% name) AND "+fooVisibleCondition(3)+" "+
+			"ORDER BY rank DESC, id LIMIT $4;",
+		params.Query,
+		orgId,
+		userId,
+		params.Limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 8UOMFW - Searching foos in database.")
+	}
+	defer rows.Close()
+
+	results = []models.FooSearchResult{}
+	for rows.Next() {
+		result := models.FooSearchResult{}
+		foo := &result.Foo
+		err := rows.Scan(&foo.ID, &foo.Name, &foo.Version, &foo.CreatedAt, &foo.UpdatedAt, &foo.DeletedAt, &foo.OwnerID, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error: A3A5U0 - Scanning row of found foos.")
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: LUVD6Z - Processing rows of found foos.")
+	}
+
+	return results, nil
+}
+
+func (fooRepo *FooRepo) GetFooByID(ctx context.Context, fooId int64) (foo *models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	foo = &models.Foo{}
+	row := (*fooRepo.db).QueryRow(
+		ctx,
+		"SELECT "+fooColumns+" FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;",
+		fooId,
+		orgId,
+	)
+	err = scanFoo(row, foo)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("39YZ4S", fmt.Sprintf("No foo found with id %d.", fooId))
+		}
+		return nil, errors.Wrap(err, "Error: 3EM1A7 - Getting foo from database.")
+	}
+
+	return foo, nil
+}
+
+// GetFoosForUpdate returns the foos of the organization with the given ids, soft deleted or not, by id. It locks them
+// until the end of the transaction, so they stay as they are until a change in the same transaction. Foos that are
+// not in the organization are left out.
+func (fooRepo *FooRepo) GetFoosForUpdate(ctx context.Context, fooIds []int64) (foos map[int64]models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := (*fooRepo.db).Query(
+		ctx,
+		"SELECT "+fooColumns+" FROM foos WHERE id = ANY($1) AND org_id = $2 FOR UPDATE;",
+		fooIds,
+		orgId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: GTQIH2 - Locking foos in database.")
+	}
+	defer rows.Close()
+
+	foos = map[int64]models.Foo{}
+	for rows.Next() {
+		foo := models.Foo{}
+		if err := scanFoo(rows, &foo); err != nil {
+			return nil, errors.Wrap(err, "Error: S0RNL2 - Scanning locked foo.")
+		}
+		foos[int64(foo.ID)] = foo
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: ZT6IIK - Processing locked foos.")
 	}
 
 	return foos, nil
 }
 
-func (fooRepo *FooRepo) CreateFoo(name string) (foo *models.Foo, err error) {
+// CreateFoo inserts a foo owned by the user. Its first version is saved as a revision.
+func (fooRepo *FooRepo) CreateFoo(ctx context.Context, name string) (foo *models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	foo = &models.Foo{}
-	err = (*fooRepo.db).QueryRow(
-		context.Background(),
-		"INSERT INTO foos (name) VALUES ($1) RETURNING id, name;",
+	row := (*fooRepo.db).QueryRow(
+		ctx,
+		fooRevisionQuery("INSERT INTO foos (org_id, owner_id, name) VALUES ($1, $2, $3) RETURNING "+fooColumns, 2),
+		orgId,
+		userId,
 		name,
-	).Scan(&foo.ID, &foo.Name)
+	)
+	err = scanFoo(row, foo)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "Error: WOPUDO - Inserting foo into database.")
+		return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: WOPUDO - Inserting foo into database.")
 	}
 
 	return foo, nil
 }
-func (fooRepo *FooRepo) DeleteFoos() (rowsAffected int64, err error) {
+
+// CreateFoos inserts a foo owned by the user for each name in one batch. The batch runs in one transaction,
+// so either every foo is created or none are.
+func (fooRepo *FooRepo) CreateFoos(ctx context.Context, names []string) (foos *[]models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, name := range names {
+		batch.Queue(fooRevisionQuery("INSERT INTO foos (org_id, owner_id, name) VALUES ($1, $2, $3) RETURNING "+fooColumns, 2), orgId, userId, name)
+	}
+
+	results := (*fooRepo.db).SendBatch(ctx, batch)
+
+	foos = &[]models.Foo{}
+	for range names {
+		foo := models.Foo{}
+		if err := scanFoo(results.QueryRow(), &foo); err != nil {
+			results.Close()
+			return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: DX63D2 - Inserting batch of foos into database.")
+		}
+		*foos = append(*foos, foo)
+	}
+
+	if err := results.Close(); err != nil {
+		return nil, errors.Wrap(err, "Error: T7CSFX - Finishing batch of foo inserts.")
+	}
+
+	return foos, nil
+}
+
+// DeleteFoos soft deletes all foos of the organization the user may change and returns them as they were before.
+func (fooRepo *FooRepo) DeleteFoos(ctx context.Context) (deleted []models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The triggers change the version and updated_at of the foos, so their old values come from the locked rows.
+	rows, err := (*fooRepo.db).Query(
+		ctx,
+		"WITH deleted AS (SELECT "+fooColumns+" FROM foos WHERE org_id = $1 AND "+fooWritableCondition(2)+" AND deleted_at = 0 FOR UPDATE) "+
+			"UPDATE foos SET deleted_at = current_epoch_milliseconds() FROM deleted WHERE foos.id = deleted.id "+
+			"RETURNING "+qualifiedFooColumns("deleted")+";",
+		orgId,
+		userId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 1BLNNL - Deleteing foos from database.")
+	}
+	defer rows.Close()
+
+	deleted = []models.Foo{}
+	for rows.Next() {
+		foo := models.Foo{}
+		if err := scanFoo(rows, &foo); err != nil {
+			return nil, errors.Wrap(err, "Error: QDVWV7 - Scanning deleted foo.")
+		}
+		deleted = append(deleted, foo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: 0RAQRF - Processing deleted foos.")
+	}
+
+	return deleted, nil
+}
+
+// DeleteFoosByID soft deletes the foos with the given ids and returns the ids that were deleted.
+func (fooRepo *FooRepo) DeleteFoosByID(ctx context.Context, fooIds []int64) (deletedIds []int64, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := (*fooRepo.db).Query(
+		ctx,
+		"UPDATE foos SET deleted_at = current_epoch_milliseconds() WHERE id = ANY($1) AND org_id = $2 AND deleted_at = 0 RETURNING id;",
+		fooIds,
+		orgId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: MIQ8AE - Deleting foos by id from database.")
+	}
+	defer rows.Close()
+
+	deletedIds = []int64{}
+	for rows.Next() {
+		var deletedId int64
+		if err := rows.Scan(&deletedId); err != nil {
+			return nil, errors.Wrap(err, "Error: TQ2BSR - Scanning deleted foo id.")
+		}
+		deletedIds = append(deletedIds, deletedId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: X2FDW0 - Processing deleted foo ids.")
+	}
+
+	return deletedIds, nil
+}
+
+// DeleteFoo soft deletes one foo. It can be brought back with RestoreFoo until it is purged.
+func (fooRepo *FooRepo) DeleteFoo(ctx context.Context, fooId int64) (err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
 	var result pgconn.CommandTag
 
-	result, err = (*fooRepo.db).Exec(context.Background(), "DELETE FROM foos;")
+	result, err = (*fooRepo.db).Exec(
+		ctx,
+		"UPDATE foos SET deleted_at = current_epoch_milliseconds() WHERE id = $1 AND org_id = $2 AND deleted_at = 0;",
+		fooId,
+		orgId,
+	)
 	if err != nil {
-		return 0, errors.Wrap(err, "Error: 1BLNNL - Deleteing foos from database.")
+		return errors.Wrap(err, "Error: LHH54F - Deleting foo from database.")
 	}
 
-	return result.RowsAffected(), nil
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("PGZFG4", fmt.Sprintf("No foo found with id %d.", fooId))
+	}
+
+	return nil
 }
 
-func (fooRepo *FooRepo) UpdateFoo(fooId int64, name string) (foo *models.Foo, err error) {
+// RestoreFoo undoes a soft delete.
+func (fooRepo *FooRepo) RestoreFoo(ctx context.Context, fooId int64) (foo *models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	foo = &models.Foo{}
-	err = (*fooRepo.db).QueryRow(
-		context.Background(),
-		"UPDATE foos SET name = $1 WHERE id = $2 RETURNING id, name;",
+	row := (*fooRepo.db).QueryRow(
+		ctx,
+		"UPDATE foos SET deleted_at = 0 WHERE id = $1 AND org_id = $2 AND deleted_at > 0 RETURNING "+fooColumns+";",
+		fooId,
+		orgId,
+	)
+	err = scanFoo(row, foo)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("1QTQCC", fmt.Sprintf("No deleted foo found with id %d.", fooId))
+		}
+		return nil, errors.Wrap(err, "Error: HL55ZQ - Restoring foo in database.")
+	}
+
+	return foo, nil
+}
+
+// PurgeFoos hard deletes the foos of the organization that were soft deleted before deletedBefore, in epoch
+// milliseconds, and returns them.
+func (fooRepo *FooRepo) PurgeFoos(ctx context.Context, deletedBefore int64) (purged []models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := (*fooRepo.db).Query(
+		ctx,
+		"DELETE FROM foos WHERE org_id = $1 AND deleted_at > 0 AND deleted_at < $2 RETURNING "+fooColumns+";",
+		orgId,
+		deletedBefore,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: CA7X0A - Purging foos from database.")
+	}
+	defer rows.Close()
+
+	purged = []models.Foo{}
+	for rows.Next() {
+		foo := models.Foo{}
+		if err := scanFoo(rows, &foo); err != nil {
+			return nil, errors.Wrap(err, "Error: 64H5BI - Scanning purged foo.")
+		}
+		purged = append(purged, foo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: UICB7G - Processing purged foos.")
+	}
+
+	return purged, nil
+}
+
+// UpdateFoo only updates the foo if it is still at version. A version of 0 updates whatever version is current.
+// It returns a precondition failed error when the foo exists at another version. The new version is saved as a
+// revision, like the foos written by CreateFoo, CreateFoos and the patches.
+func (fooRepo *FooRepo) UpdateFoo(ctx context.Context, fooId int64, name string, version int) (foo *models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	foo = &models.Foo{}
+	row := (*fooRepo.db).QueryRow(
+		ctx,
+		fooRevisionQuery("UPDATE foos SET name = $1 WHERE id = $2 AND org_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at = 0 RETURNING "+fooColumns, 5),
 		name,
 		fooId,
-	).Scan(&foo.ID, &foo.Name)
+		orgId,
+		version,
+		userId,
+	)
+	err = scanFoo(row, foo)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fooRepo.noFooUpdatedError(ctx, fooId, version)
+		}
+		return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: 2H6YX9 - Updating foo in database.")
+	}
+
+	return foo, nil
+}
+
+// PatchFoo only updates the columns set in patch, which must not be empty. Versions work as in UpdateFoo.
+func (fooRepo *FooRepo) PatchFoo(ctx context.Context, fooId int64, patch *models.FooPatch, version int) (foo *models.Foo, err error) {
+	if patch.IsEmpty() {
+		return nil, errors.New("Error: HGB5CV - The foo patch is empty.")
+	}
+
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args := fooPatchQuery(orgId, userId, fooId, patch, version)
+
+	foo = &models.Foo{}
+	err = scanFoo((*fooRepo.db).QueryRow(ctx, sql, args...), foo)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.Wrap(err, fmt.Sprintf("Error: BATWXG - No foo found with given ID: %d", fooId))
+			return nil, fooRepo.noFooUpdatedError(ctx, fooId, version)
 		}
-		return nil, errors.Wrap(err, "Error: 2H6YX9 - Updating foo in database.")
+		return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: 4L9OX6 - Patching foo in database.")
 	}
 
 	return foo, nil
 }
+
+// PatchFoos applies each patch like PatchFoo in one batch. None of the patches may be empty.
+// A patch whose foo is missing or at another version fails on its own and the rest are still applied.
+func (fooRepo *FooRepo) PatchFoos(ctx context.Context, patches []models.FooBatchPatch) (items []models.FooBatchItem, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, patch := range patches {
+		sql, args := fooPatchQuery(orgId, userId, patch.ID, patch.Patch, patch.Version)
+		batch.Queue(sql, args...)
+	}
+
+	results := (*fooRepo.db).SendBatch(ctx, batch)
+
+	items = make([]models.FooBatchItem, len(patches))
+	for i, patch := range patches {
+		items[i].ID = patch.ID
+
+		foo := &models.Foo{}
+		if err := scanFoo(results.QueryRow(), foo); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			results.Close()
+			return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: FJT29G - Patching batch of foos in database.")
+		}
+		items[i].Foo = foo
+	}
+
+	if err := results.Close(); err != nil {
+		return nil, errors.Wrap(err, "Error: 23NBAN - Finishing batch of foo patches.")
+	}
+
+	// Work out why the patches that matched no row failed, now the batch is done with the connection.
+	for i, patch := range patches {
+		if items[i].Foo != nil {
+			continue
+		}
+		items[i].Err = fooRepo.noFooUpdatedError(ctx, patch.ID, patch.Version)
+
+		if kind := apperrors.KindOf(items[i].Err); kind != apperrors.KindNotFound && kind != apperrors.KindPreconditionFailed {
+			return nil, items[i].Err
+		}
+	}
+
+	return items, nil
+}
+
+// ExportFoos calls fn with each foo the user may see, in the order of their ids, and stops at the first error of fn.
+// The foos are read from a cursor a batch of models.FooExportBatchSize at a time, so only one batch is in memory. A
+// cursor only lives in a transaction, so the repo must run in one, see WithTx.
+func (fooRepo *FooRepo) ExportFoos(ctx context.Context, includeDeleted bool, fn func(foo *models.Foo) error) (err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	conditions := "org_id = $1 AND " + fooVisibleCondition(2)
+	if !includeDeleted {
+		conditions += " AND deleted_at = 0"
+	}
+	_, err = (*fooRepo.db).Exec(
+		ctx,
+		"DECLARE foo_export NO SCROLL CURSOR FOR SELECT "+fooColumns+" FROM foos WHERE "+conditions+" ORDER BY id;",
+		orgId,
+		userId,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: QSMDPV - Opening foo export cursor.")
+	}
+
+	for {
+		fetched, err := fooRepo.fetchFooExport(ctx, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < models.FooExportBatchSize {
+			break
+		}
+	}
+
+	if _, err := (*fooRepo.db).Exec(ctx, "CLOSE foo_export;"); err != nil {
+		return errors.Wrap(err, "Error: 7WCNNP - Closing foo export cursor.")
+	}
+
+	return nil
+}
+
+// fetchFooExport calls fn with the next batch of foos of the export cursor and returns how many there were.
+func (fooRepo *FooRepo) fetchFooExport(ctx context.Context, fn func(foo *models.Foo) error) (fetched int, err error) {
+	rows, err := (*fooRepo.db).Query(ctx, fmt.Sprintf("FETCH %d FROM foo_export;", models.FooExportBatchSize))
+	if err != nil {
+		return 0, errors.Wrap(err, "Error: BD1ULR - Fetching foos from export cursor.")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		foo := models.Foo{}
+		if err := scanFoo(rows, &foo); err != nil {
+			return 0, errors.Wrap(err, "Error: C4I6O6 - Scanning row of exported foos.")
+		}
+		if err := fn(&foo); err != nil {
+			return 0, err
+		}
+		fetched++
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "Error: GJ7KD2 - Processing rows of exported foos.")
+	}
+
+	return fetched, nil
+}
+
+// ImportFoos inserts a foo owned by the user for each name, in order, and saves them as revisions like CreateFoos.
+// The names are loaded with COPY into a temporary table that is dropped at the end of the transaction, so the repo
+// must run in one, see WithTx.
+func (fooRepo *FooRepo) ImportFoos(ctx context.Context, names []string) (foos []models.Foo, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = (*fooRepo.db).Exec(ctx, "CREATE TEMPORARY TABLE foo_imports (line integer NOT NULL, name text NOT NULL) ON COMMIT DROP;")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: MKERPI - Creating foo import table.")
+	}
+
+	_, err = (*fooRepo.db).CopyFrom(
+		ctx,
+		pgx.Identifier{"foo_imports"},
+		[]string{"line", "name"},
+		pgx.CopyFromSlice(len(names), func(i int) ([]any, error) {
+			return []any{i, names[i]}, nil
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: NIP1AR - Copying foos into import table.")
+	}
+
+	rows, err := (*fooRepo.db).Query(
+		ctx,
+		fooRevisionQuery("INSERT INTO foos (org_id, owner_id, name) SELECT $1, $2, name FROM foo_imports ORDER BY line RETURNING "+fooColumns, 2),
+		orgId,
+		userId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: JJYARL - Inserting imported foos into database.")
+	}
+	defer rows.Close()
+
+	foos = []models.Foo{}
+	for rows.Next() {
+		foo := models.Foo{}
+		if err := scanFoo(rows, &foo); err != nil {
+			return nil, errors.Wrap(err, "Error: KR4LXY - Scanning row of imported foos.")
+		}
+		foos = append(foos, foo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(constraintError(err, fooConstraintFields), "Error: PKR9P8 - Processing rows of imported foos.")
+	}
+
+	return foos, nil
+}
+
+// fooPatchQuery builds the conditional UPDATE for a patch of a foo of the organization by the user, the patch must
+// not be empty. The patched foo is saved as a revision.
+func fooPatchQuery(orgId int, userId int, fooId int64, patch *models.FooPatch, version int) (sql string, args []interface{}) {
+	assignments := fooPatchAssignments(patch, &args)
+	args = append(args, fooId, orgId, version, userId)
+	sql = fooRevisionQuery(
+		fmt.Sprintf(
+			"UPDATE foos SET %s WHERE id = $%d AND org_id = $%d AND ($%d = 0 OR version = $%d) AND deleted_at = 0 RETURNING "+fooColumns,
+			strings.Join(assignments, ", "),
+			len(args)-3,
+			len(args)-2,
+			len(args)-1,
+			len(args)-1,
+		),
+		len(args),
+	)
+	return sql, args
+}
+
+// fooPatchAssignments builds the SET assignments for the fields in patch and appends their values to args.
+func fooPatchAssignments(patch *models.FooPatch, args *[]interface{}) (assignments []string) {
+	if patch.Name != nil {
+		*args = append(*args, *patch.Name)
+		assignments = append(assignments, fmt.Sprintf("name = $%d", len(*args)))
+	}
+	return assignments
+}
+
+// noFooUpdatedError works out why a conditional update of a foo matched no rows.
+func (fooRepo *FooRepo) noFooUpdatedError(ctx context.Context, fooId int64, version int) error {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var currentVersion int
+	err = (*fooRepo.db).QueryRow(
+		ctx,
+		"SELECT version FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;",
+		fooId,
+		orgId,
+	).Scan(&currentVersion)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperrors.NotFound("BATWXG", fmt.Sprintf("No foo found with id %d.", fooId))
+		}
+		return errors.Wrap(err, "Error: EYFOM1 - Getting foo version from database.")
+	}
+
+	return apperrors.PreconditionFailed("PG3L6Q", fmt.Sprintf("Foo %d is at version %d, not %d.", fooId, currentVersion, version))
+}
@@ -1,19 +1,56 @@
 // myapp/repository/repository_test.go
-package repos
+package repos_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap/zaptest"
 
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
 	"gitlab.com/sandstone2/fiberpoc/common/mocks"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/tenant"
 )
 
+// orgId is the organization the foo queries are scoped to.
+const orgId = 7
+
+// userId is the user the foo queries run for.
+const userId = 5
+
+// orgCtx is a request context of the userId user in the orgId organization.
+var orgCtx = tenant.WithUserID(tenant.WithOrgID(context.Background(), orgId), userId)
+
+// fooVisible is the condition for the foos the user in $2 may see.
+const fooVisible = "(owner_id IS NULL OR owner_id = $2 OR EXISTS (SELECT 1 FROM foo_shares WHERE foo_shares.foo_id = foos.id " +
+	"AND (foo_shares.user_id = $2 OR foo_shares.role_id IN (SELECT role_id FROM user_roles WHERE user_id = $2))))"
+
+// fooWritable is the condition for the foos the user in $2 may change.
+const fooWritable = "(owner_id IS NULL OR owner_id = $2 OR EXISTS (SELECT 1 FROM foo_shares WHERE foo_shares.foo_id = foos.id " +
+	"AND foo_shares.permission = 'write' " +
+	"AND (foo_shares.user_id = $2 OR foo_shares.role_id IN (SELECT role_id FROM user_roles WHERE user_id = $2))))"
+
+// fooScan returns a Scan stub that fills the fooColumns destinations from foo.
+func fooScan(foo models.Foo) func(dest ...any) error {
+	return func(dest ...any) error {
+		*(dest[0].(*int)) = foo.ID
+		*(dest[1].(*string)) = foo.Name
+		*(dest[2].(*int)) = foo.Version
+		*(dest[3].(*int64)) = foo.CreatedAt
+		*(dest[4].(*int64)) = foo.UpdatedAt
+		*(dest[5].(*int64)) = foo.DeletedAt
+		*(dest[6].(**int)) = foo.OwnerID
+		return nil
+	}
+}
+
 func TestFooRepo_GetFoos_Success(t *testing.T) {
 	// Create a new GoMock controller.
 	ctrl := gomock.NewController(t)
@@ -27,9 +64,9 @@ func TestFooRepo_GetFoos_Success(t *testing.T) {
 
 	// Set expectation for the mock pgx pool.
 	// Make sure the right query is called.
-	const expectedQuery = "SELECT id, name FROM foos ORDER BY id;"
+	const expectedQuery = "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE org_id = $1 AND " + fooVisible + " AND deleted_at = 0 ORDER BY id ASC LIMIT $3;"
 	mockPool.EXPECT().
-		Query(gomock.Any(), expectedQuery).
+		Query(gomock.Any(), expectedQuery, orgId, userId, 51).
 		Return(mockRows, nil)
 
 	// Set expectations for the mock pgx rows.
@@ -38,13 +75,8 @@ func TestFooRepo_GetFoos_Success(t *testing.T) {
 	mockRows.EXPECT().Next().Return(true)
 	// When Scan() is called, we simulate scanning a row with ID = 1 and Name = "Foo One".
 	mockRows.EXPECT().
-		Scan(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(dest ...interface{}) error {
-			// Set dest[0] (pointer to int) to 1 and dest[1] (pointer to string) to "Foo One".
-			*(dest[0].(*int)) = 1
-			*(dest[1].(*string)) = "Joe"
-			return nil
-		})
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Joe", CreatedAt: 1700000000000}))
 	// After the one row, Next() returns false.
 	mockRows.EXPECT().Next().Return(false)
 	// rows.Err() returns nil.
@@ -56,18 +88,22 @@ func TestFooRepo_GetFoos_Success(t *testing.T) {
 	logger := zaptest.NewLogger(t)
 
 	// Create a ne foo repo
-	fooRepo := NewFooRepository(mockPool, logger)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
 	// Call the GetFoos function under test.
-	foos, err := fooRepo.GetFoos()
+	params := &models.FooListParams{}
+	params.ApplyDefaults()
+	foos, nextCursor, err := fooRepo.GetFoos(orgCtx, params)
 	require.NoError(t, err, "GetFoos should not return an error.")
+	require.Empty(t, nextCursor, "there should be no next page.")
 	require.NotNil(t, foos, "foos should not be nil.")
 	require.Len(t, *foos, 1, "foos should be length 1.")
 
 	// Verify that the foos slice contains the expected result.
 	expectedFoo := models.Foo{
-		ID:   1,
-		Name: "Joe",
+		ID:        1,
+		Name:      "Joe",
+		CreatedAt: 1700000000000,
 	}
 	require.Equal(t, expectedFoo, (*foos)[0], "foo returned should be correct.")
 }
@@ -89,37 +125,40 @@ func TestFooRepo_GetFoos_Error(t *testing.T) {
 	// Set expectation for the mock pgx pool.
 	// Make sure the right query is called.
 	mockPool.EXPECT().
-		Query(gomock.Any(), "SELECT id, name FROM foos ORDER BY id;").
+		Query(gomock.Any(), "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE org_id = $1 AND "+fooVisible+" AND deleted_at = 0 ORDER BY id ASC LIMIT $3;", orgId, userId, 51).
 		Return(mockRows, errors.New("query failed"))
 
 	logger := zaptest.NewLogger(t)
-	fooRepo := NewFooRepository(mockPool, logger)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
+
+	params := &models.FooListParams{}
+	params.ApplyDefaults()
 
 	// Call under test
-	_, err := fooRepo.GetFoos()
+	_, _, err := fooRepo.GetFoos(orgCtx, params)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "30UUBR", "error should be wrapped with 30UUBR code")
 
 	// 2) Test mockRows.Scan failed
 	mockPool.EXPECT().
-		Query(gomock.Any(), "SELECT id, name FROM foos ORDER BY id;").
+		Query(gomock.Any(), "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE org_id = $1 AND "+fooVisible+" AND deleted_at = 0 ORDER BY id ASC LIMIT $3;", orgId, userId, 51).
 		Return(mockRows, nil)
 
 	mockRows.EXPECT().Next().Return(true)
 	// When Scan() is called, we simulate scanning a row with ID = 1 and Name = "Foo One".
 	mockRows.EXPECT().
-		Scan(gomock.Any(), gomock.Any()).
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(errors.New("scan failed"))
 
 	mockRows.EXPECT().Close()
 
-	_, err = fooRepo.GetFoos()
+	_, _, err = fooRepo.GetFoos(orgCtx, params)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "YN80XB", "error should be wrapped with YN80XB code")
 
 	// 3) Test mockRows.Err failed
 	mockPool.EXPECT().
-		Query(gomock.Any(), "SELECT id, name FROM foos ORDER BY id;").
+		Query(gomock.Any(), "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE org_id = $1 AND "+fooVisible+" AND deleted_at = 0 ORDER BY id ASC LIMIT $3;", orgId, userId, 51).
 		Return(mockRows, nil)
 
 	// Set expectations for the mock pgx rows.
@@ -128,13 +167,8 @@ func TestFooRepo_GetFoos_Error(t *testing.T) {
 	mockRows.EXPECT().Next().Return(true)
 	// When Scan() is called, we simulate scanning a row with ID = 1 and Name = "Foo One".
 	mockRows.EXPECT().
-		Scan(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(dest ...interface{}) error {
-			// Set dest[0] (pointer to int) to 1 and dest[1] (pointer to string) to "Foo One".
-			*(dest[0].(*int)) = 1
-			*(dest[1].(*string)) = "Joe"
-			return nil
-		})
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Joe", CreatedAt: 1700000000000}))
 	// After the one row, Next() returns false.
 	mockRows.EXPECT().Next().Return(false)
 	// rows.Err() returns nil.
@@ -142,213 +176,1469 @@ func TestFooRepo_GetFoos_Error(t *testing.T) {
 	// rows.Close() is called.
 	mockRows.EXPECT().Close()
 
-	_, err = fooRepo.GetFoos()
+	_, _, err = fooRepo.GetFoos(orgCtx, params)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "XV4HHL", "error should be wrapped with XV4HHL code")
 }
 
-func TestFooRepo_CreateFoo_Success(t *testing.T) {
+func TestFooRepo_GetFoos_Keyset(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Create the mock pgx pool
 	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
 
-	// Create the mock Row
-	mockRow := mocks.NewMockPgxRow(ctrl)
+	// Continue a name descending listing after "Bob" with id 7, filtered by prefix and contains.
+	cursor := models.EncodeFooCursor(&models.FooCursor{SortBy: "name", Order: "desc", Value: "Bob", ID: 7})
+	params := &models.FooListParams{
+		Limit:        2,
+		Cursor:       cursor,
+		SortBy:       "name",
+		Order:        "desc",
+		NamePrefix:   "b",
+		NameContains: "50%",
+	}
 
-	// Set up the expected query
 	mockPool.EXPECT().
-		QueryRow(
+		Query(
 			gomock.Any(),
-			"INSERT INTO foos (name) VALUES ($1) RETURNING id, name;",
-			"Test Foo",
+			"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE org_id = $1 AND "+fooVisible+" AND deleted_at = 0 AND name ILIKE $3 AND name ILIKE $4 AND (name, id) < ($5, $6) ORDER BY name DESC, id DESC LIMIT $7;",
+			orgId, userId, "b%", `%50\%%`, "Bob", 7, 3,
 		).
+		Return(mockRows, nil)
+
+	// Return three rows for a limit of two so there is a next page.
+	names := []string{"Bo 50%", "Bb 50%", "Ba 50%"}
+	for i, name := range names {
+		id, name := i+1, name
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: id, Name: name, CreatedAt: 1700000000000}))
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
+
+	foos, nextCursor, err := fooRepo.GetFoos(orgCtx, params)
+	require.NoError(t, err)
+	require.Len(t, *foos, 2, "the extra row should be trimmed.")
+
+	// The next cursor points at the last foo on this page.
+	decoded, err := models.DecodeFooCursor(nextCursor)
+	require.NoError(t, err)
+	require.Equal(t, &models.FooCursor{SortBy: "name", Order: "desc", Value: "Bb 50%", ID: 2}, decoded)
+}
+
+func TestFooRepo_CountFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	mockPool.EXPECT().
+		QueryRow(gomock.Any(), "SELECT count(*) FROM foos WHERE org_id = $1 AND "+fooVisible+" AND name ILIKE $3;", orgId, userId, "Jo%").
 		Return(mockRow)
 
-	// Mock the scan to populate our expected result
 	mockRow.EXPECT().
 		Scan(gomock.Any()).
 		DoAndReturn(func(dest ...any) error {
-			id := dest[0].(*int)
-			name := dest[1].(*string)
-			*id = 1
-			*name = "Test Foo"
+			*(dest[0].(*int64)) = 12
 			return nil
 		})
 
-	// Create logger and FooRepo
 	logger := zaptest.NewLogger(t)
-	fooRepo := NewFooRepository(mockPool, logger)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
-	// Act
-	foo, err := fooRepo.CreateFoo("Test Foo")
-
-	// Assert
+	total, err := fooRepo.CountFoos(orgCtx, &models.FooListParams{NamePrefix: "Jo", IncludeDeleted: true})
 	require.NoError(t, err)
-	require.NotNil(t, foo)
-	require.Equal(t, 1, foo.ID)
-	require.Equal(t, "Test Foo", foo.Name)
+	require.Equal(t, int64(12), total)
 }
 
-func TestFooRepo_CreateFoo_Error(t *testing.T) {
+func TestFooRepo_CountFoos_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Create the mock pgx pool
 	mockPool := mocks.NewMockPgxPool(ctrl)
-
-	// Simulate QueryRow().Scan() returning an error
 	mockRow := mocks.NewMockPgxRow(ctrl)
+
 	mockPool.EXPECT().
-		QueryRow(
-			gomock.Any(),
-			"INSERT INTO foos (name) VALUES ($1) RETURNING id, name;",
-			"Bad Foo",
-		).
+		QueryRow(gomock.Any(), "SELECT count(*) FROM foos WHERE org_id = $1 AND "+fooVisible+" AND deleted_at = 0;", orgId, userId).
 		Return(mockRow)
 
 	mockRow.EXPECT().
-		Scan(gomock.Any(), gomock.Any()).
-		Return(errors.New("scan failed"))
+		Scan(gomock.Any()).
+		Return(errors.New("count failed"))
 
 	logger := zaptest.NewLogger(t)
-	fooRepo := NewFooRepository(mockPool, logger)
-
-	// Call under test
-	foo, err := fooRepo.CreateFoo("Bad Foo")
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
-	require.Nil(t, foo)
+	total, err := fooRepo.CountFoos(orgCtx, &models.FooListParams{})
+	require.Equal(t, int64(0), total)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "WOPUDO", "should wrap with correct error code")
+	require.Contains(t, err.Error(), "KNMN02", "error should be wrapped with KNMN02 code")
 }
 
-func TestFooRepo_DeleteFoos_Success(t *testing.T) {
+func TestFooRepo_SearchFoos_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Create the mock pool interface
 	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	mockPool.EXPECT().
+		Query(
+			gomock.Any(),
+			"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id, "+
+				"ts_rank(search, query) + word_similarity($1, name) AS rank, "+
+				"ts_headline('english', replace(replace(replace(name, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query) "+
+				"FROM foos, websearch_to_tsquery('english', $1) query "+
+				"WHERE org_id = $2 AND deleted_at = 0 AND (search @@ query OR $1 <% name) AND "+
+				"(owner_id IS NULL OR owner_id = $3 OR EXISTS (SELECT 1 FROM foo_shares WHERE foo_shares.foo_id = foos.id "+
+				"AND (foo_shares.user_id = $3 OR foo_shares.role_id IN (SELECT role_id FROM user_roles WHERE user_id = $3)))) "+
+				"ORDER BY rank DESC, id LIMIT $4;",
+			"party",
+			orgId,
+			userId,
+			20,
+		).
+		Return(mockRows, nil)
+
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			require.NoError(t, fooScan(models.Foo{ID: 3, Name: "Garden Party", Version: 1})(dest[:7]...))
+			*(dest[7].(*float64)) = 1.06
+			*(dest[8].(*string)) = "Garden <b>Party</b>"
+			return nil
+		})
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
+
+	results, err := fooRepo.SearchFoos(orgCtx, &models.FooSearchParams{Query: "party", Limit: 20})
+	require.NoError(t, err)
+	require.Equal(t, []models.FooSearchResult{
+		{Foo: models.Foo{ID: 3, Name: "Garden Party", Version: 1}, Rank: 1.06, Snippet: "Garden <b>Party</b>"},
+	}, results)
+}
+
+func TestFooRepo_SearchFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test a context without a user
+	results, err := fooRepo.SearchFoos(tenant.WithOrgID(context.Background(), orgId), &models.FooSearchParams{Query: "party", Limit: 20})
+	require.Nil(t, results)
+	require.Contains(t, err.Error(), "5ANHWU")
+
+	// 2) Test the query failing
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), "party", orgId, userId, 20).
+		Return(nil, errors.New("query failed"))
+
+	results, err = fooRepo.SearchFoos(orgCtx, &models.FooSearchParams{Query: "party", Limit: 20})
+	require.Nil(t, results)
+	require.Contains(t, err.Error(), "8UOMFW")
+
+	// 3) Test the scan failing
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), "party", orgId, userId, 20).
+		Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	results, err = fooRepo.SearchFoos(orgCtx, &models.FooSearchParams{Query: "party", Limit: 20})
+	require.Nil(t, results)
+	require.Contains(t, err.Error(), "A3A5U0")
+}
+
+func TestFooRepo_GetFooByID_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
 
-	// Expect the DELETE SQL call
+	// Set expected query
 	mockPool.
 		EXPECT().
-		Exec(
+		QueryRow(
 			gomock.Any(),
-			"DELETE FROM foos;",
+			"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;",
+			int64(1),
+			orgId,
 		).
-		Return(pgconn.NewCommandTag("DELETE 5"), nil)
+		Return(mockRow)
+
+	// Simulate Scan populating values
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Joe", CreatedAt: 1700000000000, UpdatedAt: 1700000000001}))
 
 	logger := zaptest.NewLogger(t)
-	repo := NewFooRepository(mockPool, logger)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
 	// Act
-	rowsAffected, err := repo.DeleteFoos()
+	foo, err := fooRepo.GetFooByID(orgCtx, 1)
 
 	// Assert
-	require.NoError(t, err, "DeleteFoos should not return error")
-	require.Equal(t, int64(5), rowsAffected, "should report 5 rows deleted")
+	require.NoError(t, err)
+	require.Equal(t, &models.Foo{ID: 1, Name: "Joe", CreatedAt: 1700000000000, UpdatedAt: 1700000000001}, foo)
 }
 
-func TestFooRepo_DeleteFoos_Error(t *testing.T) {
+func TestFooRepo_GetFooByID_Error(t *testing.T) {
+	// There are two different error paths
+
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Create the mock pool interface
 	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
-	// Simulate Exec returning an error
+	// 1) Test no row found
 	mockPool.
 		EXPECT().
-		Exec(
+		QueryRow(gomock.Any(), "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;", int64(99), orgId).
+		Return(mockRow)
+
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	foo, err := fooRepo.GetFooByID(orgCtx, 99)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err), "error should be a not found error")
+	require.Contains(t, err.Error(), "39YZ4S", "error should have 39YZ4S code")
+
+	// 2) Test Scan failed
+	mockPool.
+		EXPECT().
+		QueryRow(gomock.Any(), "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;", int64(99), orgId).
+		Return(mockRow)
+
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+
+	foo, err = fooRepo.GetFooByID(orgCtx, 99)
+	require.Nil(t, foo)
+	require.Error(t, err)
+	require.NotEqual(t, apperrors.KindNotFound, apperrors.KindOf(err), "error should not be a not found error")
+	require.Contains(t, err.Error(), "3EM1A7", "error should be wrapped with 3EM1A7 code")
+}
+
+func TestFooRepo_GetFoosForUpdate_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	// Soft deleted foos are locked too
+	mockPool.
+		EXPECT().
+		Query(
 			gomock.Any(),
-			"DELETE FROM foos;",
+			"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE id = ANY($1) AND org_id = $2 FOR UPDATE;",
+			[]int64{1, 2, 3},
+			orgId,
 		).
-		Return(pgconn.CommandTag{}, errors.New("exec failed"))
+		Return(mockRows, nil)
+
+	// Foo 3 is not in the organization so it has no row
+	for _, foo := range []models.Foo{{ID: 1, Name: "Foo One"}, {ID: 2, Name: "Foo Two", DeletedAt: 1700000000000}} {
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(foo))
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
 
 	logger := zaptest.NewLogger(t)
-	fooRepo := NewFooRepository(mockPool, logger)
+	repo := repos.NewFooRepository(mockPool, logger)
 
-	// Call under test
-	rowsAffected, err := fooRepo.DeleteFoos()
+	foos, err := repo.GetFoosForUpdate(orgCtx, []int64{1, 2, 3})
+	require.NoError(t, err)
+	require.Equal(t, map[int64]models.Foo{1: {ID: 1, Name: "Foo One"}, 2: {ID: 2, Name: "Foo Two", DeletedAt: 1700000000000}}, foos)
+}
 
-	require.Equal(t, int64(0), rowsAffected, "should return zero rows on error")
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "1BLNNL", "error should be wrapped with 1BLNNL code")
+func TestFooRepo_GetFoosForUpdate_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test the query failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), []int64{1}, orgId).Return(nil, errors.New("query failed"))
+
+	foos, err := repo.GetFoosForUpdate(orgCtx, []int64{1})
+	require.Nil(t, foos)
+	require.Contains(t, err.Error(), "GTQIH2")
+
+	// 2) Test the scan failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), []int64{1}, orgId).Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	foos, err = repo.GetFoosForUpdate(orgCtx, []int64{1})
+	require.Nil(t, foos)
+	require.Contains(t, err.Error(), "S0RNL2")
 }
 
-func TestFooRepo_UpdateFoo_Success(t *testing.T) {
+func TestFooRepo_CreateFoo_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	// Create mocks
+	// Create the mock pgx pool
 	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	// Create the mock Row
 	mockRow := mocks.NewMockPgxRow(ctrl)
 
-	// Set expected query
-	mockPool.
-		EXPECT().
+	// Set up the expected query
+	mockPool.EXPECT().
 		QueryRow(
 			gomock.Any(),
-			"UPDATE foos SET name = $1 WHERE id = $2 RETURNING id, name;",
-			"Updated Foo",
-			int64(1),
+			"WITH foo AS (INSERT INTO foos (org_id, owner_id, name) VALUES ($1, $2, $3) RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+				"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $2 FROM foo) "+
+				"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+			orgId,
+			userId,
+			"Test Foo",
 		).
 		Return(mockRow)
 
-	// Simulate Scan populating values
+	// Mock the scan to populate our expected result
 	mockRow.EXPECT().
-		Scan(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(dest ...any) error {
-			*(dest[0].(*int)) = 1
-			*(dest[1].(*string)) = "Updated Foo"
-			return nil
-		})
+		Scan(gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Test Foo", CreatedAt: 1700000000000}))
 
+	// Create logger and FooRepo
 	logger := zaptest.NewLogger(t)
-	repo := NewFooRepository(mockPool, logger)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
 	// Act
-	foo, err := repo.UpdateFoo(1, "Updated Foo")
+	foo, err := fooRepo.CreateFoo(orgCtx, "Test Foo")
 
 	// Assert
 	require.NoError(t, err)
 	require.NotNil(t, foo)
 	require.Equal(t, 1, foo.ID)
-	require.Equal(t, "Updated Foo", foo.Name)
+	require.Equal(t, "Test Foo", foo.Name)
+	require.Equal(t, int64(1700000000000), foo.CreatedAt)
 }
 
-func TestFooRepo_UpdateFoo_Error(t *testing.T) {
+func TestFooRepo_CreateFoo_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	// Create the mock pgx pool
 	mockPool := mocks.NewMockPgxPool(ctrl)
-	mockRow := mocks.NewMockPgxRow(ctrl)
 
 	// Simulate QueryRow().Scan() returning an error
-	mockPool.
-		EXPECT().
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	mockPool.EXPECT().
 		QueryRow(
 			gomock.Any(),
-			"UPDATE foos SET name = $1 WHERE id = $2 RETURNING id, name;",
-			"Bad Name",
-			int64(99),
+			"WITH foo AS (INSERT INTO foos (org_id, owner_id, name) VALUES ($1, $2, $3) RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+				"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $2 FROM foo) "+
+				"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+			orgId,
+			userId,
+			"Bad Foo",
 		).
 		Return(mockRow)
 
-	mockRow.
-		EXPECT().
-		Scan(gomock.Any(), gomock.Any()).
-		Return(errors.New("update failed"))
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
 
 	logger := zaptest.NewLogger(t)
-	repo := NewFooRepository(mockPool, logger)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
 
-	// Act
-	foo, err := repo.UpdateFoo(99, "Bad Name")
+	// Call under test
+	foo, err := fooRepo.CreateFoo(orgCtx, "Bad Foo")
 
-	// Assert
 	require.Nil(t, foo)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "2H6YX9", "error should be wrapped with 2H6YX9 code")
+	require.Contains(t, err.Error(), "WOPUDO", "should wrap with correct error code")
+}
+
+func TestFooRepo_CreateFoo_ConstraintViolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
+
+	tests := []struct {
+		pgError *pgconn.PgError
+		code    string
+		rule    string
+	}{
+		{&pgconn.PgError{Code: "23514", ConstraintName: "foos_name_not_blank"}, "JRTRMB", "check"},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "foos_name_key", ColumnName: "name"}, "LKSTDT", "unique"},
+		{&pgconn.PgError{Code: "22001"}, "1G36FP", "max"},
+	}
+
+	for _, test := range tests {
+		mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), orgId, userId, " ").Return(mockRow)
+		mockRow.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(test.pgError)
+
+		foo, err := fooRepo.CreateFoo(orgCtx, " ")
+		require.Nil(t, foo)
+		require.Contains(t, err.Error(), "WOPUDO")
+
+		appError, ok := apperrors.From(err)
+		require.True(t, ok, test.code)
+		require.Equal(t, apperrors.KindValidation, appError.Kind)
+		require.Equal(t, test.code, appError.Code)
+		require.Len(t, appError.Fields, 1)
+		require.Equal(t, test.rule, appError.Fields[0].Code)
+	}
+
+	// The constraint is mapped to its field, or the column is used
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), orgId, userId, " ").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&pgconn.PgError{Code: "23514", ConstraintName: "foos_name_not_blank"})
+
+	_, err := fooRepo.CreateFoo(orgCtx, " ")
+	appError, _ := apperrors.From(err)
+	require.Equal(t, "name", appError.Fields[0].Field)
+}
+
+func TestFooRepo_DeleteFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create the mock pool interface
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	// Expect a soft delete that returns the foos as they were before
+	mockPool.
+		EXPECT().
+		Query(
+			gomock.Any(),
+			"WITH deleted AS (SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos "+
+				"WHERE org_id = $1 AND "+fooWritable+" AND deleted_at = 0 FOR UPDATE) "+
+				"UPDATE foos SET deleted_at = current_epoch_milliseconds() FROM deleted WHERE foos.id = deleted.id "+
+				"RETURNING deleted.id, deleted.name, deleted.version, deleted.created_at, deleted.updated_at, deleted.deleted_at, deleted.owner_id;",
+			orgId,
+			userId,
+		).
+		Return(mockRows, nil)
+
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Foo One", Version: 2}))
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	deleted, err := repo.DeleteFoos(orgCtx)
+
+	// Assert
+	require.NoError(t, err, "DeleteFoos should not return error")
+	require.Equal(t, []models.Foo{{ID: 1, Name: "Foo One", Version: 2}}, deleted)
+}
+
+func TestFooRepo_DeleteFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create the mock pool interface
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	fooRepo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test the update failing
+	mockPool.
+		EXPECT().
+		Query(gomock.Any(), gomock.Any(), orgId, userId).
+		Return(nil, errors.New("update failed"))
+
+	deleted, err := fooRepo.DeleteFoos(orgCtx)
+	require.Nil(t, deleted, "should return no foos on error")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1BLNNL", "error should be wrapped with 1BLNNL code")
+
+	// 2) Test the scan failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), orgId, userId).Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	deleted, err = fooRepo.DeleteFoos(orgCtx)
+	require.Nil(t, deleted)
+	require.Contains(t, err.Error(), "QDVWV7")
+}
+
+func TestFooRepo_DeleteFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	// Expect a soft delete, not a DELETE
+	mockPool.
+		EXPECT().
+		Exec(
+			gomock.Any(),
+			"UPDATE foos SET deleted_at = current_epoch_milliseconds() WHERE id = $1 AND org_id = $2 AND deleted_at = 0;",
+			int64(3),
+			orgId,
+		).
+		Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	err := repo.DeleteFoo(orgCtx, 3)
+	require.NoError(t, err)
+}
+
+func TestFooRepo_DeleteFoo_Error(t *testing.T) {
+	// There are two different error paths
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test no live foo with the id
+	mockPool.
+		EXPECT().
+		Exec(gomock.Any(), gomock.Any(), int64(3), orgId).
+		Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+	err := repo.DeleteFoo(orgCtx, 3)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "PGZFG4")
+
+	// 2) Test Exec failed
+	mockPool.
+		EXPECT().
+		Exec(gomock.Any(), gomock.Any(), int64(3), orgId).
+		Return(pgconn.CommandTag{}, errors.New("exec failed"))
+
+	err = repo.DeleteFoo(orgCtx, 3)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "LHH54F", "error should be wrapped with LHH54F code")
+}
+
+func TestFooRepo_RestoreFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	mockPool.
+		EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"UPDATE foos SET deleted_at = 0 WHERE id = $1 AND org_id = $2 AND deleted_at > 0 RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id;",
+			int64(3),
+			orgId,
+		).
+		Return(mockRow)
+
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 3, Name: "Back Again", CreatedAt: 1700000000000, UpdatedAt: 1700000000001}))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	foo, err := repo.RestoreFoo(orgCtx, 3)
+	require.NoError(t, err)
+	require.Equal(t, &models.Foo{ID: 3, Name: "Back Again", CreatedAt: 1700000000000, UpdatedAt: 1700000000001}, foo)
+}
+
+func TestFooRepo_RestoreFoo_Error(t *testing.T) {
+	// There are two different error paths
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test no soft deleted foo with the id
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(3), orgId).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(pgx.ErrNoRows)
+
+	foo, err := repo.RestoreFoo(orgCtx, 3)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "1QTQCC")
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(3), orgId).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("scan failed"))
+
+	foo, err = repo.RestoreFoo(orgCtx, 3)
+	require.Nil(t, foo)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "HL55ZQ", "error should be wrapped with HL55ZQ code")
+}
+
+func TestFooRepo_PurgeFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	// Only soft deleted foos older than the cut off are hard deleted
+	mockPool.
+		EXPECT().
+		Query(
+			gomock.Any(),
+			"DELETE FROM foos WHERE org_id = $1 AND deleted_at > 0 AND deleted_at < $2 RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id;",
+			orgId,
+			int64(1700000000000),
+		).
+		Return(mockRows, nil)
+
+	for _, foo := range []models.Foo{{ID: 1, DeletedAt: 1600000000000}, {ID: 2, DeletedAt: 1600000000001}} {
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(foo))
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	purged, err := repo.PurgeFoos(orgCtx, 1700000000000)
+	require.NoError(t, err)
+	require.Equal(t, []models.Foo{{ID: 1, DeletedAt: 1600000000000}, {ID: 2, DeletedAt: 1600000000001}}, purged)
+}
+
+func TestFooRepo_PurgeFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	mockPool.
+		EXPECT().
+		Query(gomock.Any(), gomock.Any(), orgId, int64(1700000000000)).
+		Return(nil, errors.New("delete failed"))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	purged, err := repo.PurgeFoos(orgCtx, 1700000000000)
+	require.Nil(t, purged)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "CA7X0A", "error should be wrapped with CA7X0A code")
+}
+
+func TestFooRepo_UpdateFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create mocks
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	// Set expected query
+	mockPool.
+		EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"WITH foo AS (UPDATE foos SET name = $1 WHERE id = $2 AND org_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at = 0 "+
+				"RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+				"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $5 FROM foo) "+
+				"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+			"Updated Foo",
+			int64(1),
+			orgId,
+			2,
+			userId,
+		).
+		Return(mockRow)
+
+	// Simulate Scan populating values
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Updated Foo", Version: 3, CreatedAt: 1700000000000, UpdatedAt: 1700000000001}))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foo, err := repo.UpdateFoo(orgCtx, 1, "Updated Foo", 2)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, foo)
+	require.Equal(t, 1, foo.ID)
+	require.Equal(t, "Updated Foo", foo.Name)
+	require.Equal(t, 3, foo.Version)
+	require.Equal(t, int64(1700000000001), foo.UpdatedAt)
+}
+
+func TestFooRepo_UpdateFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	// Simulate QueryRow().Scan() returning an error
+	mockPool.
+		EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"WITH foo AS (UPDATE foos SET name = $1 WHERE id = $2 AND org_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at = 0 "+
+				"RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+				"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $5 FROM foo) "+
+				"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+			"Bad Name",
+			int64(99),
+			orgId,
+			0,
+			userId,
+		).
+		Return(mockRow)
+
+	mockRow.
+		EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("update failed"))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foo, err := repo.UpdateFoo(orgCtx, 99, "Bad Name", 0)
+
+	// Assert
+	require.Nil(t, foo)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "2H6YX9", "error should be wrapped with 2H6YX9 code")
+}
+
+func TestFooRepo_UpdateFoo_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	mockVersionRow := mocks.NewMockPgxRow(ctrl)
+
+	// Simulate the UPDATE matching no rows
+	mockPool.
+		EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"WITH foo AS (UPDATE foos SET name = $1 WHERE id = $2 AND org_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at = 0 "+
+				"RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+				"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $5 FROM foo) "+
+				"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+			"Some Name",
+			int64(99),
+			orgId,
+			4,
+			userId,
+		).
+		Return(mockRow)
+
+	mockRow.
+		EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	// And there is no live foo with the id at all
+	mockPool.
+		EXPECT().
+		QueryRow(gomock.Any(), "SELECT version FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;", int64(99), orgId).
+		Return(mockVersionRow)
+
+	mockVersionRow.
+		EXPECT().
+		Scan(gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foo, err := repo.UpdateFoo(orgCtx, 99, "Some Name", 4)
+
+	// Assert
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err), "error should be a not found error")
+	require.Contains(t, err.Error(), "id 99", "error should name the foo")
+	require.Contains(t, err.Error(), "BATWXG", "error should have BATWXG code")
+}
+
+func TestFooRepo_UpdateFoo_VersionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	mockVersionRow := mocks.NewMockPgxRow(ctrl)
+
+	// Simulate the conditional UPDATE matching no rows
+	mockPool.
+		EXPECT().
+		QueryRow(gomock.Any(), gomock.Any(), "Some Name", int64(5), orgId, 4, userId).
+		Return(mockRow)
+
+	mockRow.
+		EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	// Because the foo has moved on to version 6
+	mockPool.
+		EXPECT().
+		QueryRow(gomock.Any(), "SELECT version FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;", int64(5), orgId).
+		Return(mockVersionRow)
+
+	mockVersionRow.
+		EXPECT().
+		Scan(gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int)) = 6
+			return nil
+		})
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foo, err := repo.UpdateFoo(orgCtx, 5, "Some Name", 4)
+
+	// Assert
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindPreconditionFailed, apperrors.KindOf(err), "error should be a precondition failed error")
+	require.Contains(t, err.Error(), "at version 6, not 4", "error should have both versions")
+	require.Contains(t, err.Error(), "PG3L6Q", "error should have PG3L6Q code")
+}
+
+func TestFooRepo_PatchFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	// Only the patched columns are set
+	mockPool.
+		EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"WITH foo AS (UPDATE foos SET name = $1 WHERE id = $2 AND org_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at = 0 "+
+				"RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+				"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $5 FROM foo) "+
+				"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+			"Patched Foo",
+			int64(1),
+			orgId,
+			2,
+			userId,
+		).
+		Return(mockRow)
+
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Patched Foo", Version: 3}))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	name := "Patched Foo"
+	foo, err := repo.PatchFoo(orgCtx, 1, &models.FooPatch{Name: &name}, 2)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "Patched Foo", foo.Name)
+	require.Equal(t, 3, foo.Version)
+}
+
+func TestFooRepo_PatchFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test an empty patch, the database must not be called
+	foo, err := repo.PatchFoo(orgCtx, 1, &models.FooPatch{}, 0)
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "HGB5CV", "error should have HGB5CV code")
+
+	// 2) Test the database failing
+	mockPool.
+		EXPECT().
+		QueryRow(gomock.Any(), gomock.Any(), "Bad Name", int64(99), orgId, 0, userId).
+		Return(mockRow)
+
+	mockRow.
+		EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("update failed"))
+
+	name := "Bad Name"
+	foo, err = repo.PatchFoo(orgCtx, 99, &models.FooPatch{Name: &name}, 0)
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "4L9OX6", "error should be wrapped with 4L9OX6 code")
+}
+
+func TestFooRepo_CreateFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockResults := mocks.NewMockPgxBatchResults(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	// One INSERT is queued per name
+	mockPool.
+		EXPECT().
+		SendBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, batch *pgx.Batch) *mocks.MockPgxBatchResults {
+			require.Equal(t, 2, batch.Len())
+			require.Equal(
+				t,
+				"WITH foo AS (INSERT INTO foos (org_id, owner_id, name) VALUES ($1, $2, $3) RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+					"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $2 FROM foo) "+
+					"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+				batch.QueuedQueries[0].SQL,
+			)
+			require.Equal(t, []any{orgId, userId, "Foo Two"}, batch.QueuedQueries[1].Arguments)
+			return mockResults
+		})
+
+	mockResults.EXPECT().QueryRow().Return(mockRow).Times(2)
+	gomock.InOrder(
+		mockRow.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Foo One", Version: 1})),
+		mockRow.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 2, Name: "Foo Two", Version: 1})),
+	)
+	mockResults.EXPECT().Close().Return(nil)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foos, err := repo.CreateFoos(orgCtx, []string{"Foo One", "Foo Two"})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, *foos, 2)
+	require.Equal(t, "Foo Two", (*foos)[1].Name)
+}
+
+func TestFooRepo_CreateFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockResults := mocks.NewMockPgxBatchResults(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	// The first insert fails so the batch is closed without reading the rest
+	mockPool.EXPECT().SendBatch(gomock.Any(), gomock.Any()).Return(mockResults)
+	mockResults.EXPECT().QueryRow().Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("insert failed"))
+	mockResults.EXPECT().Close().Return(errors.New("insert failed"))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foos, err := repo.CreateFoos(orgCtx, []string{"Foo One", "Foo Two"})
+
+	// Assert
+	require.Nil(t, foos)
+	require.Contains(t, err.Error(), "DX63D2", "error should be wrapped with DX63D2 code")
+}
+
+func TestFooRepo_PatchFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockResults := mocks.NewMockPgxBatchResults(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	mockVersionRow := mocks.NewMockPgxRow(ctrl)
+
+	mockPool.
+		EXPECT().
+		SendBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, batch *pgx.Batch) *mocks.MockPgxBatchResults {
+			require.Equal(t, 2, batch.Len())
+			require.Equal(
+				t,
+				"WITH foo AS (UPDATE foos SET name = $1 WHERE id = $2 AND org_id = $3 AND ($4 = 0 OR version = $4) AND deleted_at = 0 "+
+					"RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+					"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $5 FROM foo) "+
+					"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+				batch.QueuedQueries[0].SQL,
+			)
+			require.Equal(t, []any{"Patched Foo", int64(2), orgId, 1, userId}, batch.QueuedQueries[1].Arguments)
+			return mockResults
+		})
+
+	// The first patch is applied and the second one matches no row
+	mockResults.EXPECT().QueryRow().Return(mockRow).Times(2)
+	gomock.InOrder(
+		mockRow.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Patched Foo", Version: 3})),
+		mockRow.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(pgx.ErrNoRows),
+	)
+	mockResults.EXPECT().Close().Return(nil)
+
+	// Because the second foo has moved on to version 2
+	mockPool.
+		EXPECT().
+		QueryRow(gomock.Any(), "SELECT version FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;", int64(2), orgId).
+		Return(mockVersionRow)
+	mockVersionRow.
+		EXPECT().
+		Scan(gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int)) = 2
+			return nil
+		})
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	name := "Patched Foo"
+	items, err := repo.PatchFoos(orgCtx, []models.FooBatchPatch{
+		{ID: 1, Version: 2, Patch: &models.FooPatch{Name: &name}},
+		{ID: 2, Version: 1, Patch: &models.FooPatch{Name: &name}},
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, 3, items[0].Foo.Version)
+	require.Nil(t, items[1].Foo)
+	require.Equal(t, apperrors.KindPreconditionFailed, apperrors.KindOf(items[1].Err))
+}
+
+func TestFooRepo_PatchFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockResults := mocks.NewMockPgxBatchResults(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	mockPool.EXPECT().SendBatch(gomock.Any(), gomock.Any()).Return(mockResults)
+	mockResults.EXPECT().QueryRow().Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("update failed"))
+	mockResults.EXPECT().Close().Return(errors.New("update failed"))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	name := "Patched Foo"
+	items, err := repo.PatchFoos(orgCtx, []models.FooBatchPatch{{ID: 1, Version: 2, Patch: &models.FooPatch{Name: &name}}})
+
+	// Assert
+	require.Nil(t, items)
+	require.Contains(t, err.Error(), "FJT29G", "error should be wrapped with FJT29G code")
+}
+
+func TestFooRepo_ExportFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	// The cursor has fewer foos than a batch so a single fetch reads them all
+	gomock.InOrder(
+		mockPool.EXPECT().
+			Exec(
+				gomock.Any(),
+				"DECLARE foo_export NO SCROLL CURSOR FOR SELECT id, name, version, created_at, updated_at, deleted_at, owner_id "+
+					"FROM foos WHERE org_id = $1 AND "+fooVisible+" AND deleted_at = 0 ORDER BY id;",
+				orgId,
+				userId,
+			).
+			Return(pgconn.NewCommandTag("DECLARE CURSOR"), nil),
+		mockPool.EXPECT().Query(gomock.Any(), "FETCH 500 FROM foo_export;").Return(mockRows, nil),
+		mockPool.EXPECT().Exec(gomock.Any(), "CLOSE foo_export;").Return(pgconn.NewCommandTag("CLOSE CURSOR"), nil),
+	)
+
+	mockRows.EXPECT().Next().Return(true).Times(2)
+	gomock.InOrder(
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Foo One", Version: 1})),
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 2, Name: "Foo Two", Version: 3})),
+	)
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	exported := []models.Foo{}
+	err := repo.ExportFoos(orgCtx, false, func(foo *models.Foo) error {
+		exported = append(exported, *foo)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []models.Foo{{ID: 1, Name: "Foo One", Version: 1}, {ID: 2, Name: "Foo Two", Version: 3}}, exported)
+}
+
+func TestFooRepo_ExportFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	noop := func(foo *models.Foo) error { return nil }
+
+	// 1) Test a context without a user
+	err := repo.ExportFoos(tenant.WithOrgID(context.Background(), orgId), false, noop)
+	require.Contains(t, err.Error(), "5ANHWU")
+
+	// 2) Test opening the cursor failing, deleted foos are included
+	mockPool.EXPECT().
+		Exec(
+			gomock.Any(),
+			"DECLARE foo_export NO SCROLL CURSOR FOR SELECT id, name, version, created_at, updated_at, deleted_at, owner_id "+
+				"FROM foos WHERE org_id = $1 AND "+fooVisible+" ORDER BY id;",
+			orgId,
+			userId,
+		).
+		Return(pgconn.CommandTag{}, errors.New("declare failed"))
+
+	err = repo.ExportFoos(orgCtx, true, noop)
+	require.Contains(t, err.Error(), "QSMDPV")
+
+	// 3) Test fetching failing
+	mockPool.EXPECT().Exec(gomock.Any(), gomock.Any(), orgId, userId).Return(pgconn.NewCommandTag("DECLARE CURSOR"), nil)
+	mockPool.EXPECT().Query(gomock.Any(), "FETCH 500 FROM foo_export;").Return(nil, errors.New("fetch failed"))
+
+	err = repo.ExportFoos(orgCtx, false, noop)
+	require.Contains(t, err.Error(), "BD1ULR")
+
+	// 4) Test fn failing stops the export with its error
+	mockPool.EXPECT().Exec(gomock.Any(), gomock.Any(), orgId, userId).Return(pgconn.NewCommandTag("DECLARE CURSOR"), nil)
+	mockPool.EXPECT().Query(gomock.Any(), "FETCH 500 FROM foo_export;").Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Foo One", Version: 1}))
+	mockRows.EXPECT().Close()
+
+	writeErr := errors.New("client went away")
+	err = repo.ExportFoos(orgCtx, false, func(foo *models.Foo) error { return writeErr })
+	require.ErrorIs(t, err, writeErr)
+}
+
+func TestFooRepo_ImportFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	gomock.InOrder(
+		mockPool.EXPECT().
+			Exec(gomock.Any(), "CREATE TEMPORARY TABLE foo_imports (line integer NOT NULL, name text NOT NULL) ON COMMIT DROP;").
+			Return(pgconn.NewCommandTag("CREATE TABLE"), nil),
+		mockPool.EXPECT().
+			CopyFrom(gomock.Any(), pgx.Identifier{"foo_imports"}, []string{"line", "name"}, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ pgx.Identifier, _ []string, rowSrc pgx.CopyFromSource) (int64, error) {
+				// The names are copied with their position so they are inserted in order
+				copied := [][]any{}
+				for rowSrc.Next() {
+					values, err := rowSrc.Values()
+					require.NoError(t, err)
+					copied = append(copied, values)
+				}
+				require.Equal(t, [][]any{{0, "Foo One"}, {1, "Foo Two"}}, copied)
+				return int64(len(copied)), nil
+			}),
+		mockPool.EXPECT().
+			Query(
+				gomock.Any(),
+				"WITH foo AS (INSERT INTO foos (org_id, owner_id, name) SELECT $1, $2, name FROM foo_imports ORDER BY line "+
+					"RETURNING id, name, version, created_at, updated_at, deleted_at, owner_id), "+
+					"revision AS (INSERT INTO foo_revisions (foo_id, version, name, created_by) SELECT id, version, name, $2 FROM foo) "+
+					"SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foo;",
+				orgId,
+				userId,
+			).
+			Return(mockRows, nil),
+	)
+
+	mockRows.EXPECT().Next().Return(true).Times(2)
+	gomock.InOrder(
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 1, Name: "Foo One", Version: 1})),
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooScan(models.Foo{ID: 2, Name: "Foo Two", Version: 1})),
+	)
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foos, err := repo.ImportFoos(orgCtx, []string{"Foo One", "Foo Two"})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, foos, 2)
+	require.Equal(t, "Foo Two", foos[1].Name)
+}
+
+func TestFooRepo_ImportFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test a context without an organization
+	foos, err := repo.ImportFoos(context.Background(), []string{"Foo One"})
+	require.Nil(t, foos)
+	require.Contains(t, err.Error(), "6V70UA")
+
+	// 2) Test creating the import table failing
+	mockPool.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(pgconn.CommandTag{}, errors.New("create failed"))
+
+	foos, err = repo.ImportFoos(orgCtx, []string{"Foo One"})
+	require.Nil(t, foos)
+	require.Contains(t, err.Error(), "MKERPI")
+
+	// 3) Test the copy failing
+	mockPool.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(pgconn.NewCommandTag("CREATE TABLE"), nil)
+	mockPool.EXPECT().CopyFrom(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), errors.New("copy failed"))
+
+	foos, err = repo.ImportFoos(orgCtx, []string{"Foo One"})
+	require.Nil(t, foos)
+	require.Contains(t, err.Error(), "NIP1AR")
+
+	// 4) Test the insert violating a constraint
+	mockPool.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(pgconn.NewCommandTag("CREATE TABLE"), nil)
+	mockPool.EXPECT().CopyFrom(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), orgId, userId).
+		Return(nil, &pgconn.PgError{Code: "23514", ConstraintName: "foos_name_not_blank"})
+
+	foos, err = repo.ImportFoos(orgCtx, []string{" "})
+	require.Nil(t, foos)
+	require.Equal(t, apperrors.KindValidation, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "JJYARL")
+}
+
+func TestFooRepo_DeleteFoosByID_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	mockPool.
+		EXPECT().
+		Query(
+			gomock.Any(),
+			"UPDATE foos SET deleted_at = current_epoch_milliseconds() WHERE id = ANY($1) AND org_id = $2 AND deleted_at = 0 RETURNING id;",
+			[]int64{1, 2},
+			orgId,
+		).
+		Return(mockRows, nil)
+
+	// Only foo 1 was deleted
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int64)) = 1
+			return nil
+		})
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	deletedIds, err := repo.DeleteFoosByID(orgCtx, []int64{1, 2})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []int64{1}, deletedIds)
+}
+
+func TestFooRepo_DeleteFoosByID_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	mockPool.
+		EXPECT().
+		Query(gomock.Any(), gomock.Any(), []int64{1, 2}, orgId).
+		Return(nil, errors.New("update failed"))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	deletedIds, err := repo.DeleteFoosByID(orgCtx, []int64{1, 2})
+
+	// Assert
+	require.Nil(t, deletedIds)
+	require.Contains(t, err.Error(), "MIQ8AE", "error should be wrapped with MIQ8AE code")
+}
+
+func TestFooRepo_WithTx(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The pool must not be used once the repo is in a transaction
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	mockTx.
+		EXPECT().
+		QueryRow(gomock.Any(), "SELECT id, name, version, created_at, updated_at, deleted_at, owner_id FROM foos WHERE id = $1 AND org_id = $2 AND deleted_at = 0;", int64(7), orgId).
+		Return(mockRow)
+
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooScan(models.Foo{ID: 7, Name: "Foo In Tx"}))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// Act
+	foo, err := repo.WithTx(mockTx).GetFooByID(orgCtx, 7)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "Foo In Tx", foo.Name)
+}
+
+func TestFooRepo_NoOrganization_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No query may run without an organization to scope it to
+	mockPool := mocks.NewMockPgxPool(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRepository(mockPool, logger)
+
+	// 1) Test reading a foo
+	foo, err := repo.GetFooByID(context.Background(), 7)
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "6V70UA")
+
+	// 2) Test deleting every foo
+	deleted, err := repo.DeleteFoos(context.Background())
+	require.Nil(t, deleted)
+	require.Contains(t, err.Error(), "6V70UA")
+
+	// 3) Test creating a foo without a user to own it
+	foo, err = repo.CreateFoo(tenant.WithOrgID(context.Background(), orgId), "No Owner")
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "5ANHWU")
 }
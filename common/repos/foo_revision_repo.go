@@ -0,0 +1,120 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_foo_revision_repo.go \
+  -package=mocks \
+  -mock_names=FooRevisionRepoInterface=MockFooRevisionRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  FooRevisionRepoInterface
+*/
+
+type FooRevisionRepoInterface interface {
+	GetFooRevisions(ctx context.Context, fooId int64) (revisions []models.FooRevision, err error)
+	GetFooRevision(ctx context.Context, fooId int64, version int) (revision *models.FooRevision, err error)
+	WithTx(tx interfaces.PgxTxInterface) FooRevisionRepoInterface
+}
+
+type FooRevisionRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+// NewFooRevisionRepository makes a foo revision repo. The revisions are written by the foo repo, this repo only
+// reads them.
+func NewFooRevisionRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *FooRevisionRepo {
+	return &FooRevisionRepo{db: &db, logger: logger}
+}
+
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (fooRevisionRepo *FooRevisionRepo) WithTx(tx interfaces.PgxTxInterface) FooRevisionRepoInterface {
+	return NewFooRevisionRepository(tx, fooRevisionRepo.logger)
+}
+
+// fooRevisionColumns are the columns of a revision selected as foo_revision in the order scanFooRevision reads them.
+const fooRevisionColumns = "foo_revision.foo_id, foo_revision.version, foo_revision.name, foo_revision.created_by, foo_revision.created_at"
+
+// scanFooRevision scans a row selected with fooRevisionColumns into revision.
+func scanFooRevision(row interfaces.PgxRowInterface, revision *models.FooRevision) error {
+	return row.Scan(&revision.FooID, &revision.Version, &revision.Name, &revision.CreatedBy, &revision.CreatedAt)
+}
+
+// GetFooRevisions returns the revisions of a foo of the organization, soft deleted or not, oldest first.
+func (fooRevisionRepo *FooRevisionRepo) GetFooRevisions(ctx context.Context, fooId int64) (revisions []models.FooRevision, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := (*fooRevisionRepo.db).Query(
+		ctx,
+		"SELECT "+fooRevisionColumns+" FROM foo_revisions foo_revision JOIN foos ON foos.id = foo_revision.foo_id "+
+			"WHERE foo_revision.foo_id = $1 AND foos.org_id = $2 ORDER BY foo_revision.version;",
+		fooId,
+		orgId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: RRL6WO - Querying foo revisions from database.")
+	}
+	defer rows.Close()
+
+	revisions = []models.FooRevision{}
+	for rows.Next() {
+		revision := models.FooRevision{}
+		if err := scanFooRevision(rows, &revision); err != nil {
+			return nil, errors.Wrap(err, "Error: JV0EXL - Scanning row of foo revisions from database.")
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: MAUXG8 - Processing rows of foo revisions from database.")
+	}
+
+	return revisions, nil
+}
+
+// GetFooRevision returns the revision of a foo of the organization written at version.
+func (fooRevisionRepo *FooRevisionRepo) GetFooRevision(ctx context.Context, fooId int64, version int) (revision *models.FooRevision, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	revision = &models.FooRevision{}
+	row := (*fooRevisionRepo.db).QueryRow(
+		ctx,
+		"SELECT "+fooRevisionColumns+" FROM foo_revisions foo_revision JOIN foos ON foos.id = foo_revision.foo_id "+
+			"WHERE foo_revision.foo_id = $1 AND foo_revision.version = $2 AND foos.org_id = $3;",
+		fooId,
+		version,
+		orgId,
+	)
+	err = scanFooRevision(row, revision)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("S2DUNB", fmt.Sprintf("No revision %d found for foo %d.", version, fooId))
+		}
+		return nil, errors.Wrap(err, "Error: YLZ7T7 - Getting foo revision from database.")
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,169 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+// fooRevisionScan returns a Scan stub that copies revision into the destinations of fooRevisionColumns.
+func fooRevisionScan(revision models.FooRevision) func(dest ...any) error {
+	return func(dest ...any) error {
+		*(dest[0].(*int64)) = revision.FooID
+		*(dest[1].(*int)) = revision.Version
+		*(dest[2].(*string)) = revision.Name
+		*(dest[3].(**int)) = revision.CreatedBy
+		*(dest[4].(*int64)) = revision.CreatedAt
+		return nil
+	}
+}
+
+func TestFooRevisionRepo_GetFooRevisions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	mockPool.EXPECT().
+		Query(
+			gomock.Any(),
+			"SELECT foo_revision.foo_id, foo_revision.version, foo_revision.name, foo_revision.created_by, foo_revision.created_at "+
+				"FROM foo_revisions foo_revision JOIN foos ON foos.id = foo_revision.foo_id "+
+				"WHERE foo_revision.foo_id = $1 AND foos.org_id = $2 ORDER BY foo_revision.version;",
+			int64(4),
+			orgId,
+		).
+		Return(mockRows, nil)
+
+	author := userId
+	revisions := []models.FooRevision{
+		{FooID: 4, Version: 1, Name: "Foo", CreatedBy: &author, CreatedAt: 1700000000000},
+		{FooID: 4, Version: 2, Name: "Bar", CreatedAt: 1700000000001},
+	}
+	for _, revision := range revisions {
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(fooRevisionScan(revision))
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRevisionRepository(mockPool, logger)
+
+	result, err := repo.GetFooRevisions(orgCtx, 4)
+	require.NoError(t, err)
+	require.Equal(t, revisions, result)
+}
+
+func TestFooRevisionRepo_GetFooRevisions_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRevisionRepository(mockPool, logger)
+
+	// 1) Test a context without an organization
+	revisions, err := repo.GetFooRevisions(context.Background(), 4)
+	require.Nil(t, revisions)
+	require.Contains(t, err.Error(), "6V70UA")
+
+	// 2) Test the query failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), int64(4), orgId).Return(nil, errors.New("query failed"))
+
+	revisions, err = repo.GetFooRevisions(orgCtx, 4)
+	require.Nil(t, revisions)
+	require.Contains(t, err.Error(), "RRL6WO")
+
+	// 3) Test the scan failing
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), int64(4), orgId).Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	revisions, err = repo.GetFooRevisions(orgCtx, 4)
+	require.Nil(t, revisions)
+	require.Contains(t, err.Error(), "JV0EXL")
+}
+
+func TestFooRevisionRepo_GetFooRevision_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"SELECT foo_revision.foo_id, foo_revision.version, foo_revision.name, foo_revision.created_by, foo_revision.created_at "+
+				"FROM foo_revisions foo_revision JOIN foos ON foos.id = foo_revision.foo_id "+
+				"WHERE foo_revision.foo_id = $1 AND foo_revision.version = $2 AND foos.org_id = $3;",
+			int64(4),
+			2,
+			orgId,
+		).
+		Return(mockRow)
+
+	revision := models.FooRevision{FooID: 4, Version: 2, Name: "Bar", CreatedAt: 1700000000001}
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(fooRevisionScan(revision))
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRevisionRepository(mockPool, logger)
+
+	result, err := repo.GetFooRevision(orgCtx, 4, 2)
+	require.NoError(t, err)
+	require.Equal(t, &revision, result)
+}
+
+func TestFooRevisionRepo_GetFooRevision_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooRevisionRepository(mockPool, logger)
+
+	// 1) Test a revision that does not exist
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(4), 9, orgId).Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	revision, err := repo.GetFooRevision(orgCtx, 4, 9)
+	require.Nil(t, revision)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "S2DUNB")
+
+	// 2) Test the query failing
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(4), 9, orgId).Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("query failed"))
+
+	revision, err = repo.GetFooRevision(orgCtx, 4, 9)
+	require.Nil(t, revision)
+	require.Contains(t, err.Error(), "YLZ7T7")
+}
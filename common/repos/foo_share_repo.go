@@ -0,0 +1,211 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_foo_share_repo.go \
+  -package=mocks \
+  -mock_names=FooShareRepoInterface=MockFooShareRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  FooShareRepoInterface
+*/
+
+type FooShareRepoInterface interface {
+	GetFooAccess(ctx context.Context, fooIds []int64) (access map[int64]string, err error)
+	GetFooShares(ctx context.Context, fooId int64) (shares []models.FooShare, err error)
+	CreateFooShare(ctx context.Context, fooId int64, request *models.FooShareRequest) (share *models.FooShare, err error)
+	DeleteFooShare(ctx context.Context, fooId int64, shareId int) (share *models.FooShare, err error)
+	WithTx(tx interfaces.PgxTxInterface) FooShareRepoInterface
+}
+
+type FooShareRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+// NewFooShareRepository makes a foo share repo, scoped to the organization and user of the context of each query.
+func NewFooShareRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *FooShareRepo {
+	return &FooShareRepo{db: &db, logger: logger}
+}
+
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (fooShareRepo *FooShareRepo) WithTx(tx interfaces.PgxTxInterface) FooShareRepoInterface {
+	return NewFooShareRepository(tx, fooShareRepo.logger)
+}
+
+// fooShareColumns are the columns of a share selected as foo_share and its role in the order scanFooShare reads them.
+const fooShareColumns = "foo_share.id, foo_share.foo_id, foo_share.user_id, roles.name, foo_share.permission, foo_share.created_at"
+
+// scanFooShare scans a row selected with fooShareColumns into share.
+func scanFooShare(row interfaces.PgxRowInterface, share *models.FooShare) error {
+	return row.Scan(&share.ID, &share.FooID, &share.UserID, &share.Role, &share.Permission, &share.CreatedAt)
+}
+
+// GetFooAccess returns the access the user has to each of the foos of the organization with the given ids, soft
+// deleted or not. It is one of the models.FooAccess values, or empty when the user may not even read the foo. Foos
+// that are not in the organization are left out.
+func (fooShareRepo *FooShareRepo) GetFooAccess(ctx context.Context, fooIds []int64) (access map[int64]string, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := fooUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The best share wins, max works because write sorts after read.
+	rows, err := (*fooShareRepo.db).Query(
+		ctx,
+		"SELECT id, CASE WHEN owner_id = $3 THEN 'owner' WHEN owner_id IS NULL THEN 'write' "+
+			"ELSE COALESCE((SELECT max(permission) FROM foo_shares WHERE foo_shares.foo_id = foos.id "+
+			"AND (foo_shares.user_id = $3 OR foo_shares.role_id IN (SELECT role_id FROM user_roles WHERE user_id = $3))), '') END "+
+			"FROM foos WHERE id = ANY($1) AND org_id = $2;",
+		fooIds,
+		orgId,
+		userId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 5TIF30 - Querying foo access from database.")
+	}
+	defer rows.Close()
+
+	access = map[int64]string{}
+	for rows.Next() {
+		var fooId int64
+		var fooAccess string
+		if err := rows.Scan(&fooId, &fooAccess); err != nil {
+			return nil, errors.Wrap(err, "Error: EYR8PB - Scanning row of foo access from database.")
+		}
+		access[fooId] = fooAccess
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: TWMFTZ - Processing rows of foo access from database.")
+	}
+
+	return access, nil
+}
+
+// GetFooShares returns the shares of a foo of the organization, oldest first.
+func (fooShareRepo *FooShareRepo) GetFooShares(ctx context.Context, fooId int64) (shares []models.FooShare, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := (*fooShareRepo.db).Query(
+		ctx,
+		"SELECT "+fooShareColumns+" FROM foo_shares foo_share JOIN foos ON foos.id = foo_share.foo_id "+
+			"LEFT JOIN roles ON roles.id = foo_share.role_id WHERE foo_share.foo_id = $1 AND foos.org_id = $2 ORDER BY foo_share.id;",
+		fooId,
+		orgId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: C5Q6A6 - Querying foo shares from database.")
+	}
+	defer rows.Close()
+
+	shares = []models.FooShare{}
+	for rows.Next() {
+		share := models.FooShare{}
+		if err := scanFooShare(rows, &share); err != nil {
+			return nil, errors.Wrap(err, "Error: KZYO8W - Scanning row of foo shares from database.")
+		}
+		shares = append(shares, share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: B7FKL8 - Processing rows of foo shares from database.")
+	}
+
+	return shares, nil
+}
+
+// CreateFooShare shares a foo of the organization with the user or role of request, which must have exactly one
+// of them. The user must be a member of the organization. Sharing again with the same user or role changes the
+// permission of their share.
+func (fooShareRepo *FooShareRepo) CreateFooShare(ctx context.Context, fooId int64, request *models.FooShareRequest) (share *models.FooShare, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	share = &models.FooShare{}
+	row := (*fooShareRepo.db).QueryRow(
+		ctx,
+		"WITH foo_share AS (INSERT INTO foo_shares (foo_id, user_id, role_id, permission) "+
+			"SELECT foos.id, memberships.user_id, roles.id, $5 FROM foos "+
+			"LEFT JOIN memberships ON memberships.org_id = foos.org_id AND memberships.user_id = $3 "+
+			"LEFT JOIN roles ON roles.name = $4 "+
+			"WHERE foos.id = $1 AND foos.org_id = $2 AND (memberships.user_id IS NOT NULL OR roles.id IS NOT NULL) "+
+			"ON CONFLICT (foo_id, (COALESCE(user_id, 0)), (COALESCE(role_id, 0))) DO UPDATE SET permission = EXCLUDED.permission "+
+			"RETURNING *) "+
+			"SELECT "+fooShareColumns+" FROM foo_share LEFT JOIN roles ON roles.id = foo_share.role_id;",
+		fooId,
+		orgId,
+		request.UserID,
+		request.Role,
+		request.Permission,
+	)
+	err = scanFooShare(row, share)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			fieldError := apperrors.FieldError{Field: "role", Code: "unknown", Message: "No role with this name."}
+			if request.UserID != nil {
+				fieldError = apperrors.FieldError{Field: "user_id", Code: "not_member", Message: "The user is not a member of the organization."}
+			}
+			return nil, apperrors.Validation("07ILS3", "The request is not valid.").WithFields(fieldError)
+		}
+		return nil, errors.Wrap(err, "Error: 09ONA9 - Inserting foo share into database.")
+	}
+
+	return share, nil
+}
+
+// DeleteFooShare removes a share of a foo of the organization and returns it.
+func (fooShareRepo *FooShareRepo) DeleteFooShare(ctx context.Context, fooId int64, shareId int) (share *models.FooShare, err error) {
+	orgId, err := fooOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	share = &models.FooShare{}
+	row := (*fooShareRepo.db).QueryRow(
+		ctx,
+		"WITH foo_share AS (DELETE FROM foo_shares USING foos WHERE foo_shares.id = $1 AND foo_shares.foo_id = $2 "+
+			"AND foos.id = foo_shares.foo_id AND foos.org_id = $3 RETURNING foo_shares.*) "+
+			"SELECT "+fooShareColumns+" FROM foo_share LEFT JOIN roles ON roles.id = foo_share.role_id;",
+		shareId,
+		fooId,
+		orgId,
+	)
+	err = scanFooShare(row, share)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("AB38UR", fmt.Sprintf("No share %d found for foo %d.", shareId, fooId))
+		}
+		return nil, errors.Wrap(err, "Error: 6ND9LP - Deleting foo share from database.")
+	}
+
+	return share, nil
+}
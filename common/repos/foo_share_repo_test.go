@@ -0,0 +1,274 @@
+package repos_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestFooShareRepo_GetFooAccess_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), []int64{1, 2, 3}, orgId, userId).
+		Return(mockRows, nil)
+
+	// Foo 3 is not in the organization so it has no row
+	rows := map[int64]string{1: models.FooAccessOwner, 2: ""}
+	for _, id := range []int64{1, 2} {
+		id := id
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().
+			Scan(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(dest ...any) error {
+				*(dest[0].(*int64)) = id
+				*(dest[1].(*string)) = rows[id]
+				return nil
+			})
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	access, err := repo.GetFooAccess(orgCtx, []int64{1, 2, 3})
+	require.NoError(t, err)
+	require.Equal(t, rows, access)
+}
+
+func TestFooShareRepo_GetFooAccess_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	// 1) Test the query failing
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), []int64{1}, orgId, userId).
+		Return(nil, errors.New("query failed"))
+
+	access, err := repo.GetFooAccess(orgCtx, []int64{1})
+	require.Nil(t, access)
+	require.Contains(t, err.Error(), "5TIF30")
+
+	// 2) Test the scan failing
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), []int64{1}, orgId, userId).
+		Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	access, err = repo.GetFooAccess(orgCtx, []int64{1})
+	require.Nil(t, access)
+	require.Contains(t, err.Error(), "EYR8PB")
+}
+
+func TestFooShareRepo_GetFooShares_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+
+	mockPool.EXPECT().
+		Query(
+			gomock.Any(),
+			"SELECT foo_share.id, foo_share.foo_id, foo_share.user_id, roles.name, foo_share.permission, foo_share.created_at "+
+				"FROM foo_shares foo_share JOIN foos ON foos.id = foo_share.foo_id "+
+				"LEFT JOIN roles ON roles.id = foo_share.role_id WHERE foo_share.foo_id = $1 AND foos.org_id = $2 ORDER BY foo_share.id;",
+			int64(4),
+			orgId,
+		).
+		Return(mockRows, nil)
+
+	role := "editor"
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int)) = 2
+			*(dest[1].(*int64)) = 4
+			*(dest[3].(**string)) = &role
+			*(dest[4].(*string)) = models.FooAccessWrite
+			*(dest[5].(*int64)) = 1700000000000
+			return nil
+		})
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	shares, err := repo.GetFooShares(orgCtx, 4)
+	require.NoError(t, err)
+	require.Equal(t, []models.FooShare{{ID: 2, FooID: 4, Role: &role, Permission: "write", CreatedAt: 1700000000000}}, shares)
+}
+
+func TestFooShareRepo_CreateFooShare_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	member := 9
+	request := &models.FooShareRequest{UserID: &member, Permission: models.FooAccessRead}
+	mockPool.EXPECT().
+		QueryRow(gomock.Any(), gomock.Any(), int64(4), orgId, &member, nil, "read").
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int)) = 3
+			*(dest[1].(*int64)) = 4
+			*(dest[2].(**int)) = &member
+			*(dest[4].(*string)) = models.FooAccessRead
+			return nil
+		})
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	share, err := repo.CreateFooShare(orgCtx, 4, request)
+	require.NoError(t, err)
+	require.Equal(t, &models.FooShare{ID: 3, FooID: 4, UserID: &member, Permission: "read"}, share)
+}
+
+func TestFooShareRepo_CreateFooShare_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	member := 9
+	role := "nobody"
+
+	// 1) Test a user who is not a member of the organization
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(4), orgId, &member, nil, "read").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	share, err := repo.CreateFooShare(orgCtx, 4, &models.FooShareRequest{UserID: &member, Permission: "read"})
+	require.Nil(t, share)
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, apperrors.KindValidation, appError.Kind)
+	require.Equal(t, "07ILS3", appError.Code)
+	require.Equal(t, []apperrors.FieldError{{Field: "user_id", Code: "not_member", Message: "The user is not a member of the organization."}}, appError.Fields)
+
+	// 2) Test a role that does not exist
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(4), orgId, nil, &role, "write").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	share, err = repo.CreateFooShare(orgCtx, 4, &models.FooShareRequest{Role: &role, Permission: "write"})
+	require.Nil(t, share)
+	appError, _ = apperrors.From(err)
+	require.Equal(t, "role", appError.Fields[0].Field)
+
+	// 3) Test the insert failing
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(4), orgId, nil, &role, "write").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("insert failed"))
+
+	share, err = repo.CreateFooShare(orgCtx, 4, &models.FooShareRequest{Role: &role, Permission: "write"})
+	require.Nil(t, share)
+	require.Contains(t, err.Error(), "09ONA9")
+}
+
+func TestFooShareRepo_DeleteFooShare_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"WITH foo_share AS (DELETE FROM foo_shares USING foos WHERE foo_shares.id = $1 AND foo_shares.foo_id = $2 "+
+				"AND foos.id = foo_shares.foo_id AND foos.org_id = $3 RETURNING foo_shares.*) "+
+				"SELECT foo_share.id, foo_share.foo_id, foo_share.user_id, roles.name, foo_share.permission, foo_share.created_at "+
+				"FROM foo_share LEFT JOIN roles ON roles.id = foo_share.role_id;",
+			2,
+			int64(4),
+			orgId,
+		).
+		Return(mockRow)
+	role := "editor"
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int)) = 2
+			*(dest[1].(*int64)) = 4
+			*(dest[3].(**string)) = &role
+			*(dest[4].(*string)) = models.FooAccessWrite
+			return nil
+		})
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	share, err := repo.DeleteFooShare(orgCtx, 4, 2)
+	require.NoError(t, err)
+	require.Equal(t, &models.FooShare{ID: 2, FooID: 4, Role: &role, Permission: "write"}, share)
+}
+
+func TestFooShareRepo_DeleteFooShare_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+
+	logger := zaptest.NewLogger(t)
+	repo := repos.NewFooShareRepository(mockPool, logger)
+
+	// 1) Test a share that does not exist
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), 2, int64(4), orgId).Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	share, err := repo.DeleteFooShare(orgCtx, 4, 2)
+	require.Nil(t, share)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+
+	// 2) Test the delete failing
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), 2, int64(4), orgId).Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("delete failed"))
+
+	share, err = repo.DeleteFooShare(orgCtx, 4, 2)
+	require.Nil(t, share)
+	require.Contains(t, err.Error(), "6ND9LP")
+}
@@ -0,0 +1,87 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_oidc_login_repo.go \
+  -package=mocks \
+  -mock_names=OidcLoginRepoInterface=MockOidcLoginRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  OidcLoginRepoInterface
+*/
+
+type OidcLoginRepoInterface interface {
+	CreateLogin(ctx context.Context, stateHash []byte, login *models.OidcLogin, ttl int64) (err error)
+	TakeLogin(ctx context.Context, stateHash []byte) (login *models.OidcLogin, err error)
+}
+
+type OidcLoginRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+func NewOidcLoginRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *OidcLoginRepo {
+	return &OidcLoginRepo{db: &db, logger: logger}
+}
+
+// CreateLogin stores a login that expires ttl milliseconds from now. Logins that expired before are deleted, they
+// belong to users who never came back from the provider.
+func (oidcLoginRepo *OidcLoginRepo) CreateLogin(ctx context.Context, stateHash []byte, login *models.OidcLogin, ttl int64) (err error) {
+	_, err = (*oidcLoginRepo.db).Exec(ctx, "DELETE FROM oidc_logins WHERE expires_at < current_epoch_milliseconds();")
+	if err != nil {
+		return errors.Wrap(err, "Error: JF5ATB - Deleting expired oidc logins from database.")
+	}
+
+	_, err = (*oidcLoginRepo.db).Exec(
+		ctx,
+		"INSERT INTO oidc_logins (state_hash, provider, code_verifier, nonce, return_to, expires_at) "+
+			"VALUES ($1, $2, $3, $4, $5, current_epoch_milliseconds() + $6);",
+		stateHash,
+		login.Provider,
+		login.CodeVerifier,
+		login.Nonce,
+		login.ReturnTo,
+		ttl,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: 4XQI29 - Inserting oidc login in database.")
+	}
+
+	return nil
+}
+
+// TakeLogin deletes and returns the live login with the state hash, so a state can only be used once.
+func (oidcLoginRepo *OidcLoginRepo) TakeLogin(ctx context.Context, stateHash []byte) (login *models.OidcLogin, err error) {
+	login = &models.OidcLogin{}
+	row := (*oidcLoginRepo.db).QueryRow(
+		ctx,
+		"DELETE FROM oidc_logins WHERE state_hash = $1 AND expires_at > current_epoch_milliseconds() "+
+			"RETURNING provider, code_verifier, nonce, return_to;",
+		stateHash,
+	)
+	err = row.Scan(&login.Provider, &login.CodeVerifier, &login.Nonce, &login.ReturnTo)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.Unauthorized("SOZRGS", "The login has expired, try again.")
+		}
+		return nil, errors.Wrap(err, "Error: 2TGOI7 - Taking oidc login from database.")
+	}
+
+	return login, nil
+}
@@ -0,0 +1,75 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestOidcLoginRepo_TakeLogin_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	oidcLoginRepo := repos.NewOidcLoginRepository(mockPool, zaptest.NewLogger(t))
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"DELETE FROM oidc_logins WHERE state_hash = $1 AND expires_at > current_epoch_milliseconds() "+
+				"RETURNING provider, code_verifier, nonce, return_to;",
+			[]byte("hash"),
+		).
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*string)) = "google"
+			*(dest[1].(*string)) = "verifier"
+			*(dest[2].(*string)) = "nonce"
+			*(dest[3].(*string)) = "/foos"
+			return nil
+		})
+
+	login, err := oidcLoginRepo.TakeLogin(context.Background(), []byte("hash"))
+	require.NoError(t, err)
+	require.Equal(t, "google", login.Provider)
+	require.Equal(t, "verifier", login.CodeVerifier)
+	require.Equal(t, "nonce", login.Nonce)
+	require.Equal(t, "/foos", login.ReturnTo)
+}
+
+func TestOidcLoginRepo_TakeLogin_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	oidcLoginRepo := repos.NewOidcLoginRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test a state that was used before, expired or never existed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), []byte("hash")).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(pgx.ErrNoRows)
+
+	login, err := oidcLoginRepo.TakeLogin(context.Background(), []byte("hash"))
+	require.Nil(t, login)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), []byte("hash")).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(errors.New("scan failed"))
+
+	login, err = oidcLoginRepo.TakeLogin(context.Background(), []byte("hash"))
+	require.Nil(t, login)
+	require.Equal(t, apperrors.KindInternal, apperrors.KindOf(err))
+}
@@ -0,0 +1,234 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_organization_repo.go \
+  -package=mocks \
+  -mock_names=OrganizationRepoInterface=MockOrganizationRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  OrganizationRepoInterface
+*/
+
+type OrganizationRepoInterface interface {
+	GetOrganizations(ctx context.Context, issuer string, subject string) (orgs []models.Organization, err error)
+	GetMemberOrganization(ctx context.Context, issuer string, subject string, slug string) (org *models.Organization, userId int, err error)
+	GetDefaultOrganization(ctx context.Context, issuer string, subject string) (org *models.Organization, userId int, err error)
+	GetOrganizationBySlug(ctx context.Context, slug string) (org *models.Organization, err error)
+	CreateOrganization(ctx context.Context, slug string, name string) (org *models.Organization, err error)
+	AddMember(ctx context.Context, orgId int, userId int) (err error)
+	RemoveMember(ctx context.Context, orgId int, userId int) (err error)
+	AssignDefaultOrganization(ctx context.Context, userId int, slug string) (err error)
+	WithTx(tx interfaces.PgxTxInterface) OrganizationRepoInterface
+}
+
+type OrganizationRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+func NewOrganizationRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *OrganizationRepo {
+	return &OrganizationRepo{db: &db, logger: logger}
+}
+
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (orgRepo *OrganizationRepo) WithTx(tx interfaces.PgxTxInterface) OrganizationRepoInterface {
+	return NewOrganizationRepository(tx, orgRepo.logger)
+}
+
+// organizationColumns are the organization columns in the order scanOrganization reads them.
+const organizationColumns = "organizations.id, organizations.slug, organizations.name, organizations.created_at"
+
+// memberOrganizationsFrom joins the organizations to the live user with the issuer $1 and subject $2 through their memberships.
+const memberOrganizationsFrom = " FROM users JOIN memberships ON memberships.user_id = users.id " +
+	"JOIN organizations ON organizations.id = memberships.org_id " +
+	"WHERE users.issuer = $1 AND users.subject = $2 AND users.deleted_at = 0"
+
+// scanOrganization scans a row selected or returned with organizationColumns into org.
+func scanOrganization(row interfaces.PgxRowInterface, org *models.Organization) error {
+	return row.Scan(&org.ID, &org.Slug, &org.Name, &org.CreatedAt)
+}
+
+// GetOrganizations returns the organizations the user with the issuer and subject is a member of.
+func (orgRepo *OrganizationRepo) GetOrganizations(ctx context.Context, issuer string, subject string) (orgs []models.Organization, err error) {
+	rows, err := (*orgRepo.db).Query(
+		ctx,
+		"SELECT "+organizationColumns+memberOrganizationsFrom+" ORDER BY organizations.slug;",
+		issuer,
+		subject,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: VGE86M - Querying organizations from database.")
+	}
+	defer rows.Close()
+
+	orgs = []models.Organization{}
+	for rows.Next() {
+		org := models.Organization{}
+		if err := scanOrganization(rows, &org); err != nil {
+			return nil, errors.Wrap(err, "Error: 5U54HW - Scanning row of organizations from database.")
+		}
+		orgs = append(orgs, org)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: OSNP0F - Processing rows of organizations from database.")
+	}
+
+	return orgs, nil
+}
+
+// GetMemberOrganization returns the organization with the slug, and the id of the user with the issuer and subject,
+// when the user is a member. It is forbidden otherwise, whether or not the organization exists.
+func (orgRepo *OrganizationRepo) GetMemberOrganization(ctx context.Context, issuer string, subject string, slug string) (org *models.Organization, userId int, err error) {
+	org = &models.Organization{}
+	row := (*orgRepo.db).QueryRow(
+		ctx,
+		"SELECT "+organizationColumns+", users.id"+memberOrganizationsFrom+" AND organizations.slug = $3;",
+		issuer,
+		subject,
+		slug,
+	)
+	err = row.Scan(&org.ID, &org.Slug, &org.Name, &org.CreatedAt, &userId)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, apperrors.Forbidden("U5C8IF", fmt.Sprintf("You are not a member of the organization %s.", slug))
+		}
+		return nil, 0, errors.Wrap(err, "Error: ET0NSW - Getting organization from database.")
+	}
+
+	return org, userId, nil
+}
+
+// GetDefaultOrganization returns the default organization of the user with the issuer and subject, as long as they
+// are still a member of it, and the id of the user.
+func (orgRepo *OrganizationRepo) GetDefaultOrganization(ctx context.Context, issuer string, subject string) (org *models.Organization, userId int, err error) {
+	org = &models.Organization{}
+	row := (*orgRepo.db).QueryRow(
+		ctx,
+		"SELECT "+organizationColumns+", users.id"+memberOrganizationsFrom+" AND organizations.id = users.default_org_id;",
+		issuer,
+		subject,
+	)
+	err = row.Scan(&org.ID, &org.Slug, &org.Name, &org.CreatedAt, &userId)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, apperrors.BadRequest("TPBUUR", "You have no default organization, pick one with the "+models.OrgHeader+" header.")
+		}
+		return nil, 0, errors.Wrap(err, "Error: 598CTJ - Getting default organization from database.")
+	}
+
+	return org, userId, nil
+}
+
+func (orgRepo *OrganizationRepo) GetOrganizationBySlug(ctx context.Context, slug string) (org *models.Organization, err error) {
+	org = &models.Organization{}
+	row := (*orgRepo.db).QueryRow(
+		ctx,
+		"SELECT "+organizationColumns+" FROM organizations WHERE slug = $1;",
+		slug,
+	)
+	err = scanOrganization(row, org)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("CJQ8AG", fmt.Sprintf("No organization found with slug %s.", slug))
+		}
+		return nil, errors.Wrap(err, "Error: TSUFTO - Getting organization from database.")
+	}
+
+	return org, nil
+}
+
+func (orgRepo *OrganizationRepo) CreateOrganization(ctx context.Context, slug string, name string) (org *models.Organization, err error) {
+	org = &models.Organization{}
+	row := (*orgRepo.db).QueryRow(
+		ctx,
+		"INSERT INTO organizations (slug, name) VALUES ($1, $2) RETURNING id, slug, name, created_at;",
+		slug,
+		name,
+	)
+	err = scanOrganization(row, org)
+
+	if err != nil {
+		return nil, errors.Wrap(constraintError(err, organizationConstraintFields), "Error: OZ09T3 - Inserting organization into database.")
+	}
+
+	return org, nil
+}
+
+// AddMember makes the user a member of the organization. It becomes their default organization when they have none.
+func (orgRepo *OrganizationRepo) AddMember(ctx context.Context, orgId int, userId int) (err error) {
+	_, err = (*orgRepo.db).Exec(
+		ctx,
+		"WITH membership AS (INSERT INTO memberships (org_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING) "+
+			"UPDATE users SET default_org_id = $1 WHERE id = $2 AND default_org_id IS NULL;",
+		orgId,
+		userId,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: PA57AS - Adding member in database.")
+	}
+
+	return nil
+}
+
+// RemoveMember ends the membership of the user in the organization. When it was their default organization, their
+// oldest other membership becomes the default.
+func (orgRepo *OrganizationRepo) RemoveMember(ctx context.Context, orgId int, userId int) (err error) {
+	var removed int
+	err = (*orgRepo.db).QueryRow(
+		ctx,
+		"WITH membership AS (DELETE FROM memberships WHERE org_id = $1 AND user_id = $2 RETURNING user_id), "+
+			"user_default AS (UPDATE users SET default_org_id = (SELECT org_id FROM memberships WHERE user_id = $2 AND org_id <> $1 ORDER BY created_at LIMIT 1) "+
+			"WHERE id IN (SELECT user_id FROM membership) AND default_org_id = $1) "+
+			"SELECT count(*) FROM membership;",
+		orgId,
+		userId,
+	).Scan(&removed)
+	if err != nil {
+		return errors.Wrap(err, "Error: COS1UP - Removing member from database.")
+	}
+
+	if removed == 0 {
+		return apperrors.NotFound("CNFLM3", fmt.Sprintf("User %d is not a member of the organization.", userId))
+	}
+
+	return nil
+}
+
+// AssignDefaultOrganization makes the user a member of the organization with the slug, and it their default, when they
+// are not a member of any organization yet.
+func (orgRepo *OrganizationRepo) AssignDefaultOrganization(ctx context.Context, userId int, slug string) (err error) {
+	_, err = (*orgRepo.db).Exec(
+		ctx,
+		"WITH membership AS (INSERT INTO memberships (org_id, user_id) SELECT id, $1 FROM organizations WHERE slug = $2 "+
+			"AND NOT EXISTS (SELECT 1 FROM memberships WHERE user_id = $1) ON CONFLICT DO NOTHING RETURNING org_id) "+
+			"UPDATE users SET default_org_id = membership.org_id FROM membership WHERE users.id = $1;",
+		userId,
+		slug,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: R3I3AD - Assigning default organization in database.")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestOrganizationRepo_GetMemberOrganization_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	orgRepo := repos.NewOrganizationRepository(mockPool, zaptest.NewLogger(t))
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"SELECT organizations.id, organizations.slug, organizations.name, organizations.created_at, users.id "+
+				"FROM users JOIN memberships ON memberships.user_id = users.id "+
+				"JOIN organizations ON organizations.id = memberships.org_id "+
+				"WHERE users.issuer = $1 AND users.subject = $2 AND users.deleted_at = 0 AND organizations.slug = $3;",
+			issuer,
+			"sub-1",
+			"acme",
+		).
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int)) = 3
+			*(dest[1].(*string)) = "acme"
+			*(dest[2].(*string)) = "Acme"
+			*(dest[4].(*int)) = 7
+			return nil
+		})
+
+	org, userId, err := orgRepo.GetMemberOrganization(context.Background(), issuer, "sub-1", "acme")
+	require.NoError(t, err)
+	require.Equal(t, &models.Organization{ID: 3, Slug: "acme", Name: "Acme"}, org)
+	require.Equal(t, 7, userId)
+}
+
+func TestOrganizationRepo_GetMemberOrganization_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	orgRepo := repos.NewOrganizationRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test not a member
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1", "acme").Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(pgx.ErrNoRows)
+
+	org, _, err := orgRepo.GetMemberOrganization(context.Background(), issuer, "sub-1", "acme")
+	require.Nil(t, org)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "U5C8IF")
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1", "acme").Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("scan failed"))
+
+	org, _, err = orgRepo.GetMemberOrganization(context.Background(), issuer, "sub-1", "acme")
+	require.Nil(t, org)
+	require.Contains(t, err.Error(), "ET0NSW")
+}
+
+func TestOrganizationRepo_GetDefaultOrganization_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	orgRepo := repos.NewOrganizationRepository(mockPool, zaptest.NewLogger(t))
+
+	// A user without a default organization has to pick one
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1").Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(pgx.ErrNoRows)
+
+	org, _, err := orgRepo.GetDefaultOrganization(context.Background(), issuer, "sub-1")
+	require.Nil(t, org)
+	require.Equal(t, apperrors.KindBadRequest, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "TPBUUR")
+}
+
+func TestOrganizationRepo_CreateOrganization_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	orgRepo := repos.NewOrganizationRepository(mockPool, zaptest.NewLogger(t))
+
+	// A taken slug is a validation error of the slug field
+	mockPool.EXPECT().
+		QueryRow(gomock.Any(), "INSERT INTO organizations (slug, name) VALUES ($1, $2) RETURNING id, slug, name, created_at;", "acme", "Acme").
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&pgconn.PgError{Code: "23505", ConstraintName: "organizations_slug_key"})
+
+	org, err := orgRepo.CreateOrganization(context.Background(), "acme", "Acme")
+	require.Nil(t, org)
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, apperrors.KindValidation, appError.Kind)
+	require.Equal(t, "slug", appError.Fields[0].Field)
+}
+
+func TestOrganizationRepo_RemoveMember_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	orgRepo := repos.NewOrganizationRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test the user is not a member
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), 3, 7).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...any) error {
+		*(dest[0].(*int)) = 0
+		return nil
+	})
+
+	err := orgRepo.RemoveMember(context.Background(), 3, 7)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "CNFLM3")
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), 3, 7).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(errors.New("scan failed"))
+
+	err = orgRepo.RemoveMember(context.Background(), 3, 7)
+	require.Contains(t, err.Error(), "COS1UP")
+}
@@ -0,0 +1,180 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_role_repo.go \
+  -package=mocks \
+  -mock_names=RoleRepoInterface=MockRoleRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  RoleRepoInterface
+*/
+
+type RoleRepoInterface interface {
+	GetRoles(ctx context.Context) (roles []models.Role, err error)
+	GetUserRoles(ctx context.Context, userId int) (roles []string, err error)
+	DeleteUserRoles(ctx context.Context, userId int) (err error)
+	AddUserRoles(ctx context.Context, userId int, roles []string) (err error)
+	AssignDefaultRole(ctx context.Context, userId int, role string) (err error)
+	GetPermissionsBySubject(ctx context.Context, issuer string, subject string) (permissions []string, err error)
+	WithTx(tx interfaces.PgxTxInterface) RoleRepoInterface
+}
+
+type RoleRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+func NewRoleRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *RoleRepo {
+	return &RoleRepo{db: &db, logger: logger}
+}
+
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (roleRepo *RoleRepo) WithTx(tx interfaces.PgxTxInterface) RoleRepoInterface {
+	return NewRoleRepository(tx, roleRepo.logger)
+}
+
+// scanNames reads rows of a single name column.
+func scanNames(rows interfaces.PgxRowsInterface) (names []string, err error) {
+	defer rows.Close()
+
+	names = []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// GetRoles returns every role with the names of its permissions.
+func (roleRepo *RoleRepo) GetRoles(ctx context.Context) (roles []models.Role, err error) {
+	rows, err := (*roleRepo.db).Query(
+		ctx,
+		"SELECT roles.id, roles.name, roles.description, roles.created_at, "+
+			"COALESCE(array_agg(permissions.name ORDER BY permissions.name) FILTER (WHERE permissions.name IS NOT NULL), '{}') "+
+			"FROM roles LEFT JOIN role_permissions ON role_permissions.role_id = roles.id "+
+			"LEFT JOIN permissions ON permissions.id = role_permissions.permission_id "+
+			"GROUP BY roles.id ORDER BY roles.id;",
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 5Q8M4L - Querying roles from database.")
+	}
+	defer rows.Close()
+
+	roles = []models.Role{}
+	for rows.Next() {
+		role := models.Role{}
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt, &role.Permissions); err != nil {
+			return nil, errors.Wrap(err, "Error: 076WFQ - Scanning row of roles from database.")
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: GC4A8F - Processing rows of roles from database.")
+	}
+
+	return roles, nil
+}
+
+// GetUserRoles returns the names of the roles of a user.
+func (roleRepo *RoleRepo) GetUserRoles(ctx context.Context, userId int) (roles []string, err error) {
+	rows, err := (*roleRepo.db).Query(
+		ctx,
+		"SELECT roles.name FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE user_roles.user_id = $1 ORDER BY roles.name;",
+		userId,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: MMOY8I - Querying user roles from database.")
+	}
+
+	roles, err = scanNames(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: X25W1Z - Scanning user roles from database.")
+	}
+
+	return roles, nil
+}
+
+// DeleteUserRoles takes every role away from a user.
+func (roleRepo *RoleRepo) DeleteUserRoles(ctx context.Context, userId int) (err error) {
+	_, err = (*roleRepo.db).Exec(ctx, "DELETE FROM user_roles WHERE user_id = $1;", userId)
+	if err != nil {
+		return errors.Wrap(err, "Error: FZ0Y2Q - Deleting user roles from database.")
+	}
+
+	return nil
+}
+
+// AddUserRoles gives a user the roles with the given names. Names without a role are skipped.
+func (roleRepo *RoleRepo) AddUserRoles(ctx context.Context, userId int, roles []string) (err error) {
+	_, err = (*roleRepo.db).Exec(
+		ctx,
+		"INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE name = ANY($2) ON CONFLICT DO NOTHING;",
+		userId,
+		roles,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: ZMGJVM - Adding user roles in database.")
+	}
+
+	return nil
+}
+
+// AssignDefaultRole gives a user the role when they have no roles at all.
+func (roleRepo *RoleRepo) AssignDefaultRole(ctx context.Context, userId int, role string) (err error) {
+	_, err = (*roleRepo.db).Exec(
+		ctx,
+		"INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE name = $2 "+
+			"AND NOT EXISTS (SELECT 1 FROM user_roles WHERE user_id = $1) ON CONFLICT DO NOTHING;",
+		userId,
+		role,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: 4U1L3O - Assigning default role in database.")
+	}
+
+	return nil
+}
+
+// GetPermissionsBySubject returns the permissions of the user with the issuer and subject through all their roles.
+// A user who does not exist or is soft deleted has no permissions.
+func (roleRepo *RoleRepo) GetPermissionsBySubject(ctx context.Context, issuer string, subject string) (permissions []string, err error) {
+	rows, err := (*roleRepo.db).Query(
+		ctx,
+		"SELECT DISTINCT permissions.name FROM users "+
+			"JOIN user_roles ON user_roles.user_id = users.id "+
+			"JOIN role_permissions ON role_permissions.role_id = user_roles.role_id "+
+			"JOIN permissions ON permissions.id = role_permissions.permission_id "+
+			"WHERE users.issuer = $1 AND users.subject = $2 AND users.deleted_at = 0 ORDER BY permissions.name;",
+		issuer,
+		subject,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: OP1FW4 - Querying permissions from database.")
+	}
+
+	permissions, err = scanNames(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: X2PHV7 - Scanning permissions from database.")
+	}
+
+	return permissions, nil
+}
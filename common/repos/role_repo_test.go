@@ -0,0 +1,97 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestRoleRepo_GetPermissionsBySubject_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+	roleRepo := repos.NewRoleRepository(mockPool, zaptest.NewLogger(t))
+
+	mockPool.EXPECT().
+		Query(
+			gomock.Any(),
+			"SELECT DISTINCT permissions.name FROM users "+
+				"JOIN user_roles ON user_roles.user_id = users.id "+
+				"JOIN role_permissions ON role_permissions.role_id = user_roles.role_id "+
+				"JOIN permissions ON permissions.id = role_permissions.permission_id "+
+				"WHERE users.issuer = $1 AND users.subject = $2 AND users.deleted_at = 0 ORDER BY permissions.name;",
+			issuer,
+			"sub-1",
+		).
+		Return(mockRows, nil)
+	for _, name := range []string{"foos:read", "foos:write"} {
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().Scan(gomock.Any()).DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*string)) = name
+			return nil
+		})
+	}
+	mockRows.EXPECT().Next().Return(false)
+	mockRows.EXPECT().Err().Return(nil)
+	mockRows.EXPECT().Close()
+
+	permissions, err := roleRepo.GetPermissionsBySubject(context.Background(), issuer, "sub-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"foos:read", "foos:write"}, permissions)
+}
+
+func TestRoleRepo_GetPermissionsBySubject_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRows := mocks.NewMockPgxRows(ctrl)
+	roleRepo := repos.NewRoleRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test Query failed
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), issuer, "sub-1").Return(nil, errors.New("query failed"))
+
+	permissions, err := roleRepo.GetPermissionsBySubject(context.Background(), issuer, "sub-1")
+	require.Nil(t, permissions)
+	require.Contains(t, err.Error(), "OP1FW4")
+
+	// 2) Test Scan failed, the rows are still closed
+	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), issuer, "sub-1").Return(mockRows, nil)
+	mockRows.EXPECT().Next().Return(true)
+	mockRows.EXPECT().Scan(gomock.Any()).Return(errors.New("scan failed"))
+	mockRows.EXPECT().Close()
+
+	permissions, err = roleRepo.GetPermissionsBySubject(context.Background(), issuer, "sub-1")
+	require.Nil(t, permissions)
+	require.Contains(t, err.Error(), "X2PHV7")
+}
+
+func TestRoleRepo_AssignDefaultRole_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	roleRepo := repos.NewRoleRepository(mockPool, zaptest.NewLogger(t))
+
+	mockPool.EXPECT().
+		Exec(
+			gomock.Any(),
+			"INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE name = $2 "+
+				"AND NOT EXISTS (SELECT 1 FROM user_roles WHERE user_id = $1) ON CONFLICT DO NOTHING;",
+			1,
+			"viewer",
+		).
+		Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+	require.NoError(t, roleRepo.AssignDefaultRole(context.Background(), 1, "viewer"))
+}
@@ -0,0 +1,251 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_session_repo.go \
+  -package=mocks \
+  -mock_names=SessionRepoInterface=MockSessionRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  SessionRepoInterface
+*/
+
+type SessionRepoInterface interface {
+	CreateSession(ctx context.Context, userId int, tokenHash []byte, tokens *models.SessionTokens, userAgent string, ipAddress string, idleTimeout int64, maxAge int64) (session *models.Session, err error)
+	TouchSession(ctx context.Context, tokenHash []byte, idleTimeout int64) (session *models.Session, claims *models.Claims, err error)
+	GetSessions(ctx context.Context, issuer string, subject string) (sessions []models.Session, err error)
+	RevokeSession(ctx context.Context, issuer string, subject string, sessionId int64) (err error)
+	RevokeSessionByToken(ctx context.Context, tokenHash []byte) (err error)
+	GetSessionTokens(ctx context.Context, sessionId int64) (tokens *models.SessionTokens, err error)
+	GetSessionTokensForUpdate(ctx context.Context, sessionId int64) (tokens *models.SessionTokens, err error)
+	UpdateSessionTokens(ctx context.Context, sessionId int64, tokens *models.SessionTokens) (err error)
+	WithTx(tx interfaces.PgxTxInterface) SessionRepoInterface
+}
+
+type SessionRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+func NewSessionRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *SessionRepo {
+	return &SessionRepo{db: &db, logger: logger}
+}
+
+// WithTx returns a copy of the repo that runs its queries in tx.
+func (sessionRepo *SessionRepo) WithTx(tx interfaces.PgxTxInterface) SessionRepoInterface {
+	return NewSessionRepository(tx, sessionRepo.logger)
+}
+
+// sessionColumns are the session columns in the order scanSession reads them.
+const sessionColumns = "sessions.id, sessions.user_id, sessions.user_agent, sessions.ip_address, sessions.created_at, " +
+	"sessions.last_seen_at, sessions.expires_at, sessions.absolute_expires_at, sessions.revoked_at, " +
+	"sessions.id_token_expires_at, sessions.refresh_token IS NOT NULL"
+
+// sessionDest returns the scan destinations of sessionColumns in session.
+func sessionDest(session *models.Session) []any {
+	return []any{&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.CreatedAt,
+		&session.LastSeenAt, &session.ExpiresAt, &session.AbsoluteExpiresAt, &session.RevokedAt,
+		&session.IDTokenExpiresAt, &session.Renewable}
+}
+
+// CreateSession stores a new session of the user with the sealed tokens of its login. idleTimeout and maxAge are
+// in milliseconds.
+func (sessionRepo *SessionRepo) CreateSession(ctx context.Context, userId int, tokenHash []byte, tokens *models.SessionTokens, userAgent string, ipAddress string, idleTimeout int64, maxAge int64) (session *models.Session, err error) {
+	session = &models.Session{}
+	row := (*sessionRepo.db).QueryRow(
+		ctx,
+		"INSERT INTO sessions (token_hash, user_id, user_agent, ip_address, expires_at, absolute_expires_at, "+
+			"provider, id_token, id_token_expires_at, refresh_token) "+
+			"VALUES ($1, $2, $3, $4, current_epoch_milliseconds() + LEAST($5, $6), current_epoch_milliseconds() + $6, $7, $8, $9, $10) "+
+			"RETURNING "+sessionColumns+";",
+		tokenHash,
+		userId,
+		userAgent,
+		ipAddress,
+		idleTimeout,
+		maxAge,
+		tokens.Provider,
+		tokens.IDToken,
+		tokens.IDTokenExpiresAt,
+		tokens.RefreshToken,
+	)
+	if err := row.Scan(sessionDest(session)...); err != nil {
+		return nil, errors.Wrap(err, "Error: SGGG89 - Inserting session in database.")
+	}
+
+	return session, nil
+}
+
+// TouchSession finds the live session with the token hash and slides its expiry idleTimeout milliseconds past now,
+// capped at its absolute expiry. It returns the claims of the session's user. Sessions that are expired, revoked
+// or belong to a soft deleted user get an unauthorized error.
+func (sessionRepo *SessionRepo) TouchSession(ctx context.Context, tokenHash []byte, idleTimeout int64) (session *models.Session, claims *models.Claims, err error) {
+	session = &models.Session{}
+	claims = &models.Claims{}
+	row := (*sessionRepo.db).QueryRow(
+		ctx,
+		"UPDATE sessions SET last_seen_at = current_epoch_milliseconds(), "+
+			"expires_at = LEAST(current_epoch_milliseconds() + $2, sessions.absolute_expires_at) FROM users "+
+			"WHERE sessions.token_hash = $1 AND sessions.revoked_at = 0 AND sessions.expires_at > current_epoch_milliseconds() "+
+			"AND users.id = sessions.user_id AND users.deleted_at = 0 "+
+			"RETURNING "+sessionColumns+", users.issuer, users.subject, users.email, users.name;",
+		tokenHash,
+		idleTimeout,
+	)
+	dest := append(sessionDest(session), &claims.Issuer, &claims.Sub, &claims.Email, &claims.Name)
+	if err := row.Scan(dest...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, apperrors.Unauthorized("JRXEK9", "Your session has expired, log in again.")
+		}
+		return nil, nil, errors.Wrap(err, "Error: KGPXU9 - Touching session in database.")
+	}
+
+	return session, claims, nil
+}
+
+// GetSessions returns the live sessions of the user with the issuer and subject, the most recently used first.
+func (sessionRepo *SessionRepo) GetSessions(ctx context.Context, issuer string, subject string) (sessions []models.Session, err error) {
+	rows, err := (*sessionRepo.db).Query(
+		ctx,
+		"SELECT "+sessionColumns+" FROM sessions JOIN users ON users.id = sessions.user_id "+
+			"WHERE users.issuer = $1 AND users.subject = $2 AND sessions.revoked_at = 0 "+
+			"AND sessions.expires_at > current_epoch_milliseconds() ORDER BY sessions.last_seen_at DESC;",
+		issuer,
+		subject,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: S3QMEQ - Querying sessions from database.")
+	}
+	defer rows.Close()
+
+	sessions = []models.Session{}
+	for rows.Next() {
+		session := models.Session{}
+		if err := rows.Scan(sessionDest(&session)...); err != nil {
+			return nil, errors.Wrap(err, "Error: 8C2N4Z - Scanning row of sessions from database.")
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error: MFQEJ9 - Processing rows of sessions from database.")
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes a live session of the user with the issuer and subject. Sessions of other users are not
+// found.
+func (sessionRepo *SessionRepo) RevokeSession(ctx context.Context, issuer string, subject string, sessionId int64) (err error) {
+	result, err := (*sessionRepo.db).Exec(
+		ctx,
+		"UPDATE sessions SET revoked_at = current_epoch_milliseconds() FROM users "+
+			"WHERE sessions.id = $3 AND sessions.revoked_at = 0 AND users.id = sessions.user_id "+
+			"AND users.issuer = $1 AND users.subject = $2;",
+		issuer,
+		subject,
+		sessionId,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: NTXPGZ - Revoking session in database.")
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.NotFound("V2P70O", fmt.Sprintf("No session found with id %d.", sessionId))
+	}
+
+	return nil
+}
+
+// RevokeSessionByToken revokes the session with the token hash, if it is still live.
+func (sessionRepo *SessionRepo) RevokeSessionByToken(ctx context.Context, tokenHash []byte) (err error) {
+	_, err = (*sessionRepo.db).Exec(
+		ctx,
+		"UPDATE sessions SET revoked_at = current_epoch_milliseconds() WHERE token_hash = $1 AND revoked_at = 0;",
+		tokenHash,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: 27BGCL - Revoking session by token in database.")
+	}
+
+	return nil
+}
+
+// GetSessionTokens returns the sealed tokens of a live session. Sessions that are expired or revoked get an
+// unauthorized error.
+func (sessionRepo *SessionRepo) GetSessionTokens(ctx context.Context, sessionId int64) (tokens *models.SessionTokens, err error) {
+	tokens = &models.SessionTokens{}
+	row := (*sessionRepo.db).QueryRow(
+		ctx,
+		"SELECT provider, id_token, id_token_expires_at, refresh_token FROM sessions "+
+			"WHERE id = $1 AND revoked_at = 0 AND expires_at > current_epoch_milliseconds();",
+		sessionId,
+	)
+	if err := row.Scan(&tokens.Provider, &tokens.IDToken, &tokens.IDTokenExpiresAt, &tokens.RefreshToken); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.Unauthorized("0HM0MH", "Your session has expired, log in again.")
+		}
+		return nil, errors.Wrap(err, "Error: UEKQIG - Querying session tokens from database.")
+	}
+
+	return tokens, nil
+}
+
+// GetSessionTokensForUpdate returns the sealed tokens of a live session like GetSessionTokens and locks the session
+// until the end of the transaction, so only one request at a time renews its tokens. The repo must run in a
+// transaction, see WithTx.
+func (sessionRepo *SessionRepo) GetSessionTokensForUpdate(ctx context.Context, sessionId int64) (tokens *models.SessionTokens, err error) {
+	tokens = &models.SessionTokens{}
+	row := (*sessionRepo.db).QueryRow(
+		ctx,
+		"SELECT provider, id_token, id_token_expires_at, refresh_token FROM sessions "+
+			"WHERE id = $1 AND revoked_at = 0 AND expires_at > current_epoch_milliseconds() FOR UPDATE;",
+		sessionId,
+	)
+	if err := row.Scan(&tokens.Provider, &tokens.IDToken, &tokens.IDTokenExpiresAt, &tokens.RefreshToken); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.Unauthorized("DY9PT0", "Your session has expired, log in again.")
+		}
+		return nil, errors.Wrap(err, "Error: ZQVNEJ - Locking session tokens in database.")
+	}
+
+	return tokens, nil
+}
+
+// UpdateSessionTokens replaces the sealed tokens of a live session with renewed ones. Sessions that were revoked
+// meanwhile get an unauthorized error.
+func (sessionRepo *SessionRepo) UpdateSessionTokens(ctx context.Context, sessionId int64, tokens *models.SessionTokens) (err error) {
+	result, err := (*sessionRepo.db).Exec(
+		ctx,
+		"UPDATE sessions SET id_token = $2, id_token_expires_at = $3, refresh_token = $4 WHERE id = $1 AND revoked_at = 0;",
+		sessionId,
+		tokens.IDToken,
+		tokens.IDTokenExpiresAt,
+		tokens.RefreshToken,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error: LGMC0Q - Updating session tokens in database.")
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.Unauthorized("EHQVIH", "Your session has expired, log in again.")
+	}
+
+	return nil
+}
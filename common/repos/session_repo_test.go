@@ -0,0 +1,235 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestSessionRepo_TouchSession_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"UPDATE sessions SET last_seen_at = current_epoch_milliseconds(), "+
+				"expires_at = LEAST(current_epoch_milliseconds() + $2, sessions.absolute_expires_at) FROM users "+
+				"WHERE sessions.token_hash = $1 AND sessions.revoked_at = 0 AND sessions.expires_at > current_epoch_milliseconds() "+
+				"AND users.id = sessions.user_id AND users.deleted_at = 0 "+
+				"RETURNING sessions.id, sessions.user_id, sessions.user_agent, sessions.ip_address, sessions.created_at, "+
+				"sessions.last_seen_at, sessions.expires_at, sessions.absolute_expires_at, sessions.revoked_at, "+
+				"sessions.id_token_expires_at, sessions.refresh_token IS NOT NULL, users.issuer, users.subject, users.email, users.name;",
+			[]byte("hash"),
+			int64(3600000),
+		).
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*int64)) = 3
+			*(dest[1].(*int)) = 1
+			*(dest[10].(*bool)) = true
+			*(dest[11].(*string)) = issuer
+			*(dest[12].(*string)) = "sub-1"
+			return nil
+		})
+
+	session, claims, err := sessionRepo.TouchSession(context.Background(), []byte("hash"), 3600000)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), session.ID)
+	require.Equal(t, 1, session.UserID)
+	require.True(t, session.Renewable)
+	require.Equal(t, issuer, claims.Issuer)
+	require.Equal(t, "sub-1", claims.Sub)
+}
+
+func TestSessionRepo_TouchSession_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test an expired, revoked or unknown session
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), []byte("hash"), int64(3600000)).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(pgx.ErrNoRows)
+
+	session, claims, err := sessionRepo.TouchSession(context.Background(), []byte("hash"), 3600000)
+	require.Nil(t, session)
+	require.Nil(t, claims)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), []byte("hash"), int64(3600000)).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(errors.New("scan failed"))
+
+	session, claims, err = sessionRepo.TouchSession(context.Background(), []byte("hash"), 3600000)
+	require.Nil(t, session)
+	require.Nil(t, claims)
+	require.Contains(t, err.Error(), "KGPXU9")
+}
+
+func TestSessionRepo_RevokeSession_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	// A session of another user is not found
+	mockPool.EXPECT().
+		Exec(gomock.Any(), gomock.Any(), issuer, "sub-1", int64(9)).
+		Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+	err := sessionRepo.RevokeSession(context.Background(), issuer, "sub-1", 9)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "V2P70O")
+}
+
+func TestSessionRepo_GetSessionTokens_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test an expired or revoked session
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(3)).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(pgx.ErrNoRows)
+
+	tokens, err := sessionRepo.GetSessionTokens(context.Background(), 3)
+	require.Nil(t, tokens)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(3)).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(errors.New("scan failed"))
+
+	tokens, err = sessionRepo.GetSessionTokens(context.Background(), 3)
+	require.Nil(t, tokens)
+	require.Contains(t, err.Error(), "UEKQIG")
+}
+
+func TestSessionRepo_GetSessionTokensForUpdate_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"SELECT provider, id_token, id_token_expires_at, refresh_token FROM sessions "+
+				"WHERE id = $1 AND revoked_at = 0 AND expires_at > current_epoch_milliseconds() FOR UPDATE;",
+			int64(3),
+		).
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(dest ...any) error {
+			*(dest[0].(*string)) = "google"
+			*(dest[1].(*[]byte)) = []byte("sealed-id")
+			*(dest[2].(*int64)) = 1700003600000
+			*(dest[3].(*[]byte)) = []byte("sealed-refresh")
+			return nil
+		})
+
+	tokens, err := sessionRepo.GetSessionTokensForUpdate(context.Background(), 3)
+	require.NoError(t, err)
+	require.Equal(t, &models.SessionTokens{Provider: "google", IDToken: []byte("sealed-id"), IDTokenExpiresAt: 1700003600000, RefreshToken: []byte("sealed-refresh")}, tokens)
+}
+
+func TestSessionRepo_GetSessionTokensForUpdate_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test an expired or revoked session
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(3)).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(pgx.ErrNoRows)
+
+	tokens, err := sessionRepo.GetSessionTokensForUpdate(context.Background(), 3)
+	require.Nil(t, tokens)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "DY9PT0")
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), int64(3)).Return(mockRow)
+	mockRow.EXPECT().Scan(gomock.Any()).Return(errors.New("scan failed"))
+
+	tokens, err = sessionRepo.GetSessionTokensForUpdate(context.Background(), 3)
+	require.Nil(t, tokens)
+	require.Contains(t, err.Error(), "ZQVNEJ")
+}
+
+func TestSessionRepo_UpdateSessionTokens_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	tokens := &models.SessionTokens{IDToken: []byte("sealed-id"), IDTokenExpiresAt: 1700003600000, RefreshToken: []byte("sealed-refresh")}
+	mockPool.EXPECT().
+		Exec(
+			gomock.Any(),
+			"UPDATE sessions SET id_token = $2, id_token_expires_at = $3, refresh_token = $4 WHERE id = $1 AND revoked_at = 0;",
+			int64(3),
+			[]byte("sealed-id"),
+			int64(1700003600000),
+			[]byte("sealed-refresh"),
+		).
+		Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+
+	err := sessionRepo.UpdateSessionTokens(context.Background(), 3, tokens)
+	require.NoError(t, err)
+}
+
+func TestSessionRepo_UpdateSessionTokens_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	sessionRepo := repos.NewSessionRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test a session revoked while it was renewed
+	mockPool.EXPECT().
+		Exec(gomock.Any(), gomock.Any(), int64(3), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+
+	err := sessionRepo.UpdateSessionTokens(context.Background(), 3, &models.SessionTokens{})
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "EHQVIH")
+
+	// 2) Test the update failing
+	mockPool.EXPECT().
+		Exec(gomock.Any(), gomock.Any(), int64(3), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgconn.CommandTag{}, errors.New("exec failed"))
+
+	err = sessionRepo.UpdateSessionTokens(context.Background(), 3, &models.SessionTokens{})
+	require.Contains(t, err.Error(), "LGMC0Q")
+}
@@ -0,0 +1,115 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_user_repo.go \
+  -package=mocks \
+  -mock_names=UserRepoInterface=MockUserRepo \
+  gitlab.com/sandstone2/fiberpoc/common/repos \
+  UserRepoInterface
+*/
+
+type UserRepoInterface interface {
+	UpsertUser(ctx context.Context, issuer string, subject string, name string, email string) (user *models.User, err error)
+	GetUserBySubject(ctx context.Context, issuer string, subject string) (user *models.User, err error)
+	GetUserByID(ctx context.Context, userId int) (user *models.User, err error)
+}
+
+type UserRepo struct {
+	db     *interfaces.PgxQuerierInterface
+	logger *zap.Logger
+}
+
+func NewUserRepository(db interfaces.PgxQuerierInterface, logger *zap.Logger) *UserRepo {
+	return &UserRepo{db: &db, logger: logger}
+}
+
+// userColumns are the user columns in the order scanUser reads them.
+const userColumns = "id, issuer, subject, name, email, last_login_at, created_at, updated_at, deleted_at"
+
+// scanUser scans a row selected or returned with userColumns into user.
+func scanUser(row interfaces.PgxRowInterface, user *models.User) error {
+	return row.Scan(&user.ID, &user.Issuer, &user.Subject, &user.Name, &user.Email, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
+}
+
+// UpsertUser creates the user with the issuer and subject or refreshes their name and email. Either way the last login
+// time is set to now. A soft deleted user is not brought back and gets a forbidden error.
+func (userRepo *UserRepo) UpsertUser(ctx context.Context, issuer string, subject string, name string, email string) (user *models.User, err error) {
+	user = &models.User{}
+	row := (*userRepo.db).QueryRow(
+		ctx,
+		"INSERT INTO users (issuer, subject, name, email, last_login_at) VALUES ($1, $2, $3, $4, current_epoch_milliseconds()) "+
+			"ON CONFLICT (issuer, subject) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email, last_login_at = EXCLUDED.last_login_at "+
+			"WHERE users.deleted_at = 0 RETURNING "+userColumns+";",
+		issuer,
+		subject,
+		name,
+		email,
+	)
+	err = scanUser(row, user)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.Forbidden("GQGY9T", "This user has been deleted.")
+		}
+		return nil, errors.Wrap(constraintError(err, userConstraintFields), "Error: EECWZA - Upserting user in database.")
+	}
+
+	return user, nil
+}
+
+func (userRepo *UserRepo) GetUserBySubject(ctx context.Context, issuer string, subject string) (user *models.User, err error) {
+	user = &models.User{}
+	row := (*userRepo.db).QueryRow(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE issuer = $1 AND subject = $2 AND deleted_at = 0;",
+		issuer,
+		subject,
+	)
+	err = scanUser(row, user)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("ZW6WMS", "No user found for this login.")
+		}
+		return nil, errors.Wrap(err, "Error: ZL45GZ - Getting user from database.")
+	}
+
+	return user, nil
+}
+
+func (userRepo *UserRepo) GetUserByID(ctx context.Context, userId int) (user *models.User, err error) {
+	user = &models.User{}
+	row := (*userRepo.db).QueryRow(
+		ctx,
+		"SELECT "+userColumns+" FROM users WHERE id = $1 AND deleted_at = 0;",
+		userId,
+	)
+	err = scanUser(row, user)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.NotFound("R057V3", fmt.Sprintf("No user found with id %d.", userId))
+		}
+		return nil, errors.Wrap(err, "Error: 8RM4C6 - Getting user from database.")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,166 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+// issuer is the issuer of the users in these tests.
+const issuer = "https://accounts.google.com"
+
+// userScan returns a Scan stub that fills the userColumns destinations from user.
+func userScan(user models.User) func(dest ...any) error {
+	return func(dest ...any) error {
+		*(dest[0].(*int)) = user.ID
+		*(dest[1].(*string)) = user.Issuer
+		*(dest[2].(*string)) = user.Subject
+		*(dest[3].(*string)) = user.Name
+		*(dest[4].(*string)) = user.Email
+		*(dest[5].(*int64)) = user.LastLoginAt
+		*(dest[6].(*int64)) = user.CreatedAt
+		*(dest[7].(*int64)) = user.UpdatedAt
+		*(dest[8].(*int64)) = user.DeletedAt
+		return nil
+	}
+}
+
+func TestUserRepo_UpsertUser_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	userRepo := repos.NewUserRepository(mockPool, zaptest.NewLogger(t))
+
+	expected := models.User{ID: 1, Issuer: issuer, Subject: "sub-1", Name: "Ada", Email: "ada@example.com", LastLoginAt: 1700000000001, CreatedAt: 1700000000000}
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"INSERT INTO users (issuer, subject, name, email, last_login_at) VALUES ($1, $2, $3, $4, current_epoch_milliseconds()) "+
+				"ON CONFLICT (issuer, subject) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email, last_login_at = EXCLUDED.last_login_at "+
+				"WHERE users.deleted_at = 0 RETURNING id, issuer, subject, name, email, last_login_at, created_at, updated_at, deleted_at;",
+			issuer,
+			"sub-1",
+			"Ada",
+			"ada@example.com",
+		).
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(userScan(expected))
+
+	user, err := userRepo.UpsertUser(context.Background(), issuer, "sub-1", "Ada", "ada@example.com")
+	require.NoError(t, err)
+	require.Equal(t, &expected, user)
+}
+
+func TestUserRepo_UpsertUser_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	userRepo := repos.NewUserRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test a soft deleted user is not brought back
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1", "Ada", "ada@example.com").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	user, err := userRepo.UpsertUser(context.Background(), issuer, "sub-1", "Ada", "ada@example.com")
+	require.Nil(t, user)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "GQGY9T")
+
+	// 2) Test a blank email breaks the check constraint
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1", "Ada", "").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&pgconn.PgError{Code: "23514", ConstraintName: "users_email_check"})
+
+	user, err = userRepo.UpsertUser(context.Background(), issuer, "sub-1", "Ada", "")
+	require.Nil(t, user)
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, "email", appError.Fields[0].Field)
+
+	// 3) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1", "Ada", "ada@example.com").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+
+	user, err = userRepo.UpsertUser(context.Background(), issuer, "sub-1", "Ada", "ada@example.com")
+	require.Nil(t, user)
+	require.Contains(t, err.Error(), "EECWZA")
+}
+
+func TestUserRepo_GetUserBySubject_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	userRepo := repos.NewUserRepository(mockPool, zaptest.NewLogger(t))
+
+	expected := models.User{ID: 1, Issuer: issuer, Subject: "sub-1", Name: "Ada", Email: "ada@example.com"}
+
+	mockPool.EXPECT().
+		QueryRow(
+			gomock.Any(),
+			"SELECT id, issuer, subject, name, email, last_login_at, created_at, updated_at, deleted_at FROM users WHERE issuer = $1 AND subject = $2 AND deleted_at = 0;",
+			issuer,
+			"sub-1",
+		).
+		Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(userScan(expected))
+
+	user, err := userRepo.GetUserBySubject(context.Background(), issuer, "sub-1")
+	require.NoError(t, err)
+	require.Equal(t, &expected, user)
+}
+
+func TestUserRepo_GetUserBySubject_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPool := mocks.NewMockPgxPool(ctrl)
+	mockRow := mocks.NewMockPgxRow(ctrl)
+	userRepo := repos.NewUserRepository(mockPool, zaptest.NewLogger(t))
+
+	// 1) Test not found
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pgx.ErrNoRows)
+
+	user, err := userRepo.GetUserBySubject(context.Background(), issuer, "sub-1")
+	require.Nil(t, user)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+
+	// 2) Test Scan failed
+	mockPool.EXPECT().QueryRow(gomock.Any(), gomock.Any(), issuer, "sub-1").Return(mockRow)
+	mockRow.EXPECT().
+		Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("scan failed"))
+
+	user, err = userRepo.GetUserBySubject(context.Background(), issuer, "sub-1")
+	require.Nil(t, user)
+	require.Contains(t, err.Error(), "ZL45GZ")
+}
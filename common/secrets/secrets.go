@@ -0,0 +1,68 @@
+// Package secrets encrypts the secrets the app has to read back later, like the refresh tokens of sessions, so
+// they are not stored in the clear. Secrets the app only has to recognize, like session tokens and API keys, are
+// hashed instead.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// KeySize is the size of a key in bytes, it selects AES-256.
+const KeySize = 32
+
+// Cipher encrypts and authenticates secrets with AES-256-GCM. Every sealed secret starts with its own random nonce.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher makes a cipher with a base64 encoded key of KeySize bytes, like the output of
+// "openssl rand -base64 32".
+func NewCipher(encodedKey string) (*Cipher, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: RHNYI6 - Decoding the encryption key.")
+	}
+	if len(key) != KeySize {
+		return nil, errors.Errorf("Error: X0OI7A - The encryption key has %d bytes instead of %d.", len(key), KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: Z5K1O9 - Making the AES cipher.")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: QXYOHZ - Making the GCM cipher.")
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts a secret.
+func (c *Cipher) Seal(secret string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "Error: 2Y7DN4 - Generating nonce for the secret.")
+	}
+
+	return c.aead.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+// Open decrypts a secret sealed with the same key. Secrets sealed with another key or changed since fail.
+func (c *Cipher) Open(sealed []byte) (string, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("Error: P3BO08 - The sealed secret is too short.")
+	}
+
+	secret, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "Error: T2F7FY - Decrypting the secret.")
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", KeySize)))
+
+func TestCipher_Seal_Success(t *testing.T) {
+	c, err := NewCipher(testKey)
+	require.NoError(t, err)
+
+	// 1) Test a sealed secret opens again and does not hold the secret in the clear
+	sealed, err := c.Seal("refresh-token-1")
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "refresh-token-1")
+
+	secret, err := c.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-token-1", secret)
+
+	// 2) Test the same secret seals differently every time
+	sealedAgain, err := c.Seal("refresh-token-1")
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, sealedAgain)
+}
+
+func TestCipher_Open_Error(t *testing.T) {
+	c, err := NewCipher(testKey)
+	require.NoError(t, err)
+	sealed, err := c.Seal("refresh-token-1")
+	require.NoError(t, err)
+
+	// 1) Test a secret sealed with another key
+	other, err := NewCipher(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", KeySize))))
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	require.Contains(t, err.Error(), "T2F7FY")
+
+	// 2) Test a secret that was changed
+	sealed[len(sealed)-1] ^= 1
+	_, err = c.Open(sealed)
+	require.Contains(t, err.Error(), "T2F7FY")
+
+	// 3) Test a secret too short to have a nonce
+	_, err = c.Open([]byte("short"))
+	require.Contains(t, err.Error(), "P3BO08")
+}
+
+func TestNewCipher_Error(t *testing.T) {
+	// 1) Test a key that is not base64
+	_, err := NewCipher("not base64!")
+	require.Contains(t, err.Error(), "RHNYI6")
+
+	// 2) Test a key of the wrong size
+	_, err = NewCipher(base64.StdEncoding.EncodeToString([]byte("too short")))
+	require.Contains(t, err.Error(), "X0OI7A")
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_api_key_service.go \
+  -package=mocks \
+  -mock_names=ApiKeyServiceInterface=MockApiKeyService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  ApiKeyServiceInterface
+*/
+
+type ApiKeyServiceInterface interface {
+	CreateApiKey(ctx context.Context, claims *models.Claims, request *models.ApiKeyRequest) (newApiKey *models.NewApiKey, err error)
+	Authenticate(ctx context.Context, key string) (claims *models.Claims, apiKey *models.ApiKey, err error)
+	GetApiKeys(ctx context.Context, claims *models.Claims) (apiKeys []models.ApiKey, err error)
+	RevokeApiKey(ctx context.Context, claims *models.Claims, apiKeyId int64) (err error)
+}
+
+// apiKeyPrefixLength is how much of a key is kept as its prefix, ApiKeyPrefix and 8 characters of the secret.
+const apiKeyPrefixLength = len(models.ApiKeyPrefix) + 8
+
+type ApiKeyService struct {
+	apiKeyRepo *repos.ApiKeyRepoInterface
+	roleRepo   *repos.RoleRepoInterface
+	logger     *zap.Logger
+}
+
+// NewApiKeyService makes an API key service. The role repo is used to check the scopes of new keys against the
+// permissions of their user.
+func NewApiKeyService(apiKeyRepo repos.ApiKeyRepoInterface, roleRepo repos.RoleRepoInterface, logger *zap.Logger) *ApiKeyService {
+	return &ApiKeyService{apiKeyRepo: &apiKeyRepo, roleRepo: &roleRepo, logger: logger}
+}
+
+// hashApiKey is what the api_keys table stores instead of the key.
+func hashApiKey(key string) []byte {
+	hash := sha256.Sum256([]byte(key))
+	return hash[:]
+}
+
+// CreateApiKey creates an API key for the user with the claims. The scopes must be permissions the user has and
+// the expiry, when there is one, must be in the future. The key is in the result and is never stored.
+func (apiKeyService *ApiKeyService) CreateApiKey(ctx context.Context, claims *models.Claims, request *models.ApiKeyRequest) (newApiKey *models.NewApiKey, err error) {
+	granted, err := (*apiKeyService.roleRepo).GetPermissionsBySubject(ctx, claims.Issuer, claims.Sub)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: GD2JUG - Getting permissions.")
+	}
+
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	fields := []apperrors.FieldError{}
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			fields = append(fields, apperrors.FieldError{Field: "scopes", Code: "not_granted", Message: "You do not have the " + scope + " permission."})
+		}
+	}
+	if request.ExpiresAt != 0 && request.ExpiresAt <= time.Now().UnixMilli() {
+		fields = append(fields, apperrors.FieldError{Field: "expires_at", Code: "past", Message: "The expiry must be in the future."})
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("6YGXYL", "The request is not valid.").WithFields(fields...)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "Error: 15H6IB - Generating API key.")
+	}
+	key := models.ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	apiKey, err := (*apiKeyService.apiKeyRepo).CreateApiKey(ctx, claims.Issuer, claims.Sub, hashApiKey(key), &models.ApiKey{
+		Name:      request.Name,
+		Prefix:    key[:apiKeyPrefixLength],
+		Scopes:    scopes,
+		ExpiresAt: request.ExpiresAt,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: WA6GTH - Creating API key.")
+	}
+
+	return &models.NewApiKey{ApiKey: *apiKey, Key: key}, nil
+}
+
+// Authenticate returns the claims of the user of a live API key and the key.
+func (apiKeyService *ApiKeyService) Authenticate(ctx context.Context, key string) (claims *models.Claims, apiKey *models.ApiKey, err error) {
+	if !strings.HasPrefix(key, models.ApiKeyPrefix) {
+		return nil, nil, apperrors.Unauthorized("DWZGA5", "The API key is not valid.")
+	}
+
+	apiKey, claims, err = (*apiKeyService.apiKeyRepo).TouchApiKey(ctx, hashApiKey(key))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error: ZXOC2N - Authenticating API key.")
+	}
+
+	return claims, apiKey, nil
+}
+
+// GetApiKeys returns the API keys of the user with the claims that are not revoked.
+func (apiKeyService *ApiKeyService) GetApiKeys(ctx context.Context, claims *models.Claims) (apiKeys []models.ApiKey, err error) {
+	apiKeys, err = (*apiKeyService.apiKeyRepo).GetApiKeys(ctx, claims.Issuer, claims.Sub)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 6HX0XF - Getting API keys.")
+	}
+
+	return apiKeys, nil
+}
+
+// RevokeApiKey revokes an API key of the user with the claims.
+func (apiKeyService *ApiKeyService) RevokeApiKey(ctx context.Context, claims *models.Claims, apiKeyId int64) (err error) {
+	if err := (*apiKeyService.apiKeyRepo).RevokeApiKey(ctx, claims.Issuer, claims.Sub, apiKeyId); err != nil {
+		return errors.Wrap(err, "Error: KTIU09 - Revoking API key.")
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestApiKeyService_CreateApiKey_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mocks.NewMockApiKeyRepo(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	apiKeyService := NewApiKeyService(mockApiKeyRepo, mockRoleRepo, zaptest.NewLogger(t))
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+
+	mockRoleRepo.EXPECT().
+		GetPermissionsBySubject(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return([]string{models.PermissionFoosRead, models.PermissionFoosWrite}, nil)
+
+	// Only the hash of the key is stored, with the scopes sorted and deduplicated
+	var storedHash []byte
+	mockApiKeyRepo.EXPECT().
+		CreateApiKey(gomock.Any(), "https://idp.example.com", "sub-1", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, issuer string, subject string, keyHash []byte, apiKey *models.ApiKey) (*models.ApiKey, error) {
+			storedHash = keyHash
+			require.Equal(t, "ci", apiKey.Name)
+			require.Equal(t, []string{models.PermissionFoosRead, models.PermissionFoosWrite}, apiKey.Scopes)
+			created := *apiKey
+			created.ID = 4
+			return &created, nil
+		})
+
+	newApiKey, err := apiKeyService.CreateApiKey(context.Background(), claims, &models.ApiKeyRequest{
+		Name:   "ci",
+		Scopes: []string{models.PermissionFoosWrite, models.PermissionFoosRead, models.PermissionFoosWrite},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), newApiKey.ID)
+	require.True(t, strings.HasPrefix(newApiKey.Key, models.ApiKeyPrefix))
+	require.Equal(t, newApiKey.Key[:len(newApiKey.Prefix)], newApiKey.Prefix)
+	hash := sha256.Sum256([]byte(newApiKey.Key))
+	require.Equal(t, hash[:], storedHash)
+}
+
+func TestApiKeyService_CreateApiKey_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mocks.NewMockApiKeyRepo(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	apiKeyService := NewApiKeyService(mockApiKeyRepo, mockRoleRepo, zaptest.NewLogger(t))
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+
+	// A scope the user does not have and an expiry in the past, the key is not created
+	mockRoleRepo.EXPECT().
+		GetPermissionsBySubject(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return([]string{models.PermissionFoosRead}, nil)
+
+	newApiKey, err := apiKeyService.CreateApiKey(context.Background(), claims, &models.ApiKeyRequest{
+		Name:      "ci",
+		Scopes:    []string{models.PermissionFoosPurge},
+		ExpiresAt: time.Now().Add(-time.Hour).UnixMilli(),
+	})
+	require.Nil(t, newApiKey)
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, apperrors.KindValidation, appError.Kind)
+	require.Len(t, appError.Fields, 2)
+	require.Equal(t, "scopes", appError.Fields[0].Field)
+	require.Equal(t, "expires_at", appError.Fields[1].Field)
+}
+
+func TestApiKeyService_Authenticate_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockApiKeyRepo := mocks.NewMockApiKeyRepo(ctrl)
+	apiKeyService := NewApiKeyService(mockApiKeyRepo, mocks.NewMockRoleRepo(ctrl), zaptest.NewLogger(t))
+
+	// 1) Test a key without the prefix, the repo must not be called
+	claims, apiKey, err := apiKeyService.Authenticate(context.Background(), "key-1")
+	require.Nil(t, claims)
+	require.Nil(t, apiKey)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test an expired or revoked key
+	hash := sha256.Sum256([]byte("fpk_key-1"))
+	mockApiKeyRepo.EXPECT().
+		TouchApiKey(gomock.Any(), hash[:]).
+		Return(nil, nil, apperrors.Unauthorized("F428P9", "The API key is not valid, it may have expired or been revoked."))
+
+	claims, apiKey, err = apiKeyService.Authenticate(context.Background(), "fpk_key-1")
+	require.Nil(t, claims)
+	require.Nil(t, apiKey)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+}
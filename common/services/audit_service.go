@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_audit_service.go \
+  -package=mocks \
+  -mock_names=AuditServiceInterface=MockAuditService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  AuditServiceInterface
+*/
+
+type AuditServiceInterface interface {
+	GetAuditEvents(ctx context.Context, params *models.AuditListParams) (page *models.AuditPage, err error)
+}
+
+type AuditService struct {
+	auditRepo *repos.AuditRepoInterface
+	logger    *zap.Logger
+}
+
+// NewAuditService makes an audit service that reads the audit log. The services that change data write to it
+// themselves, in the transactions of their changes.
+func NewAuditService(auditRepo repos.AuditRepoInterface, logger *zap.Logger) *AuditService {
+	return &AuditService{auditRepo: &auditRepo, logger: logger}
+}
+
+// GetAuditEvents returns one page of the audit log of the organization, newest first.
+func (auditService *AuditService) GetAuditEvents(ctx context.Context, params *models.AuditListParams) (page *models.AuditPage, err error) {
+	params.ApplyDefaults()
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Error: AG6BT5 - Validating audit list params.")
+	}
+
+	events, nextCursor, err := (*auditService.auditRepo).GetAuditEvents(ctx, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 4UYECB - Getting audit events.")
+	}
+
+	return &models.AuditPage{Items: events, NextCursor: nextCursor}, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestAuditService_GetAuditEvents_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	auditService := NewAuditService(mockAuditRepo, logger)
+
+	// Defaults are applied before the repo is called.
+	mockAuditRepo.EXPECT().
+		GetAuditEvents(gomock.Any(), &models.AuditListParams{Action: "delete", Limit: 50}).
+		Return([]models.AuditEvent{{ID: 9, Action: "delete"}}, "next", nil)
+
+	page, err := auditService.GetAuditEvents(context.Background(), &models.AuditListParams{Action: "delete"})
+	require.NoError(t, err)
+	require.Equal(t, &models.AuditPage{Items: []models.AuditEvent{{ID: 9, Action: "delete"}}, NextCursor: "next"}, page)
+}
+
+func TestAuditService_GetAuditEvents_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	auditService := NewAuditService(mockAuditRepo, logger)
+
+	// 1) Test params that are not valid, the repo must not be called
+	page, err := auditService.GetAuditEvents(context.Background(), &models.AuditListParams{From: -1})
+	require.Nil(t, page)
+	require.Equal(t, apperrors.KindBadRequest, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "0WTJJX")
+
+	// 2) Test repo failure
+	mockAuditRepo.EXPECT().GetAuditEvents(gomock.Any(), gomock.Any()).Return(nil, "", errors.New("db error"))
+
+	page, err = auditService.GetAuditEvents(context.Background(), &models.AuditListParams{})
+	require.Nil(t, page)
+	require.Contains(t, err.Error(), "4UYECB")
+}
@@ -3,96 +3,403 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	oidc "github.com/coreos/go-oidc"
 	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
 	"go.uber.org/zap"
 	oauth2 "golang.org/x/oauth2"
 )
 
-var (
-	provider    *oidc.Provider
-	oauthConfig *oauth2.Config
-	verifier    *oidc.IDTokenVerifier
-)
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_authc_service.go \
+  -package=mocks \
+  -mock_names=AuthcServiceInterface=MockAuthcService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  AuthcServiceInterface
+*/
 
 type AuthcServiceInterface interface {
-	GetOauthConfig() *oauth2.Config
-	GenerateState() (string, error)
-	ProcessOauth(code string) (*models.Claims, *string, error)
+	GetProviders() []models.LoginProvider
+	StartLogin(ctx context.Context, provider string, returnTo string) (authURL string, state string, err error)
+	ProcessOauth(ctx context.Context, provider string, state string, code string) (claims *models.Claims, tokens *models.OidcTokens, returnTo string, err error)
+	RefreshTokens(ctx context.Context, provider string, refreshToken string) (claims *models.Claims, tokens *models.OidcTokens, err error)
+	VerifyToken(ctx context.Context, rawToken string) (*models.Claims, error)
+}
+
+// LoginTimeout is how long a user has to log in with the provider before the login state expires.
+const LoginTimeout = 5 * time.Minute
+
+// oidcProvider is a discovered OIDC provider with the OAuth2 config and ID token verifier of its client.
+type oidcProvider struct {
+	config      models.OidcProviderConfig
+	oauthConfig *oauth2.Config
+	verifier    *oidc.IDTokenVerifier
 }
 
 type AuthcService struct {
-	logger *zap.Logger
+	providers map[string]*oidcProvider
+	// names keeps the providers in their configured order for the login page.
+	names                []string
+	oidcLoginRepo        *repos.OidcLoginRepoInterface
+	allowedReturnOrigins []string
+	logger               *zap.Logger
 }
 
-func NewAuthcService(logger *zap.Logger) (*AuthcService, error) {
+// NewAuthcService discovers the endpoints and keys of every configured provider. oidcLoginRepo keeps the logins in
+// progress, and allowedReturnOrigins are the origins besides this site users may return to after a login.
+func NewAuthcService(providerConfigs []models.OidcProviderConfig, oidcLoginRepo repos.OidcLoginRepoInterface, allowedReturnOrigins []string, logger *zap.Logger) (*AuthcService, error) {
 	ctx := context.Background()
 
-	// 1. Initialize OIDC Provider
-	var err error
-	provider, err = oidc.NewProvider(ctx, "https://accounts.google.com")
-	if err != nil {
-		return nil, errors.Wrap(err, "Fatal: 3JEUER - Getting oidc provider.")
+	authcService := &AuthcService{
+		providers:            map[string]*oidcProvider{},
+		oidcLoginRepo:        &oidcLoginRepo,
+		allowedReturnOrigins: allowedReturnOrigins,
+		logger:               logger,
 	}
+	for _, providerConfig := range providerConfigs {
+		// 1. Initialize OIDC Provider
+		provider, err := oidc.NewProvider(ctx, providerConfig.Issuer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fatal: 3JEUER - Getting oidc provider %s.", providerConfig.Name)
+		}
+
+		// 2. Setup OAuth2 config
+		scopes := providerConfig.Scopes
+		if providerConfig.OfflineAccess {
+			scopes, err = offlineScopes(provider, scopes)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Fatal: 6O5Q3F - Getting the scopes of oidc provider %s.", providerConfig.Name)
+			}
+		}
+		oauthConfig := &oauth2.Config{
+			ClientID:     providerConfig.ClientId,
+			ClientSecret: providerConfig.ClientSecret,
+			RedirectURL:  providerConfig.RedirectUri,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		}
 
-	// 2. Setup OAuth2 config
-	oauthConfig = &oauth2.Config{
-		ClientID:     *models.GlobalConfig.GetGoogleOidcClientId(),
-		ClientSecret: *models.GlobalConfig.GetGoogleOidcClientSecret(),
-		RedirectURL:  *models.GlobalConfig.GetRedirectUri(),
-		Endpoint:     provider.Endpoint(),
-		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		// 3. Verifier for the ID Token
+		verifier := provider.Verifier(&oidc.Config{ClientID: providerConfig.ClientId})
+
+		authcService.providers[providerConfig.Name] = &oidcProvider{config: providerConfig, oauthConfig: oauthConfig, verifier: verifier}
+		authcService.names = append(authcService.names, providerConfig.Name)
 	}
 
-	// 3. Verifier for the ID Token
-	verifier = provider.Verifier(&oidc.Config{ClientID: *models.GlobalConfig.GetGoogleOidcClientId()})
+	return authcService, nil
+}
 
-	return &AuthcService{logger: logger}, nil
+// offlineScopes adds the offline_access scope to scopes when the provider supports it. Providers like Google do not
+// know the scope and only issue refresh tokens for the access_type=offline parameter, which StartLogin sends.
+func offlineScopes(provider *oidc.Provider, scopes []string) ([]string, error) {
+	var discovery struct {
+		ScopesSupported []string `json:"scopes_supported"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(scopes, oidc.ScopeOfflineAccess) || !slices.Contains(discovery.ScopesSupported, oidc.ScopeOfflineAccess) {
+		return scopes, nil
+	}
+	return append(slices.Clone(scopes), oidc.ScopeOfflineAccess), nil
 }
 
-func (authcService *AuthcService) GetOauthConfig() *oauth2.Config {
-	return oauthConfig
+// GetProviders returns the providers users can log in with, in their configured order.
+func (authcService *AuthcService) GetProviders() []models.LoginProvider {
+	providers := make([]models.LoginProvider, 0, len(authcService.names))
+	for _, name := range authcService.names {
+		providers = append(providers, models.LoginProvider{Name: name, DisplayName: authcService.providers[name].config.DisplayName})
+	}
+	return providers
 }
 
-func (authcService *AuthcService) GetVerifier() *oidc.IDTokenVerifier {
-	return verifier
+func (authcService *AuthcService) getProvider(name string) (*oidcProvider, error) {
+	provider, ok := authcService.providers[name]
+	if !ok {
+		return nil, apperrors.NotFound("2KJTXH", "No login provider named "+name+".")
+	}
+	return provider, nil
 }
 
-func (authcService *AuthcService) GenerateState() (string, error) {
+// randomToken returns 32 random bytes encoded for URLs.
+func randomToken() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
 	if err != nil {
-		return "", errors.Wrap(err, "Error: Z34I1P - Generating state for oidc.")
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashState is what the oidc_logins table stores instead of the state.
+func hashState(state string) []byte {
+	hash := sha256.Sum256([]byte(state))
+	return hash[:]
+}
+
+// StartLogin starts a login with provider. It stores a fresh state, S256 PKCE code verifier and nonce and returns
+// the URL of the provider to redirect to and the state for the state cookie. returnTo is where the user goes after
+// the login, it must be a path on this site or a URL with an allowed origin.
+func (authcService *AuthcService) StartLogin(ctx context.Context, provider string, returnTo string) (authURL string, state string, err error) {
+	oidcProvider, err := authcService.getProvider(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := checkReturnTo(returnTo, authcService.allowedReturnOrigins); err != nil {
+		return "", "", err
+	}
+
+	// 1. Generate a secure, random state string and nonce
+	state, err = randomToken()
+	if err != nil {
+		return "", "", errors.Wrap(err, "Error: Z34I1P - Generating state for oidc.")
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", errors.Wrap(err, "Error: YH6EPM - Generating nonce for oidc.")
+	}
+
+	// 2. Keep them with the PKCE verifier until the callback
+	login := &models.OidcLogin{Provider: provider, CodeVerifier: oauth2.GenerateVerifier(), Nonce: nonce, ReturnTo: returnTo}
+	if err := (*authcService.oidcLoginRepo).CreateLogin(ctx, hashState(state), login, LoginTimeout.Milliseconds()); err != nil {
+		return "", "", errors.Wrap(err, "Error: R20W9X - Storing oidc login.")
+	}
+
+	options := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(login.CodeVerifier), oidc.Nonce(nonce)}
+	if oidcProvider.config.OfflineAccess {
+		options = append(options, oauth2.AccessTypeOffline)
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+	authURL = oidcProvider.oauthConfig.AuthCodeURL(state, options...)
+	return authURL, state, nil
 }
 
-func (authcService *AuthcService) ProcessOauth(code string) (*models.Claims, *string, error) {
-	token, err := oauthConfig.Exchange(context.Background(), code)
+// ProcessOauth finishes the login with provider that has the state. It exchanges the code for tokens with the
+// PKCE verifier of the login, checks the nonce of the ID token and returns the claims, the tokens and where the user
+// returns to.
+func (authcService *AuthcService) ProcessOauth(ctx context.Context, provider string, state string, code string) (claims *models.Claims, tokens *models.OidcTokens, returnTo string, err error) {
+	oidcProvider, err := authcService.getProvider(provider)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error: FN1SF9 - Exchanging the code for a token.")
+		return nil, nil, "", err
+	}
+
+	login, err := (*authcService.oidcLoginRepo).TakeLogin(ctx, hashState(state))
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "Error: CHDBZ6 - Taking oidc login.")
+	}
+	if login.Provider != provider {
+		return nil, nil, "", apperrors.Unauthorized("N7FAP4", "The login was started with another provider.")
+	}
+
+	token, err := oidcProvider.oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(login.CodeVerifier))
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "Error: FN1SF9 - Exchanging the code for a token.")
 	}
 
 	rawIDToken, ok := token.Extra("id_token").(string)
 	if !ok {
-		return nil, nil, errors.New("Error: ZODLPM - Extracting the jwt.")
+		return nil, nil, "", errors.New("Error: ZODLPM - Extracting the jwt.")
 	}
 
-	idToken, err := verifier.Verify(context.Background(), rawIDToken)
+	idToken, err := oidcProvider.verifier.Verify(ctx, rawIDToken)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Error: FX6ZJP - Verifying the jwt.")
+		return nil, nil, "", errors.Wrap(err, "Error: FX6ZJP - Verifying the jwt.")
 	}
 
-	// 5. Extract user claims
-	claims := &models.Claims{}
+	// The nonce ties the ID token to this login, so a token from another login can not be replayed.
+	if idToken.Nonce != login.Nonce {
+		return nil, nil, "", apperrors.Unauthorized("GCOS9A", "The ID token is not from this login.")
+	}
 
-	if err := idToken.Claims(claims); err != nil {
-		return nil, nil, errors.Wrap(err, "Error: WTWOO1 - Extracting the claims.")
+	claims, err = oidcProvider.claims(idToken)
+	if err != nil {
+		return nil, nil, "", err
 	}
 
-	return claims, &rawIDToken, nil
+	tokens = &models.OidcTokens{
+		Provider:         provider,
+		IDToken:          rawIDToken,
+		IDTokenExpiresAt: idToken.Expiry.UnixMilli(),
+		RefreshToken:     token.RefreshToken,
+	}
+	return claims, tokens, login.ReturnTo, nil
+}
+
+// RefreshTokens gets a fresh ID token from provider with a refresh token of an earlier login. The provider may
+// rotate the refresh token, tokens has the one to use next time. A refresh token the provider rejects, because it
+// expired or the user revoked it, gets an unauthorized error.
+func (authcService *AuthcService) RefreshTokens(ctx context.Context, provider string, refreshToken string) (claims *models.Claims, tokens *models.OidcTokens, err error) {
+	oidcProvider, err := authcService.getProvider(provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := oidcProvider.oauthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.Response != nil && retrieveErr.Response.StatusCode < 500 {
+			return nil, nil, apperrors.Unauthorized("Z0UMCB", "Your login with the provider has ended, log in again.").WithCause(err)
+		}
+		return nil, nil, errors.Wrap(err, "Error: UDSFBO - Refreshing the token.")
+	}
 
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, nil, errors.New("Error: B3S04I - The provider did not return an ID token for the refresh token.")
+	}
+
+	idToken, err := oidcProvider.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error: 2HIVGR - Verifying the refreshed jwt.")
+	}
+
+	claims, err = oidcProvider.claims(idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens = &models.OidcTokens{
+		Provider:         provider,
+		IDToken:          rawIDToken,
+		IDTokenExpiresAt: idToken.Expiry.UnixMilli(),
+		RefreshToken:     token.RefreshToken,
+	}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = refreshToken
+	}
+	return claims, tokens, nil
+}
+
+// checkReturnTo allows paths on this site, and URLs whose origin is in allowedOrigins, as the page to return to
+// after a login. Anything else could send users to another site right after they logged in.
+func checkReturnTo(returnTo string, allowedOrigins []string) error {
+	if returnTo == "" {
+		return nil
+	}
+
+	invalid := apperrors.BadRequest("L82GID", "return_to must be a path on this site or an allowed URL.")
+
+	// Browsers treat a backslash like a slash, so "/\evil.example.com" would be another site.
+	if strings.ContainsAny(returnTo, "\\\r\n\t") {
+		return invalid
+	}
+
+	returnURL, err := url.Parse(returnTo)
+	if err != nil {
+		return invalid.WithCause(err)
+	}
+
+	if returnURL.Scheme == "" && returnURL.Host == "" {
+		if strings.HasPrefix(returnTo, "/") && !strings.HasPrefix(returnTo, "//") {
+			return nil
+		}
+		return invalid
+	}
+
+	origin := returnURL.Scheme + "://" + returnURL.Host
+	if (returnURL.Scheme == "https" || returnURL.Scheme == "http") && returnURL.User == nil && slices.Contains(allowedOrigins, origin) {
+		return nil
+	}
+	return invalid
+}
+
+// VerifyToken verifies an ID token from any of the providers. The unverified iss claim of the token picks the
+// providers to try, so a token is only checked against the keys of the issuer it claims to come from.
+func (authcService *AuthcService) VerifyToken(ctx context.Context, rawToken string) (*models.Claims, error) {
+	issuer, err := unverifiedIssuer(rawToken)
+	if err != nil {
+		return nil, apperrors.Unauthorized("S2UU5K", "The token is not valid.").WithCause(err)
+	}
+
+	var verifyErr error = errors.Errorf("Error: QECWHW - No provider has the issuer %q.", issuer)
+	for _, name := range authcService.names {
+		oidcProvider := authcService.providers[name]
+		if oidcProvider.config.Issuer != issuer {
+			continue
+		}
+
+		idToken, err := oidcProvider.verifier.Verify(ctx, rawToken)
+		if err != nil {
+			verifyErr = err
+			continue
+		}
+		return oidcProvider.claims(idToken)
+	}
+
+	return nil, apperrors.Unauthorized("S2UU5K", "The token is not valid.").WithCause(verifyErr)
+}
+
+// claims maps the claims of a verified ID token to models.Claims with the claim names configured for the provider.
+func (oidcProvider *oidcProvider) claims(idToken *oidc.IDToken) (*models.Claims, error) {
+	rawClaims := map[string]any{}
+	if err := idToken.Claims(&rawClaims); err != nil {
+		return nil, errors.Wrap(err, "Error: WTWOO1 - Extracting the claims.")
+	}
+
+	claims := mapClaims(rawClaims, &oidcProvider.config)
+	claims.Issuer = idToken.Issuer
+	return claims, nil
+}
+
+// mapClaims picks the subject, email and name out of raw ID token claims. Claims that are missing or not strings
+// are left empty.
+func mapClaims(rawClaims map[string]any, providerConfig *models.OidcProviderConfig) *models.Claims {
+	return &models.Claims{
+		Sub:   lookupClaim(rawClaims, providerConfig.SubjectClaim),
+		Email: lookupClaim(rawClaims, providerConfig.EmailClaim),
+		Name:  lookupClaim(rawClaims, providerConfig.NameClaim),
+	}
+}
+
+// lookupClaim returns the string claim at path, where a dot steps into a nested object.
+func lookupClaim(rawClaims map[string]any, path string) string {
+	var value any = rawClaims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+
+	claim, _ := value.(string)
+	return claim
+}
+
+// unverifiedIssuer reads the iss claim of a JWT without verifying it.
+func unverifiedIssuer(rawToken string) (string, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return "", errors.New("Error: NDRQLW - The token is not a JWT.")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.Wrap(err, "Error: 77VOEE - Decoding the JWT payload.")
+	}
+
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", errors.Wrap(err, "Error: QG13DL - Parsing the JWT payload.")
+	}
+	return claims.Issuer, nil
 }
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+	oauth2 "golang.org/x/oauth2"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+)
+
+func TestMapClaims_Success(t *testing.T) {
+	rawClaims := map[string]any{
+		"sub":                "sub-1",
+		"oid":                "oid-1",
+		"email":              "ada@example.com",
+		"name":               "Ada",
+		"preferred_username": "ada",
+		"profile":            map[string]any{"email": "ada@profile.example.com"},
+		"groups":             []any{"admins"},
+	}
+
+	// 1) Test the standard claims
+	claims := mapClaims(rawClaims, &models.OidcProviderConfig{SubjectClaim: "sub", EmailClaim: "email", NameClaim: "name"})
+	require.Equal(t, &models.Claims{Sub: "sub-1", Email: "ada@example.com", Name: "Ada"}, claims)
+
+	// 2) Test renamed and nested claims, like Azure AD oid and preferred_username
+	claims = mapClaims(rawClaims, &models.OidcProviderConfig{SubjectClaim: "oid", EmailClaim: "profile.email", NameClaim: "preferred_username"})
+	require.Equal(t, &models.Claims{Sub: "oid-1", Email: "ada@profile.example.com", Name: "ada"}, claims)
+
+	// 3) Test missing claims and claims that are not strings are left empty
+	claims = mapClaims(rawClaims, &models.OidcProviderConfig{SubjectClaim: "missing", EmailClaim: "name.email", NameClaim: "groups"})
+	require.Equal(t, &models.Claims{}, claims)
+}
+
+func TestAuthcService_VerifyToken_Error(t *testing.T) {
+	authcService := &AuthcService{providers: map[string]*oidcProvider{}, logger: zaptest.NewLogger(t)}
+
+	// 1) Test a token that is not a JWT
+	claims, err := authcService.VerifyToken(context.Background(), "not-a-jwt")
+	require.Nil(t, claims)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "NDRQLW")
+
+	// 2) Test a token from an issuer that is not configured
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"https://evil.example.com","sub":"sub-1"}`))
+	claims, err = authcService.VerifyToken(context.Background(), "e30."+payload+".c2ln")
+	require.Nil(t, claims)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "QECWHW")
+}
+
+func TestAuthcService_StartLogin_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOidcLoginRepo := mocks.NewMockOidcLoginRepo(ctrl)
+	authcService := &AuthcService{
+		providers: map[string]*oidcProvider{"google": {oauthConfig: &oauth2.Config{
+			ClientID: "client-1",
+			Endpoint: oauth2.Endpoint{AuthURL: "https://idp.example.com/auth"},
+		}}},
+		oidcLoginRepo: &[]repos.OidcLoginRepoInterface{mockOidcLoginRepo}[0],
+		logger:        zaptest.NewLogger(t),
+	}
+
+	var storedHash []byte
+	var storedLogin *models.OidcLogin
+	mockOidcLoginRepo.EXPECT().
+		CreateLogin(gomock.Any(), gomock.Any(), gomock.Any(), LoginTimeout.Milliseconds()).
+		DoAndReturn(func(ctx context.Context, stateHash []byte, login *models.OidcLogin, ttl int64) error {
+			storedHash, storedLogin = stateHash, login
+			return nil
+		})
+
+	authURL, state, err := authcService.StartLogin(context.Background(), "google", "/foos?limit=5")
+	require.NoError(t, err)
+
+	// The state is only stored hashed, with the verifier, nonce and return_to of the login
+	require.Equal(t, hashState(state), storedHash)
+	require.Equal(t, "google", storedLogin.Provider)
+	require.Equal(t, "/foos?limit=5", storedLogin.ReturnTo)
+
+	// The provider gets the state, the S256 challenge of the verifier and the nonce
+	parsedURL, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsedURL.Query()
+	require.Equal(t, state, query.Get("state"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.Equal(t, oauth2.S256ChallengeFromVerifier(storedLogin.CodeVerifier), query.Get("code_challenge"))
+	require.Equal(t, storedLogin.Nonce, query.Get("nonce"))
+	require.Empty(t, query.Get("access_type"))
+
+	// A provider with offline access also asks for a refresh token the way Google wants it
+	authcService.providers["google"].config.OfflineAccess = true
+	mockOidcLoginRepo.EXPECT().CreateLogin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	authURL, _, err = authcService.StartLogin(context.Background(), "google", "")
+	require.NoError(t, err)
+	parsedURL, err = url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, "offline", parsedURL.Query().Get("access_type"))
+}
+
+func TestAuthcService_StartLogin_Error(t *testing.T) {
+	authcService := &AuthcService{
+		providers:            map[string]*oidcProvider{"google": {oauthConfig: &oauth2.Config{}}},
+		allowedReturnOrigins: []string{"https://app.example.com"},
+		logger:               zaptest.NewLogger(t),
+	}
+
+	// 1) Test an unknown provider
+	_, _, err := authcService.StartLogin(context.Background(), "okta", "")
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+
+	// 2) Test a return_to on another site, nothing is stored
+	_, _, err = authcService.StartLogin(context.Background(), "google", "https://evil.example.com/")
+	require.Equal(t, apperrors.KindBadRequest, apperrors.KindOf(err))
+}
+
+func TestAuthcService_ProcessOauth_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOidcLoginRepo := mocks.NewMockOidcLoginRepo(ctrl)
+	authcService := &AuthcService{
+		providers:     map[string]*oidcProvider{"google": {oauthConfig: &oauth2.Config{}}, "okta": {oauthConfig: &oauth2.Config{}}},
+		oidcLoginRepo: &[]repos.OidcLoginRepoInterface{mockOidcLoginRepo}[0],
+		logger:        zaptest.NewLogger(t),
+	}
+
+	// 1) Test a state that was used before or expired
+	mockOidcLoginRepo.EXPECT().
+		TakeLogin(gomock.Any(), hashState("state-1")).
+		Return(nil, apperrors.Unauthorized("SOZRGS", "The login has expired, try again."))
+
+	claims, tokens, returnTo, err := authcService.ProcessOauth(context.Background(), "google", "state-1", "code-1")
+	require.Nil(t, claims)
+	require.Nil(t, tokens)
+	require.Empty(t, returnTo)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test a login started with another provider
+	mockOidcLoginRepo.EXPECT().
+		TakeLogin(gomock.Any(), hashState("state-2")).
+		Return(&models.OidcLogin{Provider: "okta"}, nil)
+
+	claims, _, _, err = authcService.ProcessOauth(context.Background(), "google", "state-2", "code-1")
+	require.Nil(t, claims)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "N7FAP4")
+}
+
+func TestAuthcService_RefreshTokens_Error(t *testing.T) {
+	authcService := &AuthcService{providers: map[string]*oidcProvider{}, logger: zaptest.NewLogger(t)}
+
+	// A provider that is not configured any more
+	claims, tokens, err := authcService.RefreshTokens(context.Background(), "okta", "refresh-1")
+	require.Nil(t, claims)
+	require.Nil(t, tokens)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+}
+
+func TestCheckReturnTo(t *testing.T) {
+	allowedOrigins := []string{"https://app.example.com"}
+
+	tests := []struct {
+		returnTo string
+		valid    bool
+	}{
+		{"", true},
+		{"/", true},
+		{"/foos?limit=5#top", true},
+		{"https://app.example.com/foos", true},
+		{"foos", false},
+		{"//evil.example.com", false},
+		{"/\\evil.example.com", false},
+		{"https://evil.example.com/", false},
+		{"https://app.example.com.evil.example.com/", false},
+		{"https://user@app.example.com/", false},
+		{"http://app.example.com/", false},
+		{"javascript:alert(1)", false},
+		{"/foos\r\nSet-Cookie: a=b", false},
+	}
+
+	for _, test := range tests {
+		err := checkReturnTo(test.returnTo, allowedOrigins)
+		if test.valid {
+			require.NoError(t, err, test.returnTo)
+		} else {
+			require.Equal(t, apperrors.KindBadRequest, apperrors.KindOf(err), test.returnTo)
+		}
+	}
+}
@@ -1,9 +1,17 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
 	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/validation"
 	"go.uber.org/zap"
 )
 
@@ -22,31 +30,147 @@ mockgen \
 */
 
 type FooServiceInterface interface {
-	GetFoos() (foos *[]models.Foo, err error)
-	CreateFoo(name string) (foo *models.Foo, err error)
-	DeleteFoos() (rowsAffected int64, err error)
-	UpdateFoo(fooId int64, name string) (foo *models.Foo, err error)
+	GetFoos(ctx context.Context, params *models.FooListParams) (page *models.FooPage, err error)
+	SearchFoos(ctx context.Context, params *models.FooSearchParams) (results []models.FooSearchResult, err error)
+	GetFooByID(ctx context.Context, fooId int64) (foo *models.Foo, err error)
+	CreateFoo(ctx context.Context, name string) (foo *models.Foo, err error)
+	CreateFoos(ctx context.Context, names []string) (items []models.FooBatchItem, err error)
+	DeleteFoos(ctx context.Context) (rowsAffected int64, err error)
+	DeleteFoosByID(ctx context.Context, fooIds []int64) (items []models.FooBatchItem, err error)
+	DeleteFoo(ctx context.Context, fooId int64) (err error)
+	RestoreFoo(ctx context.Context, fooId int64) (foo *models.Foo, err error)
+	PurgeFoos(ctx context.Context, olderThan time.Duration) (rowsAffected int64, err error)
+	UpdateFoo(ctx context.Context, fooId int64, name string, version int) (foo *models.Foo, err error)
+	PatchFoo(ctx context.Context, fooId int64, patch *models.FooPatch, version int) (foo *models.Foo, err error)
+	PatchFoos(ctx context.Context, patches []models.FooBatchPatch) (items []models.FooBatchItem, err error)
+	GetFooShares(ctx context.Context, fooId int64) (shares []models.FooShare, err error)
+	ShareFoo(ctx context.Context, fooId int64, request *models.FooShareRequest) (share *models.FooShare, err error)
+	UnshareFoo(ctx context.Context, fooId int64, shareId int) (err error)
+	GetFooRevisions(ctx context.Context, fooId int64) (revisions []models.FooRevision, err error)
+	GetFooRevision(ctx context.Context, fooId int64, revision int) (fooRevision *models.FooRevision, err error)
+	DiffFooRevisions(ctx context.Context, fooId int64, from int, to int) (diff *models.FooRevisionDiff, err error)
+	RestoreFooRevision(ctx context.Context, fooId int64, revision int, version int) (foo *models.Foo, err error)
+	ExportFoos(ctx context.Context, includeDeleted bool, fn func(foo *models.Foo) error) (err error)
+	ImportFoos(ctx context.Context, rows []models.FooImportRow, dryRun bool) (report *models.FooImportReport, err error)
 }
 
 type FooService struct {
-	fooRepo *repos.FooRepoInterface
-	logger  *zap.Logger
+	fooRepo         *repos.FooRepoInterface
+	fooShareRepo    *repos.FooShareRepoInterface
+	fooRevisionRepo *repos.FooRevisionRepoInterface
+	auditRepo       *repos.AuditRepoInterface
+	txManager       *interfaces.PgxTxManagerInterface
+	logger          *zap.Logger
+}
+
+// NewFooService makes a foo service. txManager runs the units of work that need more than one repo call.
+// fooShareRepo tells what the user of a request may do with a foo, which changes check once the foo is locked.
+// fooRevisionRepo reads the revisions fooRepo writes. Every change is recorded with auditRepo in the unit of work
+// of the change.
+func NewFooService(fooRepo repos.FooRepoInterface, fooShareRepo repos.FooShareRepoInterface, fooRevisionRepo repos.FooRevisionRepoInterface, auditRepo repos.AuditRepoInterface, txManager interfaces.PgxTxManagerInterface, logger *zap.Logger) *FooService {
+	return &FooService{fooRepo: &fooRepo, fooShareRepo: &fooShareRepo, fooRevisionRepo: &fooRevisionRepo, auditRepo: &auditRepo, txManager: &txManager, logger: logger}
+}
+
+// fooAccessRanks orders the foo access, a user with one access may do everything the lower ones allow.
+var fooAccessRanks = map[string]int{
+	models.FooAccessRead:  1,
+	models.FooAccessWrite: 2,
+	models.FooAccessOwner: 3,
+}
+
+// fooAccessError is the error of a user with access to foo fooId who needs at least need. Foos the user may not
+// even read are not found, so they can not tell them from foos that do not exist.
+func fooAccessError(fooId int64, access string, need string) error {
+	if fooAccessRanks[access] == 0 {
+		return apperrors.NotFound("EDGPYP", fmt.Sprintf("No foo found with id %d.", fooId))
+	}
+	if fooAccessRanks[access] < fooAccessRanks[need] {
+		return apperrors.Forbidden("A2BGNI", fmt.Sprintf("You need %s access to foo %d.", need, fooId))
+	}
+	return nil
+}
+
+// checkFooAccess makes sure the user of ctx has at least need access to the foo.
+func (fooService *FooService) checkFooAccess(ctx context.Context, fooId int64, need string) error {
+	access, err := (*fooService.fooShareRepo).GetFooAccess(ctx, []int64{fooId})
+	if err != nil {
+		return errors.Wrap(err, "Error: 782120 - Getting access to foo.")
+	}
+
+	return fooAccessError(fooId, access[fooId], need)
 }
 
-func NewFooService(fooRepo repos.FooRepoInterface, logger *zap.Logger) *FooService {
-	return &FooService{fooRepo: &fooRepo, logger: logger}
+// checkLockedFooAccess is checkFooAccess in the transaction tx of a change, after the foo was locked with
+// GetFoosForUpdate. Shares and owners changed before the lock are seen, a check before the transaction could miss them.
+func (fooService *FooService) checkLockedFooAccess(ctx context.Context, tx interfaces.PgxTxInterface, fooId int64, need string) error {
+	access, err := (*fooService.fooShareRepo).WithTx(tx).GetFooAccess(ctx, []int64{fooId})
+	if err != nil {
+		return errors.Wrap(err, "Error: 81N7AK - Getting access to locked foo.")
+	}
+
+	return fooAccessError(fooId, access[fooId], need)
 }
 
-func (fooService *FooService) GetFoos() (foos *[]models.Foo, err error) {
-	foos, err = (*fooService.fooRepo).GetFoos()
+func (fooService *FooService) GetFoos(ctx context.Context, params *models.FooListParams) (page *models.FooPage, err error) {
+	params.ApplyDefaults()
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Error: I73KAC - Validating foo list params.")
+	}
+
+	foos, nextCursor, err := (*fooService.fooRepo).GetFoos(ctx, params)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error: WZDCXT - Getting foos.")
 	}
-	return foos, nil
+
+	page = &models.FooPage{Items: *foos, NextCursor: nextCursor}
+
+	if params.IncludeTotal {
+		total, err := (*fooService.fooRepo).CountFoos(ctx, params)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error: FWQJM1 - Counting foos.")
+		}
+		page.Total = &total
+	}
+
+	return page, nil
 }
 
-func (fooService *FooService) CreateFoo(name string) (foo *models.Foo, err error) {
-	foo, err = (*fooService.fooRepo).CreateFoo(name)
+// SearchFoos finds the foos the user may see by their name, best match first, see FooRepo.SearchFoos.
+func (fooService *FooService) SearchFoos(ctx context.Context, params *models.FooSearchParams) (results []models.FooSearchResult, err error) {
+	params.ApplyDefaults()
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(err, "Error: CSKLUF - Validating foo search params.")
+	}
+
+	results, err = (*fooService.fooRepo).SearchFoos(ctx, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: KL6FPJ - Searching foos.")
+	}
+
+	return results, nil
+}
+
+func (fooService *FooService) GetFooByID(ctx context.Context, fooId int64) (foo *models.Foo, err error) {
+	if err := fooService.checkFooAccess(ctx, fooId, models.FooAccessRead); err != nil {
+		return nil, errors.Wrap(err, "Error: U47X7L - Checking access to foo.")
+	}
+
+	foo, err = (*fooService.fooRepo).GetFooByID(ctx, fooId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: T6D444 - Getting foo.")
+	}
+
+	return foo, nil
+}
+
+func (fooService *FooService) CreateFoo(ctx context.Context, name string) (foo *models.Foo, err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		foo, err = (*fooService.fooRepo).WithTx(tx).CreateFoo(ctx, name)
+		if err != nil {
+			return err
+		}
+		return fooService.audit(ctx, tx, fooAuditEvent(models.AuditActionCreate, nil, foo))
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Error: DWA4G7 - Creating foos.")
 	}
@@ -54,8 +178,65 @@ func (fooService *FooService) CreateFoo(name string) (foo *models.Foo, err error
 	return foo, nil
 }
 
-func (fooService *FooService) DeleteFoos() (rowsAffected int64, err error) {
-	rowsAffected, err = (*fooService.fooRepo).DeleteFoos()
+// CreateFoos creates a foo for each name. Names that break the FooRequest rules fail on their own and the rest
+// are created together.
+func (fooService *FooService) CreateFoos(ctx context.Context, names []string) (items []models.FooBatchItem, err error) {
+	if err := checkFooBatchSize(len(names)); err != nil {
+		return nil, err
+	}
+
+	items = make([]models.FooBatchItem, len(names))
+	validNames := []string{}
+	validIndexes := []int{}
+	for i, name := range names {
+		request := models.FooRequest{Name: name}
+		if err := validation.Check("XTL5HQ", &request); err != nil {
+			items[i].Err = err
+			continue
+		}
+		validNames = append(validNames, request.Name)
+		validIndexes = append(validIndexes, i)
+	}
+	if len(validNames) == 0 {
+		return items, nil
+	}
+
+	var foos *[]models.Foo
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		foos, err = (*fooService.fooRepo).WithTx(tx).CreateFoos(ctx, validNames)
+		if err != nil {
+			return err
+		}
+		events := make([]models.AuditEvent, len(*foos))
+		for i := range *foos {
+			events[i] = fooAuditEvent(models.AuditActionCreate, nil, &(*foos)[i])
+		}
+		return fooService.audit(ctx, tx, events...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 6X0KV3 - Creating batch of foos.")
+	}
+
+	for j, foo := range *foos {
+		items[validIndexes[j]] = models.FooBatchItem{ID: int64(foo.ID), Foo: &foo}
+	}
+
+	return items, nil
+}
+
+func (fooService *FooService) DeleteFoos(ctx context.Context) (rowsAffected int64, err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		deleted, err := (*fooService.fooRepo).WithTx(tx).DeleteFoos(ctx)
+		if err != nil {
+			return err
+		}
+		rowsAffected = int64(len(deleted))
+		events := make([]models.AuditEvent, len(deleted))
+		for i := range deleted {
+			events[i] = fooAuditEvent(models.AuditActionDelete, &deleted[i], nil)
+		}
+		return fooService.audit(ctx, tx, events...)
+	})
 	if err != nil {
 		return 0, errors.Wrap(err, "Error: BA8TAX - Deleting foos.")
 	}
@@ -63,11 +244,558 @@ func (fooService *FooService) DeleteFoos() (rowsAffected int64, err error) {
 	return rowsAffected, nil
 }
 
-func (fooService *FooService) UpdateFoo(fooId int64, name string) (foo *models.Foo, err error) {
-	foo, err = (*fooService.fooRepo).UpdateFoo(fooId, name)
+// DeleteFoosByID soft deletes the foos with the given ids. Ids without a foo, or of a foo the user may not change,
+// fail on their own. The access is checked once the foos are locked.
+func (fooService *FooService) DeleteFoosByID(ctx context.Context, fooIds []int64) (items []models.FooBatchItem, err error) {
+	if err := checkFooBatchSize(len(fooIds)); err != nil {
+		return nil, err
+	}
+
+	items = make([]models.FooBatchItem, len(fooIds))
+	var deletedIds []int64
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, fooIds)
+		if err != nil {
+			return err
+		}
+		access, err := (*fooService.fooShareRepo).WithTx(tx).GetFooAccess(ctx, fooIds)
+		if err != nil {
+			return errors.Wrap(err, "Error: STON1M - Getting access to foos.")
+		}
+		allowedIds := []int64{}
+		for i, fooId := range fooIds {
+			items[i].ID = fooId
+			if items[i].Err = fooAccessError(fooId, access[fooId], models.FooAccessWrite); items[i].Err == nil {
+				allowedIds = append(allowedIds, fooId)
+			}
+		}
+		if len(allowedIds) == 0 {
+			return nil
+		}
+		deletedIds, err = fooRepo.DeleteFoosByID(ctx, allowedIds)
+		if err != nil {
+			return err
+		}
+		events := make([]models.AuditEvent, len(deletedIds))
+		for i, deletedId := range deletedIds {
+			foo := before[deletedId]
+			events[i] = fooAuditEvent(models.AuditActionDelete, &foo, nil)
+		}
+		return fooService.audit(ctx, tx, events...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 5PSJ8K - Deleting foos by id.")
+	}
+
+	deleted := map[int64]bool{}
+	for _, deletedId := range deletedIds {
+		deleted[deletedId] = true
+	}
+
+	for i, fooId := range fooIds {
+		if items[i].Err == nil && !deleted[fooId] {
+			items[i].Err = apperrors.NotFound("ZCM2ZO", fmt.Sprintf("No foo found with id %d.", fooId))
+		}
+	}
+
+	return items, nil
+}
+
+func (fooService *FooService) DeleteFoo(ctx context.Context, fooId int64) (err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, []int64{fooId})
+		if err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessWrite); err != nil {
+			return errors.Wrap(err, "Error: AHJQY9 - Checking access to foo.")
+		}
+		if err := fooRepo.DeleteFoo(ctx, fooId); err != nil {
+			return err
+		}
+		foo := before[fooId]
+		return fooService.audit(ctx, tx, fooAuditEvent(models.AuditActionDelete, &foo, nil))
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error: JUYM2A - Deleting foo.")
+	}
+
+	return nil
+}
+
+func (fooService *FooService) RestoreFoo(ctx context.Context, fooId int64) (foo *models.Foo, err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, []int64{fooId})
+		if err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessWrite); err != nil {
+			return errors.Wrap(err, "Error: 15UFA2 - Checking access to foo.")
+		}
+		foo, err = fooRepo.RestoreFoo(ctx, fooId)
+		if err != nil {
+			return err
+		}
+		deleted := before[fooId]
+		return fooService.audit(ctx, tx, fooAuditEvent(models.AuditActionRestore, &deleted, foo))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: LVW0Q3 - Restoring foo.")
+	}
+
+	return foo, nil
+}
+
+// PurgeFoos hard deletes the foos that have been soft deleted for longer than olderThan.
+func (fooService *FooService) PurgeFoos(ctx context.Context, olderThan time.Duration) (rowsAffected int64, err error) {
+	if olderThan <= 0 {
+		return 0, apperrors.BadRequest("86SSOP", "The purge retention must be positive.")
+	}
+
+	deletedBefore := time.Now().Add(-olderThan).UnixMilli()
+
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		purged, err := (*fooService.fooRepo).WithTx(tx).PurgeFoos(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		rowsAffected = int64(len(purged))
+		events := make([]models.AuditEvent, len(purged))
+		for i := range purged {
+			events[i] = fooAuditEvent(models.AuditActionPurge, &purged[i], nil)
+		}
+		return fooService.audit(ctx, tx, events...)
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Error: B3EU9T - Purging foos.")
+	}
+
+	return rowsAffected, nil
+}
+
+// UpdateFoo replaces the foo if it is still at version, see FooRepo.UpdateFoo.
+func (fooService *FooService) UpdateFoo(ctx context.Context, fooId int64, name string, version int) (foo *models.Foo, err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, []int64{fooId})
+		if err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessWrite); err != nil {
+			return errors.Wrap(err, "Error: 1KPAKX - Checking access to foo.")
+		}
+		foo, err = fooRepo.UpdateFoo(ctx, fooId, name, version)
+		if err != nil {
+			return err
+		}
+		updated := before[fooId]
+		return fooService.audit(ctx, tx, fooAuditEvent(models.AuditActionUpdate, &updated, foo))
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Error: GZNHKW - Updating foos.")
 	}
 
 	return foo, nil
 }
+
+// PatchFoo updates only the fields set in patch if the foo is still at version. An empty patch changes nothing
+// and returns the foo as it is.
+func (fooService *FooService) PatchFoo(ctx context.Context, fooId int64, patch *models.FooPatch, version int) (foo *models.Foo, err error) {
+	if patch.IsEmpty() {
+		if err := fooService.checkFooAccess(ctx, fooId, models.FooAccessWrite); err != nil {
+			return nil, errors.Wrap(err, "Error: Q96UT4 - Checking access to foo.")
+		}
+		foo, err = (*fooService.fooRepo).GetFooByID(ctx, fooId)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error: 6VX9UB - Getting foo to patch.")
+		}
+		if version != 0 && foo.Version != version {
+			return nil, apperrors.PreconditionFailed("KG97B6", fmt.Sprintf("Foo %d is at version %d, not %d.", fooId, foo.Version, version))
+		}
+		return foo, nil
+	}
+
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, []int64{fooId})
+		if err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessWrite); err != nil {
+			return errors.Wrap(err, "Error: Q96UT4 - Checking access to foo.")
+		}
+		foo, err = fooRepo.PatchFoo(ctx, fooId, patch, version)
+		if err != nil {
+			return err
+		}
+		patched := before[fooId]
+		return fooService.audit(ctx, tx, fooAuditEvent(models.AuditActionUpdate, &patched, foo))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: OTR4M3 - Patching foo.")
+	}
+
+	return foo, nil
+}
+
+// PatchFoos applies each patch if its foo is still at the given version. Every item needs an id, a version
+// and a patch that changes something, and the user must be allowed to change its foo. Items fail on their own, see
+// FooRepo.PatchFoos.
+func (fooService *FooService) PatchFoos(ctx context.Context, patches []models.FooBatchPatch) (items []models.FooBatchItem, err error) {
+	if err := checkFooBatchSize(len(patches)); err != nil {
+		return nil, err
+	}
+
+	items = make([]models.FooBatchItem, len(patches))
+	validPatches := []models.FooBatchPatch{}
+	validIndexes := []int{}
+	for i, patch := range patches {
+		items[i].ID = patch.ID
+		switch {
+		case patch.ID <= 0:
+			items[i].Err = apperrors.Validation("PVKCGZ", "Every item needs the id of a foo.")
+		case patch.Version <= 0:
+			items[i].Err = apperrors.Validation("EKQTC7", "Every item needs the version of the foo it changes.")
+		case patch.PatchErr != nil:
+			items[i].Err = patch.PatchErr
+		case patch.Patch == nil || patch.Patch.IsEmpty():
+			items[i].Err = apperrors.Validation("KDRBKB", "Every item needs a patch that changes something.")
+		default:
+			if err := validation.Check("ULSRRH", patch.Patch); err != nil {
+				items[i].Err = err
+				continue
+			}
+			validPatches = append(validPatches, patch)
+			validIndexes = append(validIndexes, i)
+		}
+	}
+	if len(validPatches) == 0 {
+		return items, nil
+	}
+
+	fooIds := make([]int64, len(validPatches))
+	for j, patch := range validPatches {
+		fooIds[j] = patch.ID
+	}
+
+	// The access checks after the lock, the patches, the checks of the ones that fail and the audit of the others are
+	// one unit of work.
+	var patchedItems []models.FooBatchItem
+	allowedIndexes := []int{}
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, fooIds)
+		if err != nil {
+			return err
+		}
+		access, err := (*fooService.fooShareRepo).WithTx(tx).GetFooAccess(ctx, fooIds)
+		if err != nil {
+			return errors.Wrap(err, "Error: 3VO32X - Getting access to foos.")
+		}
+		allowedPatches := []models.FooBatchPatch{}
+		for j, patch := range validPatches {
+			if err := fooAccessError(patch.ID, access[patch.ID], models.FooAccessWrite); err != nil {
+				items[validIndexes[j]].Err = err
+				continue
+			}
+			allowedPatches = append(allowedPatches, patch)
+			allowedIndexes = append(allowedIndexes, validIndexes[j])
+		}
+		if len(allowedPatches) == 0 {
+			return nil
+		}
+		patchedItems, err = fooRepo.PatchFoos(ctx, allowedPatches)
+		if err != nil {
+			return err
+		}
+		events := []models.AuditEvent{}
+		for _, item := range patchedItems {
+			if item.Foo != nil {
+				patched := before[item.ID]
+				events = append(events, fooAuditEvent(models.AuditActionUpdate, &patched, item.Foo))
+			}
+		}
+		return fooService.audit(ctx, tx, events...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: G0INB9 - Patching batch of foos.")
+	}
+
+	for j, item := range patchedItems {
+		items[allowedIndexes[j]] = item
+	}
+
+	return items, nil
+}
+
+// GetFooShares returns who the foo is shared with. Only its owner may see them.
+func (fooService *FooService) GetFooShares(ctx context.Context, fooId int64) (shares []models.FooShare, err error) {
+	if err := fooService.checkFooAccess(ctx, fooId, models.FooAccessOwner); err != nil {
+		return nil, errors.Wrap(err, "Error: 47QNDZ - Checking access to foo.")
+	}
+
+	shares, err = (*fooService.fooShareRepo).GetFooShares(ctx, fooId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: SJZ5FG - Getting foo shares.")
+	}
+
+	return shares, nil
+}
+
+// ShareFoo gives a member of the organization or every user with a role read or write access to the foo. Only its
+// owner may share it. The share is recorded in the audit log.
+func (fooService *FooService) ShareFoo(ctx context.Context, fooId int64, request *models.FooShareRequest) (share *models.FooShare, err error) {
+	if err := validation.Check("CIJ8HY", request); err != nil {
+		return nil, err
+	}
+
+	fields := []apperrors.FieldError{}
+	if (request.UserID == nil) == (request.Role == nil) {
+		fields = append(fields, apperrors.FieldError{Field: "user_id", Code: "grantee", Message: "Give either a user_id or a role."})
+	}
+	if request.Permission != models.FooAccessRead && request.Permission != models.FooAccessWrite {
+		fields = append(fields, apperrors.FieldError{Field: "permission", Code: "permission", Message: "The permission must be read or write."})
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("5ONFLQ", "The request is not valid.").WithFields(fields...)
+	}
+
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		if _, err := (*fooService.fooRepo).WithTx(tx).GetFoosForUpdate(ctx, []int64{fooId}); err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessOwner); err != nil {
+			return errors.Wrap(err, "Error: IAR3SI - Checking access to foo.")
+		}
+		share, err = (*fooService.fooShareRepo).WithTx(tx).CreateFooShare(ctx, fooId, request)
+		if err != nil {
+			return err
+		}
+		return fooService.audit(ctx, tx, fooShareAuditEvent(models.AuditActionShare, nil, share))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 4N2I5F - Sharing foo.")
+	}
+
+	return share, nil
+}
+
+// UnshareFoo removes a share of the foo. Only its owner may remove it, which is recorded in the audit log.
+func (fooService *FooService) UnshareFoo(ctx context.Context, fooId int64, shareId int) (err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		if _, err := (*fooService.fooRepo).WithTx(tx).GetFoosForUpdate(ctx, []int64{fooId}); err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessOwner); err != nil {
+			return errors.Wrap(err, "Error: W2FW4D - Checking access to foo.")
+		}
+		share, err := (*fooService.fooShareRepo).WithTx(tx).DeleteFooShare(ctx, fooId, shareId)
+		if err != nil {
+			return err
+		}
+		return fooService.audit(ctx, tx, fooShareAuditEvent(models.AuditActionUnshare, share, nil))
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error: KSG3HN - Unsharing foo.")
+	}
+
+	return nil
+}
+
+// GetFooRevisions returns every revision of the foo, oldest first.
+func (fooService *FooService) GetFooRevisions(ctx context.Context, fooId int64) (revisions []models.FooRevision, err error) {
+	if err := fooService.checkFooAccess(ctx, fooId, models.FooAccessRead); err != nil {
+		return nil, errors.Wrap(err, "Error: RIIQW3 - Checking access to foo.")
+	}
+
+	revisions, err = (*fooService.fooRevisionRepo).GetFooRevisions(ctx, fooId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: W4WK96 - Getting foo revisions.")
+	}
+
+	return revisions, nil
+}
+
+// GetFooRevision returns the revision of the foo written at version revision.
+func (fooService *FooService) GetFooRevision(ctx context.Context, fooId int64, revision int) (fooRevision *models.FooRevision, err error) {
+	if err := fooService.checkFooAccess(ctx, fooId, models.FooAccessRead); err != nil {
+		return nil, errors.Wrap(err, "Error: QCQVSS - Checking access to foo.")
+	}
+
+	fooRevision, err = (*fooService.fooRevisionRepo).GetFooRevision(ctx, fooId, revision)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 7PMWEE - Getting foo revision.")
+	}
+
+	return fooRevision, nil
+}
+
+// DiffFooRevisions returns what changed in the foo from revision from to revision to. from may come after to, the
+// diff then shows how to go back.
+func (fooService *FooService) DiffFooRevisions(ctx context.Context, fooId int64, from int, to int) (diff *models.FooRevisionDiff, err error) {
+	if err := fooService.checkFooAccess(ctx, fooId, models.FooAccessRead); err != nil {
+		return nil, errors.Wrap(err, "Error: WZFO3P - Checking access to foo.")
+	}
+
+	fromRevision, err := (*fooService.fooRevisionRepo).GetFooRevision(ctx, fooId, from)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: OSHTUF - Getting foo revision to diff from.")
+	}
+
+	toRevision, err := (*fooService.fooRevisionRepo).GetFooRevision(ctx, fooId, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: V85BIZ - Getting foo revision to diff to.")
+	}
+
+	return models.DiffFooRevisions(fromRevision, toRevision), nil
+}
+
+// RestoreFooRevision writes the foo back as it was at revision, if the foo is still at version, see
+// FooRepo.UpdateFoo. The restored foo is a new revision, the revisions after revision are kept.
+func (fooService *FooService) RestoreFooRevision(ctx context.Context, fooId int64, revision int, version int) (foo *models.Foo, err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		fooRepo := (*fooService.fooRepo).WithTx(tx)
+		before, err := fooRepo.GetFoosForUpdate(ctx, []int64{fooId})
+		if err != nil {
+			return err
+		}
+		if err := fooService.checkLockedFooAccess(ctx, tx, fooId, models.FooAccessWrite); err != nil {
+			return errors.Wrap(err, "Error: 36O6BW - Checking access to foo.")
+		}
+		fooRevision, err := (*fooService.fooRevisionRepo).WithTx(tx).GetFooRevision(ctx, fooId, revision)
+		if err != nil {
+			return err
+		}
+		foo, err = fooRepo.UpdateFoo(ctx, fooId, fooRevision.Name, version)
+		if err != nil {
+			return err
+		}
+		updated := before[fooId]
+		return fooService.audit(ctx, tx, fooAuditEvent(models.AuditActionUpdate, &updated, foo))
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: ZM15LQ - Restoring foo revision.")
+	}
+
+	return foo, nil
+}
+
+// ExportFoos calls fn with each foo the user may see, see FooRepo.ExportFoos. The cursor reads the foos as they were
+// when the export started, however long fn takes.
+func (fooService *FooService) ExportFoos(ctx context.Context, includeDeleted bool, fn func(foo *models.Foo) error) (err error) {
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		return (*fooService.fooRepo).WithTx(tx).ExportFoos(ctx, includeDeleted, fn)
+	})
+	if err != nil {
+		return errors.Wrap(err, "Error: D4KLQE - Exporting foos.")
+	}
+
+	return nil
+}
+
+// errFooImportDryRun rolls back the transaction of a dry run import after the foos were inserted.
+var errFooImportDryRun = errors.New("Error: UFKI8L - Rolling back dry run of foo import.")
+
+// ImportFoos creates a foo owned by the user for each row, all of them or, when a row is not valid, none. The report
+// lists the errors of every row that is not valid. A dry run inserts the foos like a real import and rolls back, so
+// it also finds the errors only the database can tell.
+func (fooService *FooService) ImportFoos(ctx context.Context, rows []models.FooImportRow, dryRun bool) (report *models.FooImportReport, err error) {
+	if len(rows) == 0 || len(rows) > models.MaxFooImportRows {
+		return nil, apperrors.Validation("59YALT", fmt.Sprintf("An import must have between 1 and %d foos.", models.MaxFooImportRows))
+	}
+
+	report = &models.FooImportReport{DryRun: dryRun, Total: len(rows), Errors: []models.FooImportError{}}
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		if row.Err != nil {
+			report.Errors = append(report.Errors, models.FooImportError{Line: row.Line, Errors: []apperrors.FieldError{*row.Err}})
+			continue
+		}
+		request := models.FooRequest{Name: row.Name}
+		if fields := validation.Validate(&request); len(fields) > 0 {
+			report.Errors = append(report.Errors, models.FooImportError{Line: row.Line, Errors: fields})
+			continue
+		}
+		names[i] = request.Name
+	}
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	err = (*fooService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		foos, err := (*fooService.fooRepo).WithTx(tx).ImportFoos(ctx, names)
+		if err != nil {
+			return err
+		}
+		events := make([]models.AuditEvent, len(foos))
+		for i := range foos {
+			events[i] = fooAuditEvent(models.AuditActionCreate, nil, &foos[i])
+		}
+		if err := fooService.audit(ctx, tx, events...); err != nil {
+			return err
+		}
+		report.Imported = len(foos)
+		if dryRun {
+			return errFooImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFooImportDryRun) {
+		return nil, errors.Wrap(err, "Error: YA7QLO - Importing foos.")
+	}
+
+	return report, nil
+}
+
+// audit records the events in the audit log in the transaction tx of the changes.
+func (fooService *FooService) audit(ctx context.Context, tx interfaces.PgxTxInterface, events ...models.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := (*fooService.auditRepo).WithTx(tx).CreateAuditEvents(ctx, events); err != nil {
+		return errors.Wrap(err, "Error: ZA32FJ - Recording foo changes in the audit log.")
+	}
+	return nil
+}
+
+// fooAuditEvent is the audit event of a change of a foo, before is nil for a create and after for a delete or purge.
+func fooAuditEvent(action string, before *models.Foo, after *models.Foo) models.AuditEvent {
+	event := models.AuditEvent{Entity: models.AuditEntityFoo, Action: action}
+	// Marshalling a struct of strings and ints can not fail.
+	if before != nil {
+		event.EntityID = int64(before.ID)
+		event.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		event.EntityID = int64(after.ID)
+		event.After, _ = json.Marshal(after)
+	}
+	return event
+}
+
+// fooShareAuditEvent is the audit event of sharing a foo, or of removing a share, before is nil for a share and
+// after for an unshare. The event is about the foo of the share, so it is in the log of the foo.
+func fooShareAuditEvent(action string, before *models.FooShare, after *models.FooShare) models.AuditEvent {
+	event := models.AuditEvent{Entity: models.AuditEntityFoo, Action: action}
+	if before != nil {
+		event.EntityID = before.FooID
+		event.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		event.EntityID = after.FooID
+		event.After, _ = json.Marshal(after)
+	}
+	return event
+}
+
+// checkFooBatchSize makes sure a batch has between 1 and MaxFooBatchSize items.
+func checkFooBatchSize(size int) error {
+	if size == 0 || size > models.MaxFooBatchSize {
+		return apperrors.Validation("NROOZB", fmt.Sprintf("A batch must have between 1 and %d items.", models.MaxFooBatchSize))
+	}
+	return nil
+}
@@ -1,36 +1,120 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap/zaptest"
 
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
 	"gitlab.com/sandstone2/fiberpoc/common/mocks"
 	"gitlab.com/sandstone2/fiberpoc/common/models"
 )
 
+// runInTx stubs PgxTxManagerInterface.WithTx to run the unit of work in tx.
+func runInTx(tx interfaces.PgxTxInterface) func(ctx context.Context, fn func(tx interfaces.PgxTxInterface) error) error {
+	return func(ctx context.Context, fn func(tx interfaces.PgxTxInterface) error) error {
+		return fn(tx)
+	}
+}
+
+// fooTx stubs a unit of work of the foo service in mockTx, which the foo repo joins.
+func fooTx(mockTxManager *mocks.MockPgxTxManager, mockTx *mocks.MockPgxTx, mockFooRepo *mocks.MockFooRepo) {
+	mockTxManager.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(runInTx(mockTx))
+	mockFooRepo.EXPECT().WithTx(mockTx).Return(mockFooRepo)
+}
+
+// expectAudit expects the events to be recorded in the audit log in mockTx.
+func expectAudit(mockAuditRepo *mocks.MockAuditRepo, mockTx *mocks.MockPgxTx, events ...models.AuditEvent) {
+	mockAuditRepo.EXPECT().WithTx(mockTx).Return(mockAuditRepo)
+	mockAuditRepo.EXPECT().CreateAuditEvents(gomock.Any(), events).Return(nil)
+}
+
+// fooEvent is the audit event of a change of foo, before or after it. The other one is nil.
+func fooEvent(t *testing.T, action string, before *models.Foo, after *models.Foo) models.AuditEvent {
+	event := models.AuditEvent{Entity: models.AuditEntityFoo, Action: action}
+	var err error
+	if before != nil {
+		event.EntityID = int64(before.ID)
+		event.Before, err = json.Marshal(before)
+		require.NoError(t, err)
+	}
+	if after != nil {
+		event.EntityID = int64(after.ID)
+		event.After, err = json.Marshal(after)
+		require.NoError(t, err)
+	}
+	return event
+}
+
+// fooShareTx expects a transaction that locks the foo with fooId and then gives the user access to it, like the
+// transactions that share a foo or remove a share.
+func fooShareTx(mockTxManager *mocks.MockPgxTxManager, mockTx *mocks.MockPgxTx, mockFooRepo *mocks.MockFooRepo, mockFooShareRepo *mocks.MockFooShareRepo, access string, fooId int64) {
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().GetFoosForUpdate(gomock.Any(), []int64{fooId}).Return(map[int64]models.Foo{fooId: {ID: int(fooId)}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, access, fooId)
+}
+
+// shareEvent is the audit event of sharing a foo with share, or of removing it when unshared.
+func shareEvent(t *testing.T, action string, share *models.FooShare) models.AuditEvent {
+	data, err := json.Marshal(share)
+	require.NoError(t, err)
+	event := models.AuditEvent{Entity: models.AuditEntityFoo, EntityID: share.FooID, Action: action}
+	if action == models.AuditActionUnshare {
+		event.Before = data
+	} else {
+		event.After = data
+	}
+	return event
+}
+
+// lockedFooAccess stubs FooShareRepoInterface.GetFooAccess in mockTx, after the foos are locked, like fooAccess.
+func lockedFooAccess(mockFooShareRepo *mocks.MockFooShareRepo, mockTx *mocks.MockPgxTx, access string, fooIds ...int64) {
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	fooAccess(mockFooShareRepo, access, fooIds...)
+}
+
+// fooAccess stubs FooShareRepoInterface.GetFooAccess to give the user access to the foos with fooIds.
+func fooAccess(mockFooShareRepo *mocks.MockFooShareRepo, access string, fooIds ...int64) {
+	accessById := map[int64]string{}
+	for _, fooId := range fooIds {
+		accessById[fooId] = access
+	}
+	mockFooShareRepo.EXPECT().GetFooAccess(gomock.Any(), fooIds).Return(accessById, nil)
+}
+
 func TestFooService_GetFoos_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
 
-	expected := &[]models.Foo{{ID: 1, Name: "Joe"}}
+	// Defaults are applied before the repo is called.
+	expectedParams := &models.FooListParams{Limit: 50, SortBy: "id", Order: "asc", IncludeTotal: true}
+	mockFooRepo.EXPECT().
+		GetFoos(gomock.Any(), expectedParams).
+		Return(&[]models.Foo{{ID: 1, Name: "Joe"}}, "next", nil)
 	mockFooRepo.EXPECT().
-		GetFoos().
-		Return(expected, nil)
+		CountFoos(gomock.Any(), expectedParams).
+		Return(int64(3), nil)
 
 	logger := zaptest.NewLogger(t)
 
 	// fix: pass a pointer to mockFooRepo
-	fooService := NewFooService(mockFooRepo, logger)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
 
-	foos, err := fooService.GetFoos()
+	page, err := fooService.GetFoos(context.Background(), &models.FooListParams{IncludeTotal: true})
 	require.NoError(t, err)
-	require.Equal(t, expected, foos)
+
+	total := int64(3)
+	require.Equal(t, &models.FooPage{Items: []models.Foo{{ID: 1, Name: "Joe"}}, NextCursor: "next", Total: &total}, page)
 }
 
 func TestFooService_GetFoos_Error(t *testing.T) {
@@ -38,64 +122,200 @@ func TestFooService_GetFoos_Error(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
 
 	fooRepoError := errors.New("db failure")
 	mockFooRepo.EXPECT().
-		GetFoos().
-		Return(nil, fooRepoError)
+		GetFoos(gomock.Any(), gomock.Any()).
+		Return(nil, "", fooRepoError)
 
 	logger := zaptest.NewLogger(t)
 
 	// Pass pointer to mockFooRepo
-	fooService := NewFooService(mockFooRepo, logger)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
 
-	foos, err := fooService.GetFoos()
-	require.Nil(t, foos)
+	page, err := fooService.GetFoos(context.Background(), &models.FooListParams{})
+	require.Nil(t, page)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "WZDCXT")
+
+	// Invalid params never reach the repo.
+	page, err = fooService.GetFoos(context.Background(), &models.FooListParams{SortBy: "email"})
+	require.Nil(t, page)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "I73KAC")
 }
 
-func TestFooService_CreateFoo_Success(t *testing.T) {
+func TestFooService_SearchFoos_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
 
-	// Set up expected Foo to return
-	expectedFoo := &models.Foo{ID: 1, Name: "Test Foo"}
+	// The query is trimmed and the default limit applied before the repo is called
+	results := []models.FooSearchResult{{Foo: models.Foo{ID: 3, Name: "Garden Party"}, Rank: 1.06, Snippet: "Garden <b>Party</b>"}}
+	mockFooRepo.EXPECT().
+		SearchFoos(gomock.Any(), &models.FooSearchParams{Query: "party", Limit: models.DefaultFooSearchLimit}).
+		Return(results, nil)
+
+	found, err := fooService.SearchFoos(context.Background(), &models.FooSearchParams{Query: "  party "})
+	require.NoError(t, err)
+	require.Equal(t, results, found)
+}
+
+func TestFooService_SearchFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
+
+	// 1) Test a blank query never reaches the repo
+	found, err := fooService.SearchFoos(context.Background(), &models.FooSearchParams{Query: "  "})
+	require.Nil(t, found)
+	require.Equal(t, apperrors.KindBadRequest, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "MKNBCH")
+
+	// 2) Test a limit that is too high
+	found, err = fooService.SearchFoos(context.Background(), &models.FooSearchParams{Query: "party", Limit: models.MaxFooSearchLimit + 1})
+	require.Nil(t, found)
+	require.Contains(t, err.Error(), "HDSQQ9")
+
+	// 3) Test repo failure
+	mockFooRepo.EXPECT().SearchFoos(gomock.Any(), gomock.Any()).Return(nil, errors.New("db failure"))
+
+	found, err = fooService.SearchFoos(context.Background(), &models.FooSearchParams{Query: "party"})
+	require.Nil(t, found)
+	require.Contains(t, err.Error(), "KL6FPJ")
+}
+
+func TestFooService_GetFooByID_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
 
+	expectedFoo := &models.Foo{ID: 7, Name: "Joe"}
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
 	mockFooRepo.EXPECT().
-		CreateFoo("Test Foo").
+		GetFooByID(gomock.Any(), int64(7)).
 		Return(expectedFoo, nil)
 
 	logger := zaptest.NewLogger(t)
 
-	fooService := NewFooService(mockFooRepo, logger) // Pass pointer
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
 
-	foo, err := fooService.CreateFoo("Test Foo")
+	foo, err := fooService.GetFooByID(context.Background(), 7)
 	require.NoError(t, err)
 	require.Equal(t, expectedFoo, foo)
 }
 
-func TestFooService_CreateFoo_Error(t *testing.T) {
+func TestFooService_GetFooByID_Error(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+
+	logger := zaptest.NewLogger(t)
+
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
 
-	fooRepoError := errors.New("insert failed")
+	// 1) Test repo failure
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
 	mockFooRepo.EXPECT().
-		CreateFoo("Test Foo").
-		Return(nil, fooRepoError)
+		GetFooByID(gomock.Any(), int64(7)).
+		Return(nil, apperrors.NotFound("39YZ4S", "No foo found with id 7."))
 
+	foo, err := fooService.GetFooByID(context.Background(), 7)
+	require.Nil(t, foo)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "T6D444")
+
+	// The typed error must survive the wrapping so handlers can map it.
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+
+	// 2) Test a foo that is not shared with the user is not found
+	fooAccess(mockFooShareRepo, "", 7)
+
+	foo, err = fooService.GetFooByID(context.Background(), 7)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "EDGPYP")
+
+	// 3) Test failing to get the access
+	mockFooShareRepo.EXPECT().GetFooAccess(gomock.Any(), []int64{7}).Return(nil, errors.New("db error"))
+
+	foo, err = fooService.GetFooByID(context.Background(), 7)
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "782120")
+}
+
+func TestFooService_CreateFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
 	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
 
-	fooService := NewFooService(mockFooRepo, logger) // pass pointer
+	// The foo is created and audited in one transaction
+	expectedFoo := &models.Foo{ID: 1, Name: "Test Foo"}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		CreateFoo(gomock.Any(), "Test Foo").
+		Return(expectedFoo, nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionCreate, nil, expectedFoo))
+
+	foo, err := fooService.CreateFoo(context.Background(), "Test Foo")
+	require.NoError(t, err)
+	require.Equal(t, expectedFoo, foo)
+}
+
+func TestFooService_CreateFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test repo failure
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		CreateFoo(gomock.Any(), "Test Foo").
+		Return(nil, errors.New("insert failed"))
 
-	foo, err := fooService.CreateFoo("Test Foo")
+	foo, err := fooService.CreateFoo(context.Background(), "Test Foo")
 	require.Nil(t, foo)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "DWA4G7")
+
+	// 2) Test the audit failing
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		CreateFoo(gomock.Any(), "Test Foo").
+		Return(&models.Foo{ID: 1, Name: "Test Foo"}, nil)
+	mockAuditRepo.EXPECT().WithTx(mockTx).Return(mockAuditRepo)
+	mockAuditRepo.EXPECT().CreateAuditEvents(gomock.Any(), gomock.Any()).Return(errors.New("insert failed"))
+
+	foo, err = fooService.CreateFoo(context.Background(), "Test Foo")
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "ZA32FJ")
 }
 
 func TestFooService_DeleteFoos_Success(t *testing.T) {
@@ -103,39 +323,254 @@ func TestFooService_DeleteFoos_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
 
+	deleted := []models.Foo{{ID: 1, Name: "One", Version: 2}, {ID: 2, Name: "Two", Version: 1}}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
 	mockFooRepo.EXPECT().
-		DeleteFoos().
-		Return(int64(5), nil)
+		DeleteFoos(gomock.Any()).
+		Return(deleted, nil)
+	expectAudit(mockAuditRepo, mockTx,
+		fooEvent(t, models.AuditActionDelete, &deleted[0], nil),
+		fooEvent(t, models.AuditActionDelete, &deleted[1], nil),
+	)
+
+	rowsAffected, err := fooService.DeleteFoos(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rowsAffected)
+}
 
+func TestFooService_DeleteFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
 	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
 
-	fooService := NewFooService(mockFooRepo, logger) // pass pointer
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		DeleteFoos(gomock.Any()).
+		Return(nil, errors.New("delete failed"))
 
-	rowsAffected, err := fooService.DeleteFoos()
+	rowsAffected, err := fooService.DeleteFoos(context.Background())
+	require.Equal(t, int64(0), rowsAffected)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "BA8TAX")
+}
+
+func TestFooService_DeleteFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// The foo is locked to audit it as it was before the delete
+	before := models.Foo{ID: 3, Name: "Gone", Version: 2}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{3}).
+		Return(map[int64]models.Foo{3: before}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 3)
+	mockFooRepo.EXPECT().
+		DeleteFoo(gomock.Any(), int64(3)).
+		Return(nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionDelete, &before, nil))
+
+	err := fooService.DeleteFoo(context.Background(), 3)
 	require.NoError(t, err)
-	require.Equal(t, int64(5), rowsAffected)
 }
 
-func TestFooService_DeleteFoos_Error(t *testing.T) {
+func TestFooService_DeleteFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test repo failure
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{3}).
+		Return(map[int64]models.Foo{3: {ID: 3}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessOwner, 3)
+	mockFooRepo.EXPECT().
+		DeleteFoo(gomock.Any(), int64(3)).
+		Return(errors.New("delete failed"))
+
+	err := fooService.DeleteFoo(context.Background(), 3)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "JUYM2A")
+
+	// 2) Test a foo only shared to read once it is locked is not deleted
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{3}).
+		Return(map[int64]models.Foo{3: {ID: 3}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessRead, 3)
+
+	err = fooService.DeleteFoo(context.Background(), 3)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "A2BGNI")
+
+	// 3) Test failing to lock the foo
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{3}).
+		Return(nil, errors.New("lock failed"))
+
+	err = fooService.DeleteFoo(context.Background(), 3)
+	require.Contains(t, err.Error(), "JUYM2A")
+}
+
+func TestFooService_RestoreFoo_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	before := models.Foo{ID: 3, Name: "Back Again", Version: 2, DeletedAt: 1700000000000}
+	expectedFoo := &models.Foo{ID: 3, Name: "Back Again", Version: 3}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{3}).
+		Return(map[int64]models.Foo{3: before}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 3)
+	mockFooRepo.EXPECT().
+		RestoreFoo(gomock.Any(), int64(3)).
+		Return(expectedFoo, nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionRestore, &before, expectedFoo))
+
+	foo, err := fooService.RestoreFoo(context.Background(), 3)
+	require.NoError(t, err)
+	require.Equal(t, expectedFoo, foo)
+}
+
+func TestFooService_RestoreFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{3}).
+		Return(map[int64]models.Foo{}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 3)
+	mockFooRepo.EXPECT().
+		RestoreFoo(gomock.Any(), int64(3)).
+		Return(nil, errors.New("restore failed"))
+
+	foo, err := fooService.RestoreFoo(context.Background(), 3)
+	require.Nil(t, foo)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "LVW0Q3")
+}
+
+func TestFooService_PurgeFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
 
-	fooRepoError := errors.New("delete failed")
+	// The cut off is the retention window back from now, in epoch milliseconds.
+	before := time.Now().Add(-time.Hour).UnixMilli()
+	purged := []models.Foo{{ID: 1, DeletedAt: 1}, {ID: 2, DeletedAt: 2}, {ID: 3, DeletedAt: 3}, {ID: 4, DeletedAt: 4}}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
 	mockFooRepo.EXPECT().
-		DeleteFoos().
-		Return(int64(0), fooRepoError)
+		PurgeFoos(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, deletedBefore int64) ([]models.Foo, error) {
+			require.GreaterOrEqual(t, deletedBefore, before)
+			require.LessOrEqual(t, deletedBefore, time.Now().Add(-time.Hour).UnixMilli())
+			return purged, nil
+		})
+	events := []models.AuditEvent{}
+	for i := range purged {
+		events = append(events, fooEvent(t, models.AuditActionPurge, &purged[i], nil))
+	}
+	expectAudit(mockAuditRepo, mockTx, events...)
+
+	rowsAffected, err := fooService.PurgeFoos(context.Background(), time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), rowsAffected)
+}
+
+func TestFooService_PurgeFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
 	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test a retention that would purge everything is refused before the repo is called
+	rowsAffected, err := fooService.PurgeFoos(context.Background(), 0)
+	require.Equal(t, int64(0), rowsAffected)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "86SSOP")
 
-	fooService := NewFooService(mockFooRepo, logger) // pass pointer
+	// 2) Test repo failure
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		PurgeFoos(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("purge failed"))
 
-	rowsAffected, err := fooService.DeleteFoos()
+	rowsAffected, err = fooService.PurgeFoos(context.Background(), time.Hour)
 	require.Equal(t, int64(0), rowsAffected)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "BA8TAX")
+	require.Contains(t, err.Error(), "B3EU9T")
+
+	// 3) Test nothing to purge is not audited
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		PurgeFoos(gomock.Any(), gomock.Any()).
+		Return([]models.Foo{}, nil)
+
+	rowsAffected, err = fooService.PurgeFoos(context.Background(), time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), rowsAffected)
 }
 
 func TestFooService_UpdateFoo_Success(t *testing.T) {
@@ -143,21 +578,30 @@ func TestFooService_UpdateFoo_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
 
 	fooID := int64(42)
 	newName := "Updated Name"
 
-	expectedFoo := &models.Foo{ID: int(fooID), Name: newName}
+	before := models.Foo{ID: int(fooID), Name: "Old Name", Version: 3}
+	expectedFoo := &models.Foo{ID: int(fooID), Name: newName, Version: 4}
 
+	fooTx(mockTxManager, mockTx, mockFooRepo)
 	mockFooRepo.EXPECT().
-		UpdateFoo(fooID, newName).
+		GetFoosForUpdate(gomock.Any(), []int64{fooID}).
+		Return(map[int64]models.Foo{fooID: before}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, fooID)
+	mockFooRepo.EXPECT().
+		UpdateFoo(gomock.Any(), fooID, newName, 3).
 		Return(expectedFoo, nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionUpdate, &before, expectedFoo))
 
-	logger := zaptest.NewLogger(t)
-
-	fooService := NewFooService(mockFooRepo, logger) // pass pointer
-
-	foo, err := fooService.UpdateFoo(fooID, newName)
+	foo, err := fooService.UpdateFoo(context.Background(), fooID, newName, 3)
 	require.NoError(t, err)
 	require.Equal(t, expectedFoo, foo)
 }
@@ -167,21 +611,926 @@ func TestFooService_UpdateFoo_Error(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
 
 	fooID := int64(100)
 	newName := "Some Name"
-	fooRepoError := errors.New("update failed")
 
+	// 1) Test repo failure
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{fooID}).
+		Return(map[int64]models.Foo{fooID: {ID: int(fooID)}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, fooID)
+	mockFooRepo.EXPECT().
+		UpdateFoo(gomock.Any(), fooID, newName, 3).
+		Return(nil, errors.New("update failed"))
+
+	foo, err := fooService.UpdateFoo(context.Background(), fooID, newName, 3)
+	require.Nil(t, foo)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "GZNHKW")
+
+	// 2) Test the version check of the repo survives the transaction
+	fooTx(mockTxManager, mockTx, mockFooRepo)
 	mockFooRepo.EXPECT().
-		UpdateFoo(fooID, newName).
-		Return(nil, fooRepoError)
+		GetFoosForUpdate(gomock.Any(), []int64{fooID}).
+		Return(map[int64]models.Foo{fooID: {ID: int(fooID), Version: 4}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, fooID)
+	mockFooRepo.EXPECT().
+		UpdateFoo(gomock.Any(), fooID, newName, 3).
+		Return(nil, apperrors.PreconditionFailed("PG3L6Q", "Foo 100 is at version 4, not 3."))
+
+	foo, err = fooService.UpdateFoo(context.Background(), fooID, newName, 3)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindPreconditionFailed, apperrors.KindOf(err))
+}
 
+func TestFooService_PatchFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test the patch is passed to the repo
+	name := "Patched Name"
+	patch := &models.FooPatch{Name: &name}
+	before := models.Foo{ID: 42, Name: "Old Name", Version: 3}
+	expectedFoo := &models.Foo{ID: 42, Name: name, Version: 4}
+
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{42}).
+		Return(map[int64]models.Foo{42: before}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 42)
+	mockFooRepo.EXPECT().
+		PatchFoo(gomock.Any(), int64(42), patch, 3).
+		Return(expectedFoo, nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionUpdate, &before, expectedFoo))
+
+	foo, err := fooService.PatchFoo(context.Background(), 42, patch, 3)
+	require.NoError(t, err)
+	require.Equal(t, expectedFoo, foo)
+
+	// 2) Test an empty patch returns the foo unchanged and is not audited
+	currentFoo := &models.Foo{ID: 42, Name: name, Version: 4}
+
+	fooAccess(mockFooShareRepo, models.FooAccessWrite, 42)
+	mockFooRepo.EXPECT().
+		GetFooByID(gomock.Any(), int64(42)).
+		Return(currentFoo, nil)
+
+	foo, err = fooService.PatchFoo(context.Background(), 42, &models.FooPatch{}, 4)
+	require.NoError(t, err)
+	require.Equal(t, currentFoo, foo)
+}
+
+func TestFooService_PatchFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
 	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test repo failure
+	name := "Patched Name"
+	patch := &models.FooPatch{Name: &name}
+
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{42}).
+		Return(map[int64]models.Foo{42: {ID: 42}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 42)
+	mockFooRepo.EXPECT().
+		PatchFoo(gomock.Any(), int64(42), patch, 3).
+		Return(nil, errors.New("db error"))
+
+	foo, err := fooService.PatchFoo(context.Background(), 42, patch, 3)
+	require.Nil(t, foo)
+	require.Contains(t, err.Error(), "OTR4M3")
 
-	fooService := NewFooService(mockFooRepo, logger) // pass pointer
+	// 2) Test an empty patch against a foo at another version
+	fooAccess(mockFooShareRepo, models.FooAccessWrite, 42)
+	mockFooRepo.EXPECT().
+		GetFooByID(gomock.Any(), int64(42)).
+		Return(&models.Foo{ID: 42, Version: 5}, nil)
 
-	foo, err := fooService.UpdateFoo(fooID, newName)
+	foo, err = fooService.PatchFoo(context.Background(), 42, &models.FooPatch{}, 4)
 	require.Nil(t, foo)
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "GZNHKW")
+	require.Equal(t, apperrors.KindPreconditionFailed, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "KG97B6")
+
+	// 3) Test a foo only shared to read once it is locked is not patched
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{42}).
+		Return(map[int64]models.Foo{42: {ID: 42}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessRead, 42)
+
+	foo, err = fooService.PatchFoo(context.Background(), 42, patch, 3)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+}
+
+func TestFooService_CreateFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// The blank name fails on its own and is not sent to the repo
+	created := []models.Foo{{ID: 1, Name: "Foo One"}, {ID: 2, Name: "Foo Two"}}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		CreateFoos(gomock.Any(), []string{"Foo One", "Foo Two"}).
+		Return(&created, nil)
+	expectAudit(mockAuditRepo, mockTx,
+		fooEvent(t, models.AuditActionCreate, nil, &created[0]),
+		fooEvent(t, models.AuditActionCreate, nil, &created[1]),
+	)
+
+	items, err := fooService.CreateFoos(context.Background(), []string{"Foo One", " ", "Foo Two"})
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	require.Equal(t, "Foo One", items[0].Foo.Name)
+	appError, ok := apperrors.From(items[1].Err)
+	require.True(t, ok)
+	require.Equal(t, apperrors.KindValidation, appError.Kind)
+	require.Equal(t, "XTL5HQ", appError.Code)
+	require.Equal(t, int64(2), items[2].ID)
+	require.Equal(t, "Foo Two", items[2].Foo.Name)
+}
+
+func TestFooService_CreateFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test an empty batch, the repo must not be called
+	items, err := fooService.CreateFoos(context.Background(), []string{})
+	require.Nil(t, items)
+	require.Contains(t, err.Error(), "NROOZB")
+
+	// 2) Test repo failure
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		CreateFoos(gomock.Any(), []string{"Foo One"}).
+		Return(nil, errors.New("db error"))
+
+	items, err = fooService.CreateFoos(context.Background(), []string{"Foo One"})
+	require.Nil(t, items)
+	require.Contains(t, err.Error(), "6X0KV3")
+}
+
+func TestFooService_PatchFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	name := "Patched Name"
+	validPatch := models.FooBatchPatch{ID: 1, Version: 2, Patch: &models.FooPatch{Name: &name}}
+	missingPatch := models.FooBatchPatch{ID: 5, Version: 2, Patch: &models.FooPatch{Name: &name}}
+	readOnlyPatch := models.FooBatchPatch{ID: 4, Version: 2, Patch: &models.FooPatch{Name: &name}}
+
+	// Only the valid patches the user may make once the foos are locked are sent to the repo, in a transaction, and
+	// only the ones that changed a foo are audited
+	before := models.Foo{ID: 1, Name: "Old Name", Version: 2}
+	patched := &models.Foo{ID: 1, Name: name, Version: 3}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{1, 4, 5}).
+		Return(map[int64]models.Foo{1: before, 4: {ID: 4}}, nil)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().
+		GetFooAccess(gomock.Any(), []int64{1, 4, 5}).
+		Return(map[int64]string{1: models.FooAccessWrite, 4: models.FooAccessRead, 5: models.FooAccessWrite}, nil)
+	mockFooRepo.EXPECT().
+		PatchFoos(gomock.Any(), []models.FooBatchPatch{validPatch, missingPatch}).
+		Return([]models.FooBatchItem{
+			{ID: 1, Foo: patched},
+			{ID: 5, Err: apperrors.NotFound("BATWXG", "No foo found with id 5.")},
+		}, nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionUpdate, &before, patched))
+
+	items, err := fooService.PatchFoos(context.Background(), []models.FooBatchPatch{
+		{ID: 2, Patch: &models.FooPatch{Name: &name}},
+		validPatch,
+		{ID: 3, Version: 1, Patch: &models.FooPatch{}},
+		readOnlyPatch,
+		missingPatch,
+		{ID: 6, Version: 2, PatchErr: apperrors.BadRequest("7D6E0S", "The id of a foo can not be changed.")},
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 6)
+	require.Contains(t, items[0].Err.Error(), "EKQTC7")
+	require.Equal(t, 3, items[1].Foo.Version)
+	require.Contains(t, items[2].Err.Error(), "KDRBKB")
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(items[3].Err))
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(items[4].Err))
+	require.Contains(t, items[5].Err.Error(), "7D6E0S")
+}
+
+func TestFooService_PatchFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	name := "Patched Name"
+	patches := []models.FooBatchPatch{{ID: 1, Version: 2, Patch: &models.FooPatch{Name: &name}}}
+
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{1}).
+		Return(map[int64]models.Foo{1: {ID: 1}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 1)
+	mockFooRepo.EXPECT().
+		PatchFoos(gomock.Any(), patches).
+		Return(nil, errors.New("db error"))
+
+	items, err := fooService.PatchFoos(context.Background(), patches)
+	require.Nil(t, items)
+	require.Contains(t, err.Error(), "G0INB9")
+}
+
+func TestFooService_DeleteFoosByID_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// Once the foos are locked foo 4 is only shared to read and foo 5 is not shared at all, neither is sent to the repo
+	before := map[int64]models.Foo{1: {ID: 1, Name: "One"}, 3: {ID: 3, Name: "Three"}}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{1, 2, 3, 4, 5}).
+		Return(before, nil)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().
+		GetFooAccess(gomock.Any(), []int64{1, 2, 3, 4, 5}).
+		Return(map[int64]string{1: models.FooAccessOwner, 2: models.FooAccessWrite, 3: models.FooAccessWrite, 4: models.FooAccessRead, 5: ""}, nil)
+	mockFooRepo.EXPECT().
+		DeleteFoosByID(gomock.Any(), []int64{1, 2, 3}).
+		Return([]int64{1, 3}, nil)
+	one, three := before[1], before[3]
+	expectAudit(mockAuditRepo, mockTx,
+		fooEvent(t, models.AuditActionDelete, &one, nil),
+		fooEvent(t, models.AuditActionDelete, &three, nil),
+	)
+
+	items, err := fooService.DeleteFoosByID(context.Background(), []int64{1, 2, 3, 4, 5})
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+	require.NoError(t, items[0].Err)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(items[1].Err))
+	require.Equal(t, int64(2), items[1].ID)
+	require.NoError(t, items[2].Err)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(items[3].Err))
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(items[4].Err))
+}
+
+func TestFooService_DeleteFoosByID_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		GetFoosForUpdate(gomock.Any(), []int64{1}).
+		Return(map[int64]models.Foo{1: {ID: 1}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 1)
+	mockFooRepo.EXPECT().
+		DeleteFoosByID(gomock.Any(), []int64{1}).
+		Return(nil, errors.New("db error"))
+
+	items, err := fooService.DeleteFoosByID(context.Background(), []int64{1})
+	require.Nil(t, items)
+	require.Contains(t, err.Error(), "5PSJ8K")
+}
+
+func TestFooService_GetFooShares_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
+
+	role := "editor"
+	expectedShares := []models.FooShare{{ID: 1, FooID: 7, Role: &role, Permission: models.FooAccessWrite}}
+	fooAccess(mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().GetFooShares(gomock.Any(), int64(7)).Return(expectedShares, nil)
+
+	shares, err := fooService.GetFooShares(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, expectedShares, shares)
+}
+
+func TestFooService_GetFooShares_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, nil, logger)
+
+	// 1) Test only the owner sees the shares
+	fooAccess(mockFooShareRepo, models.FooAccessWrite, 7)
+
+	shares, err := fooService.GetFooShares(context.Background(), 7)
+	require.Nil(t, shares)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "47QNDZ")
+
+	// 2) Test repo failure
+	fooAccess(mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().GetFooShares(gomock.Any(), int64(7)).Return(nil, errors.New("db error"))
+
+	shares, err = fooService.GetFooShares(context.Background(), 7)
+	require.Nil(t, shares)
+	require.Contains(t, err.Error(), "SJZ5FG")
+}
+
+func TestFooService_ShareFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// The role and permission are trimmed before they are stored, and the share is audited in its transaction
+	role := " editor "
+	request := &models.FooShareRequest{Role: &role, Permission: " read "}
+	trimmedRole := "editor"
+	expectedShare := &models.FooShare{ID: 2, FooID: 7, Role: &trimmedRole, Permission: models.FooAccessRead}
+
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().
+		CreateFooShare(gomock.Any(), int64(7), &models.FooShareRequest{Role: &trimmedRole, Permission: "read"}).
+		Return(expectedShare, nil)
+	expectAudit(mockAuditRepo, mockTx, shareEvent(t, models.AuditActionShare, expectedShare))
+
+	share, err := fooService.ShareFoo(context.Background(), 7, request)
+	require.NoError(t, err)
+	require.Equal(t, expectedShare, share)
+}
+
+func TestFooService_ShareFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	member := 9
+	role := "editor"
+
+	// 1) Test a share with both a user and a role and an unknown permission, the repo must not be called
+	share, err := fooService.ShareFoo(context.Background(), 7, &models.FooShareRequest{UserID: &member, Role: &role, Permission: "admin"})
+	require.Nil(t, share)
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, "5ONFLQ", appError.Code)
+	require.Len(t, appError.Fields, 2)
+
+	// 2) Test a missing permission
+	share, err = fooService.ShareFoo(context.Background(), 7, &models.FooShareRequest{UserID: &member})
+	require.Nil(t, share)
+	require.Contains(t, err.Error(), "CIJ8HY")
+
+	// 3) Test only the owner shares the foo
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, models.FooAccessWrite, 7)
+
+	share, err = fooService.ShareFoo(context.Background(), 7, &models.FooShareRequest{UserID: &member, Permission: "read"})
+	require.Nil(t, share)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "IAR3SI")
+
+	// 4) Test repo failure
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().CreateFooShare(gomock.Any(), int64(7), gomock.Any()).Return(nil, errors.New("db error"))
+
+	share, err = fooService.ShareFoo(context.Background(), 7, &models.FooShareRequest{UserID: &member, Permission: "read"})
+	require.Nil(t, share)
+	require.Contains(t, err.Error(), "4N2I5F")
+
+	// 5) Test the share is rolled back when it can not be audited
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().
+		CreateFooShare(gomock.Any(), int64(7), gomock.Any()).
+		Return(&models.FooShare{ID: 3, FooID: 7, UserID: &member, Permission: models.FooAccessRead}, nil)
+	mockAuditRepo.EXPECT().WithTx(mockTx).Return(mockAuditRepo)
+	mockAuditRepo.EXPECT().CreateAuditEvents(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+
+	share, err = fooService.ShareFoo(context.Background(), 7, &models.FooShareRequest{UserID: &member, Permission: "read"})
+	require.Nil(t, share)
+	require.Contains(t, err.Error(), "ZA32FJ")
+}
+
+func TestFooService_UnshareFoo_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, mockAuditRepo, mockTxManager, logger)
+
+	// The removed share is audited in its transaction
+	member := 9
+	removed := &models.FooShare{ID: 2, FooID: 7, UserID: &member, Permission: models.FooAccessWrite}
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().DeleteFooShare(gomock.Any(), int64(7), 2).Return(removed, nil)
+	expectAudit(mockAuditRepo, mockTx, shareEvent(t, models.AuditActionUnshare, removed))
+
+	err := fooService.UnshareFoo(context.Background(), 7, 2)
+	require.NoError(t, err)
+}
+
+func TestFooService_UnshareFoo_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, nil, nil, mockTxManager, logger)
+
+	// 1) Test a foo the user can not see is not found
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, "", 7)
+
+	err := fooService.UnshareFoo(context.Background(), 7, 2)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "W2FW4D")
+
+	// 2) Test repo failure
+	fooShareTx(mockTxManager, mockTx, mockFooRepo, mockFooShareRepo, models.FooAccessOwner, 7)
+	mockFooShareRepo.EXPECT().WithTx(mockTx).Return(mockFooShareRepo)
+	mockFooShareRepo.EXPECT().DeleteFooShare(gomock.Any(), int64(7), 2).Return(nil, errors.New("db error"))
+
+	err = fooService.UnshareFoo(context.Background(), 7, 2)
+	require.Contains(t, err.Error(), "KSG3HN")
+}
+
+func TestFooService_GetFooRevisions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, nil, nil, logger)
+
+	// Readers see the revisions too
+	revisions := []models.FooRevision{{FooID: 7, Version: 1, Name: "Foo"}, {FooID: 7, Version: 2, Name: "Bar"}}
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().GetFooRevisions(gomock.Any(), int64(7)).Return(revisions, nil)
+
+	result, err := fooService.GetFooRevisions(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, revisions, result)
+}
+
+func TestFooService_GetFooRevisions_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, nil, nil, logger)
+
+	// 1) Test a foo the user can not see is not found
+	fooAccess(mockFooShareRepo, "", 7)
+
+	revisions, err := fooService.GetFooRevisions(context.Background(), 7)
+	require.Nil(t, revisions)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "RIIQW3")
+
+	// 2) Test repo failure
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().GetFooRevisions(gomock.Any(), int64(7)).Return(nil, errors.New("db error"))
+
+	revisions, err = fooService.GetFooRevisions(context.Background(), 7)
+	require.Nil(t, revisions)
+	require.Contains(t, err.Error(), "W4WK96")
+}
+
+func TestFooService_GetFooRevision_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, nil, nil, logger)
+
+	revision := &models.FooRevision{FooID: 7, Version: 2, Name: "Bar"}
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 2).Return(revision, nil)
+
+	result, err := fooService.GetFooRevision(context.Background(), 7, 2)
+	require.NoError(t, err)
+	require.Equal(t, revision, result)
+}
+
+func TestFooService_GetFooRevision_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, nil, nil, logger)
+
+	// 1) Test a foo the user can not see is not found
+	fooAccess(mockFooShareRepo, "", 7)
+
+	revision, err := fooService.GetFooRevision(context.Background(), 7, 2)
+	require.Nil(t, revision)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "QCQVSS")
+
+	// 2) Test a revision that does not exist
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().
+		GetFooRevision(gomock.Any(), int64(7), 9).
+		Return(nil, apperrors.NotFound("S2DUNB", "No revision 9 found for foo 7."))
+
+	revision, err = fooService.GetFooRevision(context.Background(), 7, 9)
+	require.Nil(t, revision)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "7PMWEE")
+}
+
+func TestFooService_DiffFooRevisions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, nil, nil, logger)
+
+	// 1) Test a changed name
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 1).Return(&models.FooRevision{FooID: 7, Version: 1, Name: "Foo"}, nil)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 3).Return(&models.FooRevision{FooID: 7, Version: 3, Name: "Bar"}, nil)
+
+	diff, err := fooService.DiffFooRevisions(context.Background(), 7, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, &models.FooRevisionDiff{
+		FooID:   7,
+		From:    1,
+		To:      3,
+		Changes: []models.FooFieldChange{{Field: "name", From: "Foo", To: "Bar"}},
+	}, diff)
+
+	// 2) Test revisions that are the same have no changes
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 1).Return(&models.FooRevision{FooID: 7, Version: 1, Name: "Foo"}, nil)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 4).Return(&models.FooRevision{FooID: 7, Version: 4, Name: "Foo"}, nil)
+
+	diff, err = fooService.DiffFooRevisions(context.Background(), 7, 1, 4)
+	require.NoError(t, err)
+	require.Empty(t, diff.Changes)
+	require.NotNil(t, diff.Changes)
+}
+
+func TestFooService_DiffFooRevisions_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, nil, nil, logger)
+
+	// 1) Test a foo the user can not see is not found
+	fooAccess(mockFooShareRepo, "", 7)
+
+	diff, err := fooService.DiffFooRevisions(context.Background(), 7, 1, 3)
+	require.Nil(t, diff)
+	require.Contains(t, err.Error(), "WZFO3P")
+
+	// 2) Test a from revision that does not exist
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().
+		GetFooRevision(gomock.Any(), int64(7), 1).
+		Return(nil, apperrors.NotFound("S2DUNB", "No revision 1 found for foo 7."))
+
+	diff, err = fooService.DiffFooRevisions(context.Background(), 7, 1, 3)
+	require.Nil(t, diff)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "OSHTUF")
+
+	// 3) Test a to revision that does not exist
+	fooAccess(mockFooShareRepo, models.FooAccessRead, 7)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 1).Return(&models.FooRevision{FooID: 7, Version: 1, Name: "Foo"}, nil)
+	mockFooRevisionRepo.EXPECT().
+		GetFooRevision(gomock.Any(), int64(7), 3).
+		Return(nil, apperrors.NotFound("S2DUNB", "No revision 3 found for foo 7."))
+
+	diff, err = fooService.DiffFooRevisions(context.Background(), 7, 1, 3)
+	require.Nil(t, diff)
+	require.Contains(t, err.Error(), "V85BIZ")
+}
+
+func TestFooService_RestoreFooRevision_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, mockAuditRepo, mockTxManager, logger)
+
+	// The name of revision 1 is written back as version 4 and audited as an update
+	before := models.Foo{ID: 7, Name: "Bar", Version: 3}
+	expectedFoo := &models.Foo{ID: 7, Name: "Foo", Version: 4}
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().GetFoosForUpdate(gomock.Any(), []int64{7}).Return(map[int64]models.Foo{7: before}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 7)
+	mockFooRevisionRepo.EXPECT().WithTx(mockTx).Return(mockFooRevisionRepo)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 1).Return(&models.FooRevision{FooID: 7, Version: 1, Name: "Foo"}, nil)
+	mockFooRepo.EXPECT().UpdateFoo(gomock.Any(), int64(7), "Foo", 3).Return(expectedFoo, nil)
+	expectAudit(mockAuditRepo, mockTx, fooEvent(t, models.AuditActionUpdate, &before, expectedFoo))
+
+	foo, err := fooService.RestoreFooRevision(context.Background(), 7, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, expectedFoo, foo)
+}
+
+func TestFooService_RestoreFooRevision_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockFooShareRepo := mocks.NewMockFooShareRepo(ctrl)
+	mockFooRevisionRepo := mocks.NewMockFooRevisionRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, mockFooShareRepo, mockFooRevisionRepo, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test readers may not restore
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().GetFoosForUpdate(gomock.Any(), []int64{7}).Return(map[int64]models.Foo{7: {ID: 7, Version: 3}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessRead, 7)
+
+	foo, err := fooService.RestoreFooRevision(context.Background(), 7, 1, 3)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "36O6BW")
+
+	// 2) Test a revision that does not exist
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().GetFoosForUpdate(gomock.Any(), []int64{7}).Return(map[int64]models.Foo{7: {ID: 7, Version: 3}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 7)
+	mockFooRevisionRepo.EXPECT().WithTx(mockTx).Return(mockFooRevisionRepo)
+	mockFooRevisionRepo.EXPECT().
+		GetFooRevision(gomock.Any(), int64(7), 9).
+		Return(nil, apperrors.NotFound("S2DUNB", "No revision 9 found for foo 7."))
+
+	foo, err = fooService.RestoreFooRevision(context.Background(), 7, 9, 3)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "ZM15LQ")
+
+	// 3) Test the foo moved on to another version
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().GetFoosForUpdate(gomock.Any(), []int64{7}).Return(map[int64]models.Foo{7: {ID: 7, Version: 4}}, nil)
+	lockedFooAccess(mockFooShareRepo, mockTx, models.FooAccessWrite, 7)
+	mockFooRevisionRepo.EXPECT().WithTx(mockTx).Return(mockFooRevisionRepo)
+	mockFooRevisionRepo.EXPECT().GetFooRevision(gomock.Any(), int64(7), 1).Return(&models.FooRevision{FooID: 7, Version: 1, Name: "Foo"}, nil)
+	mockFooRepo.EXPECT().
+		UpdateFoo(gomock.Any(), int64(7), "Foo", 3).
+		Return(nil, apperrors.PreconditionFailed("PG3L6Q", "Foo 7 is at version 4, not 3."))
+
+	foo, err = fooService.RestoreFooRevision(context.Background(), 7, 1, 3)
+	require.Nil(t, foo)
+	require.Equal(t, apperrors.KindPreconditionFailed, apperrors.KindOf(err))
+}
+
+func TestFooService_ExportFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, nil, nil, nil, mockTxManager, logger)
+
+	// The repo reads the cursor in the transaction and hands each foo to fn
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().
+		ExportFoos(gomock.Any(), true, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ bool, fn func(foo *models.Foo) error) error {
+			return fn(&models.Foo{ID: 1, Name: "Foo One"})
+		})
+
+	exported := []models.Foo{}
+	err := fooService.ExportFoos(context.Background(), true, func(foo *models.Foo) error {
+		exported = append(exported, *foo)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []models.Foo{{ID: 1, Name: "Foo One"}}, exported)
+}
+
+func TestFooService_ExportFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, nil, nil, nil, mockTxManager, logger)
+
+	// 1) Test repo failure
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().ExportFoos(gomock.Any(), false, gomock.Any()).Return(errors.New("db error"))
+
+	err := fooService.ExportFoos(context.Background(), false, func(foo *models.Foo) error { return nil })
+	require.Contains(t, err.Error(), "D4KLQE")
+}
+
+func TestFooService_ImportFoos_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, nil, nil, mockAuditRepo, mockTxManager, logger)
+
+	rows := []models.FooImportRow{{Line: 2, Name: " Foo One "}, {Line: 3, Name: "Foo Two"}}
+	imported := []models.Foo{{ID: 1, Name: "Foo One"}, {ID: 2, Name: "Foo Two"}}
+
+	// 1) Test the names are trimmed, imported and audited
+	fooTx(mockTxManager, mockTx, mockFooRepo)
+	mockFooRepo.EXPECT().ImportFoos(gomock.Any(), []string{"Foo One", "Foo Two"}).Return(imported, nil)
+	expectAudit(mockAuditRepo, mockTx,
+		fooEvent(t, models.AuditActionCreate, nil, &imported[0]),
+		fooEvent(t, models.AuditActionCreate, nil, &imported[1]),
+	)
+
+	report, err := fooService.ImportFoos(context.Background(), rows, false)
+	require.NoError(t, err)
+	require.Equal(t, &models.FooImportReport{Total: 2, Imported: 2, Errors: []models.FooImportError{}}, report)
+
+	// 2) Test a dry run imports the same way and rolls back
+	mockTxManager.EXPECT().
+		WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(tx interfaces.PgxTxInterface) error) error {
+			err := fn(mockTx)
+			require.Error(t, err, "a dry run must roll back")
+			return err
+		})
+	mockFooRepo.EXPECT().WithTx(mockTx).Return(mockFooRepo)
+	mockFooRepo.EXPECT().ImportFoos(gomock.Any(), []string{"Foo One", "Foo Two"}).Return(imported, nil)
+	expectAudit(mockAuditRepo, mockTx,
+		fooEvent(t, models.AuditActionCreate, nil, &imported[0]),
+		fooEvent(t, models.AuditActionCreate, nil, &imported[1]),
+	)
+
+	report, err = fooService.ImportFoos(context.Background(), rows, true)
+	require.NoError(t, err)
+	require.Equal(t, &models.FooImportReport{DryRun: true, Total: 2, Imported: 2, Errors: []models.FooImportError{}}, report)
+
+	// 3) Test rows that are not valid are reported by line and nothing is imported
+	columnsError := apperrors.FieldError{Code: "columns", Message: "The row needs 2 columns like the header row."}
+	report, err = fooService.ImportFoos(context.Background(), []models.FooImportRow{
+		{Line: 2, Name: "Foo One"},
+		{Line: 3, Name: " "},
+		{Line: 4, Err: &columnsError},
+	}, false)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Total)
+	require.Equal(t, 0, report.Imported)
+	require.Len(t, report.Errors, 2)
+	require.Equal(t, 3, report.Errors[0].Line)
+	require.Equal(t, "name", report.Errors[0].Errors[0].Field)
+	require.Equal(t, "required", report.Errors[0].Errors[0].Code)
+	require.Equal(t, models.FooImportError{Line: 4, Errors: []apperrors.FieldError{columnsError}}, report.Errors[1])
+}
+
+func TestFooService_ImportFoos_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFooRepo := mocks.NewMockFooRepo(ctrl)
+	mockAuditRepo := mocks.NewMockAuditRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	logger := zaptest.NewLogger(t)
+	fooService := NewFooService(mockFooRepo, nil, nil, mockAuditRepo, mockTxManager, logger)
+
+	// 1) Test an empty import, the repo must not be called
+	report, err := fooService.ImportFoos(context.Background(), []models.FooImportRow{}, false)
+	require.Nil(t, report)
+	require.Equal(t, apperrors.KindValidation, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "59YALT")
+
+	// 2) Test repo failure, also in a dry run
+	for _, dryRun := range []bool{false, true} {
+		fooTx(mockTxManager, mockTx, mockFooRepo)
+		mockFooRepo.EXPECT().ImportFoos(gomock.Any(), []string{"Foo One"}).Return(nil, errors.New("db error"))
+
+		report, err = fooService.ImportFoos(context.Background(), []models.FooImportRow{{Line: 1, Name: "Foo One"}}, dryRun)
+		require.Nil(t, report)
+		require.Contains(t, err.Error(), "YA7QLO")
+	}
 }
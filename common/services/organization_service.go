@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_organization_service.go \
+  -package=mocks \
+  -mock_names=OrganizationServiceInterface=MockOrganizationService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  OrganizationServiceInterface
+*/
+
+type OrganizationServiceInterface interface {
+	ResolveOrganization(ctx context.Context, claims *models.Claims, slug string) (org *models.Organization, userId int, err error)
+	GetOrganizations(ctx context.Context, claims *models.Claims) (orgs []models.Organization, err error)
+	CreateOrganization(ctx context.Context, claims *models.Claims, request *models.OrganizationRequest) (org *models.Organization, err error)
+	AddMember(ctx context.Context, slug string, userId int) (err error)
+	RemoveMember(ctx context.Context, slug string, userId int) (err error)
+}
+
+type OrganizationService struct {
+	orgRepo   *repos.OrganizationRepoInterface
+	userRepo  *repos.UserRepoInterface
+	txManager *interfaces.PgxTxManagerInterface
+	logger    *zap.Logger
+}
+
+// NewOrganizationService makes an organization service. txManager creates an organization and the membership of
+// its creator as one unit of work.
+func NewOrganizationService(orgRepo repos.OrganizationRepoInterface, userRepo repos.UserRepoInterface, txManager interfaces.PgxTxManagerInterface, logger *zap.Logger) *OrganizationService {
+	return &OrganizationService{orgRepo: &orgRepo, userRepo: &userRepo, txManager: &txManager, logger: logger}
+}
+
+// ResolveOrganization returns the organization of a request of the user with the claims, and the id of the user.
+// It is the organization with the slug, which the user must be a member of, or their default organization when slug
+// is empty.
+func (orgService *OrganizationService) ResolveOrganization(ctx context.Context, claims *models.Claims, slug string) (org *models.Organization, userId int, err error) {
+	if slug == "" {
+		org, userId, err = (*orgService.orgRepo).GetDefaultOrganization(ctx, claims.Issuer, claims.Sub)
+	} else {
+		org, userId, err = (*orgService.orgRepo).GetMemberOrganization(ctx, claims.Issuer, claims.Sub, slug)
+	}
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "Error: VSR0ST - Resolving organization.")
+	}
+
+	return org, userId, nil
+}
+
+// GetOrganizations returns the organizations the user with the claims is a member of.
+func (orgService *OrganizationService) GetOrganizations(ctx context.Context, claims *models.Claims) (orgs []models.Organization, err error) {
+	orgs, err = (*orgService.orgRepo).GetOrganizations(ctx, claims.Issuer, claims.Sub)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 5WOT46 - Getting organizations.")
+	}
+
+	return orgs, nil
+}
+
+// CreateOrganization creates an organization with the user with the claims as its first member.
+func (orgService *OrganizationService) CreateOrganization(ctx context.Context, claims *models.Claims, request *models.OrganizationRequest) (org *models.Organization, err error) {
+	user, err := (*orgService.userRepo).GetUserBySubject(ctx, claims.Issuer, claims.Sub)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: PRP3T3 - Getting user.")
+	}
+
+	err = (*orgService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		orgRepo := (*orgService.orgRepo).WithTx(tx)
+		org, err = orgRepo.CreateOrganization(ctx, request.Slug, request.Name)
+		if err != nil {
+			return err
+		}
+		return orgRepo.AddMember(ctx, org.ID, user.ID)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: NMSVV7 - Creating organization.")
+	}
+
+	return org, nil
+}
+
+// AddMember makes the user with the id a member of the organization with the slug.
+func (orgService *OrganizationService) AddMember(ctx context.Context, slug string, userId int) (err error) {
+	org, err := (*orgService.orgRepo).GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return errors.Wrap(err, "Error: WZBKZL - Getting organization.")
+	}
+
+	if _, err := (*orgService.userRepo).GetUserByID(ctx, userId); err != nil {
+		return errors.Wrap(err, "Error: 3ZX257 - Getting user.")
+	}
+
+	if err := (*orgService.orgRepo).AddMember(ctx, org.ID, userId); err != nil {
+		return errors.Wrap(err, "Error: B6KRMT - Adding member.")
+	}
+
+	return nil
+}
+
+// RemoveMember ends the membership of the user with the id in the organization with the slug.
+func (orgService *OrganizationService) RemoveMember(ctx context.Context, slug string, userId int) (err error) {
+	org, err := (*orgService.orgRepo).GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return errors.Wrap(err, "Error: Z67X8N - Getting organization.")
+	}
+
+	if err := (*orgService.orgRepo).RemoveMember(ctx, org.ID, userId); err != nil {
+		return errors.Wrap(err, "Error: WPC75A - Removing member.")
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestOrganizationService_ResolveOrganization_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	orgService := NewOrganizationService(mockOrgRepo, mocks.NewMockUserRepo(ctrl), mocks.NewMockPgxTxManager(ctrl), zaptest.NewLogger(t))
+	claims := &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}
+
+	// 1) Test the organization with the slug
+	acme := &models.Organization{ID: 3, Slug: "acme"}
+	mockOrgRepo.EXPECT().GetMemberOrganization(gomock.Any(), "https://idp.example.com", "sub-1", "acme").Return(acme, 7, nil)
+
+	org, userId, err := orgService.ResolveOrganization(context.Background(), claims, "acme")
+	require.NoError(t, err)
+	require.Equal(t, acme, org)
+	require.Equal(t, 7, userId)
+
+	// 2) Test the default organization without a slug
+	defaultOrg := &models.Organization{ID: 1, Slug: "default"}
+	mockOrgRepo.EXPECT().GetDefaultOrganization(gomock.Any(), "https://idp.example.com", "sub-1").Return(defaultOrg, 7, nil)
+
+	org, _, err = orgService.ResolveOrganization(context.Background(), claims, "")
+	require.NoError(t, err)
+	require.Equal(t, defaultOrg, org)
+}
+
+func TestOrganizationService_ResolveOrganization_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	orgService := NewOrganizationService(mockOrgRepo, mocks.NewMockUserRepo(ctrl), mocks.NewMockPgxTxManager(ctrl), zaptest.NewLogger(t))
+
+	mockOrgRepo.EXPECT().
+		GetMemberOrganization(gomock.Any(), "https://idp.example.com", "sub-1", "acme").
+		Return(nil, 0, apperrors.Forbidden("U5C8IF", "You are not a member of the organization acme."))
+
+	org, _, err := orgService.ResolveOrganization(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}, "acme")
+	require.Nil(t, org)
+	require.Equal(t, apperrors.KindForbidden, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "VSR0ST")
+}
+
+func TestOrganizationService_CreateOrganization_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	orgService := NewOrganizationService(mockOrgRepo, mockUserRepo, mockTxManager, zaptest.NewLogger(t))
+
+	// The organization and the membership of its creator are created in one transaction
+	acme := &models.Organization{ID: 3, Slug: "acme", Name: "Acme"}
+	mockUserRepo.EXPECT().GetUserBySubject(gomock.Any(), "https://idp.example.com", "sub-1").Return(&models.User{ID: 7}, nil)
+	mockTxManager.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(runInTx(mockTx))
+	mockOrgRepo.EXPECT().WithTx(mockTx).Return(mockOrgRepo)
+	mockOrgRepo.EXPECT().CreateOrganization(gomock.Any(), "acme", "Acme").Return(acme, nil)
+	mockOrgRepo.EXPECT().AddMember(gomock.Any(), 3, 7).Return(nil)
+
+	org, err := orgService.CreateOrganization(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}, &models.OrganizationRequest{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	require.Equal(t, acme, org)
+}
+
+func TestOrganizationService_AddMember_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	orgService := NewOrganizationService(mockOrgRepo, mockUserRepo, mocks.NewMockPgxTxManager(ctrl), zaptest.NewLogger(t))
+
+	// 1) Test an unknown organization
+	mockOrgRepo.EXPECT().GetOrganizationBySlug(gomock.Any(), "acme").Return(nil, apperrors.NotFound("CJQ8AG", "No organization found with slug acme."))
+
+	err := orgService.AddMember(context.Background(), "acme", 7)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "WZBKZL")
+
+	// 2) Test an unknown user
+	mockOrgRepo.EXPECT().GetOrganizationBySlug(gomock.Any(), "acme").Return(&models.Organization{ID: 3}, nil)
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), 7).Return(nil, apperrors.NotFound("R057V3", "No user found with id 7."))
+
+	err = orgService.AddMember(context.Background(), "acme", 7)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "3ZX257")
+
+	// 3) Test repo failure
+	mockOrgRepo.EXPECT().GetOrganizationBySlug(gomock.Any(), "acme").Return(&models.Organization{ID: 3}, nil)
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), 7).Return(&models.User{ID: 7}, nil)
+	mockOrgRepo.EXPECT().AddMember(gomock.Any(), 3, 7).Return(errors.New("fail"))
+
+	err = orgService.AddMember(context.Background(), "acme", 7)
+	require.Contains(t, err.Error(), "B6KRMT")
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_role_service.go \
+  -package=mocks \
+  -mock_names=RoleServiceInterface=MockRoleService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  RoleServiceInterface
+*/
+
+type RoleServiceInterface interface {
+	GetRoles(ctx context.Context) (roles []models.Role, err error)
+	GetUserRoles(ctx context.Context, userId int) (userRoles *models.UserRoles, err error)
+	SetUserRoles(ctx context.Context, userId int, roles []string) (userRoles *models.UserRoles, err error)
+	GetPermissions(ctx context.Context, issuer string, subject string) (permissions []string, err error)
+}
+
+type RoleService struct {
+	roleRepo  *repos.RoleRepoInterface
+	userRepo  *repos.UserRepoInterface
+	txManager *interfaces.PgxTxManagerInterface
+	logger    *zap.Logger
+}
+
+// NewRoleService makes a role service. txManager replaces the roles of a user as one unit of work.
+func NewRoleService(roleRepo repos.RoleRepoInterface, userRepo repos.UserRepoInterface, txManager interfaces.PgxTxManagerInterface, logger *zap.Logger) *RoleService {
+	return &RoleService{roleRepo: &roleRepo, userRepo: &userRepo, txManager: &txManager, logger: logger}
+}
+
+func (roleService *RoleService) GetRoles(ctx context.Context) (roles []models.Role, err error) {
+	roles, err = (*roleService.roleRepo).GetRoles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: E3VFPO - Getting roles.")
+	}
+
+	return roles, nil
+}
+
+func (roleService *RoleService) GetUserRoles(ctx context.Context, userId int) (userRoles *models.UserRoles, err error) {
+	if _, err := (*roleService.userRepo).GetUserByID(ctx, userId); err != nil {
+		return nil, errors.Wrap(err, "Error: 76NP2W - Getting user.")
+	}
+
+	roles, err := (*roleService.roleRepo).GetUserRoles(ctx, userId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: GNXXFH - Getting user roles.")
+	}
+
+	return &models.UserRoles{UserID: userId, Roles: roles}, nil
+}
+
+// SetUserRoles replaces all the roles of a user. Every name must be a role, otherwise nothing changes.
+func (roleService *RoleService) SetUserRoles(ctx context.Context, userId int, roles []string) (userRoles *models.UserRoles, err error) {
+	if _, err := (*roleService.userRepo).GetUserByID(ctx, userId); err != nil {
+		return nil, errors.Wrap(err, "Error: ENKGC0 - Getting user.")
+	}
+
+	knownRoles, err := (*roleService.roleRepo).GetRoles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: UU5VP9 - Getting roles.")
+	}
+
+	roles = slices.Clone(roles)
+	slices.Sort(roles)
+	roles = slices.Compact(roles)
+
+	fields := []apperrors.FieldError{}
+	for _, role := range roles {
+		known := slices.ContainsFunc(knownRoles, func(knownRole models.Role) bool { return knownRole.Name == role })
+		if !known {
+			fields = append(fields, apperrors.FieldError{Field: "roles", Code: "unknown", Message: "No role named " + role + "."})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("3CHEMI", "The request is not valid.").WithFields(fields...)
+	}
+
+	err = (*roleService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		roleRepo := (*roleService.roleRepo).WithTx(tx)
+		if err := roleRepo.DeleteUserRoles(ctx, userId); err != nil {
+			return err
+		}
+		return roleRepo.AddUserRoles(ctx, userId, roles)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: I212YC - Setting user roles.")
+	}
+
+	return &models.UserRoles{UserID: userId, Roles: roles}, nil
+}
+
+// GetPermissions returns the permissions the user with the issuer and subject has through their roles.
+func (roleService *RoleService) GetPermissions(ctx context.Context, issuer string, subject string) (permissions []string, err error) {
+	permissions, err = (*roleService.roleRepo).GetPermissionsBySubject(ctx, issuer, subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: GES840 - Getting permissions.")
+	}
+
+	return permissions, nil
+}
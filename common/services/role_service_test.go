@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+// knownRoles are the roles the migrations create.
+var knownRoles = []models.Role{{ID: 1, Name: "viewer"}, {ID: 2, Name: "editor"}, {ID: 3, Name: "admin"}}
+
+func TestRoleService_SetUserRoles_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	roleService := NewRoleService(mockRoleRepo, mockUserRepo, mockTxManager, zaptest.NewLogger(t))
+
+	// Duplicates are dropped and the old roles are replaced in one transaction
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), 7).Return(&models.User{ID: 7}, nil)
+	mockRoleRepo.EXPECT().GetRoles(gomock.Any()).Return(knownRoles, nil)
+	mockTxManager.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(runInTx(mockTx))
+	mockRoleRepo.EXPECT().WithTx(mockTx).Return(mockRoleRepo)
+	mockRoleRepo.EXPECT().DeleteUserRoles(gomock.Any(), 7).Return(nil)
+	mockRoleRepo.EXPECT().AddUserRoles(gomock.Any(), 7, []string{"admin", "editor"}).Return(nil)
+
+	userRoles, err := roleService.SetUserRoles(context.Background(), 7, []string{"editor", "admin", "editor"})
+	require.NoError(t, err)
+	require.Equal(t, &models.UserRoles{UserID: 7, Roles: []string{"admin", "editor"}}, userRoles)
+}
+
+func TestRoleService_SetUserRoles_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	roleService := NewRoleService(mockRoleRepo, mockUserRepo, mockTxManager, zaptest.NewLogger(t))
+
+	// 1) Test an unknown user
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), 7).Return(nil, apperrors.NotFound("R057V3", "No user found with id 7."))
+
+	userRoles, err := roleService.SetUserRoles(context.Background(), 7, []string{"editor"})
+	require.Nil(t, userRoles)
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+
+	// 2) Test an unknown role, nothing is changed
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), 7).Return(&models.User{ID: 7}, nil)
+	mockRoleRepo.EXPECT().GetRoles(gomock.Any()).Return(knownRoles, nil)
+
+	userRoles, err = roleService.SetUserRoles(context.Background(), 7, []string{"editor", "owner"})
+	require.Nil(t, userRoles)
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, "3CHEMI", appError.Code)
+	require.Equal(t, []apperrors.FieldError{{Field: "roles", Code: "unknown", Message: "No role named owner."}}, appError.Fields)
+
+	// 3) Test the transaction failed
+	mockUserRepo.EXPECT().GetUserByID(gomock.Any(), 7).Return(&models.User{ID: 7}, nil)
+	mockRoleRepo.EXPECT().GetRoles(gomock.Any()).Return(knownRoles, nil)
+	mockTxManager.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(runInTx(mockTx))
+	mockRoleRepo.EXPECT().WithTx(mockTx).Return(mockRoleRepo)
+	mockRoleRepo.EXPECT().DeleteUserRoles(gomock.Any(), 7).Return(errors.New("fail"))
+
+	userRoles, err = roleService.SetUserRoles(context.Background(), 7, []string{"editor"})
+	require.Nil(t, userRoles)
+	require.Contains(t, err.Error(), "I212YC")
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/interfaces"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"gitlab.com/sandstone2/fiberpoc/common/secrets"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_session_service.go \
+  -package=mocks \
+  -mock_names=SessionServiceInterface=MockSessionService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  SessionServiceInterface
+*/
+
+type SessionServiceInterface interface {
+	CreateSession(ctx context.Context, user *models.User, tokens *models.OidcTokens, userAgent string, ipAddress string) (token string, session *models.Session, err error)
+	Authenticate(ctx context.Context, token string) (claims *models.Claims, session *models.Session, err error)
+	RenewTokens(ctx context.Context, claims *models.Claims, session *models.Session) (err error)
+	GetIDToken(ctx context.Context, session *models.Session) (idToken *models.IDTokenResponse, err error)
+	GetSessions(ctx context.Context, claims *models.Claims, currentSessionId int64) (sessions []models.Session, err error)
+	RevokeSession(ctx context.Context, claims *models.Claims, sessionId int64) (err error)
+	Logout(ctx context.Context, token string) (err error)
+}
+
+// maxUserAgentLength is the length of the user_agent column, longer user agents are cut.
+const maxUserAgentLength = 500
+
+// TokenRenewBefore is how long before its ID token expires a session renews it with its refresh token.
+const TokenRenewBefore = 5 * time.Minute
+
+// TokenRenewTimeout bounds a renewal of the tokens of a session, which holds the lock on the session while it calls
+// the provider.
+const TokenRenewTimeout = 10 * time.Second
+
+type SessionService struct {
+	sessionRepo  *repos.SessionRepoInterface
+	authcService *AuthcServiceInterface
+	tokenCipher  *secrets.Cipher
+	txManager    *interfaces.PgxTxManagerInterface
+	idleTimeout  time.Duration
+	maxAge       time.Duration
+	logger       *zap.Logger
+}
+
+// NewSessionService makes a session service. Sessions expire after idleTimeout without use and after maxAge
+// no matter what. tokenCipher seals the ID and refresh tokens sessions keep, and authcService renews them. Without
+// a tokenCipher sessions keep no tokens. txManager runs the renewals, which lock their session.
+func NewSessionService(sessionRepo repos.SessionRepoInterface, authcService AuthcServiceInterface, tokenCipher *secrets.Cipher, txManager interfaces.PgxTxManagerInterface, idleTimeout time.Duration, maxAge time.Duration, logger *zap.Logger) *SessionService {
+	return &SessionService{
+		sessionRepo:  &sessionRepo,
+		authcService: &authcService,
+		tokenCipher:  tokenCipher,
+		txManager:    &txManager,
+		idleTimeout:  idleTimeout,
+		maxAge:       maxAge,
+		logger:       logger,
+	}
+}
+
+// hashSessionToken is what the sessions table stores instead of the token.
+func hashSessionToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// sealTokens seals the tokens of a login for the sessions table. Without a token cipher only the provider is kept.
+func (sessionService *SessionService) sealTokens(tokens *models.OidcTokens) (sealed *models.SessionTokens, err error) {
+	sealed = &models.SessionTokens{Provider: tokens.Provider}
+	if sessionService.tokenCipher == nil {
+		return sealed, nil
+	}
+
+	sealed.IDToken, err = sessionService.tokenCipher.Seal(tokens.IDToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 99OHOT - Sealing the ID token.")
+	}
+	sealed.IDTokenExpiresAt = tokens.IDTokenExpiresAt
+
+	if tokens.RefreshToken != "" {
+		sealed.RefreshToken, err = sessionService.tokenCipher.Seal(tokens.RefreshToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error: 4SEJS1 - Sealing the refresh token.")
+		}
+	}
+
+	return sealed, nil
+}
+
+// CreateSession starts a session for the user of a login with the tokens of the login. The token goes in the
+// session cookie and is never stored.
+func (sessionService *SessionService) CreateSession(ctx context.Context, user *models.User, tokens *models.OidcTokens, userAgent string, ipAddress string) (token string, session *models.Session, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, errors.Wrap(err, "Error: QJ2IX2 - Generating session token.")
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+
+	if utf8.RuneCountInString(userAgent) > maxUserAgentLength {
+		userAgent = string([]rune(userAgent)[:maxUserAgentLength])
+	}
+
+	sealed, err := sessionService.sealTokens(tokens)
+	if err != nil {
+		return "", nil, err
+	}
+
+	session, err = (*sessionService.sessionRepo).CreateSession(
+		ctx,
+		user.ID,
+		hashSessionToken(token),
+		sealed,
+		userAgent,
+		ipAddress,
+		sessionService.idleTimeout.Milliseconds(),
+		sessionService.maxAge.Milliseconds(),
+	)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "Error: U6A8WM - Creating session.")
+	}
+
+	return token, session, nil
+}
+
+// Authenticate returns the claims of the user of a live session and slides its expiry forward.
+func (sessionService *SessionService) Authenticate(ctx context.Context, token string) (claims *models.Claims, session *models.Session, err error) {
+	if token == "" {
+		return nil, nil, apperrors.Unauthorized("XUWHAA", "Your session has expired, log in again.")
+	}
+
+	session, claims, err = (*sessionService.sessionRepo).TouchSession(ctx, hashSessionToken(token), sessionService.idleTimeout.Milliseconds())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error: I4QHZ6 - Authenticating session.")
+	}
+
+	return claims, session, nil
+}
+
+// RenewTokens renews the ID token of a session with its refresh token once the ID token expires within
+// TokenRenewBefore. Sessions without a refresh token are left alone. When the provider rejects the refresh token the
+// session is revoked and an unauthorized error returned. Other failures are only logged, so a provider that can not
+// be reached does not end the session and the next request tries again.
+func (sessionService *SessionService) RenewTokens(ctx context.Context, claims *models.Claims, session *models.Session) (err error) {
+	if !session.Renewable || time.Until(time.UnixMilli(session.IDTokenExpiresAt)) > TokenRenewBefore {
+		return nil
+	}
+
+	err = sessionService.renewTokens(ctx, claims, session)
+	if err == nil {
+		return nil
+	}
+	if apperrors.KindOf(err) != apperrors.KindUnauthorized {
+		sessionService.logger.Sugar().Warnf("Error: J3KX71 - Renewing the tokens of session %d. Error: %v", session.ID, err)
+		return nil
+	}
+
+	if revokeErr := (*sessionService.sessionRepo).RevokeSession(ctx, claims.Issuer, claims.Sub, session.ID); revokeErr != nil && apperrors.KindOf(revokeErr) != apperrors.KindNotFound {
+		return errors.Wrap(revokeErr, "Error: WAUG9Z - Revoking the session the provider ended.")
+	}
+	return errors.Wrap(err, "Error: OSC8ZU - Renewing the tokens of the session.")
+}
+
+// renewTokens trades the refresh token of a session for new tokens and stores them. The new ID token must be of the
+// same user. The session is locked while its tokens are renewed, so requests that need a renewal at the same time
+// spend the refresh token once. The ones that waited for the lock find the tokens renewed and leave them alone.
+// The renewal runs with TokenRenewTimeout instead of the deadline of the request, so a slow provider holds the lock
+// for no longer than that, and a request that ends early does not throw away a refresh token the provider rotated.
+func (sessionService *SessionService) renewTokens(ctx context.Context, claims *models.Claims, session *models.Session) (err error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), TokenRenewTimeout)
+	defer cancel()
+
+	idTokenExpiresAt := session.IDTokenExpiresAt
+	err = (*sessionService.txManager).WithTx(ctx, func(tx interfaces.PgxTxInterface) error {
+		sessionRepo := (*sessionService.sessionRepo).WithTx(tx)
+
+		tokens, err := sessionRepo.GetSessionTokensForUpdate(ctx, session.ID)
+		if err != nil {
+			return errors.Wrap(err, "Error: XLQENF - Getting the tokens of the session.")
+		}
+		if tokens.RefreshToken == nil {
+			return nil
+		}
+		// Another request renewed them while this one waited for the lock, its refresh token was spent.
+		if time.Until(time.UnixMilli(tokens.IDTokenExpiresAt)) > TokenRenewBefore {
+			idTokenExpiresAt = tokens.IDTokenExpiresAt
+			return nil
+		}
+
+		// A session sealed with a key that was removed or changed since can never be renewed.
+		if sessionService.tokenCipher == nil {
+			return apperrors.Unauthorized("XXDG4W", "Your session can not be renewed, log in again.")
+		}
+		refreshToken, err := sessionService.tokenCipher.Open(tokens.RefreshToken)
+		if err != nil {
+			return apperrors.Unauthorized("XXDG4W", "Your session can not be renewed, log in again.").WithCause(err)
+		}
+
+		renewedClaims, renewed, err := (*sessionService.authcService).RefreshTokens(ctx, tokens.Provider, refreshToken)
+		if err != nil {
+			return errors.Wrap(err, "Error: 19BQPX - Refreshing the tokens with the provider.")
+		}
+		if renewedClaims.Issuer != claims.Issuer || renewedClaims.Sub != claims.Sub {
+			return apperrors.Unauthorized("KN6CYE", "The provider renewed the login of another user, log in again.")
+		}
+
+		sealed, err := sessionService.sealTokens(renewed)
+		if err != nil {
+			return errors.Wrap(err, "Error: UX2QED - Sealing the renewed tokens.")
+		}
+		if err := sessionRepo.UpdateSessionTokens(ctx, session.ID, sealed); err != nil {
+			return errors.Wrap(err, "Error: ZPNN0D - Storing the renewed tokens.")
+		}
+		idTokenExpiresAt = sealed.IDTokenExpiresAt
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	session.IDTokenExpiresAt = idTokenExpiresAt
+	return nil
+}
+
+// GetIDToken returns the ID token of a session, for clients that call the API with Bearer tokens. It runs after
+// RenewTokens, so the token of a renewable session is good for at least TokenRenewBefore. Sessions whose ID token
+// expired get an unauthorized error.
+func (sessionService *SessionService) GetIDToken(ctx context.Context, session *models.Session) (idToken *models.IDTokenResponse, err error) {
+	if sessionService.tokenCipher == nil {
+		return nil, apperrors.Unauthorized("SB9R47", "Sessions keep no ID token, call the API with the session cookie.")
+	}
+
+	tokens, err := (*sessionService.sessionRepo).GetSessionTokens(ctx, session.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: HD2VPT - Getting the tokens of the session.")
+	}
+	if tokens.IDToken == nil || tokens.IDTokenExpiresAt <= time.Now().UnixMilli() {
+		return nil, apperrors.Unauthorized("FORJFX", "The ID token of your session has expired, log in again.")
+	}
+
+	rawIDToken, err := sessionService.tokenCipher.Open(tokens.IDToken)
+	if err != nil {
+		return nil, apperrors.Unauthorized("HM4AXC", "The ID token of your session can not be read, log in again.").WithCause(err)
+	}
+
+	return &models.IDTokenResponse{IDToken: rawIDToken, ExpiresAt: tokens.IDTokenExpiresAt}, nil
+}
+
+// GetSessions returns the live sessions of the user with the claims and marks the one with currentSessionId.
+func (sessionService *SessionService) GetSessions(ctx context.Context, claims *models.Claims, currentSessionId int64) (sessions []models.Session, err error) {
+	sessions, err = (*sessionService.sessionRepo).GetSessions(ctx, claims.Issuer, claims.Sub)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: 12EG7L - Getting sessions.")
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionId
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs out a session of the user with the claims.
+func (sessionService *SessionService) RevokeSession(ctx context.Context, claims *models.Claims, sessionId int64) (err error) {
+	if err := (*sessionService.sessionRepo).RevokeSession(ctx, claims.Issuer, claims.Sub, sessionId); err != nil {
+		return errors.Wrap(err, "Error: 50YG08 - Revoking session.")
+	}
+
+	return nil
+}
+
+// Logout revokes the session with the token. Tokens without a live session are ignored.
+func (sessionService *SessionService) Logout(ctx context.Context, token string) (err error) {
+	if token == "" {
+		return nil
+	}
+
+	if err := (*sessionService.sessionRepo).RevokeSessionByToken(ctx, hashSessionToken(token)); err != nil {
+		return errors.Wrap(err, "Error: 1SG1RR - Logging out.")
+	}
+
+	return nil
+}
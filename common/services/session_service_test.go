@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/secrets"
+)
+
+// newTokenCipher makes a token cipher with a test key.
+func newTokenCipher(t *testing.T) *secrets.Cipher {
+	tokenCipher, err := secrets.NewCipher(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", secrets.KeySize))))
+	require.NoError(t, err)
+	return tokenCipher
+}
+
+// sealedTokens returns the tokens of a session as the repo returns them, sealed with tokenCipher.
+func sealedTokens(t *testing.T, tokenCipher *secrets.Cipher, idToken string, expiresAt int64, refreshToken string) *models.SessionTokens {
+	tokens := &models.SessionTokens{Provider: "google", IDTokenExpiresAt: expiresAt}
+	var err error
+	tokens.IDToken, err = tokenCipher.Seal(idToken)
+	require.NoError(t, err)
+	if refreshToken != "" {
+		tokens.RefreshToken, err = tokenCipher.Seal(refreshToken)
+		require.NoError(t, err)
+	}
+	return tokens
+}
+
+func TestSessionService_CreateSession_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, nil, nil, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	// Only the hash of the token is stored
+	var storedHash []byte
+	mockSessionRepo.EXPECT().
+		CreateSession(gomock.Any(), 1, gomock.Any(), &models.SessionTokens{Provider: "google"}, "curl/8.0", "10.0.0.1", int64(3600000), int64(86400000)).
+		DoAndReturn(func(ctx context.Context, userId int, tokenHash []byte, tokens *models.SessionTokens, userAgent string, ipAddress string, idleTimeout int64, maxAge int64) (*models.Session, error) {
+			storedHash = tokenHash
+			return &models.Session{ID: 3, UserID: userId}, nil
+		})
+
+	// Without a token cipher the tokens of the login are not kept
+	tokens := &models.OidcTokens{Provider: "google", IDToken: "id-1", IDTokenExpiresAt: 1700003600000, RefreshToken: "refresh-1"}
+	token, session, err := sessionService.CreateSession(context.Background(), &models.User{ID: 1}, tokens, "curl/8.0", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), session.ID)
+	require.NotEmpty(t, token)
+	hash := sha256.Sum256([]byte(token))
+	require.Equal(t, hash[:], storedHash)
+}
+
+func TestSessionService_CreateSession_SealsTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenCipher := newTokenCipher(t)
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, nil, tokenCipher, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	// The tokens are only stored sealed
+	var stored *models.SessionTokens
+	mockSessionRepo.EXPECT().
+		CreateSession(gomock.Any(), 1, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, userId int, tokenHash []byte, tokens *models.SessionTokens, userAgent string, ipAddress string, idleTimeout int64, maxAge int64) (*models.Session, error) {
+			stored = tokens
+			return &models.Session{ID: 3, UserID: userId}, nil
+		})
+
+	tokens := &models.OidcTokens{Provider: "google", IDToken: "id-1", IDTokenExpiresAt: 1700003600000, RefreshToken: "refresh-1"}
+	_, _, err := sessionService.CreateSession(context.Background(), &models.User{ID: 1}, tokens, "curl/8.0", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "google", stored.Provider)
+	require.Equal(t, int64(1700003600000), stored.IDTokenExpiresAt)
+	require.NotContains(t, string(stored.RefreshToken), "refresh-1")
+
+	refreshToken, err := tokenCipher.Open(stored.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-1", refreshToken)
+}
+
+func TestSessionService_Authenticate_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, nil, nil, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	// 1) Test an empty token, the repo must not be called
+	claims, session, err := sessionService.Authenticate(context.Background(), "")
+	require.Nil(t, claims)
+	require.Nil(t, session)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test an expired or revoked session
+	hash := sha256.Sum256([]byte("token-1"))
+	mockSessionRepo.EXPECT().
+		TouchSession(gomock.Any(), hash[:], int64(3600000)).
+		Return(nil, nil, apperrors.Unauthorized("JRXEK9", "Your session has expired, log in again."))
+
+	claims, session, err = sessionService.Authenticate(context.Background(), "token-1")
+	require.Nil(t, claims)
+	require.Nil(t, session)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "I4QHZ6")
+}
+
+func TestSessionService_GetSessions_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, nil, nil, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	mockSessionRepo.EXPECT().
+		GetSessions(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return([]models.Session{{ID: 4}, {ID: 3}}, nil)
+
+	sessions, err := sessionService.GetSessions(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1"}, 3)
+	require.NoError(t, err)
+	require.Equal(t, []models.Session{{ID: 4}, {ID: 3, Current: true}}, sessions)
+}
+
+func TestSessionService_Logout_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, nil, nil, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	mockSessionRepo.EXPECT().RevokeSessionByToken(gomock.Any(), gomock.Any()).Return(errors.New("fail"))
+
+	err := sessionService.Logout(context.Background(), "token-1")
+	require.Contains(t, err.Error(), "1SG1RR")
+}
+
+// sessionTx stubs a unit of work of the session service in mockTx, which the session repo joins.
+func sessionTx(mockTxManager *mocks.MockPgxTxManager, mockTx *mocks.MockPgxTx, mockSessionRepo *mocks.MockSessionRepo) {
+	mockTxManager.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(runInTx(mockTx))
+	mockSessionRepo.EXPECT().WithTx(mockTx).Return(mockSessionRepo)
+}
+
+func TestSessionService_RenewTokens_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenCipher := newTokenCipher(t)
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	mockAuthcService := mocks.NewMockAuthcService(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, mockAuthcService, tokenCipher, mockTxManager, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	claims := &models.Claims{Issuer: "https://accounts.google.com", Sub: "sub-1"}
+
+	// 1) Test an ID token that is still good and a session without a refresh token are left alone
+	err := sessionService.RenewTokens(context.Background(), claims, &models.Session{ID: 3, Renewable: true, IDTokenExpiresAt: time.Now().Add(time.Hour).UnixMilli()})
+	require.NoError(t, err)
+	err = sessionService.RenewTokens(context.Background(), claims, &models.Session{ID: 3, IDTokenExpiresAt: time.Now().UnixMilli()})
+	require.NoError(t, err)
+
+	// 2) Test an ID token about to expire is renewed with the refresh token and the rotated refresh token stored
+	expiresAt := time.Now().Add(time.Hour).UnixMilli()
+	sessionTx(mockTxManager, mockTx, mockSessionRepo)
+	mockSessionRepo.EXPECT().
+		GetSessionTokensForUpdate(gomock.Any(), int64(3)).
+		Return(sealedTokens(t, tokenCipher, "id-1", time.Now().UnixMilli(), "refresh-1"), nil)
+	mockAuthcService.EXPECT().
+		RefreshTokens(gomock.Any(), "google", "refresh-1").
+		DoAndReturn(func(ctx context.Context, provider string, refreshToken string) (*models.Claims, *models.OidcTokens, error) {
+			// The request ended, but the renewal runs to its own deadline.
+			require.NoError(t, ctx.Err())
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			require.LessOrEqual(t, time.Until(deadline), TokenRenewTimeout)
+			return claims, &models.OidcTokens{Provider: "google", IDToken: "id-2", IDTokenExpiresAt: expiresAt, RefreshToken: "refresh-2"}, nil
+		})
+	var stored *models.SessionTokens
+	mockSessionRepo.EXPECT().
+		UpdateSessionTokens(gomock.Any(), int64(3), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, sessionId int64, tokens *models.SessionTokens) error {
+			stored = tokens
+			return nil
+		})
+
+	requestCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session := &models.Session{ID: 3, Renewable: true, IDTokenExpiresAt: time.Now().Add(time.Minute).UnixMilli()}
+	err = sessionService.RenewTokens(requestCtx, claims, session)
+	require.NoError(t, err)
+	require.Equal(t, expiresAt, session.IDTokenExpiresAt)
+
+	refreshToken, err := tokenCipher.Open(stored.RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-2", refreshToken)
+}
+
+func TestSessionService_RenewTokens_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenCipher := newTokenCipher(t)
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	mockAuthcService := mocks.NewMockAuthcService(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, mockAuthcService, tokenCipher, mockTxManager, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	claims := &models.Claims{Issuer: "https://accounts.google.com", Sub: "sub-1"}
+
+	// Two requests read the session before its ID token was renewed. The lock lets the first renew it with
+	// refresh-1, which the provider rotates, and the second finds the renewed tokens once it gets the lock.
+	expiresAt := time.Now().Add(time.Hour).UnixMilli()
+	renewed := sealedTokens(t, tokenCipher, "id-2", expiresAt, "refresh-2")
+	sessionTx(mockTxManager, mockTx, mockSessionRepo)
+	sessionTx(mockTxManager, mockTx, mockSessionRepo)
+	gomock.InOrder(
+		mockSessionRepo.EXPECT().
+			GetSessionTokensForUpdate(gomock.Any(), int64(3)).
+			Return(sealedTokens(t, tokenCipher, "id-1", time.Now().UnixMilli(), "refresh-1"), nil),
+		mockSessionRepo.EXPECT().
+			GetSessionTokensForUpdate(gomock.Any(), int64(3)).
+			Return(renewed, nil),
+	)
+	// The refresh token is spent once, a second spend would be rejected and end the session
+	mockAuthcService.EXPECT().
+		RefreshTokens(gomock.Any(), "google", "refresh-1").
+		Return(claims, &models.OidcTokens{Provider: "google", IDToken: "id-2", IDTokenExpiresAt: expiresAt, RefreshToken: "refresh-2"}, nil).
+		Times(1)
+	mockSessionRepo.EXPECT().UpdateSessionTokens(gomock.Any(), int64(3), gomock.Any()).Return(nil).Times(1)
+	mockSessionRepo.EXPECT().RevokeSession(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	first := &models.Session{ID: 3, Renewable: true, IDTokenExpiresAt: time.Now().UnixMilli()}
+	second := &models.Session{ID: 3, Renewable: true, IDTokenExpiresAt: time.Now().UnixMilli()}
+	require.NoError(t, sessionService.RenewTokens(context.Background(), claims, first))
+	require.NoError(t, sessionService.RenewTokens(context.Background(), claims, second))
+	require.Equal(t, expiresAt, first.IDTokenExpiresAt)
+	require.Equal(t, expiresAt, second.IDTokenExpiresAt)
+}
+
+func TestSessionService_RenewTokens_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenCipher := newTokenCipher(t)
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	mockAuthcService := mocks.NewMockAuthcService(ctrl)
+	mockTxManager := mocks.NewMockPgxTxManager(ctrl)
+	mockTx := mocks.NewMockPgxTx(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, mockAuthcService, tokenCipher, mockTxManager, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	claims := &models.Claims{Issuer: "https://accounts.google.com", Sub: "sub-1"}
+	newSession := func() *models.Session {
+		return &models.Session{ID: 3, Renewable: true, IDTokenExpiresAt: time.Now().UnixMilli()}
+	}
+	mockTxManager.EXPECT().WithTx(gomock.Any(), gomock.Any()).DoAndReturn(runInTx(mockTx)).Times(3)
+	mockSessionRepo.EXPECT().WithTx(mockTx).Return(mockSessionRepo).Times(3)
+	mockSessionRepo.EXPECT().
+		GetSessionTokensForUpdate(gomock.Any(), int64(3)).
+		Return(sealedTokens(t, tokenCipher, "id-1", time.Now().UnixMilli(), "refresh-1"), nil).
+		Times(3)
+
+	// 1) Test a refresh token the provider rejects ends the session
+	mockAuthcService.EXPECT().
+		RefreshTokens(gomock.Any(), "google", "refresh-1").
+		Return(nil, nil, apperrors.Unauthorized("Z0UMCB", "Your login with the provider has ended, log in again."))
+	mockSessionRepo.EXPECT().RevokeSession(gomock.Any(), claims.Issuer, claims.Sub, int64(3)).Return(nil)
+
+	err := sessionService.RenewTokens(context.Background(), claims, newSession())
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "OSC8ZU")
+
+	// 2) Test a provider that can not be reached does not end the session
+	mockAuthcService.EXPECT().
+		RefreshTokens(gomock.Any(), "google", "refresh-1").
+		Return(nil, nil, errors.New("Error: UDSFBO - Refreshing the token."))
+
+	err = sessionService.RenewTokens(context.Background(), claims, newSession())
+	require.NoError(t, err)
+
+	// 3) Test an ID token of another user ends the session
+	mockAuthcService.EXPECT().
+		RefreshTokens(gomock.Any(), "google", "refresh-1").
+		Return(&models.Claims{Issuer: claims.Issuer, Sub: "sub-2"}, &models.OidcTokens{Provider: "google", IDToken: "id-2"}, nil)
+	mockSessionRepo.EXPECT().RevokeSession(gomock.Any(), claims.Issuer, claims.Sub, int64(3)).Return(nil)
+
+	err = sessionService.RenewTokens(context.Background(), claims, newSession())
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "KN6CYE")
+}
+
+func TestSessionService_GetIDToken_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenCipher := newTokenCipher(t)
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+	sessionService := NewSessionService(mockSessionRepo, nil, tokenCipher, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+
+	expiresAt := time.Now().Add(time.Hour).UnixMilli()
+	mockSessionRepo.EXPECT().
+		GetSessionTokens(gomock.Any(), int64(3)).
+		Return(sealedTokens(t, tokenCipher, "id-1", expiresAt, ""), nil)
+
+	idToken, err := sessionService.GetIDToken(context.Background(), &models.Session{ID: 3})
+	require.NoError(t, err)
+	require.Equal(t, &models.IDTokenResponse{IDToken: "id-1", ExpiresAt: expiresAt}, idToken)
+}
+
+func TestSessionService_GetIDToken_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenCipher := newTokenCipher(t)
+	mockSessionRepo := mocks.NewMockSessionRepo(ctrl)
+
+	// 1) Test sessions keep no tokens without a token cipher
+	sessionService := NewSessionService(mockSessionRepo, nil, nil, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+	idToken, err := sessionService.GetIDToken(context.Background(), &models.Session{ID: 3})
+	require.Nil(t, idToken)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test an ID token that expired and can not be renewed
+	sessionService = NewSessionService(mockSessionRepo, nil, tokenCipher, nil, time.Hour, 24*time.Hour, zaptest.NewLogger(t))
+	mockSessionRepo.EXPECT().
+		GetSessionTokens(gomock.Any(), int64(3)).
+		Return(sealedTokens(t, tokenCipher, "id-1", time.Now().Add(-time.Minute).UnixMilli(), ""), nil)
+
+	idToken, err = sessionService.GetIDToken(context.Background(), &models.Session{ID: 3})
+	require.Nil(t, idToken)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+	require.Contains(t, err.Error(), "FORJFX")
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+	"gitlab.com/sandstone2/fiberpoc/common/repos"
+	"go.uber.org/zap"
+)
+
+/*
+Install mockgen with these commands:
+go get go.uber.org/mock/gomock
+go install go.uber.org/mock/mockgen@latest
+
+Then create mocks for the below interface with these commands:
+mockgen \
+  -destination=./mocks/mock_user_service.go \
+  -package=mocks \
+  -mock_names=UserServiceInterface=MockUserService \
+  gitlab.com/sandstone2/fiberpoc/common/services \
+  UserServiceInterface
+*/
+
+type UserServiceInterface interface {
+	ProvisionUser(ctx context.Context, claims *models.Claims) (user *models.User, err error)
+	GetUserBySubject(ctx context.Context, issuer string, subject string) (user *models.User, err error)
+}
+
+type UserService struct {
+	userRepo    *repos.UserRepoInterface
+	roleRepo    *repos.RoleRepoInterface
+	orgRepo     *repos.OrganizationRepoInterface
+	defaultRole string
+	defaultOrg  string
+	logger      *zap.Logger
+}
+
+// NewUserService makes a user service. Users without any role get defaultRole when they log in, an empty
+// defaultRole gives them none. Likewise users who are not a member of any organization join the organization with
+// the slug defaultOrg.
+func NewUserService(userRepo repos.UserRepoInterface, roleRepo repos.RoleRepoInterface, orgRepo repos.OrganizationRepoInterface, defaultRole string, defaultOrg string, logger *zap.Logger) *UserService {
+	return &UserService{userRepo: &userRepo, roleRepo: &roleRepo, orgRepo: &orgRepo, defaultRole: defaultRole, defaultOrg: defaultOrg, logger: logger}
+}
+
+// ProvisionUser creates or refreshes the user of a successful login from their ID token claims.
+// Users without a name in their claims are named after their email, or their subject when the provider sends no
+// email either. Users without a role get the default role and users without an organization join the default
+// organization.
+func (userService *UserService) ProvisionUser(ctx context.Context, claims *models.Claims) (user *models.User, err error) {
+	if claims.Sub == "" {
+		return nil, apperrors.Unauthorized("0Y5GB8", "The ID token has no subject.")
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+	if name == "" {
+		name = claims.Sub
+	}
+
+	user, err = (*userService.userRepo).UpsertUser(ctx, claims.Issuer, claims.Sub, name, claims.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: P0GCPY - Provisioning user.")
+	}
+
+	if userService.defaultRole != "" {
+		if err := (*userService.roleRepo).AssignDefaultRole(ctx, user.ID, userService.defaultRole); err != nil {
+			return nil, errors.Wrap(err, "Error: 9DXPLJ - Assigning the default role.")
+		}
+	}
+
+	if userService.defaultOrg != "" {
+		if err := (*userService.orgRepo).AssignDefaultOrganization(ctx, user.ID, userService.defaultOrg); err != nil {
+			return nil, errors.Wrap(err, "Error: G121D1 - Assigning the default organization.")
+		}
+	}
+
+	return user, nil
+}
+
+func (userService *UserService) GetUserBySubject(ctx context.Context, issuer string, subject string) (user *models.User, err error) {
+	user, err = (*userService.userRepo).GetUserBySubject(ctx, issuer, subject)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error: X0RA8D - Getting user.")
+	}
+
+	return user, nil
+}
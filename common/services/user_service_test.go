@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+	"gitlab.com/sandstone2/fiberpoc/common/mocks"
+	"gitlab.com/sandstone2/fiberpoc/common/models"
+)
+
+func TestUserService_ProvisionUser_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	userService := NewUserService(mockUserRepo, mockRoleRepo, mockOrgRepo, "viewer", "default", zaptest.NewLogger(t))
+
+	// 1) Test the claims are stored
+	expected := &models.User{ID: 1, Issuer: "https://idp.example.com", Subject: "sub-1", Name: "Ada", Email: "ada@example.com"}
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://idp.example.com", "sub-1", "Ada", "ada@example.com").
+		Return(expected, nil)
+	mockRoleRepo.EXPECT().AssignDefaultRole(gomock.Any(), 1, "viewer").Return(nil)
+	mockOrgRepo.EXPECT().AssignDefaultOrganization(gomock.Any(), 1, "default").Return(nil)
+
+	user, err := userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1", Name: "Ada", Email: "ada@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, expected, user)
+
+	// 2) Test a user without a name is named after their email
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://idp.example.com", "sub-2", "bob@example.com", "bob@example.com").
+		Return(&models.User{ID: 2}, nil)
+	mockRoleRepo.EXPECT().AssignDefaultRole(gomock.Any(), 2, "viewer").Return(nil)
+	mockOrgRepo.EXPECT().AssignDefaultOrganization(gomock.Any(), 2, "default").Return(nil)
+
+	_, err = userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-2", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	// 3) Test a user without a name or an email is named after their subject
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://login.microsoftonline.com/tenant/v2.0", "sub-4", "sub-4", "").
+		Return(&models.User{ID: 4}, nil)
+	mockRoleRepo.EXPECT().AssignDefaultRole(gomock.Any(), 4, "viewer").Return(nil)
+	mockOrgRepo.EXPECT().AssignDefaultOrganization(gomock.Any(), 4, "default").Return(nil)
+
+	_, err = userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://login.microsoftonline.com/tenant/v2.0", Sub: "sub-4"})
+	require.NoError(t, err)
+
+	// 4) Test no default role or organization is assigned when there is none
+	userService = NewUserService(mockUserRepo, mockRoleRepo, mockOrgRepo, "", "", zaptest.NewLogger(t))
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://idp.example.com", "sub-3", "Cy", "cy@example.com").
+		Return(&models.User{ID: 3}, nil)
+
+	_, err = userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-3", Name: "Cy", Email: "cy@example.com"})
+	require.NoError(t, err)
+}
+
+func TestUserService_ProvisionUser_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	userService := NewUserService(mockUserRepo, mockRoleRepo, mockOrgRepo, "viewer", "default", zaptest.NewLogger(t))
+
+	// 1) Test claims without a subject, the repo must not be called
+	user, err := userService.ProvisionUser(context.Background(), &models.Claims{Name: "Ada"})
+	require.Nil(t, user)
+	require.Equal(t, apperrors.KindUnauthorized, apperrors.KindOf(err))
+
+	// 2) Test repo failure
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://idp.example.com", "sub-1", "Ada", "ada@example.com").
+		Return(nil, errors.New("fail"))
+
+	user, err = userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1", Name: "Ada", Email: "ada@example.com"})
+	require.Nil(t, user)
+	require.Contains(t, err.Error(), "P0GCPY")
+
+	// 3) Test assigning the default role failed
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://idp.example.com", "sub-1", "Ada", "ada@example.com").
+		Return(&models.User{ID: 1}, nil)
+	mockRoleRepo.EXPECT().AssignDefaultRole(gomock.Any(), 1, "viewer").Return(errors.New("fail"))
+
+	user, err = userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1", Name: "Ada", Email: "ada@example.com"})
+	require.Nil(t, user)
+	require.Contains(t, err.Error(), "9DXPLJ")
+
+	// 4) Test assigning the default organization failed
+	mockUserRepo.EXPECT().
+		UpsertUser(gomock.Any(), "https://idp.example.com", "sub-1", "Ada", "ada@example.com").
+		Return(&models.User{ID: 1}, nil)
+	mockRoleRepo.EXPECT().AssignDefaultRole(gomock.Any(), 1, "viewer").Return(nil)
+	mockOrgRepo.EXPECT().AssignDefaultOrganization(gomock.Any(), 1, "default").Return(errors.New("fail"))
+
+	user, err = userService.ProvisionUser(context.Background(), &models.Claims{Issuer: "https://idp.example.com", Sub: "sub-1", Name: "Ada", Email: "ada@example.com"})
+	require.Nil(t, user)
+	require.Contains(t, err.Error(), "G121D1")
+}
+
+func TestUserService_GetUserBySubject_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepo(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepo(ctrl)
+	mockOrgRepo := mocks.NewMockOrganizationRepo(ctrl)
+	userService := NewUserService(mockUserRepo, mockRoleRepo, mockOrgRepo, "viewer", "default", zaptest.NewLogger(t))
+
+	mockUserRepo.EXPECT().
+		GetUserBySubject(gomock.Any(), "https://idp.example.com", "sub-1").
+		Return(nil, apperrors.NotFound("ZW6WMS", "No user found for this login."))
+
+	user, err := userService.GetUserBySubject(context.Background(), "https://idp.example.com", "sub-1")
+	require.Nil(t, user)
+	require.Contains(t, err.Error(), "X0RA8D")
+	require.Equal(t, apperrors.KindNotFound, apperrors.KindOf(err))
+}
@@ -0,0 +1,52 @@
+// Package tenant carries the organization of a request, and the user making it, in its context. The tenant
+// middleware puts them there and the repos of tenant data scope their queries to them, and the audit repo records the
+// actor, so the services do not pass them along.
+package tenant
+
+import "context"
+
+type orgIdKey struct{}
+
+type userIdKey struct{}
+
+// WithOrgID returns a copy of ctx for the organization with the id.
+func WithOrgID(ctx context.Context, orgId int) context.Context {
+	return context.WithValue(ctx, orgIdKey{}, orgId)
+}
+
+// OrgID returns the id of the organization of ctx. ok is false when ctx has none.
+func OrgID(ctx context.Context) (orgId int, ok bool) {
+	orgId, ok = ctx.Value(orgIdKey{}).(int)
+	return orgId, ok
+}
+
+// WithUserID returns a copy of ctx for the user with the id.
+func WithUserID(ctx context.Context, userId int) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+// UserID returns the id of the user of ctx. ok is false when ctx has none.
+func UserID(ctx context.Context) (userId int, ok bool) {
+	userId, ok = ctx.Value(userIdKey{}).(int)
+	return userId, ok
+}
+
+// Actor is who makes a request, as the audit log records them. Subject and Email come from the claims of their login.
+type Actor struct {
+	Subject   string
+	Email     string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx for requests made by actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorOf returns who makes the request of ctx. ok is false when ctx has no actor.
+func ActorOf(ctx context.Context) (actor Actor, ok bool) {
+	actor, ok = ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,132 @@
+// Package validation checks request DTOs against the rules in their validate struct tags, so handlers can
+// reject bad input with a 422 before it reaches the services.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+// charsets are the character sets the charset rule can check for.
+var charsets = map[string]func(r rune) bool{
+	// printable is letters, marks, numbers, punctuation, symbols and the ASCII space, so no control characters,
+	// tabs or new lines.
+	"printable": unicode.IsPrint,
+	// slug is lower case ASCII letters, digits and -, safe in URLs, host names and headers.
+	"slug": func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-'
+	},
+}
+
+// Check validates dto, see Validate, and returns a validation error with the given code listing every failed
+// field. It returns nil when dto is valid.
+func Check(code string, dto any) error {
+	fields := Validate(dto)
+	if len(fields) == 0 {
+		return nil
+	}
+	return apperrors.Validation(code, "The request is not valid.").WithFields(fields...)
+}
+
+// Validate runs the rules in the validate tags of the string fields of dto, which must be a pointer to a struct.
+// The rules of a field run in order and stop at the first that fails, so each field has at most one error.
+//
+//	trim       trims white space off the value, dto is changed
+//	required   the value can not be empty
+//	min=N      the value needs at least N characters
+//	max=N      the value can have at most N characters
+//	charset=C  the value can only have characters of the charset C, see charsets
+//
+// Pointer fields that are nil are skipped so the optional fields of patches are only checked when they are set.
+// Fields are named by their json tag. Validate panics on a tag it does not understand, as that is a bug.
+func Validate(dto any) (fields []apperrors.FieldError) {
+	value := reflect.ValueOf(dto)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Error: ZQKHDW - Can only validate pointers to structs, not %T.", dto))
+	}
+	value = value.Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() != reflect.String {
+			panic(fmt.Sprintf("Error: VEO7L3 - Can only validate string fields, not %s.", field.Name))
+		}
+
+		if fieldError := checkRules(fieldValue, rules); fieldError != nil {
+			fieldError.Field = jsonName(field)
+			fields = append(fields, *fieldError)
+		}
+	}
+
+	return fields
+}
+
+// checkRules runs the comma separated rules on the string in value and returns the first that fails.
+func checkRules(value reflect.Value, rules string) *apperrors.FieldError {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		text := value.String()
+		switch name {
+		case "trim":
+			value.SetString(strings.TrimSpace(text))
+		case "required":
+			if text == "" {
+				return &apperrors.FieldError{Code: name, Message: "This field is required."}
+			}
+		case "min":
+			if utf8.RuneCountInString(text) < ruleInt(rule, arg) {
+				return &apperrors.FieldError{Code: name, Message: fmt.Sprintf("This field needs at least %s characters.", arg)}
+			}
+		case "max":
+			if utf8.RuneCountInString(text) > ruleInt(rule, arg) {
+				return &apperrors.FieldError{Code: name, Message: fmt.Sprintf("This field can have at most %s characters.", arg)}
+			}
+		case "charset":
+			inCharset, ok := charsets[arg]
+			if !ok {
+				panic(fmt.Sprintf("Error: YWW9WQ - Unknown charset in validate rule %q.", rule))
+			}
+			if strings.IndexFunc(text, func(r rune) bool { return !inCharset(r) }) >= 0 {
+				return &apperrors.FieldError{Code: name, Message: fmt.Sprintf("This field can only have %s characters.", arg)}
+			}
+		default:
+			panic(fmt.Sprintf("Error: ICWHTI - Unknown validate rule %q.", rule))
+		}
+	}
+	return nil
+}
+
+func ruleInt(rule string, arg string) int {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		panic(fmt.Sprintf("Error: XANFGS - Validate rule %q needs a number.", rule))
+	}
+	return n
+}
+
+// jsonName is the name of the field in JSON bodies.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
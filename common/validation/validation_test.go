@@ -0,0 +1,78 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/sandstone2/fiberpoc/common/apperrors"
+)
+
+type testRequest struct {
+	Name     string  `json:"name" validate:"trim,required,max=5,charset=printable"`
+	Nickname *string `json:"nickname,omitempty" validate:"trim,min=2"`
+	Note     string  `json:"note"`
+	Slug     string  `json:"slug" validate:"charset=slug"`
+}
+
+func TestValidate_Success(t *testing.T) {
+	// 1) Test trim changes the request
+	request := &testRequest{Name: "  Foo  ", Note: " kept "}
+	require.Empty(t, Validate(request))
+	require.Equal(t, "Foo", request.Name)
+	require.Equal(t, " kept ", request.Note, "fields without rules are not changed")
+
+	// 2) Test a nil pointer field is skipped and a set one is checked
+	nickname := " Bo "
+	request = &testRequest{Name: "Foo", Nickname: &nickname}
+	require.Empty(t, Validate(request))
+	require.Equal(t, "Bo", *request.Nickname)
+
+	// 3) Test max counts characters, not bytes
+	require.Empty(t, Validate(&testRequest{Name: "ééééé"}))
+
+	// 4) Test a slug of lower case letters, digits and -
+	require.Empty(t, Validate(&testRequest{Name: "Foo", Slug: "acme-2"}))
+}
+
+func TestValidate_Error(t *testing.T) {
+	short := "B"
+	tests := []struct {
+		request *testRequest
+		field   string
+		code    string
+	}{
+		{&testRequest{Name: "   "}, "name", "required"},
+		{&testRequest{Name: strings.Repeat("x", 6)}, "name", "max"},
+		{&testRequest{Name: "a\tb"}, "name", "charset"},
+		{&testRequest{Name: "Foo", Nickname: &short}, "nickname", "min"},
+		{&testRequest{Name: "Foo", Slug: "Acme.com"}, "slug", "charset"},
+	}
+
+	for _, test := range tests {
+		fields := Validate(test.request)
+		require.Len(t, fields, 1, test.code)
+		require.Equal(t, test.field, fields[0].Field)
+		require.Equal(t, test.code, fields[0].Code)
+		require.NotEmpty(t, fields[0].Message)
+	}
+
+	// Unknown rules are bugs
+	require.Panics(t, func() {
+		Validate(&struct {
+			Name string `validate:"email"`
+		}{})
+	})
+}
+
+func TestCheck_Error(t *testing.T) {
+	require.NoError(t, Check("HMZVRU", &testRequest{Name: "Foo"}))
+
+	err := Check("HMZVRU", &testRequest{Name: ""})
+	appError, ok := apperrors.From(err)
+	require.True(t, ok)
+	require.Equal(t, apperrors.KindValidation, appError.Kind)
+	require.Equal(t, "HMZVRU", appError.Code)
+	require.Equal(t, []apperrors.FieldError{{Field: "name", Code: "required", Message: "This field is required."}}, appError.Fields)
+}